@@ -0,0 +1,58 @@
+package dag
+
+// ValidateAcyclic checks that the edges don't form a cycle using DFS.
+// Returns ErrCycleDetected if they do. Stores call it before every write
+// that adds or rewires edges; it can also be used to pre-check a DAG.
+func ValidateAcyclic(nodes []Node, edges []Edge) error {
+	adj := make(map[string][]string)
+	for _, e := range edges {
+		adj[e.FromNodeID] = append(adj[e.FromNodeID], e.ToNodeID)
+	}
+
+	const (
+		unvisited = 0
+		visiting  = 1
+		visited   = 2
+	)
+
+	state := make(map[string]int)
+	for _, n := range nodes {
+		state[n.ID] = unvisited
+	}
+	// Also include nodes referenced only in edges.
+	for _, e := range edges {
+		if _, ok := state[e.FromNodeID]; !ok {
+			state[e.FromNodeID] = unvisited
+		}
+		if _, ok := state[e.ToNodeID]; !ok {
+			state[e.ToNodeID] = unvisited
+		}
+	}
+
+	var dfs func(id string) bool
+	dfs = func(id string) bool {
+		state[id] = visiting
+		for _, next := range adj[id] {
+			switch state[next] {
+			case visiting:
+				return true
+			case unvisited:
+				if dfs(next) {
+					return true
+				}
+			}
+		}
+		state[id] = visited
+		return false
+	}
+
+	for id, s := range state {
+		if s == unvisited {
+			if dfs(id) {
+				return ErrCycleDetected
+			}
+		}
+	}
+
+	return nil
+}
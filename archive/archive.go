@@ -0,0 +1,265 @@
+// Package archive moves cold DAGs out of the database into object storage
+// and brings them back when they are read again.
+//
+//	a := archive.New(store, archive.Dir("/var/lib/dag-archive"))
+//	a.Archive(ctx, "old-flow")          // gzip JSON to the bucket, rows deleted
+//	d, _ := a.GetDAG(ctx, "old-flow")   // restored transparently
+//
+// Any object store fits behind Bucket; see DOCS.md for S3 and GCS adapters.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// ErrNotFound is returned when a DAG is neither in the store nor archived.
+// Bucket implementations return it from Get for a missing key.
+var ErrNotFound = errors.New("archive: not found")
+
+// Bucket is the object storage an archive writes to.
+type Bucket interface {
+	Put(ctx context.Context, key string, body []byte) error
+	// Get returns ErrNotFound if key does not exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete succeeds if key does not exist.
+	Delete(ctx context.Context, key string) error
+}
+
+// Store wraps a dag.Store. GetDAG and GetDAGInfo restore archived DAGs on a
+// miss, DAGExists counts archived DAGs, and DeleteDAG removes the archived
+// copy too. Every other method goes straight to the wrapped store.
+type Store struct {
+	dag.Store
+	Bucket Bucket
+	// Prefix is prepended to every object key, e.g. "dags/".
+	Prefix string
+	// Binary writes new archives with DAG.MarshalBinary instead of JSON,
+	// which is faster for large DAGs. Restores read either, so it can be
+	// switched at any time; object keys keep their .json.gz name.
+	Binary bool
+}
+
+// New wraps s so DAGs can be archived to b.
+func New(s dag.Store, b Bucket) *Store {
+	return &Store{Store: s, Bucket: b}
+}
+
+// Key returns the object key a DAG is archived under.
+func (a *Store) Key(dagID string) string {
+	return a.Prefix + dagID + ".json.gz"
+}
+
+// Snapshot writes the DAG to the bucket without touching the database. It
+// fits dag.Reaper's BeforeDelete hook.
+func (a *Store) Snapshot(ctx context.Context, dagID string) error {
+	d, err := a.Store.GetDAG(ctx, dagID)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return fmt.Errorf("%w: dag %s", ErrNotFound, dagID)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := a.encode(zw, d); err != nil {
+		return fmt.Errorf("archive: encode %s: %w", dagID, err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("archive: compress %s: %w", dagID, err)
+	}
+	if err := a.Bucket.Put(ctx, a.Key(dagID), buf.Bytes()); err != nil {
+		return fmt.Errorf("archive: put %s: %w", dagID, err)
+	}
+	return nil
+}
+
+// encode writes d as Binary asks.
+func (a *Store) encode(w io.Writer, d *dag.DAG) error {
+	if !a.Binary {
+		return json.NewEncoder(w).Encode(d)
+	}
+	b, err := d.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// decode reads an archived DAG in either encoding; JSON starts with "{".
+func decode(raw []byte, d *dag.DAG) error {
+	if len(raw) > 0 && raw[0] == '{' {
+		return json.Unmarshal(raw, d)
+	}
+	return d.UnmarshalBinary(raw)
+}
+
+// Archive writes the DAG to the bucket and then deletes it from the store.
+func (a *Store) Archive(ctx context.Context, dagID string) error {
+	if err := a.Snapshot(ctx, dagID); err != nil {
+		return err
+	}
+	return a.Store.DeleteDAG(ctx, dagID)
+}
+
+// RestoreFromArchive recreates an archived DAG in the store, with its
+// lifecycle status, and removes it from the bucket. An expires_at already
+// in the past is cleared so the reaper does not immediately take it away
+// again.
+func (a *Store) RestoreFromArchive(ctx context.Context, dagID string) (*dag.DAG, error) {
+	body, err := a.Bucket.Get(ctx, a.Key(dagID))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: dag %s", ErrNotFound, dagID)
+		}
+		return nil, fmt.Errorf("archive: get %s: %w", dagID, err)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("archive: decompress %s: %w", dagID, err)
+	}
+	defer zr.Close()
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("archive: decompress %s: %w", dagID, err)
+	}
+
+	var d dag.DAG
+	if err := decode(raw, &d); err != nil {
+		return nil, fmt.Errorf("archive: decode %s: %w", dagID, err)
+	}
+	if d.ExpiresAt != nil && d.ExpiresAt.Before(time.Now()) {
+		d.ExpiresAt = nil
+	}
+
+	status := d.Status
+	restored, err := a.Store.CreateDAG(ctx, &d, dag.CreateDAGOptions{Replace: true})
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case dag.StatusPublished:
+		err = a.Store.PublishDAG(ctx, dagID)
+	case dag.StatusArchived:
+		err = a.Store.ArchiveDAG(ctx, dagID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	restored.Status = status
+	if err := a.Bucket.Delete(ctx, a.Key(dagID)); err != nil {
+		return nil, fmt.Errorf("archive: delete %s: %w", dagID, err)
+	}
+	return restored, nil
+}
+
+// GetDAG returns the DAG from the store, restoring it from the bucket if it
+// was archived. Like the wrapped store it returns nil for an unknown DAG.
+func (a *Store) GetDAG(ctx context.Context, dagID string, opts ...dag.GetDAGOptions) (*dag.DAG, error) {
+	d, err := a.Store.GetDAG(ctx, dagID, opts...)
+	if err != nil || d != nil {
+		return d, err
+	}
+	d, err = a.RestoreFromArchive(ctx, dagID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return getOptions(opts).Trim(d), nil
+}
+
+// getOptions returns the options passed to GetDAG or GetDAGs, if any.
+func getOptions(opts []dag.GetDAGOptions) dag.GetDAGOptions {
+	if len(opts) > 0 {
+		return opts[0]
+	}
+	return dag.GetDAGOptions{}
+}
+
+// GetDAGs returns the DAGs from the store, restoring those it misses from
+// the bucket one by one.
+func (a *Store) GetDAGs(ctx context.Context, dagIDs []string, opts ...dag.GetDAGOptions) (map[string]*dag.DAG, error) {
+	dags, err := a.Store.GetDAGs(ctx, dagIDs, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range dagIDs {
+		if dags[id] != nil {
+			continue
+		}
+		d, err := a.RestoreFromArchive(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		dags[id] = getOptions(opts).Trim(d)
+	}
+	return dags, nil
+}
+
+// StreamDAG streams the DAG from the store, restoring it from the bucket
+// first if it was archived.
+func (a *Store) StreamDAG(ctx context.Context, dagID string, onNode func(dag.Node) error, onEdge func(dag.Edge) error) error {
+	if _, err := a.GetDAGInfo(ctx, dagID); err != nil {
+		return err
+	}
+	return a.Store.StreamDAG(ctx, dagID, onNode, onEdge)
+}
+
+// GetDAGInfo returns the DAG's metadata, restoring it from the bucket if it
+// was archived.
+func (a *Store) GetDAGInfo(ctx context.Context, dagID string) (*dag.DAGInfo, error) {
+	info, err := a.Store.GetDAGInfo(ctx, dagID)
+	if err != nil || info != nil {
+		return info, err
+	}
+	if _, err := a.RestoreFromArchive(ctx, dagID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return a.Store.GetDAGInfo(ctx, dagID)
+}
+
+// DAGExists reports whether the DAG is in the store or archived. An
+// archived DAG is not restored, but checking for one reads its object.
+func (a *Store) DAGExists(ctx context.Context, dagID string) (bool, error) {
+	ok, err := a.Store.DAGExists(ctx, dagID)
+	if err != nil || ok {
+		return ok, err
+	}
+	if _, err := a.Bucket.Get(ctx, a.Key(dagID)); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// DeleteDAG deletes the DAG from the store and its archived copy, if any.
+func (a *Store) DeleteDAG(ctx context.Context, dagID string) error {
+	if err := a.Store.DeleteDAG(ctx, dagID); err != nil {
+		return err
+	}
+	if err := a.Bucket.Delete(ctx, a.Key(dagID)); err != nil {
+		return fmt.Errorf("archive: delete %s: %w", dagID, err)
+	}
+	return nil
+}
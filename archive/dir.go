@@ -0,0 +1,66 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Dir is a Bucket backed by a local directory, for development and for
+// deployments that mount object storage as a filesystem.
+type Dir string
+
+// errBadKey rejects keys that would resolve outside the directory.
+var errBadKey = errors.New("archive: key escapes directory")
+
+func (d Dir) path(key string) (string, error) {
+	k := filepath.FromSlash(key)
+	if !filepath.IsLocal(k) {
+		return "", errBadKey
+	}
+	return filepath.Join(string(d), k), nil
+}
+
+// Put writes body to key atomically.
+func (d Dir) Put(_ context.Context, key string, body []byte) error {
+	p, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, body, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+// Get reads key, returning ErrNotFound if it does not exist.
+func (d Dir) Get(_ context.Context, key string) ([]byte, error) {
+	p, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return b, err
+}
+
+// Delete removes key.
+func (d Dir) Delete(_ context.Context, key string) error {
+	p, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,39 @@
+package dag
+
+import (
+	"context"
+	"time"
+)
+
+type actorKey struct{}
+
+// WithActor returns a context whose writes are recorded as made by actor,
+// such as a user or service name, in Event.Actor. The HTTP server sets it
+// from a trusted header; library callers set it themselves.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor WithActor stored in ctx, or "".
+func ActorFrom(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// AuditFilter selects events of the audit trail for QueryAudit. Every
+// condition that is set must hold; the zero value selects every event.
+type AuditFilter struct {
+	// DAGID keeps the events of one DAG.
+	DAGID string
+	// Actor keeps the events written under WithActor(ctx, Actor).
+	Actor string
+	// Types keeps events of any of these types.
+	Types []EventType
+	// From and To keep events recorded at or after From and before To.
+	From time.Time
+	To   time.Time
+	// AfterSeq pages: pass the Seq of the last event of the previous page.
+	AfterSeq int64
+	// Limit caps the number of events returned. 0 means no limit.
+	Limit int
+}
@@ -0,0 +1,487 @@
+// Package authz checks every call to a dag.Store against an embedder's
+// permission model, so access rules hold for every caller of the library,
+// not only for those behind an HTTP middleware.
+//
+//	s := authz.New(store, authz.AuthorizerFunc(func(ctx context.Context, op authz.Op, dagID string) error {
+//		if !canAccess(userFrom(ctx), op, dagID) {
+//			return authz.ErrDenied
+//		}
+//		return nil
+//	}))
+//
+// Store asks the Authorizer before calling the wrapped store, and returns
+// its error without calling the store if it refuses.
+package authz
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// ErrDenied is the error an Authorizer returns, or wraps, to refuse an
+// operation.
+var ErrDenied = errors.New("authz: permission denied")
+
+// ErrNoLocator is returned for a call that names only a node or an edge
+// when Store has no Locator to find its DAG.
+var ErrNoLocator = errors.New("authz: cannot find the DAG of a node or edge")
+
+// Op is the kind of access an operation needs.
+type Op string
+
+const (
+	// OpRead reads a DAG, its nodes or edges.
+	OpRead Op = "read"
+	// OpCreate creates a DAG.
+	OpCreate Op = "create"
+	// OpWrite changes a DAG's nodes, edges, tags or settings, or creates
+	// its draft.
+	OpWrite Op = "write"
+	// OpDelete deletes a DAG, or replaces it with CreateDAG.
+	OpDelete Op = "delete"
+	// OpPublish publishes or archives a DAG, or promotes its draft.
+	OpPublish Op = "publish"
+	// OpList searches or counts DAGs. Its dagID is "".
+	OpList Op = "list"
+	// OpAdmin creates or drops the schema, or lists expired DAGs. Its
+	// dagID is "".
+	OpAdmin Op = "admin"
+)
+
+// Authorizer decides whether the caller, typically identified by a value
+// in ctx, may perform op on dagID. A non-nil error refuses it.
+type Authorizer interface {
+	Authorize(ctx context.Context, op Op, dagID string) error
+}
+
+// AuthorizerFunc adapts a function to Authorizer.
+type AuthorizerFunc func(ctx context.Context, op Op, dagID string) error
+
+func (f AuthorizerFunc) Authorize(ctx context.Context, op Op, dagID string) error {
+	return f(ctx, op, dagID)
+}
+
+// Locator finds the DAG that owns a node or edge, returning "" if there is
+// none. *postgres.PGStore implements it.
+type Locator interface {
+	NodeDAG(ctx context.Context, nodeID string) (string, error)
+	EdgeDAG(ctx context.Context, edgeID string) (string, error)
+}
+
+// Store wraps a dag.Store, authorizing every call. Calls that name only a
+// node or edge (GetNode, UpdateEdge, Ancestors, ...) are authorized
+// against the DAG Locator finds for it; an unknown node or edge goes
+// through unauthorized, so the wrapped store reports it missing.
+//
+// Store implements every dag.Store method itself rather than embedding the
+// wrapped store, so a method added to the interface cannot bypass it.
+type Store struct {
+	store   dag.Store
+	auth    Authorizer
+	Locator Locator
+}
+
+var _ dag.Store = (*Store)(nil)
+
+// New wraps store with a. If store is a Locator, such as *postgres.PGStore,
+// it is used to find the DAG of node and edge calls; otherwise set
+// Locator, or those calls fail with ErrNoLocator.
+func New(store dag.Store, a Authorizer) *Store {
+	s := &Store{store: store, auth: a}
+	s.Locator, _ = store.(Locator)
+	return s
+}
+
+// node authorizes op on the DAG of nodeID.
+func (s *Store) node(ctx context.Context, op Op, nodeID string) error {
+	if s.Locator == nil {
+		return ErrNoLocator
+	}
+	dagID, err := s.Locator.NodeDAG(ctx, nodeID)
+	if err != nil || dagID == "" {
+		return err
+	}
+	return s.auth.Authorize(ctx, op, dagID)
+}
+
+// edge authorizes op on the DAG of edgeID.
+func (s *Store) edge(ctx context.Context, op Op, edgeID string) error {
+	if s.Locator == nil {
+		return ErrNoLocator
+	}
+	dagID, err := s.Locator.EdgeDAG(ctx, edgeID)
+	if err != nil || dagID == "" {
+		return err
+	}
+	return s.auth.Authorize(ctx, op, dagID)
+}
+
+// --- Schema ---
+
+func (s *Store) CreateSchema(ctx context.Context) error {
+	if err := s.auth.Authorize(ctx, OpAdmin, ""); err != nil {
+		return err
+	}
+	return s.store.CreateSchema(ctx)
+}
+
+func (s *Store) DropSchema(ctx context.Context) error {
+	if err := s.auth.Authorize(ctx, OpAdmin, ""); err != nil {
+		return err
+	}
+	return s.store.DropSchema(ctx)
+}
+
+// --- DAG ---
+
+// CreateDAG needs OpCreate, and OpDelete too with Replace.
+func (s *Store) CreateDAG(ctx context.Context, d *dag.DAG, opts ...dag.CreateDAGOptions) (*dag.DAG, error) {
+	if err := s.auth.Authorize(ctx, OpCreate, d.ID); err != nil {
+		return nil, err
+	}
+	if len(opts) > 0 && opts[0].Replace {
+		if err := s.auth.Authorize(ctx, OpDelete, d.ID); err != nil {
+			return nil, err
+		}
+	}
+	return s.store.CreateDAG(ctx, d, opts...)
+}
+
+func (s *Store) GetDAG(ctx context.Context, dagID string, opts ...dag.GetDAGOptions) (*dag.DAG, error) {
+	if err := s.auth.Authorize(ctx, OpRead, dagID); err != nil {
+		return nil, err
+	}
+	return s.store.GetDAG(ctx, dagID, opts...)
+}
+
+// GetDAGs needs OpRead on every DAG asked for, and fails if one is refused.
+func (s *Store) GetDAGs(ctx context.Context, dagIDs []string, opts ...dag.GetDAGOptions) (map[string]*dag.DAG, error) {
+	for _, id := range dagIDs {
+		if err := s.auth.Authorize(ctx, OpRead, id); err != nil {
+			return nil, err
+		}
+	}
+	return s.store.GetDAGs(ctx, dagIDs, opts...)
+}
+
+func (s *Store) StreamDAG(ctx context.Context, dagID string, onNode func(dag.Node) error, onEdge func(dag.Edge) error) error {
+	if err := s.auth.Authorize(ctx, OpRead, dagID); err != nil {
+		return err
+	}
+	return s.store.StreamDAG(ctx, dagID, onNode, onEdge)
+}
+
+func (s *Store) DeleteDAG(ctx context.Context, dagID string) error {
+	if err := s.auth.Authorize(ctx, OpDelete, dagID); err != nil {
+		return err
+	}
+	return s.store.DeleteDAG(ctx, dagID)
+}
+
+func (s *Store) GetDAGInfo(ctx context.Context, dagID string) (*dag.DAGInfo, error) {
+	if err := s.auth.Authorize(ctx, OpRead, dagID); err != nil {
+		return nil, err
+	}
+	return s.store.GetDAGInfo(ctx, dagID)
+}
+
+func (s *Store) DAGExists(ctx context.Context, dagID string) (bool, error) {
+	if err := s.auth.Authorize(ctx, OpRead, dagID); err != nil {
+		return false, err
+	}
+	return s.store.DAGExists(ctx, dagID)
+}
+
+// CountDAGs needs OpList, and counts only the matching DAGs the caller may
+// read: it searches for all of them and authorizes each with OpRead.
+func (s *Store) CountDAGs(ctx context.Context, q dag.SearchQuery) (int, error) {
+	if err := s.auth.Authorize(ctx, OpList, ""); err != nil {
+		return 0, err
+	}
+	n, err := s.store.CountDAGs(ctx, q)
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	q.Limit = n
+	res, err := s.store.SearchDAGs(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	return len(s.readable(ctx, res)), nil
+}
+
+// SearchDAGs needs OpList, and drops the results whose DAG is refused
+// OpRead. While refusals leave fewer than q.Limit results, it searches
+// again for twice as many, so a short page means there are no more.
+func (s *Store) SearchDAGs(ctx context.Context, q dag.SearchQuery) ([]dag.SearchResult, error) {
+	if err := s.auth.Authorize(ctx, OpList, ""); err != nil {
+		return nil, err
+	}
+	want := q.Limit
+	if want <= 0 {
+		want = defaultSearchLimit
+	}
+	for q.Limit = want; ; q.Limit *= 2 {
+		res, err := s.store.SearchDAGs(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		kept := s.readable(ctx, res)
+		if len(kept) >= want || len(res) < q.Limit {
+			return kept[:min(len(kept), want)], nil
+		}
+	}
+}
+
+// defaultSearchLimit is the number of results SearchDAGs returns when
+// SearchQuery.Limit is 0.
+const defaultSearchLimit = 50
+
+// readable returns the results whose DAG the caller may read, reusing
+// res's backing array.
+func (s *Store) readable(ctx context.Context, res []dag.SearchResult) []dag.SearchResult {
+	kept := res[:0]
+	for _, r := range res {
+		if s.auth.Authorize(ctx, OpRead, r.ID) == nil {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+func (s *Store) ExpiredDAGs(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	if err := s.auth.Authorize(ctx, OpAdmin, ""); err != nil {
+		return nil, err
+	}
+	return s.store.ExpiredDAGs(ctx, before, limit)
+}
+
+func (s *Store) AddDAGTags(ctx context.Context, dagID string, tags ...string) ([]string, error) {
+	if err := s.auth.Authorize(ctx, OpWrite, dagID); err != nil {
+		return nil, err
+	}
+	return s.store.AddDAGTags(ctx, dagID, tags...)
+}
+
+func (s *Store) RemoveDAGTags(ctx context.Context, dagID string, tags ...string) ([]string, error) {
+	if err := s.auth.Authorize(ctx, OpWrite, dagID); err != nil {
+		return nil, err
+	}
+	return s.store.RemoveDAGTags(ctx, dagID, tags...)
+}
+
+func (s *Store) UpdateSettings(ctx context.Context, dagID string, settings dag.Settings) error {
+	if err := s.auth.Authorize(ctx, OpWrite, dagID); err != nil {
+		return err
+	}
+	return s.store.UpdateSettings(ctx, dagID, settings)
+}
+
+// --- Lifecycle ---
+
+func (s *Store) PublishDAG(ctx context.Context, dagID string) error {
+	if err := s.auth.Authorize(ctx, OpPublish, dagID); err != nil {
+		return err
+	}
+	return s.store.PublishDAG(ctx, dagID)
+}
+
+func (s *Store) ArchiveDAG(ctx context.Context, dagID string) error {
+	if err := s.auth.Authorize(ctx, OpPublish, dagID); err != nil {
+		return err
+	}
+	return s.store.ArchiveDAG(ctx, dagID)
+}
+
+func (s *Store) CreateDraft(ctx context.Context, dagID string) (*dag.DAG, error) {
+	if err := s.auth.Authorize(ctx, OpWrite, dagID); err != nil {
+		return nil, err
+	}
+	return s.store.CreateDraft(ctx, dagID)
+}
+
+func (s *Store) PromoteDraft(ctx context.Context, dagID string) (*dag.DAG, error) {
+	if err := s.auth.Authorize(ctx, OpPublish, dagID); err != nil {
+		return nil, err
+	}
+	return s.store.PromoteDraft(ctx, dagID)
+}
+
+// --- Nodes ---
+
+func (s *Store) AddNode(ctx context.Context, dagID string, node *dag.Node) (string, error) {
+	if err := s.auth.Authorize(ctx, OpWrite, dagID); err != nil {
+		return "", err
+	}
+	return s.store.AddNode(ctx, dagID, node)
+}
+
+func (s *Store) GetNode(ctx context.Context, nodeID string) (*dag.Node, error) {
+	if err := s.node(ctx, OpRead, nodeID); err != nil {
+		return nil, err
+	}
+	return s.store.GetNode(ctx, nodeID)
+}
+
+func (s *Store) UpdateNode(ctx context.Context, node *dag.Node) error {
+	if err := s.node(ctx, OpWrite, node.ID); err != nil {
+		return err
+	}
+	return s.store.UpdateNode(ctx, node)
+}
+
+func (s *Store) DeleteNode(ctx context.Context, nodeID string) error {
+	if err := s.node(ctx, OpWrite, nodeID); err != nil {
+		return err
+	}
+	return s.store.DeleteNode(ctx, nodeID)
+}
+
+func (s *Store) ListNodes(ctx context.Context, dagID string) ([]dag.Node, error) {
+	if err := s.auth.Authorize(ctx, OpRead, dagID); err != nil {
+		return nil, err
+	}
+	return s.store.ListNodes(ctx, dagID)
+}
+
+func (s *Store) FindNodesByTag(ctx context.Context, dagID, tag string) ([]dag.Node, error) {
+	if err := s.auth.Authorize(ctx, OpRead, dagID); err != nil {
+		return nil, err
+	}
+	return s.store.FindNodesByTag(ctx, dagID, tag)
+}
+
+func (s *Store) CountNodes(ctx context.Context, dagID string) (int, error) {
+	if err := s.auth.Authorize(ctx, OpRead, dagID); err != nil {
+		return 0, err
+	}
+	return s.store.CountNodes(ctx, dagID)
+}
+
+func (s *Store) ListNodesPage(ctx context.Context, dagID string, opts dag.ListOptions) (*dag.Page[dag.Node], error) {
+	if err := s.auth.Authorize(ctx, OpRead, dagID); err != nil {
+		return nil, err
+	}
+	return s.store.ListNodesPage(ctx, dagID, opts)
+}
+
+func (s *Store) AddNodes(ctx context.Context, dagID string, nodes []dag.Node) ([]dag.BatchResult, error) {
+	if err := s.auth.Authorize(ctx, OpWrite, dagID); err != nil {
+		return nil, err
+	}
+	return s.store.AddNodes(ctx, dagID, nodes)
+}
+
+// --- Edges ---
+
+func (s *Store) AddEdge(ctx context.Context, dagID string, edge *dag.Edge) (string, error) {
+	if err := s.auth.Authorize(ctx, OpWrite, dagID); err != nil {
+		return "", err
+	}
+	return s.store.AddEdge(ctx, dagID, edge)
+}
+
+func (s *Store) GetEdge(ctx context.Context, edgeID string) (*dag.Edge, error) {
+	if err := s.edge(ctx, OpRead, edgeID); err != nil {
+		return nil, err
+	}
+	return s.store.GetEdge(ctx, edgeID)
+}
+
+func (s *Store) UpdateEdge(ctx context.Context, edge *dag.Edge) error {
+	if err := s.edge(ctx, OpWrite, edge.ID); err != nil {
+		return err
+	}
+	return s.store.UpdateEdge(ctx, edge)
+}
+
+func (s *Store) DeleteEdge(ctx context.Context, edgeID string) error {
+	if err := s.edge(ctx, OpWrite, edgeID); err != nil {
+		return err
+	}
+	return s.store.DeleteEdge(ctx, edgeID)
+}
+
+func (s *Store) ListEdges(ctx context.Context, dagID string) ([]dag.Edge, error) {
+	if err := s.auth.Authorize(ctx, OpRead, dagID); err != nil {
+		return nil, err
+	}
+	return s.store.ListEdges(ctx, dagID)
+}
+
+func (s *Store) FindEdges(ctx context.Context, dagID string, filter dag.EdgeFilter) ([]dag.Edge, error) {
+	if err := s.auth.Authorize(ctx, OpRead, dagID); err != nil {
+		return nil, err
+	}
+	return s.store.FindEdges(ctx, dagID, filter)
+}
+
+func (s *Store) CountEdges(ctx context.Context, dagID string) (int, error) {
+	if err := s.auth.Authorize(ctx, OpRead, dagID); err != nil {
+		return 0, err
+	}
+	return s.store.CountEdges(ctx, dagID)
+}
+
+func (s *Store) ListEdgesPage(ctx context.Context, dagID string, opts dag.ListOptions) (*dag.Page[dag.Edge], error) {
+	if err := s.auth.Authorize(ctx, OpRead, dagID); err != nil {
+		return nil, err
+	}
+	return s.store.ListEdgesPage(ctx, dagID, opts)
+}
+
+func (s *Store) AddEdges(ctx context.Context, dagID string, edges []dag.Edge) ([]dag.BatchResult, error) {
+	if err := s.auth.Authorize(ctx, OpWrite, dagID); err != nil {
+		return nil, err
+	}
+	return s.store.AddEdges(ctx, dagID, edges)
+}
+
+func (s *Store) ReorderEdges(ctx context.Context, fromNodeID string, edgeIDs []string) error {
+	if err := s.node(ctx, OpWrite, fromNodeID); err != nil {
+		return err
+	}
+	return s.store.ReorderEdges(ctx, fromNodeID, edgeIDs)
+}
+
+// --- Change sets ---
+
+func (s *Store) ApplyChangeSet(ctx context.Context, dagID string, cs *dag.ChangeSet) error {
+	if err := s.auth.Authorize(ctx, OpWrite, dagID); err != nil {
+		return err
+	}
+	return s.store.ApplyChangeSet(ctx, dagID, cs)
+}
+
+// --- Traversal ---
+
+func (s *Store) Ancestors(ctx context.Context, nodeID string) ([]dag.Node, error) {
+	if err := s.node(ctx, OpRead, nodeID); err != nil {
+		return nil, err
+	}
+	return s.store.Ancestors(ctx, nodeID)
+}
+
+func (s *Store) Descendants(ctx context.Context, nodeID string) ([]dag.Node, error) {
+	if err := s.node(ctx, OpRead, nodeID); err != nil {
+		return nil, err
+	}
+	return s.store.Descendants(ctx, nodeID)
+}
+
+func (s *Store) Path(ctx context.Context, dagID, fromID, toID string) ([]dag.Node, error) {
+	if err := s.auth.Authorize(ctx, OpRead, dagID); err != nil {
+		return nil, err
+	}
+	return s.store.Path(ctx, dagID, fromID, toID)
+}
+
+func (s *Store) Neighborhood(ctx context.Context, nodeID string, k int, dir dag.Direction) (*dag.DAG, error) {
+	if err := s.node(ctx, OpRead, nodeID); err != nil {
+		return nil, err
+	}
+	return s.store.Neighborhood(ctx, nodeID, k, dir)
+}
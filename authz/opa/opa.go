@@ -0,0 +1,152 @@
+// Package opa authorizes dag.Store calls with Open Policy Agent, for
+// organizations that keep their authorization rules in Rego:
+//
+//	a := &opa.Authorizer{
+//		Eval:   &opa.Server{URL: "http://localhost:8181", Policy: "dag/authz/allow"},
+//		Claims: claimsFromContext,
+//		Store:  store,
+//	}
+//	s := authz.New(store, a)
+//
+// Each call is evaluated with an Input document:
+//
+//	{"op": "write", "dag_id": "onboarding-form", "dag": {"name": ..., "tags": [...], "status": "draft", ...}, "claims": {...}}
+//
+// The policy allows it by evaluating to true, or to an object with "allow"
+// true. Anything else refuses it with authz.ErrDenied, and an object's
+// "reason" becomes part of the error.
+package opa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/meikuraledutech/dag"
+	"github.com/meikuraledutech/dag/authz"
+)
+
+// Input is the document a policy is evaluated with.
+type Input struct {
+	Op    authz.Op `json:"op"`
+	DAGID string   `json:"dag_id"`
+	// DAG is the DAG's metadata, or nil if it does not exist yet, the
+	// operation names no DAG, or Authorizer.Store is unset.
+	DAG *dag.DAGInfo `json:"dag"`
+	// Claims describe the caller, as Authorizer.Claims returns them.
+	Claims any `json:"claims"`
+}
+
+// Evaluator evaluates the policy for input and returns its result, or nil
+// if the policy is undefined for it. Server evaluates over OPA's REST API;
+// a prepared query of OPA's Go SDK can be adapted to evaluate in process.
+type Evaluator interface {
+	Evaluate(ctx context.Context, input *Input) (json.RawMessage, error)
+}
+
+// Authorizer is an authz.Authorizer that asks Eval.
+type Authorizer struct {
+	Eval Evaluator
+	// Claims returns the caller's claims from ctx, such as a verified
+	// JWT's. If nil, Input.Claims is null.
+	Claims func(ctx context.Context) any
+	// Store reads the DAG metadata for Input.DAG. Give it the unwrapped
+	// store, not the authz.Store, which would ask the policy again.
+	Store dag.Store
+}
+
+var _ authz.Authorizer = (*Authorizer)(nil)
+
+// Authorize evaluates the policy for op on dagID. Errors reading the
+// metadata or evaluating the policy refuse the call too, so an unreachable
+// OPA fails closed.
+func (a *Authorizer) Authorize(ctx context.Context, op authz.Op, dagID string) error {
+	input := &Input{Op: op, DAGID: dagID}
+	if a.Claims != nil {
+		input.Claims = a.Claims(ctx)
+	}
+	if a.Store != nil && dagID != "" {
+		info, err := a.Store.GetDAGInfo(ctx, dagID)
+		if err != nil {
+			return fmt.Errorf("opa: read dag %s: %w", dagID, err)
+		}
+		input.DAG = info
+	}
+	result, err := a.Eval.Evaluate(ctx, input)
+	if err != nil {
+		return err
+	}
+	return decide(result, op, dagID)
+}
+
+// decide turns a policy result into nil or a refusal.
+func decide(result json.RawMessage, op authz.Op, dagID string) error {
+	var allow bool
+	if json.Unmarshal(result, &allow) == nil {
+		if allow {
+			return nil
+		}
+		return fmt.Errorf("%w: %s on %q", authz.ErrDenied, op, dagID)
+	}
+	var obj struct {
+		Allow  bool   `json:"allow"`
+		Reason string `json:"reason"`
+	}
+	if json.Unmarshal(result, &obj) == nil && obj.Allow {
+		return nil
+	}
+	if obj.Reason != "" {
+		return fmt.Errorf("%w: %s on %q: %s", authz.ErrDenied, op, dagID, obj.Reason)
+	}
+	return fmt.Errorf("%w: %s on %q", authz.ErrDenied, op, dagID)
+}
+
+// Server evaluates a policy on an OPA server through its Data API.
+type Server struct {
+	// URL is the server's base URL, such as "http://localhost:8181".
+	URL string
+	// Policy is the path of the rule under /v1/data, such as
+	// "dag/authz/allow".
+	Policy string
+	// Client sends the requests; nil means http.DefaultClient.
+	Client *http.Client
+}
+
+// Evaluate posts {"input": input} to /v1/data/<Policy> and returns the
+// response's "result".
+func (s *Server) Evaluate(ctx context.Context, input *Input) (json.RawMessage, error) {
+	body, err := json.Marshal(map[string]any{"input": input})
+	if err != nil {
+		return nil, fmt.Errorf("opa: encode input: %w", err)
+	}
+	url := strings.TrimSuffix(s.URL, "/") + "/v1/data/" + strings.Trim(s.Policy, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("opa: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("opa: evaluate %s: %w", s.Policy, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("opa: evaluate %s: %s: %s", s.Policy, resp.Status, bytes.TrimSpace(msg))
+	}
+	var out struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("opa: decode response: %w", err)
+	}
+	return out.Result, nil
+}
@@ -0,0 +1,244 @@
+package dag
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// binaryMagic starts every DAG encoded by MarshalBinary; its last byte is
+// the format version.
+var binaryMagic = []byte("DAGB\x01")
+
+// ErrInvalidBinary is returned by UnmarshalBinary for input MarshalBinary
+// did not produce.
+var ErrInvalidBinary = errors.New("dag: invalid binary encoding")
+
+// MarshalBinary encodes d in a compact binary form for caches and
+// archives: lengths and integers as varints, node and edge data as the
+// raw JSON bytes, so nothing is parsed or escaped. It is several times
+// faster than encoding/json on large DAGs and smaller too. The encoding
+// is versioned and read back by UnmarshalBinary, including by later
+// versions of this package. ExpiresAt comes back in UTC.
+func (d *DAG) MarshalBinary() ([]byte, error) {
+	settings, err := json.Marshal(d.Settings)
+	if err != nil {
+		return nil, err
+	}
+	size := 64 + len(settings)
+	for _, n := range d.Nodes {
+		size += 16 + len(n.ID) + len(n.Ref) + len(n.Data)
+	}
+	for _, e := range d.Edges {
+		size += 24 + len(e.ID) + len(e.FromNodeID) + len(e.ToNodeID) + len(e.Data)
+	}
+	w := binaryWriter{b: make([]byte, 0, size)}
+	w.b = append(w.b, binaryMagic...)
+	w.str(d.ID)
+	w.str(d.Name)
+	w.strs(d.Tags)
+	w.str(string(d.Status))
+	if d.ExpiresAt != nil {
+		w.uint(1)
+		w.int(d.ExpiresAt.UnixNano())
+	} else {
+		w.uint(0)
+	}
+	w.bytes(settings)
+	w.str(d.Hash)
+
+	w.uint(uint64(len(d.Nodes)))
+	for _, n := range d.Nodes {
+		w.str(n.ID)
+		w.str(n.Ref)
+		w.bytes(n.Data)
+		w.strs(n.Tags)
+	}
+	w.uint(uint64(len(d.Edges)))
+	for _, e := range d.Edges {
+		w.str(e.ID)
+		w.str(e.FromNodeID)
+		w.str(e.ToNodeID)
+		w.str(e.FromNodeRef)
+		w.str(e.ToNodeRef)
+		w.bytes(e.Data)
+		w.int(int64(e.OrderIndex))
+	}
+	return w.b, nil
+}
+
+// UnmarshalBinary decodes data written by MarshalBinary into d. It returns
+// ErrInvalidBinary if data is not such an encoding or is cut short.
+func (d *DAG) UnmarshalBinary(data []byte) error {
+	if len(data) < len(binaryMagic) || string(data[:len(binaryMagic)-1]) != string(binaryMagic[:len(binaryMagic)-1]) {
+		return ErrInvalidBinary
+	}
+	if v := data[len(binaryMagic)-1]; v != binaryMagic[len(binaryMagic)-1] {
+		return fmt.Errorf("%w: unknown version %d", ErrInvalidBinary, v)
+	}
+	r := binaryReader{b: data[len(binaryMagic):]}
+	var out DAG
+	out.ID = r.str()
+	out.Name = r.str()
+	out.Tags = r.strs()
+	out.Status = Status(r.str())
+	if r.uint() == 1 {
+		t := time.Unix(0, r.int()).UTC()
+		out.ExpiresAt = &t
+	}
+	if settings := r.bytes(); r.err == nil {
+		if err := json.Unmarshal(settings, &out.Settings); err != nil {
+			return fmt.Errorf("%w: settings: %v", ErrInvalidBinary, err)
+		}
+	}
+	out.Hash = r.str()
+
+	out.Nodes = make([]Node, r.count())
+	for i := range out.Nodes {
+		n := &out.Nodes[i]
+		n.ID = r.str()
+		n.Ref = r.str()
+		n.Data = r.raw()
+		n.Tags = r.strs()
+	}
+	out.Edges = make([]Edge, r.count())
+	for i := range out.Edges {
+		e := &out.Edges[i]
+		e.ID = r.str()
+		e.FromNodeID = r.str()
+		e.ToNodeID = r.str()
+		e.FromNodeRef = r.str()
+		e.ToNodeRef = r.str()
+		e.Data = r.raw()
+		e.OrderIndex = int(r.int())
+	}
+	if r.err != nil {
+		return r.err
+	}
+	*d = out
+	return nil
+}
+
+type binaryWriter struct{ b []byte }
+
+func (w *binaryWriter) uint(v uint64) { w.b = binary.AppendUvarint(w.b, v) }
+func (w *binaryWriter) int(v int64)   { w.b = binary.AppendVarint(w.b, v) }
+
+func (w *binaryWriter) str(s string) {
+	w.uint(uint64(len(s)))
+	w.b = append(w.b, s...)
+}
+
+// bytes writes b with a length one more than its own, so that nil (0)
+// and empty (1) come back apart.
+func (w *binaryWriter) bytes(b []byte) {
+	if b == nil {
+		w.uint(0)
+		return
+	}
+	w.uint(uint64(len(b)) + 1)
+	w.b = append(w.b, b...)
+}
+
+func (w *binaryWriter) strs(ss []string) {
+	if ss == nil {
+		w.uint(0)
+		return
+	}
+	w.uint(uint64(len(ss)) + 1)
+	for _, s := range ss {
+		w.str(s)
+	}
+}
+
+// binaryReader reads what binaryWriter wrote. The first error sticks and
+// every later read returns a zero value.
+type binaryReader struct {
+	b   []byte
+	err error
+}
+
+func (r *binaryReader) fail() {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: truncated", ErrInvalidBinary)
+	}
+	r.b = nil
+}
+
+func (r *binaryReader) uint() uint64 {
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *binaryReader) int() int64 {
+	v, n := binary.Varint(r.b)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+// count reads a slice length, checked against what is left so corrupt
+// input can't make UnmarshalBinary allocate a huge slice.
+func (r *binaryReader) count() int {
+	n := r.uint()
+	if n > uint64(len(r.b)) {
+		r.fail()
+		return 0
+	}
+	return int(n)
+}
+
+func (r *binaryReader) next(n uint64) []byte {
+	if n > uint64(len(r.b)) {
+		r.fail()
+		return nil
+	}
+	b := r.b[:n:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *binaryReader) str() string { return string(r.next(r.uint())) }
+
+func (r *binaryReader) bytes() []byte {
+	n := r.uint()
+	if n == 0 {
+		return nil
+	}
+	return r.next(n - 1)
+}
+
+// raw is bytes as a json.RawMessage, copied so it does not pin the input.
+func (r *binaryReader) raw() json.RawMessage {
+	b := r.bytes()
+	if b == nil {
+		return nil
+	}
+	return append(json.RawMessage(nil), b...)
+}
+
+func (r *binaryReader) strs() []string {
+	n := r.uint()
+	if n == 0 {
+		return nil
+	}
+	if n-1 > uint64(len(r.b)) {
+		r.fail()
+		return nil
+	}
+	ss := make([]string, n-1)
+	for i := range ss {
+		ss[i] = r.str()
+	}
+	return ss
+}
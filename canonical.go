@@ -0,0 +1,81 @@
+package dag
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"slices"
+)
+
+// MarshalCanonical encodes d as JSON that depends only on its contents,
+// for diffs, hashing and storing flows in git: nodes sorted by ID and
+// edges by ID, then ends, tags sorted, object keys in data sorted, numbers
+// kept as written, ExpiresAt in UTC, and two-space indentation with a
+// final newline. Hash is left out, since it is derived from the rest.
+// Refs are kept, and break ties between nodes and edges without IDs.
+func MarshalCanonical(d *DAG) ([]byte, error) {
+	c := *d
+	c.Hash = ""
+	c.Tags = sortedTags(d.Tags)
+	if d.ExpiresAt != nil {
+		t := d.ExpiresAt.UTC()
+		c.ExpiresAt = &t
+	}
+
+	c.Nodes = make([]Node, len(d.Nodes))
+	for i, n := range d.Nodes {
+		n.Data, n.Tags = canonicalData(n.Data), sortedTags(n.Tags)
+		c.Nodes[i] = n
+	}
+	slices.SortFunc(c.Nodes, func(a, b Node) int {
+		return cmp.Or(cmp.Compare(a.ID, b.ID), cmp.Compare(a.Ref, b.Ref), bytes.Compare(a.Data, b.Data))
+	})
+	c.Edges = make([]Edge, len(d.Edges))
+	for i, e := range d.Edges {
+		e.Data = canonicalData(e.Data)
+		c.Edges[i] = e
+	}
+	slices.SortFunc(c.Edges, func(a, b Edge) int {
+		return cmp.Or(cmp.Compare(a.ID, b.ID),
+			cmp.Compare(a.FromNodeID, b.FromNodeID), cmp.Compare(a.ToNodeID, b.ToNodeID),
+			cmp.Compare(a.FromNodeRef, b.FromNodeRef), cmp.Compare(a.ToNodeRef, b.ToNodeRef),
+			cmp.Compare(a.OrderIndex, b.OrderIndex), bytes.Compare(a.Data, b.Data))
+	})
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(&c); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// canonicalData is canonicalJSON without escaping <, > and &, which
+// canonicalJSON keeps so that Hash does not change.
+func canonicalData(data json.RawMessage) json.RawMessage {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return json.RawMessage("null")
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return json.RawMessage("null")
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// sortedTags returns a sorted copy of tags, or nil if there are none.
+func sortedTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	tags = slices.Clone(tags)
+	slices.Sort(tags)
+	return tags
+}
@@ -0,0 +1,98 @@
+package dag
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+)
+
+// ChangeSet is a batch of node and edge writes to one DAG that
+// ApplyChangeSet applies all together or not at all. The graph is checked
+// once, as the whole change set leaves it, so an editor can for example
+// rewire an edge and delete the node it used to point at in one save.
+//
+// New nodes may carry a Ref, and new or updated edges may point at those
+// nodes with FromNodeRef / ToNodeRef, as in CreateDAG. IDs left empty are
+// generated and written back into the change set.
+type ChangeSet struct {
+	AddNodes    []Node   `json:"add_nodes,omitempty"`
+	UpdateNodes []Node   `json:"update_nodes,omitempty"`
+	DeleteNodes []string `json:"delete_nodes,omitempty"`
+	AddEdges    []Edge   `json:"add_edges,omitempty"`
+	UpdateEdges []Edge   `json:"update_edges,omitempty"`
+	DeleteEdges []string `json:"delete_edges,omitempty"`
+}
+
+// Empty reports whether cs changes nothing.
+func (cs *ChangeSet) Empty() bool {
+	return len(cs.AddNodes) == 0 && len(cs.UpdateNodes) == 0 && len(cs.DeleteNodes) == 0 &&
+		len(cs.AddEdges) == 0 && len(cs.UpdateEdges) == 0 && len(cs.DeleteEdges) == 0
+}
+
+// Diff returns the change set that turns from's nodes and edges into to's,
+// matching them by ID. Nodes and edges of to without an ID, or with one
+// from does not have, are added; those missing from to are deleted; and
+// those whose data, tags or ends differ are updated. JSON data is compared
+// by value, so formatting and key order don't count as changes. Other DAG
+// fields and OrderIndex are ignored.
+func Diff(from, to *DAG) *ChangeSet {
+	cs := &ChangeSet{}
+	old := make(map[string]*Node, len(from.Nodes))
+	for i := range from.Nodes {
+		old[from.Nodes[i].ID] = &from.Nodes[i]
+	}
+	kept := make(map[string]bool, len(to.Nodes))
+	for _, n := range to.Nodes {
+		o, ok := old[n.ID]
+		switch {
+		case n.ID == "" || !ok:
+			cs.AddNodes = append(cs.AddNodes, n)
+		case !sameJSON(o.Data, n.Data) || !slices.Equal(o.Tags, n.Tags):
+			if n.Tags == nil {
+				n.Tags = []string{}
+			}
+			cs.UpdateNodes = append(cs.UpdateNodes, n)
+		}
+		kept[n.ID] = true
+	}
+	for _, n := range from.Nodes {
+		if !kept[n.ID] {
+			cs.DeleteNodes = append(cs.DeleteNodes, n.ID)
+		}
+	}
+
+	oldEdges := make(map[string]*Edge, len(from.Edges))
+	for i := range from.Edges {
+		oldEdges[from.Edges[i].ID] = &from.Edges[i]
+	}
+	kept = make(map[string]bool, len(to.Edges))
+	for _, e := range to.Edges {
+		o, ok := oldEdges[e.ID]
+		switch {
+		case e.ID == "" || !ok:
+			cs.AddEdges = append(cs.AddEdges, e)
+		case o.FromNodeID != e.FromNodeID || o.ToNodeID != e.ToNodeID || e.FromNodeRef != "" || e.ToNodeRef != "" || !sameJSON(o.Data, e.Data):
+			cs.UpdateEdges = append(cs.UpdateEdges, e)
+		}
+		kept[e.ID] = true
+	}
+	for _, e := range from.Edges {
+		if !kept[e.ID] {
+			cs.DeleteEdges = append(cs.DeleteEdges, e.ID)
+		}
+	}
+	return cs
+}
+
+// sameJSON reports whether a and b hold the same JSON value.
+func sameJSON(a, b json.RawMessage) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var av, bv any
+	if json.Unmarshal(a, &av) != nil || json.Unmarshal(b, &bv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
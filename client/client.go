@@ -0,0 +1,293 @@
+// Package client is a dag.Store backed by the HTTP API that server/ serves,
+// so a Go service can switch between talking to Postgres directly and going
+// through the API without changing code:
+//
+//	var store dag.Store = client.New("https://dags.internal", client.Options{
+//		Header: http.Header{"Authorization": {"Bearer " + token}},
+//	})
+//	d, err := store.GetDAG(ctx, "onboarding")
+//
+// Errors the server answers with come back as *Error, which unwraps to the
+// matching dag error where there is one, so errors.Is(err,
+// dag.ErrCycleDetected) works as against a local store. Lookups that find
+// nothing return nil and no error, as PGStore does.
+//
+// The server validates requests more strictly than the stores do, for
+// example capping page sizes and batch lengths, and applies its own
+// configuration such as redaction, so results can differ in those details.
+package client
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// Options configures a Client. The zero value sends requests over a pooled
+// transport, without extra headers, time limits or retries.
+type Options struct {
+	// HTTPClient sends the requests. nil means a client of its own whose
+	// transport keeps MaxIdleConns connections to the server open.
+	HTTPClient *http.Client
+	// MaxIdleConns caps the idle connections kept open to the server for
+	// reuse, when HTTPClient is nil. 0 means 64.
+	MaxIdleConns int
+	// Timeout bounds each call, retries and waits included, unless ctx
+	// ends sooner. 0 means only ctx does. For StreamDAG it also bounds
+	// reading the stream.
+	Timeout time.Duration
+	// Retry retries failed requests; see RetryPolicy.
+	Retry RetryPolicy
+	// Header is added to every request, e.g. Authorization.
+	Header http.Header
+	// ActorHeader, if set, names a header that carries dag.ActorFrom of
+	// each call's context, for servers run with DAG_ACTOR_HEADER, so the
+	// audit trail records who made each write.
+	ActorHeader string
+}
+
+// Client is a dag.Store that calls the v1 HTTP API. It is safe for
+// concurrent use.
+type Client struct {
+	base string
+	opts Options
+}
+
+var _ dag.Store = (*Client)(nil)
+
+// New returns a Client for the server at baseURL, such as
+// "http://localhost:3000". Requests go to the /v1 routes under it.
+func New(baseURL string, opts ...Options) *Client {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.HTTPClient == nil {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.MaxIdleConnsPerHost = cmp.Or(o.MaxIdleConns, 64)
+		t.MaxIdleConns = t.MaxIdleConnsPerHost
+		o.HTTPClient = &http.Client{Transport: t}
+	}
+	return &Client{base: strings.TrimRight(baseURL, "/") + "/v1", opts: o}
+}
+
+// Error is an error response from the server, decoded from its error
+// envelope. Unwrap returns the dag error Code stands for, if any.
+type Error struct {
+	// Status is the HTTP status code.
+	Status    int             `json:"-"`
+	Code      string          `json:"code"`
+	Message   string          `json:"message"`
+	Details   json.RawMessage `json:"details,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("dag: server answered %d", e.Status)
+	}
+	return fmt.Sprintf("dag: server answered %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// Unwrap returns the dag error for e's code, or for a validation failure
+// the dag error for its first field, or nil.
+func (e *Error) Unwrap() error {
+	if err, ok := codeErrors[e.Code]; ok {
+		return err
+	}
+	if e.Code == "validation_failed" {
+		var fields []struct {
+			Field string `json:"field"`
+		}
+		if json.Unmarshal(e.Details, &fields) == nil && len(fields) > 0 {
+			return fieldErrors[fields[0].Field]
+		}
+	}
+	return nil
+}
+
+// codeErrors maps the server's error codes to the dag errors they report.
+var codeErrors = map[string]error{
+	"cycle_detected":      dag.ErrCycleDetected,
+	"node_not_found":      dag.ErrNodeNotFound,
+	"edge_not_found":      dag.ErrEdgeNotFound,
+	"dag_not_found":       dag.ErrDAGNotFound,
+	"dag_frozen":          dag.ErrDAGFrozen,
+	"dag_already_exists":  dag.ErrDAGExists,
+	"version_not_found":   dag.ErrNoVersion,
+	"invalid_order":       dag.ErrInvalidOrder,
+	"parallel_edge":       dag.ErrParallelEdge,
+	"duplicate_condition": dag.ErrDuplicateCondition,
+	"not_tree":            dag.ErrNotTree,
+	"multiple_roots":      dag.ErrMultipleRoots,
+	"disconnected":        dag.ErrDisconnected,
+	"too_deep":            dag.ErrTooDeep,
+	"out_degree_exceeded": dag.ErrOutDegree,
+	"quota_exceeded":      dag.ErrQuotaExceeded,
+	"locked":              dag.ErrLocked,
+}
+
+// fieldErrors maps the field of a validation failure to the dag error the
+// server reports with it.
+var fieldErrors = map[string]error{
+	"cursor":    dag.ErrInvalidCursor,
+	"sort":      dag.ErrInvalidSort,
+	"match":     dag.ErrInvalidFilter,
+	"k":         dag.ErrInvalidHops,
+	"fields":    dag.ErrInvalidField,
+	"data.type": dag.ErrUnknownNodeType,
+}
+
+// isNotFound reports whether err is a 404 from the server with code, which
+// the lookups turn into a nil result.
+func isNotFound(err error, code string) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Status == http.StatusNotFound && (code == "" || e.Code == code)
+}
+
+// send makes a request and returns the response if its status is 2xx; any
+// other status is returned as *Error. in, if non-nil, is sent as JSON.
+// Failed requests are retried as Options.Retry says. The caller closes the
+// body.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, in any) (*http.Response, error) {
+	u := c.base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var body []byte
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, fmt.Errorf("dag: encode request: %w", err)
+		}
+		body = b
+	}
+	key, _ := ctx.Value(requestKeyCtx{}).(string)
+	retry := method != http.MethodPost || key != ""
+
+	cancel := context.CancelFunc(func() {})
+	if c.opts.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.opts.Timeout)
+	}
+	for attempt := 1; ; attempt++ {
+		resp, err := c.do(ctx, method, u, body, key)
+		var wait time.Duration
+		switch {
+		case err != nil:
+			err = fmt.Errorf("dag: %s %s: %w", method, path, err)
+		case resp.StatusCode < 300:
+			// The timeout covers reading the body too.
+			resp.Body = cancelOnClose{resp.Body, cancel}
+			return resp, nil
+		default:
+			wait = retryAfter(resp)
+			err = decodeError(resp)
+			resp.Body.Close()
+		}
+		if !retry || attempt >= c.opts.Retry.MaxAttempts || !retryable(err) || ctx.Err() != nil {
+			cancel()
+			return nil, err
+		}
+		if wait == 0 {
+			wait = c.opts.Retry.backoff(attempt)
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			cancel()
+			return nil, err
+		case <-t.C:
+		}
+	}
+}
+
+// do sends one request.
+func (c *Client) do(ctx context.Context, method, u string, body []byte, key string) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range c.opts.Header {
+		req.Header[k] = vs
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	if c.opts.ActorHeader != "" {
+		if actor := dag.ActorFrom(ctx); actor != "" {
+			req.Header.Set(c.opts.ActorHeader, actor)
+		}
+	}
+	return c.opts.HTTPClient.Do(req)
+}
+
+// cancelOnClose releases a call's timeout once its response is read.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// decodeError reads the error envelope of resp. A body that is not one,
+// as on HEAD, gives an Error with only Status set.
+func decodeError(resp *http.Response) error {
+	var env struct {
+		Error Error `json:"error"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&env)
+	e := env.Error
+	e.Status = resp.StatusCode
+	return &e
+}
+
+// call makes a request and decodes the JSON response into out, unless out
+// is nil.
+func (c *Client) call(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	resp, err := c.send(ctx, method, path, query, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("dag: decode %s %s: %w", method, path, err)
+		}
+	}
+	// Drain the rest so the connection can be reused.
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// route joins segments into a request path, escaping each one.
+func route(segments ...string) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteByte('/')
+		b.WriteString(url.PathEscape(s))
+	}
+	return b.String()
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RetryPolicy says how a Client retries failed requests. The zero value
+// never retries.
+//
+// A request is retried if it could not be sent or the server answered 429,
+// a 5xx other than 501, or 409 idempotency_in_progress. GET, HEAD, PUT and
+// DELETE are retried freely. POST is retried only with an idempotency key:
+// the calls whose routes honour one (CreateDAG, AddNode, AddNodes, AddEdge,
+// AddEdges and ApplyChangeSet) get a fresh key per call when MaxAttempts is
+// above 1, sent unchanged on every attempt, so a retry cannot apply the
+// write twice. Other POSTs are not retried.
+type RetryPolicy struct {
+	// MaxAttempts is the number of tries per call, the first included.
+	// 0 and 1 mean no retries.
+	MaxAttempts int
+	// MinBackoff is the wait before the first retry, doubled before each
+	// further one up to MaxBackoff, with jitter. They default to 100ms and
+	// 5s. A Retry-After header from the server replaces the backoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// backoff returns the wait before retry n, counting from 1: an exponential
+// step between MinBackoff and MaxBackoff, then a random point in its upper
+// half, so clients that failed together do not retry together.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	lo, hi := p.MinBackoff, p.MaxBackoff
+	if lo <= 0 {
+		lo = 100 * time.Millisecond
+	}
+	if hi <= 0 {
+		hi = 5 * time.Second
+	}
+	d := lo
+	for i := 1; i < n && d < hi; i++ {
+		d *= 2
+	}
+	d = min(d, hi)
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryable reports whether a request that failed with err may succeed if
+// sent again.
+func retryable(err error) bool {
+	var e *Error
+	if !errors.As(err, &e) {
+		// Not sent, or no response: the connection failed.
+		return true
+	}
+	switch {
+	case e.Status == http.StatusTooManyRequests:
+		return true
+	case e.Status == http.StatusConflict:
+		return e.Code == "idempotency_in_progress"
+	case e.Status == http.StatusNotImplemented:
+		return false
+	}
+	return e.Status >= 500
+}
+
+// retryAfter reads the Retry-After header of resp, in seconds or as an HTTP
+// date, or returns 0 if it has none.
+func retryAfter(resp *http.Response) time.Duration {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// Context keys: idempotencyKeyCtx holds the caller's key, from
+// WithIdempotencyKey, and requestKeyCtx the key send puts on the request.
+type (
+	idempotencyKeyCtx struct{}
+	requestKeyCtx     struct{}
+)
+
+// WithIdempotencyKey returns a copy of ctx whose write carries key as its
+// Idempotency-Key, instead of a key the Client makes up. Reusing the key
+// for the same write later, even from another process, replays the
+// server's first answer rather than writing again. It applies to the calls
+// RetryPolicy lists, whether or not retries are on; an AddNodes or AddEdges
+// split into several requests sends key-2, key-3 and so on after the first.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+// idempotent returns ctx marked so that send gives its POST an
+// idempotency key: the caller's, or a new one if retries are on. part
+// numbers the requests of a split call from 1; later parts get their own
+// key, since the server refuses a key reused with another body.
+func (c *Client) idempotent(ctx context.Context, part int) context.Context {
+	key, _ := ctx.Value(idempotencyKeyCtx{}).(string)
+	switch {
+	case key == "" && c.opts.Retry.MaxAttempts > 1:
+		key = uuid.NewString()
+	case key == "":
+		return ctx
+	case part > 1:
+		key += "-" + strconv.Itoa(part)
+	}
+	return context.WithValue(ctx, requestKeyCtx{}, key)
+}
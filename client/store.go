@@ -0,0 +1,650 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// Batch limits of the server: GetDAGs, AddNodes and AddEdges split larger
+// calls into several requests.
+const (
+	maxBatchGet   = 100
+	maxBatchItems = 1000
+)
+
+// Count headers of the HEAD routes.
+const (
+	totalCountHeader = "X-Total-Count"
+)
+
+// ── Schema ──────────────────────────────────────────────────────────────
+
+func (c *Client) CreateSchema(ctx context.Context) error {
+	return c.call(ctx, http.MethodPost, "/schema", nil, nil, nil)
+}
+
+func (c *Client) DropSchema(ctx context.Context) error {
+	return c.call(ctx, http.MethodDelete, "/schema", nil, nil, nil)
+}
+
+// ── DAG ─────────────────────────────────────────────────────────────────
+
+func (c *Client) CreateDAG(ctx context.Context, d *dag.DAG, opts ...dag.CreateDAGOptions) (*dag.DAG, error) {
+	var o dag.CreateDAGOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	q := url.Values{}
+	if o.Replace {
+		q.Set("replace", "true")
+	}
+	if o.DryRun {
+		q.Set("dry_run", "true")
+	}
+	var out dag.DAG
+	if err := c.call(c.idempotent(ctx, 1), http.MethodPost, "/dag", q, d, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) GetDAG(ctx context.Context, dagID string, opts ...dag.GetDAGOptions) (*dag.DAG, error) {
+	var out dag.DAG
+	err := c.call(ctx, http.MethodGet, route("dag", dagID), getQuery(opts), nil, &out)
+	if isNotFound(err, "dag_not_found") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) GetDAGs(ctx context.Context, dagIDs []string, opts ...dag.GetDAGOptions) (map[string]*dag.DAG, error) {
+	out := make(map[string]*dag.DAG, len(dagIDs))
+	for ids := range slices.Chunk(dagIDs, maxBatchGet) {
+		q := getQuery(opts)
+		q["id"] = ids
+		var page struct {
+			Items map[string]*dag.DAG `json:"items"`
+		}
+		if err := c.call(ctx, http.MethodGet, "/dags:batch", q, nil, &page); err != nil {
+			return nil, err
+		}
+		maps.Copy(out, page.Items)
+	}
+	return out, nil
+}
+
+// StreamDAG reads the streamed GET /dag/:id response token by token, so
+// like PGStore it does not hold the whole DAG in memory. An unknown DAG
+// streams nothing.
+func (c *Client) StreamDAG(ctx context.Context, dagID string, onNode func(dag.Node) error, onEdge func(dag.Edge) error) error {
+	resp, err := c.send(ctx, http.MethodGet, route("dag", dagID), nil, nil)
+	if isNotFound(err, "dag_not_found") {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("dag: decode dag stream: %w", err)
+		}
+		switch tok {
+		case "nodes":
+			err = streamArray(dec, onNode)
+		case "edges":
+			err = streamArray(dec, onEdge)
+		default:
+			var skip json.RawMessage
+			if err = dec.Decode(&skip); err != nil {
+				err = fmt.Errorf("dag: decode dag stream: %w", err)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamArray decodes a JSON array from dec one element at a time and calls
+// fn with each, or skips the elements if fn is nil. An error from fn is
+// returned as-is.
+func streamArray[T any](dec *json.Decoder, fn func(T) error) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	for dec.More() {
+		var v T
+		if err := dec.Decode(&v); err != nil {
+			return fmt.Errorf("dag: decode dag stream: %w", err)
+		}
+		if fn != nil {
+			if err := fn(v); err != nil {
+				return err
+			}
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+// expectDelim reads the next token of dec and fails unless it is d.
+func expectDelim(dec *json.Decoder, d json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("dag: decode dag stream: %w", err)
+	}
+	if tok != d {
+		return fmt.Errorf("dag: decode dag stream: expected %v, got %v", d, tok)
+	}
+	return nil
+}
+
+func (c *Client) DeleteDAG(ctx context.Context, dagID string) error {
+	return c.call(ctx, http.MethodDelete, route("dag", dagID), nil, nil, nil)
+}
+
+func (c *Client) GetDAGInfo(ctx context.Context, dagID string) (*dag.DAGInfo, error) {
+	var out dag.DAGInfo
+	err := c.call(ctx, http.MethodGet, route("dag", dagID, "info"), nil, nil, &out)
+	if isNotFound(err, "dag_not_found") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) DAGExists(ctx context.Context, dagID string) (bool, error) {
+	err := c.call(ctx, http.MethodHead, route("dag", dagID), nil, nil, nil)
+	if isNotFound(err, "") {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (c *Client) CountDAGs(ctx context.Context, q dag.SearchQuery) (int, error) {
+	return c.count(ctx, "/dags", searchValues(q))
+}
+
+func (c *Client) SearchDAGs(ctx context.Context, q dag.SearchQuery) ([]dag.SearchResult, error) {
+	var page struct {
+		Items []dag.SearchResult `json:"items"`
+	}
+	if err := c.call(ctx, http.MethodGet, "/dags", searchValues(q), nil, &page); err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+func (c *Client) ExpiredDAGs(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	q := url.Values{"before": {before.UTC().Format(time.RFC3339Nano)}}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var page struct {
+		Items []string `json:"items"`
+	}
+	if err := c.call(ctx, http.MethodGet, "/dags:expired", q, nil, &page); err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+func (c *Client) AddDAGTags(ctx context.Context, dagID string, tags ...string) ([]string, error) {
+	var out struct {
+		Tags []string `json:"tags"`
+	}
+	body := struct {
+		Tags []string `json:"tags"`
+	}{tags}
+	if err := c.call(ctx, http.MethodPost, route("dag", dagID, "tags"), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out.Tags, nil
+}
+
+// RemoveDAGTags removes the tags one request at a time, as the API takes
+// one tag per DELETE.
+func (c *Client) RemoveDAGTags(ctx context.Context, dagID string, tags ...string) ([]string, error) {
+	if len(tags) == 0 {
+		info, err := c.GetDAGInfo(ctx, dagID)
+		if err != nil || info == nil {
+			return nil, err
+		}
+		return info.Tags, nil
+	}
+	var out struct {
+		Tags []string `json:"tags"`
+	}
+	for _, tag := range tags {
+		if err := c.call(ctx, http.MethodDelete, route("dag", dagID, "tags", tag), nil, nil, &out); err != nil {
+			return nil, err
+		}
+	}
+	return out.Tags, nil
+}
+
+func (c *Client) UpdateSettings(ctx context.Context, dagID string, s dag.Settings) error {
+	return c.call(ctx, http.MethodPut, route("dag", dagID, "settings"), nil, s, nil)
+}
+
+// ── Lifecycle ───────────────────────────────────────────────────────────
+
+func (c *Client) PublishDAG(ctx context.Context, dagID string) error {
+	return c.setStatus(ctx, dagID, dag.StatusPublished)
+}
+
+func (c *Client) ArchiveDAG(ctx context.Context, dagID string) error {
+	return c.setStatus(ctx, dagID, dag.StatusArchived)
+}
+
+func (c *Client) setStatus(ctx context.Context, dagID string, status dag.Status) error {
+	body := struct {
+		Status dag.Status `json:"status"`
+	}{status}
+	return c.call(ctx, http.MethodPut, route("dag", dagID, "status"), nil, body, nil)
+}
+
+func (c *Client) CreateDraft(ctx context.Context, dagID string) (*dag.DAG, error) {
+	var out dag.DAG
+	if err := c.call(ctx, http.MethodPost, route("dag", dagID, "draft"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) PromoteDraft(ctx context.Context, dagID string) (*dag.DAG, error) {
+	var out dag.DAG
+	if err := c.call(ctx, http.MethodPost, route("dag", dagID, "draft", "promote"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ── Nodes ───────────────────────────────────────────────────────────────
+
+// AddNode sets node.ID to the ID the server assigned, as PGStore does.
+func (c *Client) AddNode(ctx context.Context, dagID string, node *dag.Node) (string, error) {
+	var out struct {
+		ID string `json:"id"`
+	}
+	if err := c.call(c.idempotent(ctx, 1), http.MethodPost, route("dag", dagID, "nodes"), nil, node, &out); err != nil {
+		return "", err
+	}
+	node.ID = out.ID
+	return out.ID, nil
+}
+
+func (c *Client) GetNode(ctx context.Context, nodeID string) (*dag.Node, error) {
+	var out dag.Node
+	err := c.call(ctx, http.MethodGet, route("nodes", nodeID), nil, nil, &out)
+	if isNotFound(err, "node_not_found") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) UpdateNode(ctx context.Context, node *dag.Node) error {
+	return c.call(ctx, http.MethodPut, route("nodes", node.ID), nil, node, nil)
+}
+
+func (c *Client) DeleteNode(ctx context.Context, nodeID string) error {
+	return c.call(ctx, http.MethodDelete, route("nodes", nodeID), nil, nil, nil)
+}
+
+func (c *Client) ListNodes(ctx context.Context, dagID string) ([]dag.Node, error) {
+	var out []dag.Node
+	if err := c.call(ctx, http.MethodGet, route("dag", dagID, "nodes"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) FindNodesByTag(ctx context.Context, dagID, tag string) ([]dag.Node, error) {
+	var out []dag.Node
+	if err := c.call(ctx, http.MethodGet, route("dag", dagID, "nodes"), url.Values{"tag": {tag}}, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) CountNodes(ctx context.Context, dagID string) (int, error) {
+	return c.count(ctx, route("dag", dagID, "nodes"), nil)
+}
+
+func (c *Client) ListNodesPage(ctx context.Context, dagID string, opts dag.ListOptions) (*dag.Page[dag.Node], error) {
+	return listPage[dag.Node](ctx, c, route("dag", dagID, "nodes"), opts)
+}
+
+func (c *Client) AddNodes(ctx context.Context, dagID string, nodes []dag.Node) ([]dag.BatchResult, error) {
+	return addBatch(ctx, c, route("dag", dagID, "nodes:batch"), nodes)
+}
+
+// ── Edges ───────────────────────────────────────────────────────────────
+
+// AddEdge sets edge.ID to the ID the server assigned, as PGStore does.
+func (c *Client) AddEdge(ctx context.Context, dagID string, edge *dag.Edge) (string, error) {
+	var out struct {
+		ID string `json:"id"`
+	}
+	if err := c.call(c.idempotent(ctx, 1), http.MethodPost, route("dag", dagID, "edges"), nil, edge, &out); err != nil {
+		return "", err
+	}
+	edge.ID = out.ID
+	return out.ID, nil
+}
+
+func (c *Client) GetEdge(ctx context.Context, edgeID string) (*dag.Edge, error) {
+	var out dag.Edge
+	err := c.call(ctx, http.MethodGet, route("edges", edgeID), nil, nil, &out)
+	if isNotFound(err, "edge_not_found") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) UpdateEdge(ctx context.Context, edge *dag.Edge) error {
+	return c.call(ctx, http.MethodPut, route("edges", edge.ID), nil, edge, nil)
+}
+
+func (c *Client) DeleteEdge(ctx context.Context, edgeID string) error {
+	return c.call(ctx, http.MethodDelete, route("edges", edgeID), nil, nil, nil)
+}
+
+func (c *Client) ListEdges(ctx context.Context, dagID string) ([]dag.Edge, error) {
+	var out []dag.Edge
+	if err := c.call(ctx, http.MethodGet, route("dag", dagID, "edges"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) FindEdges(ctx context.Context, dagID string, filter dag.EdgeFilter) ([]dag.Edge, error) {
+	q := url.Values{}
+	if filter.Contains != nil {
+		q.Set("contains", string(filter.Contains))
+	}
+	for k, v := range map[string]string{"match": filter.Match, "from_node_id": filter.FromNodeID, "to_node_id": filter.ToNodeID} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	var out []dag.Edge
+	if err := c.call(ctx, http.MethodGet, route("dag", dagID, "edges"), q, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) CountEdges(ctx context.Context, dagID string) (int, error) {
+	return c.count(ctx, route("dag", dagID, "edges"), nil)
+}
+
+func (c *Client) ListEdgesPage(ctx context.Context, dagID string, opts dag.ListOptions) (*dag.Page[dag.Edge], error) {
+	return listPage[dag.Edge](ctx, c, route("dag", dagID, "edges"), opts)
+}
+
+func (c *Client) AddEdges(ctx context.Context, dagID string, edges []dag.Edge) ([]dag.BatchResult, error) {
+	return addBatch(ctx, c, route("dag", dagID, "edges:batch"), edges)
+}
+
+func (c *Client) ReorderEdges(ctx context.Context, fromNodeID string, edgeIDs []string) error {
+	if edgeIDs == nil {
+		edgeIDs = []string{}
+	}
+	body := struct {
+		EdgeIDs []string `json:"edge_ids"`
+	}{edgeIDs}
+	return c.call(ctx, http.MethodPut, route("nodes", fromNodeID, "edges", "order"), nil, body, nil)
+}
+
+// ── Change sets ─────────────────────────────────────────────────────────
+
+// ApplyChangeSet fills cs with the IDs the server resolved, as PGStore
+// does.
+func (c *Client) ApplyChangeSet(ctx context.Context, dagID string, cs *dag.ChangeSet) error {
+	var out dag.ChangeSet
+	if err := c.call(c.idempotent(ctx, 1), http.MethodPost, route("dag", dagID, "changes"), nil, cs, &out); err != nil {
+		return err
+	}
+	*cs = out
+	return nil
+}
+
+// ── Traversal ───────────────────────────────────────────────────────────
+
+func (c *Client) Ancestors(ctx context.Context, nodeID string) ([]dag.Node, error) {
+	var out []dag.Node
+	if err := c.call(ctx, http.MethodGet, route("nodes", nodeID, "ancestors"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) Descendants(ctx context.Context, nodeID string) ([]dag.Node, error) {
+	var out []dag.Node
+	if err := c.call(ctx, http.MethodGet, route("nodes", nodeID, "descendants"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) Path(ctx context.Context, dagID, fromID, toID string) ([]dag.Node, error) {
+	var out struct {
+		Nodes []dag.Node `json:"nodes"`
+	}
+	err := c.call(ctx, http.MethodGet, route("dag", dagID, "path"), url.Values{"from": {fromID}, "to": {toID}}, nil, &out)
+	if isNotFound(err, "path_not_found") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out.Nodes, nil
+}
+
+func (c *Client) Neighborhood(ctx context.Context, nodeID string, k int, dir dag.Direction) (*dag.DAG, error) {
+	q := url.Values{"k": {strconv.Itoa(k)}}
+	if dir != "" {
+		q.Set("direction", string(dir))
+	}
+	var out dag.DAG
+	if err := c.call(ctx, http.MethodGet, route("nodes", nodeID, "neighborhood"), q, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ── Helpers ─────────────────────────────────────────────────────────────
+
+// count asks a HEAD route for its X-Total-Count.
+func (c *Client) count(ctx context.Context, path string, q url.Values) (int, error) {
+	resp, err := c.send(ctx, http.MethodHead, path, q, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	n, err := strconv.Atoi(resp.Header.Get(totalCountHeader))
+	if err != nil {
+		return 0, fmt.Errorf("dag: HEAD %s: bad %s: %w", path, totalCountHeader, err)
+	}
+	return n, nil
+}
+
+// listPage asks a list route for a page. Without paging options the route
+// answers with a plain array, which becomes a single page.
+func listPage[T any](ctx context.Context, c *Client, path string, opts dag.ListOptions) (*dag.Page[T], error) {
+	resp, err := c.send(ctx, http.MethodGet, path, listValues(opts), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("dag: GET %s: %w", path, err)
+	}
+	page := &dag.Page[T]{}
+	if trimmed := strings.TrimSpace(string(b)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(b, &page.Items)
+	} else {
+		err = json.Unmarshal(b, page)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dag: decode GET %s: %w", path, err)
+	}
+	return page, nil
+}
+
+// addBatch posts items to a batch route, in chunks the server accepts, and
+// turns its multi-status results into BatchResults in the order of items.
+func addBatch[T any](ctx context.Context, c *Client, path string, items []T) ([]dag.BatchResult, error) {
+	results := make([]dag.BatchResult, 0, len(items))
+	n := 0
+	for chunk := range slices.Chunk(items, maxBatchItems) {
+		n++
+		var out struct {
+			Results []struct {
+				Index  int    `json:"index"`
+				Status int    `json:"status"`
+				ID     string `json:"id"`
+				Error  *Error `json:"error"`
+			} `json:"results"`
+		}
+		if err := c.call(c.idempotent(ctx, n), http.MethodPost, path, nil, chunk, &out); err != nil {
+			return nil, err
+		}
+		part := make([]dag.BatchResult, len(chunk))
+		for _, r := range out.Results {
+			if r.Index < 0 || r.Index >= len(part) {
+				continue
+			}
+			if r.Error != nil {
+				r.Error.Status = r.Status
+				part[r.Index].Err = r.Error
+				continue
+			}
+			part[r.Index].ID = r.ID
+		}
+		results = append(results, part...)
+	}
+	return results, nil
+}
+
+// getQuery encodes GetDAGOptions as the query parameters of GET /dag/:id.
+func getQuery(opts []dag.GetDAGOptions) url.Values {
+	q := url.Values{}
+	if len(opts) == 0 {
+		return q
+	}
+	o := opts[0]
+	var skip []string
+	if o.SkipData {
+		skip = append(skip, "data")
+	}
+	if o.SkipNodes {
+		skip = append(skip, "nodes")
+	}
+	if o.SkipEdges {
+		skip = append(skip, "edges")
+	}
+	if len(skip) > 0 {
+		q.Set("skip", strings.Join(skip, ","))
+	}
+	if len(o.Fields) > 0 {
+		q.Set("fields", strings.Join(o.Fields, ","))
+	}
+	if len(o.Locale) > 0 {
+		q.Set("locale", strings.Join(o.Locale, ","))
+	}
+	if o.Topological {
+		q.Set("order", "topological")
+	}
+	return q
+}
+
+// listValues encodes ListOptions as the query parameters of the list
+// routes.
+func listValues(opts dag.ListOptions) url.Values {
+	q := url.Values{}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		q.Set("cursor", opts.Cursor)
+	}
+	if opts.Sort != "" {
+		q.Set("sort", opts.Sort)
+	}
+	if len(opts.Filter) > 0 {
+		var pairs []string
+		for _, k := range slices.Sorted(maps.Keys(opts.Filter)) {
+			pairs = append(pairs, k+":"+opts.Filter[k])
+		}
+		q.Set("filter", strings.Join(pairs, ","))
+	}
+	if len(opts.Fields) > 0 {
+		q.Set("fields", strings.Join(opts.Fields, ","))
+	}
+	return q
+}
+
+// searchValues encodes a SearchQuery as the query parameters of GET /dags.
+func searchValues(s dag.SearchQuery) url.Values {
+	q := url.Values{}
+	if s.Name != "" {
+		q.Set("name", s.Name)
+	}
+	if s.Tag != "" {
+		q.Add("tag", s.Tag)
+	}
+	for _, t := range s.Tags {
+		q.Add("tag", t)
+	}
+	for _, t := range s.AnyTags {
+		q.Add("any_tag", t)
+	}
+	if !s.CreatedAfter.IsZero() {
+		q.Set("created_after", s.CreatedAfter.UTC().Format(time.RFC3339Nano))
+	}
+	if s.Status != "" {
+		q.Set("status", string(s.Status))
+	}
+	if s.Text != "" {
+		q.Set("q", s.Text)
+	}
+	if s.IncludeNodeData {
+		q.Set("nodes", "true")
+	}
+	if s.Limit > 0 {
+		q.Set("limit", strconv.Itoa(s.Limit))
+	}
+	return q
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"expvar"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Per-method counters, published at /debug/vars when DAG_GRPC_METRICS_ADDR is set.
+var (
+	rpcCalls   = expvar.NewMap("dag_grpc_calls")
+	rpcErrors  = expvar.NewMap("dag_grpc_errors")
+	rpcLatency = expvar.NewMap("dag_grpc_latency_us")
+)
+
+// metricsInterceptor counts calls, errors (by status code) and cumulative
+// latency per method.
+func metricsInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+
+	rpcCalls.Add(info.FullMethod, 1)
+	rpcLatency.Add(info.FullMethod, time.Since(start).Microseconds())
+	if err != nil {
+		rpcErrors.Add(info.FullMethod+" "+status.Code(err).String(), 1)
+	}
+	return resp, err
+}
+
+// authInterceptor requires "authorization: Bearer <token>" metadata on every
+// call except health checks and reflection, which load balancers and tools
+// call unauthenticated.
+func authInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if isPublicMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		if err := checkToken(ctx, token); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// streamAuthInterceptor is authInterceptor for streaming RPCs
+// (reflection and health Watch).
+func streamAuthInterceptor(token string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isPublicMethod(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		if err := checkToken(ss.Context(), token); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func isPublicMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/grpc.health.v1.Health/") ||
+		strings.HasPrefix(fullMethod, "/grpc.reflection.")
+}
+
+func checkToken(ctx context.Context, token string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		got, ok := strings.CutPrefix(v, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+}
@@ -0,0 +1,94 @@
+// Command dag-grpc serves dag.Store over gRPC (dag.v1.DagService) with the
+// standard health service and server reflection.
+//
+// Environment:
+//
+//	DATABASE_URL           PostgreSQL connection string (required)
+//	DAG_GRPC_ADDR          listen address (default ":50051")
+//	DAG_GRPC_TOKEN         if set, every call must send "authorization: Bearer <token>"
+//	DAG_GRPC_METRICS_ADDR  if set, serves expvar counters at /debug/vars on this address
+package main
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/meikuraledutech/dag"
+	"github.com/meikuraledutech/dag/postgres"
+	dagv1 "github.com/meikuraledutech/dag/proto/dag/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+func main() {
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		log.Fatal("DATABASE_URL is not set")
+	}
+	addr := os.Getenv("DAG_GRPC_ADDR")
+	if addr == "" {
+		addr = ":50051"
+	}
+
+	pool, err := pgxpool.New(context.Background(), dbURL)
+	if err != nil {
+		log.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+
+	var store dag.Store = postgres.New(pool)
+
+	unary := []grpc.UnaryServerInterceptor{metricsInterceptor}
+	var stream []grpc.StreamServerInterceptor
+	if token := os.Getenv("DAG_GRPC_TOKEN"); token != "" {
+		unary = append(unary, authInterceptor(token))
+		stream = append(stream, streamAuthInterceptor(token))
+	}
+
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
+	dagv1.RegisterDagServiceServer(srv, &service{store: store})
+
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	hs.SetServingStatus(dagv1.DagService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	reflection.Register(srv)
+
+	if metricsAddr := os.Getenv("DAG_GRPC_METRICS_ADDR"); metricsAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/debug/vars", expvar.Handler())
+			log.Printf("metrics: %v", http.ListenAndServe(metricsAddr, mux))
+		}()
+	}
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("listen: %v", err)
+	}
+
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+		<-sig
+		hs.Shutdown()
+		srv.GracefulStop()
+	}()
+
+	log.Printf("dag-grpc listening on %s", addr)
+	if err := srv.Serve(lis); err != nil {
+		log.Fatalf("serve: %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/meikuraledutech/dag"
+	"github.com/meikuraledutech/dag/dagpb"
+	dagv1 "github.com/meikuraledutech/dag/proto/dag/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// service implements dagv1.DagServiceServer on top of a dag.Store.
+type service struct {
+	dagv1.UnimplementedDagServiceServer
+	store dag.Store
+}
+
+func (s *service) CreateDAG(ctx context.Context, req *dagv1.CreateDAGRequest) (*dagv1.CreateDAGResponse, error) {
+	if req.GetDag().GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "dag.id is required")
+	}
+	d, err := s.store.CreateDAG(ctx, dagFromProto(req.GetDag()), dag.CreateDAGOptions{Replace: req.GetReplace()})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &dagv1.CreateDAGResponse{Dag: dagpb.FromDAG(d)}, nil
+}
+
+func (s *service) GetDAG(ctx context.Context, req *dagv1.GetDAGRequest) (*dagv1.GetDAGResponse, error) {
+	d, err := s.store.GetDAG(ctx, req.GetDagId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if d == nil {
+		return nil, status.Error(codes.NotFound, "dag not found")
+	}
+	return &dagv1.GetDAGResponse{Dag: dagpb.FromDAG(d)}, nil
+}
+
+func (s *service) DeleteDAG(ctx context.Context, req *dagv1.DeleteDAGRequest) (*dagv1.DeleteDAGResponse, error) {
+	if err := s.store.DeleteDAG(ctx, req.GetDagId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &dagv1.DeleteDAGResponse{}, nil
+}
+
+func (s *service) AddNode(ctx context.Context, req *dagv1.AddNodeRequest) (*dagv1.AddNodeResponse, error) {
+	n := dagpb.ToNode(req.GetNode())
+	id, err := s.store.AddNode(ctx, req.GetDagId(), &n)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &dagv1.AddNodeResponse{Id: id}, nil
+}
+
+func (s *service) GetNode(ctx context.Context, req *dagv1.GetNodeRequest) (*dagv1.GetNodeResponse, error) {
+	n, err := s.store.GetNode(ctx, req.GetNodeId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if n == nil {
+		return nil, toStatus(dag.ErrNodeNotFound)
+	}
+	return &dagv1.GetNodeResponse{Node: dagpb.FromNode(*n)}, nil
+}
+
+func (s *service) UpdateNode(ctx context.Context, req *dagv1.UpdateNodeRequest) (*dagv1.UpdateNodeResponse, error) {
+	n := dagpb.ToNode(req.GetNode())
+	if err := s.store.UpdateNode(ctx, &n); err != nil {
+		return nil, toStatus(err)
+	}
+	return &dagv1.UpdateNodeResponse{}, nil
+}
+
+func (s *service) DeleteNode(ctx context.Context, req *dagv1.DeleteNodeRequest) (*dagv1.DeleteNodeResponse, error) {
+	if err := s.store.DeleteNode(ctx, req.GetNodeId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &dagv1.DeleteNodeResponse{}, nil
+}
+
+func (s *service) ListNodes(ctx context.Context, req *dagv1.ListNodesRequest) (*dagv1.ListNodesResponse, error) {
+	nodes, err := s.store.ListNodes(ctx, req.GetDagId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &dagv1.ListNodesResponse{}
+	for _, n := range nodes {
+		resp.Nodes = append(resp.Nodes, dagpb.FromNode(n))
+	}
+	return resp, nil
+}
+
+func (s *service) AddEdge(ctx context.Context, req *dagv1.AddEdgeRequest) (*dagv1.AddEdgeResponse, error) {
+	e := edgeFromProto(req.GetEdge())
+	id, err := s.store.AddEdge(ctx, req.GetDagId(), &e)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &dagv1.AddEdgeResponse{Id: id}, nil
+}
+
+func (s *service) GetEdge(ctx context.Context, req *dagv1.GetEdgeRequest) (*dagv1.GetEdgeResponse, error) {
+	e, err := s.store.GetEdge(ctx, req.GetEdgeId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if e == nil {
+		return nil, toStatus(dag.ErrEdgeNotFound)
+	}
+	return &dagv1.GetEdgeResponse{Edge: dagpb.FromEdge(*e)}, nil
+}
+
+func (s *service) UpdateEdge(ctx context.Context, req *dagv1.UpdateEdgeRequest) (*dagv1.UpdateEdgeResponse, error) {
+	e := edgeFromProto(req.GetEdge())
+	if err := s.store.UpdateEdge(ctx, &e); err != nil {
+		return nil, toStatus(err)
+	}
+	return &dagv1.UpdateEdgeResponse{}, nil
+}
+
+func (s *service) DeleteEdge(ctx context.Context, req *dagv1.DeleteEdgeRequest) (*dagv1.DeleteEdgeResponse, error) {
+	if err := s.store.DeleteEdge(ctx, req.GetEdgeId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &dagv1.DeleteEdgeResponse{}, nil
+}
+
+func (s *service) ListEdges(ctx context.Context, req *dagv1.ListEdgesRequest) (*dagv1.ListEdgesResponse, error) {
+	edges, err := s.store.ListEdges(ctx, req.GetDagId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &dagv1.ListEdgesResponse{}
+	for _, e := range edges {
+		resp.Edges = append(resp.Edges, dagpb.FromEdge(e))
+	}
+	return resp, nil
+}
+
+// toStatus maps store errors to gRPC status codes, mirroring the HTTP server.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, dag.ErrCycleDetected):
+		return status.Error(codes.FailedPrecondition, "cycle detected")
+	case errors.Is(err, dag.ErrNodeNotFound):
+		return status.Error(codes.NotFound, "node not found")
+	case errors.Is(err, dag.ErrEdgeNotFound):
+		return status.Error(codes.NotFound, "edge not found")
+	case errors.Is(err, dag.ErrDAGNotFound):
+		return status.Error(codes.NotFound, "dag not found")
+	case errors.Is(err, dag.ErrDAGExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, dag.ErrDAGFrozen), errors.Is(err, dag.ErrParallelEdge),
+		errors.Is(err, dag.ErrNotTree), errors.Is(err, dag.ErrMultipleRoots), errors.Is(err, dag.ErrDisconnected), errors.Is(err, dag.ErrTooDeep),
+		errors.Is(err, dag.ErrOutDegree), errors.Is(err, dag.ErrDuplicateCondition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, dag.ErrInvalidData), errors.Is(err, dag.ErrUnknownNodeType):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// ── Conversions ─────────────────────────────────────────────────────
+
+// dagFromProto converts a request DAG, dropping the output-only status and
+// hash so clients cannot set them.
+func dagFromProto(m *dagv1.DAG) *dag.DAG {
+	d := dagpb.ToDAG(m)
+	if d != nil {
+		d.Status, d.Hash = "", ""
+	}
+	return d
+}
+
+// edgeFromProto converts a request edge, dropping the output-only
+// order_index; the store assigns it.
+func edgeFromProto(m *dagv1.Edge) dag.Edge {
+	e := dagpb.ToEdge(m)
+	e.OrderIndex = 0
+	return e
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/meikuraledutech/dag/postgres"
+)
+
+// entryExt ends the name of every tar entry; the rest is the escaped DAG ID.
+const entryExt = ".jsonl"
+
+// dumpArchive writes every DAG selected by o to o.file as a tar archive with
+// one entry per DAG, each a dump of that DAG alone. DAGs are read one at a
+// time, each from its own snapshot and buffered in memory for its tar
+// header, so a DAG deleted after the listing is skipped.
+func dumpArchive(ctx context.Context, store *postgres.PGStore, o *options) error {
+	ids, err := store.DAGIDs(ctx)
+	if err != nil {
+		return err
+	}
+	var selected []string
+	for _, id := range ids {
+		if o.keep(id) {
+			selected = append(selected, id)
+		}
+	}
+
+	f, err := os.Create(o.file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zw, err := compressor(f, o.file)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(zw)
+
+	var buf bytes.Buffer
+	dumped := 0
+	for i, id := range selected {
+		buf.Reset()
+		if err := store.DumpDAG(ctx, &buf, id); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		if bytes.Count(buf.Bytes(), []byte("\n")) == 1 {
+			o.progress("skipped %s: deleted since listed", id)
+			continue
+		}
+		hdr := &tar.Header{
+			Name:    url.PathEscape(id) + entryExt,
+			Mode:    0o644,
+			Size:    int64(buf.Len()),
+			ModTime: time.Now(),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(buf.Bytes()); err != nil {
+			return err
+		}
+		dumped++
+		o.progress("dumped %d/%d %s (%d bytes)", i+1, len(selected), id, buf.Len())
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	o.progress("dumped %d dags to %s", dumped, o.file)
+	return f.Close()
+}
+
+// restoreArchive loads every DAG in the tar archive o.file that o selects,
+// each in its own transaction, so a failure leaves the DAGs before it
+// restored.
+func restoreArchive(ctx context.Context, store *postgres.PGStore, o *options) error {
+	f, err := os.Open(o.file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zr, err := decompressor(f, o.file)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	// The target may be a fresh database.
+	if err := store.CreateSchema(ctx); err != nil {
+		return err
+	}
+	tr := tar.NewReader(zr)
+	restored, skipped := 0, 0
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg || !strings.HasSuffix(hdr.Name, entryExt) {
+			continue
+		}
+		id, err := url.PathUnescape(strings.TrimSuffix(hdr.Name, entryExt))
+		if err != nil {
+			return fmt.Errorf("entry %q: %w", hdr.Name, err)
+		}
+		if !o.keep(id) {
+			skipped++
+			continue
+		}
+		if err := store.Restore(ctx, tr); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		restored++
+		o.progress("restored %d %s", restored, id)
+	}
+	o.progress("restored %d dags from %s, skipped %d", restored, o.file, skipped)
+	return nil
+}
+
+// nopWriteCloser adds a Close that does nothing to an uncompressed file.
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// compressor wraps w in the compression file's name asks for.
+func compressor(w io.Writer, file string) (io.WriteCloser, error) {
+	switch {
+	case strings.HasSuffix(file, ".gz"), strings.HasSuffix(file, ".tgz"):
+		return gzip.NewWriter(w), nil
+	case strings.HasSuffix(file, ".zst"):
+		return zstd.NewWriter(w)
+	}
+	return nopWriteCloser{w}, nil
+}
+
+// decompressor wraps r to undo the compression file's name says it has.
+func decompressor(r io.Reader, file string) (io.ReadCloser, error) {
+	switch {
+	case strings.HasSuffix(file, ".gz"), strings.HasSuffix(file, ".tgz"):
+		return gzip.NewReader(r)
+	case strings.HasSuffix(file, ".zst"):
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return io.NopCloser(r), nil
+}
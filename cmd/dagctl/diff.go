@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/meikuraledutech/dag"
+	"github.com/meikuraledutech/dag/export"
+	"github.com/meikuraledutech/dag/postgres"
+)
+
+// maxValue caps how much of a JSON value diff prints on one line.
+const maxValue = 120
+
+// diffCommand parses the arguments of diff and returns the command and
+// whether it needs the database, which it does only for a side that is not
+// a file.
+func diffCommand(args []string) (func(context.Context, *postgres.PGStore) error, bool) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the change set that turns old into new instead")
+	quiet := fs.Bool("q", false, "print nothing; only the exit code tells")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		usage()
+	}
+	oldArg, newArg := fs.Arg(0), fs.Arg(1)
+
+	run := func(ctx context.Context, store *postgres.PGStore) error {
+		a, err := loadDAG(ctx, store, oldArg)
+		if err != nil {
+			return err
+		}
+		b, err := loadDAG(ctx, store, newArg)
+		if err != nil {
+			return err
+		}
+		w := io.Writer(os.Stdout)
+		if *asJSON || *quiet {
+			w = io.Discard
+		}
+		d := &differ{w: w}
+		d.printf("--- %s\n+++ %s\n", oldArg, newArg)
+		d.dag(a, b)
+		if *asJSON && !*quiet {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(dag.Diff(a, b)); err != nil {
+				return err
+			}
+		}
+		if d.changes > 0 {
+			return errFound
+		}
+		return nil
+	}
+	return run, !isFile(oldArg) || !isFile(newArg)
+}
+
+// isFile reports whether name is an existing regular file.
+func isFile(name string) bool {
+	fi, err := os.Stat(name)
+	return err == nil && fi.Mode().IsRegular()
+}
+
+// loadDAG reads the export file arg, in the format its extension names
+// (JSON if none), or else gets the DAG with ID arg from store.
+func loadDAG(ctx context.Context, store *postgres.PGStore, arg string) (*dag.DAG, error) {
+	if !isFile(arg) {
+		d, err := store.GetDAG(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+		if d == nil {
+			return nil, fmt.Errorf("%s: %w", arg, dag.ErrDAGNotFound)
+		}
+		return d, nil
+	}
+	f, err := os.Open(arg)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	format, err := export.ParseFormat(strings.TrimPrefix(filepath.Ext(arg), "."))
+	if err != nil {
+		format = export.JSON
+	}
+	d, err := export.Read(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", arg, err)
+	}
+	return d, nil
+}
+
+// differ prints the differences between two DAGs and counts them.
+type differ struct {
+	w       io.Writer
+	changes int
+}
+
+func (d *differ) printf(format string, args ...any) {
+	fmt.Fprintf(d.w, format, args...)
+}
+
+// change prints one difference.
+func (d *differ) change(format string, args ...any) {
+	d.changes++
+	d.printf(format+"\n", args...)
+}
+
+// detail prints a line under the last change, indented.
+func (d *differ) detail(format string, args ...any) {
+	d.printf("    "+format+"\n", args...)
+}
+
+// dag prints how b differs from a: its own fields, then its nodes and
+// edges as dag.Diff matches them, then a summary line.
+func (d *differ) dag(a, b *dag.DAG) {
+	if a.Name != b.Name {
+		d.change("name: %q -> %q", a.Name, b.Name)
+	}
+	if a.Status != b.Status {
+		d.change("status: %s -> %s", orNone(string(a.Status)), orNone(string(b.Status)))
+	}
+	if added, removed := setDiff(a.Tags, b.Tags); len(added)+len(removed) > 0 {
+		d.change("tags: %s", tagChange(added, removed))
+	}
+	if !sameTime(a.ExpiresAt, b.ExpiresAt) {
+		d.change("expires_at: %s -> %s", timeString(a.ExpiresAt), timeString(b.ExpiresAt))
+	}
+	walkJSON("settings", asValue(a.Settings), asValue(b.Settings), d.change)
+
+	cs := dag.Diff(a, b)
+	oldNodes := make(map[string]dag.Node, len(a.Nodes))
+	for _, n := range a.Nodes {
+		oldNodes[n.ID] = n
+	}
+	for _, n := range cs.AddNodes {
+		d.change("+ node %s %s%s", nodeName(n), short(n.Data), tagList(n.Tags))
+	}
+	for _, id := range cs.DeleteNodes {
+		n := oldNodes[id]
+		d.change("- node %s %s%s", id, short(n.Data), tagList(n.Tags))
+	}
+	for _, n := range cs.UpdateNodes {
+		o := oldNodes[n.ID]
+		d.change("~ node %s", n.ID)
+		walkJSON("data", rawValue(o.Data), rawValue(n.Data), d.detail)
+		if added, removed := setDiff(o.Tags, n.Tags); len(added)+len(removed) > 0 {
+			d.detail("tags: %s", tagChange(added, removed))
+		} else if !slices.Equal(o.Tags, n.Tags) {
+			d.detail("tags: reordered")
+		}
+	}
+
+	oldEdges := make(map[string]dag.Edge, len(a.Edges))
+	for _, e := range a.Edges {
+		oldEdges[e.ID] = e
+	}
+	updated := make(map[string]dag.Edge, len(cs.UpdateEdges))
+	for _, e := range cs.UpdateEdges {
+		updated[e.ID] = e
+	}
+	for _, e := range cs.AddEdges {
+		d.change("+ edge %s %s %s", edgeName(e), ends(e), short(e.Data))
+	}
+	for _, id := range cs.DeleteEdges {
+		e := oldEdges[id]
+		d.change("- edge %s %s %s", id, ends(e), short(e.Data))
+	}
+	reordered := 0
+	for _, e := range b.Edges {
+		o, ok := oldEdges[e.ID]
+		_, changed := updated[e.ID]
+		if !ok || e.ID == "" || (!changed && o.OrderIndex == e.OrderIndex) {
+			continue
+		}
+		d.change("~ edge %s %s", e.ID, ends(e))
+		if ends(o) != ends(e) {
+			d.detail("ends: %s -> %s", ends(o), ends(e))
+		}
+		walkJSON("data", rawValue(o.Data), rawValue(e.Data), d.detail)
+		if o.OrderIndex != e.OrderIndex {
+			d.detail("order: %d -> %d", o.OrderIndex, e.OrderIndex)
+			if !changed {
+				reordered++
+			}
+		}
+	}
+
+	if d.changes > 0 {
+		d.printf("nodes: +%d -%d ~%d, edges: +%d -%d ~%d\n",
+			len(cs.AddNodes), len(cs.DeleteNodes), len(cs.UpdateNodes),
+			len(cs.AddEdges), len(cs.DeleteEdges), len(cs.UpdateEdges)+reordered)
+	}
+}
+
+// walkJSON calls emit for every leaf where the decoded JSON values a and b
+// differ, naming it by its path from path.
+func walkJSON(path string, a, b any, emit func(string, ...any)) {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, ok := av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			x, inA := av[k]
+			y, inB := bv[k]
+			switch {
+			case !inA:
+				emit("%s.%s: + %s", path, k, compact(y))
+			case !inB:
+				emit("%s.%s: - %s", path, k, compact(x))
+			default:
+				walkJSON(path+"."+k, x, y, emit)
+			}
+		}
+		return
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			break
+		}
+		for i := range max(len(av), len(bv)) {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(av):
+				emit("%s: + %s", p, compact(bv[i]))
+			case i >= len(bv):
+				emit("%s: - %s", p, compact(av[i]))
+			default:
+				walkJSON(p, av[i], bv[i], emit)
+			}
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		emit("%s: %s -> %s", path, compact(a), compact(b))
+	}
+}
+
+// rawValue decodes raw JSON, treating empty input as null.
+func rawValue(raw json.RawMessage) any {
+	var v any
+	if len(bytes.TrimSpace(raw)) > 0 {
+		_ = json.Unmarshal(raw, &v)
+	}
+	return v
+}
+
+// asValue converts v to its decoded JSON form.
+func asValue(v any) any {
+	raw, _ := json.Marshal(v)
+	return rawValue(raw)
+}
+
+// compact renders a decoded JSON value on one line, cut at maxValue.
+func compact(v any) string {
+	raw, _ := json.Marshal(v)
+	return truncate(string(raw))
+}
+
+// short renders raw JSON data on one line, cut at maxValue.
+func short(raw json.RawMessage) string {
+	return compact(rawValue(raw))
+}
+
+func truncate(s string) string {
+	if r := []rune(s); len(r) > maxValue {
+		return string(r[:maxValue-1]) + "…"
+	}
+	return s
+}
+
+// nodeName names a node by its ID, or its ref if it has none yet.
+func nodeName(n dag.Node) string {
+	if n.ID == "" {
+		return "ref:" + n.Ref
+	}
+	return n.ID
+}
+
+func edgeName(e dag.Edge) string {
+	if e.ID == "" {
+		return "(new)"
+	}
+	return e.ID
+}
+
+// ends renders an edge's endpoints, by ref where it has no ID.
+func ends(e dag.Edge) string {
+	from, to := e.FromNodeID, e.ToNodeID
+	if e.FromNodeRef != "" {
+		from = "ref:" + e.FromNodeRef
+	}
+	if e.ToNodeRef != "" {
+		to = "ref:" + e.ToNodeRef
+	}
+	return from + " -> " + to
+}
+
+// setDiff returns the tags of b not in a, and of a not in b.
+func setDiff(a, b []string) (added, removed []string) {
+	for _, t := range b {
+		if !slices.Contains(a, t) {
+			added = append(added, t)
+		}
+	}
+	for _, t := range a {
+		if !slices.Contains(b, t) {
+			removed = append(removed, t)
+		}
+	}
+	return added, removed
+}
+
+func tagChange(added, removed []string) string {
+	var parts []string
+	for _, t := range added {
+		parts = append(parts, "+"+t)
+	}
+	for _, t := range removed {
+		parts = append(parts, "-"+t)
+	}
+	return strings.Join(parts, " ")
+}
+
+func tagList(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(tags, " ") + "]"
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func timeString(t *time.Time) string {
+	if t == nil {
+		return "(none)"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/meikuraledutech/dag"
+	"github.com/meikuraledutech/dag/postgres"
+)
+
+// severity ranks a lint finding.
+type severity int
+
+const (
+	info severity = iota
+	warning
+	failure
+)
+
+var severityNames = []string{"info", "warning", "error"}
+
+func (s severity) String() string { return severityNames[s] }
+
+func (s severity) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+func parseSeverity(name string) (severity, error) {
+	i := slices.Index(severityNames, strings.ToLower(name))
+	if i < 0 {
+		return 0, fmt.Errorf("unknown severity %q (want info, warning or error)", name)
+	}
+	return severity(i), nil
+}
+
+// finding is one problem lint reports.
+type finding struct {
+	Severity severity `json:"severity"`
+	// Rule names the check, such as "unreachable".
+	Rule string `json:"rule"`
+	// ID is the node or edge the finding is about, if any.
+	ID      string `json:"id,omitempty"`
+	Message string `json:"message"`
+}
+
+// lintStats summarises the shape of a DAG.
+type lintStats struct {
+	Nodes        int `json:"nodes"`
+	Edges        int `json:"edges"`
+	Roots        int `json:"roots"`
+	Leaves       int `json:"leaves"`
+	Depth        int `json:"depth"`
+	MaxOutDegree int `json:"max_out_degree"`
+}
+
+// lintOptions are the flags of lint.
+type lintOptions struct {
+	start        string
+	conditionKey string
+	labelKeys    []string
+}
+
+// lintCommand parses the arguments of lint and returns the command and
+// whether it needs the database, which it does unless the DAG is a file.
+func lintCommand(args []string) (func(context.Context, *postgres.PGStore) error, bool) {
+	o := lintOptions{labelKeys: []string{"label", "name", "title", "question"}}
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	fs.StringVar(&o.start, "start", "", "node the flow starts at (default every node without incoming edges)")
+	fs.StringVar(&o.conditionKey, "condition-key", "answer", "edge data key that holds a branch's condition")
+	labels := fs.String("label-keys", strings.Join(o.labelKeys, ","), "node data keys, comma-separated, any of which is a label")
+	failOn := fs.String("fail-on", "error", "lowest severity that makes lint exit 1: info, warning or error")
+	asJSON := fs.Bool("json", false, "print stats and findings as JSON")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+	threshold, err := parseSeverity(*failOn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	o.labelKeys = strings.Split(*labels, ",")
+	arg := fs.Arg(0)
+
+	run := func(ctx context.Context, store *postgres.PGStore) error {
+		d, err := loadDAG(ctx, store, arg)
+		if err != nil {
+			return err
+		}
+		stats, findings := lint(d, o)
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(struct {
+				ID       string    `json:"id"`
+				Stats    lintStats `json:"stats"`
+				Findings []finding `json:"findings"`
+			}{d.ID, stats, findings})
+		} else {
+			err = printLint(d, stats, findings)
+		}
+		if err != nil {
+			return err
+		}
+		for _, f := range findings {
+			if f.Severity >= threshold {
+				return errFound
+			}
+		}
+		return nil
+	}
+	return run, !isFile(arg)
+}
+
+// lint computes the stats of d and checks it, returning the findings worst
+// first, then in node and edge order.
+func lint(d *dag.DAG, o lintOptions) (lintStats, []finding) {
+	var findings []finding
+	add := func(sev severity, rule, id, format string, args ...any) {
+		findings = append(findings, finding{sev, rule, id, fmt.Sprintf(format, args...)})
+	}
+
+	known := make(map[string]bool, len(d.Nodes))
+	for _, n := range d.Nodes {
+		known[n.ID] = true
+	}
+	out := make(map[string][]dag.Edge)
+	hasIn := make(map[string]bool)
+	var edges []dag.Edge
+	for _, e := range d.Edges {
+		if !known[e.FromNodeID] || !known[e.ToNodeID] {
+			add(failure, "dangling_edge", e.ID, "edge %s -> %s points at a node the DAG does not have", e.FromNodeID, e.ToNodeID)
+			continue
+		}
+		edges = append(edges, e)
+		out[e.FromNodeID] = append(out[e.FromNodeID], e)
+		hasIn[e.ToNodeID] = true
+	}
+
+	stats := lintStats{Nodes: len(d.Nodes), Edges: len(d.Edges)}
+	for _, n := range d.Nodes {
+		if !hasIn[n.ID] {
+			stats.Roots++
+		}
+		if len(out[n.ID]) == 0 {
+			stats.Leaves++
+		}
+		stats.MaxOutDegree = max(stats.MaxOutDegree, len(out[n.ID]))
+	}
+
+	acyclic := dag.ValidateAcyclic(d.Nodes, edges) == nil
+	if !acyclic {
+		add(failure, "cycle", "", "the edges form a cycle")
+	} else {
+		stats.Depth = dag.Depth(edges)
+		if err := d.Settings.CheckGraph(d.Nodes, edges); err != nil {
+			add(failure, "settings", "", "%v", err)
+		}
+	}
+	var starts []string
+	switch {
+	case o.start != "" && !known[o.start]:
+		add(failure, "start", o.start, "the start node is not in the DAG")
+	case o.start != "":
+		starts = []string{o.start}
+	case stats.Roots > 1 && len(edges) > 0:
+		add(warning, "multiple_roots", "", "%d nodes have no incoming edges; a flow usually has one start", stats.Roots)
+	}
+	if acyclic && (o.start == "" || starts != nil) {
+		orphans, _ := dag.Orphans(&dag.DAG{Nodes: d.Nodes, Edges: edges}, starts...)
+		for _, id := range orphans {
+			add(failure, "unreachable", id, "no start node reaches this node")
+		}
+	}
+
+	for _, n := range d.Nodes {
+		if !hasLabel(n.Data, o.labelKeys) {
+			add(warning, "no_label", n.ID, "node data has no %s", orList(o.labelKeys))
+		}
+		lintBranches(n.ID, out[n.ID], o.conditionKey, add)
+	}
+
+	slices.SortStableFunc(findings, func(a, b finding) int { return cmp.Compare(b.Severity, a.Severity) })
+	return stats, findings
+}
+
+// lintBranches checks the edges leaving one node. Where a node has several,
+// each is a branch picked by its condition, and an edge without one is the
+// default; experiments, whose edges carry a "variant", are split by weight
+// instead and not checked.
+func lintBranches(nodeID string, out []dag.Edge, key string, add func(severity, string, string, string, ...any)) {
+	if len(out) < 2 {
+		return
+	}
+	out = slices.Clone(out)
+	slices.SortStableFunc(out, func(a, b dag.Edge) int { return a.OrderIndex - b.OrderIndex })
+	var defaults []string
+	for _, e := range out {
+		var data map[string]json.RawMessage
+		_ = json.Unmarshal(e.Data, &data)
+		if _, ok := data["variant"]; ok {
+			return
+		}
+		cond, ok := data[key]
+		switch {
+		case !ok:
+			defaults = append(defaults, e.ID)
+		case string(cond) == "null" || string(cond) == `""`:
+			add(warning, "empty_condition", e.ID, "edge from %s has an empty %q", nodeID, key)
+		}
+	}
+	for _, id := range defaults[min(1, len(defaults)):] {
+		add(failure, "missing_condition", id, "edge from %s has no %q, and edge %s before it is already the default, so it is never taken", nodeID, key, defaults[0])
+	}
+	if len(defaults) == 0 {
+		add(info, "no_default", nodeID, "every edge has a %q condition; an answer matching none of them stops the flow", key)
+	}
+}
+
+// hasLabel reports whether data has a non-empty string under one of keys.
+func hasLabel(data json.RawMessage, keys []string) bool {
+	var m map[string]any
+	if json.Unmarshal(data, &m) != nil {
+		return false
+	}
+	for _, k := range keys {
+		if s, ok := m[k].(string); ok && strings.TrimSpace(s) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// orList renders keys as "a, b or c".
+func orList(keys []string) string {
+	if len(keys) < 2 {
+		return strings.Join(keys, "")
+	}
+	return strings.Join(keys[:len(keys)-1], ", ") + " or " + keys[len(keys)-1]
+}
+
+// printLint writes the stats and findings as a table.
+func printLint(d *dag.DAG, stats lintStats, findings []finding) error {
+	fmt.Printf("%s %q\n", d.ID, d.Name)
+	fmt.Printf("nodes %d  edges %d  roots %d  leaves %d  depth %d  max out-degree %d\n\n",
+		stats.Nodes, stats.Edges, stats.Roots, stats.Leaves, stats.Depth, stats.MaxOutDegree)
+	if len(findings) == 0 {
+		fmt.Println("no findings")
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	count := make([]int, len(severityNames))
+	for _, f := range findings {
+		count[f.Severity]++
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", strings.ToUpper(f.Severity.String()), f.Rule, cmp.Or(f.ID, "-"), f.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\nerrors: %d, warnings: %d, info: %d\n", count[failure], count[warning], count[info])
+	return nil
+}
@@ -0,0 +1,235 @@
+// Command dagctl runs maintenance tasks against a DAG database.
+//
+// Usage:
+//
+//	dagctl dump [-f file] [-match glob]...      write DAGs to file (default stdout)
+//	dagctl restore [-f file] [-match glob]...   load a dump from file (default stdin)
+//	dagctl migrate up [-to version]             apply pending schema migrations
+//	dagctl migrate down [-to version] -yes      revert migrations (default: the last one)
+//	dagctl migrate status                       list migrations and whether each is applied
+//	dagctl diff [-json] [-q] old new            compare two DAGs, each a file or a DAG ID
+//	dagctl lint [-fail-on severity] [-json] dag  print stats and findings for a file or DAG ID
+//
+// A file name ending in ".tar", ".tar.gz", ".tgz" or ".tar.zst" holds one
+// tar entry per DAG, dumped and restored one DAG at a time with progress on
+// stderr; -match keeps only the DAGs whose ID matches one of the globs.
+// Any other name holds a single JSON-lines dump of the whole store, taken
+// in one snapshot. A name ending in ".gz" or ".tgz" is gzip-compressed, and
+// one ending in ".zst" zstd-compressed. -out and -in are aliases of -f.
+//
+// migrate moves the schema between the versions postgres.MigrateUp and
+// MigrateDown know, so it can be upgraded apart from the application.
+// down drops what the reverted migrations added, data included, and so
+// needs -yes.
+//
+// diff prints how new differs from old: DAG fields, nodes and edges added,
+// removed or changed, and the data fields that changed. Each side is an
+// export file (.json, .graphml or .csv) if one exists by that name, else a
+// DAG ID. Like diff(1) it exits 0 if the DAGs are the same, 1 if they
+// differ and 2 on error, so a CI job can gate on it.
+//
+// lint prints a DAG's stats and what is likely wrong with it, each finding
+// an error, a warning or info: unreachable nodes, branches without a
+// condition that can never be taken, nodes without a label, and so on. It
+// exits 1 if a finding is at least as severe as -fail-on (default error),
+// and 2 on error.
+//
+// DATABASE_URL selects the database; diff and lint of files do not need it.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/meikuraledutech/dag/postgres"
+)
+
+// errFound ends diff and lint with exit code 1 and no message: the DAGs
+// differ, or the lint found problems.
+var errFound = errors.New("found")
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: dagctl dump|restore [-f file] [-match glob]... [-q]")
+	fmt.Fprintln(os.Stderr, "       dagctl migrate up|down|status [-to version] [-yes]")
+	fmt.Fprintln(os.Stderr, "       dagctl diff [-json] [-q] old new")
+	fmt.Fprintln(os.Stderr, "       dagctl lint [-fail-on info|warning|error] [-start node] [-condition-key key] [-label-keys keys] [-json] dag")
+	os.Exit(2)
+}
+
+// options are the flags shared by dump and restore.
+type options struct {
+	file  string
+	match []string
+	quiet bool
+}
+
+// keep reports whether the DAG dagID is selected by -match.
+func (o *options) keep(dagID string) bool {
+	if len(o.match) == 0 {
+		return true
+	}
+	for _, m := range o.match {
+		if ok, _ := path.Match(m, dagID); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// progress reports a step of a per-DAG dump or restore, unless -q is set.
+func (o *options) progress(format string, args ...any) {
+	if !o.quiet {
+		log.Printf(format, args...)
+	}
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("dagctl: ")
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd := os.Args[1]
+
+	// run gets a nil store if needDB is false.
+	var run func(context.Context, *postgres.PGStore) error
+	needDB, failCode := true, 1
+	switch cmd {
+	case "dump", "restore":
+		run = dumpCommand(cmd, os.Args[2:])
+	case "migrate":
+		run = migrateCommand(os.Args[2:])
+	case "diff":
+		run, needDB = diffCommand(os.Args[2:])
+		failCode = 2
+	case "lint":
+		run, needDB = lintCommand(os.Args[2:])
+		failCode = 2
+	default:
+		usage()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var store *postgres.PGStore
+	if needDB {
+		dbURL := os.Getenv("DATABASE_URL")
+		if dbURL == "" {
+			log.Print("DATABASE_URL is not set")
+			os.Exit(failCode)
+		}
+		pool, err := pgxpool.New(ctx, dbURL)
+		if err != nil {
+			log.Printf("connect: %v", err)
+			os.Exit(failCode)
+		}
+		defer pool.Close()
+		store = postgres.New(pool)
+	}
+
+	err := run(ctx, store)
+	switch {
+	case errors.Is(err, errFound):
+		os.Exit(1)
+	case err != nil:
+		log.Printf("%s: %v", cmd, err)
+		os.Exit(failCode)
+	}
+}
+
+// dumpCommand parses the flags of dump or restore and returns the command.
+func dumpCommand(cmd string, args []string) func(context.Context, *postgres.PGStore) error {
+	var o options
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	fs.StringVar(&o.file, "f", "", "dump file (default stdout/stdin)")
+	fs.StringVar(&o.file, "out", "", "alias of -f for dump")
+	fs.StringVar(&o.file, "in", "", "alias of -f for restore")
+	fs.Func("match", "only DAGs whose ID matches this glob (repeatable; archives only)", func(v string) error {
+		if _, err := path.Match(v, ""); err != nil {
+			return err
+		}
+		o.match = append(o.match, v)
+		return nil
+	})
+	fs.BoolVar(&o.quiet, "q", false, "do not report progress")
+	fs.Parse(args)
+	if len(o.match) > 0 && !isArchive(o.file) {
+		log.Fatal("-match needs a .tar, .tar.gz, .tgz or .tar.zst file")
+	}
+
+	return func(ctx context.Context, store *postgres.PGStore) error {
+		switch {
+		case cmd == "dump" && isArchive(o.file):
+			return dumpArchive(ctx, store, &o)
+		case cmd == "dump":
+			return dump(ctx, store, o.file)
+		case isArchive(o.file):
+			return restoreArchive(ctx, store, &o)
+		}
+		return restore(ctx, store, o.file)
+	}
+}
+
+func dump(ctx context.Context, store *postgres.PGStore, file string) error {
+	if file == "" {
+		return store.DumpAll(ctx, os.Stdout)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w, err := compressor(f, file)
+	if err != nil {
+		return err
+	}
+	if err := store.DumpAll(ctx, w); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func restore(ctx context.Context, store *postgres.PGStore, file string) error {
+	r := os.Stdin
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	zr, err := decompressor(r, file)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	// The target may be a fresh database.
+	if err := store.CreateSchema(ctx); err != nil {
+		return err
+	}
+	return store.Restore(ctx, zr)
+}
+
+// isArchive reports whether file names a per-DAG tar archive.
+func isArchive(file string) bool {
+	for _, ext := range []string{".tar", ".tar.gz", ".tgz", ".tar.zst"} {
+		if strings.HasSuffix(file, ext) {
+			return true
+		}
+	}
+	return false
+}
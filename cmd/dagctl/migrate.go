@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/meikuraledutech/dag/postgres"
+)
+
+// migrateCommand parses the arguments of migrate up, down or status and
+// returns the command.
+func migrateCommand(args []string) func(context.Context, *postgres.PGStore) error {
+	if len(args) == 0 {
+		usage()
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("migrate "+sub, flag.ExitOnError)
+	to := fs.Int("to", -1, "target version (up: default the newest; down: default the one before the last applied)")
+	yes := fs.Bool("yes", false, "confirm down, which drops data")
+	fs.Parse(args[1:])
+
+	switch sub {
+	case "status":
+		return migrateStatus
+	case "up":
+		return func(ctx context.Context, store *postgres.PGStore) error {
+			done, err := store.MigrateUp(ctx, max(*to, 0))
+			report("applied", done)
+			return err
+		}
+	case "down":
+		if !*yes {
+			log.Fatal("migrate down drops the tables and columns it reverts, with their data; pass -yes to go ahead")
+		}
+		return func(ctx context.Context, store *postgres.PGStore) error {
+			target := *to
+			if target < 0 {
+				var err error
+				if target, err = previousVersion(ctx, store); err != nil {
+					return err
+				}
+			}
+			done, err := store.MigrateDown(ctx, target)
+			report("reverted", done)
+			return err
+		}
+	}
+	usage()
+	return nil
+}
+
+// migrateStatus prints every migration and whether it is applied.
+func migrateStatus(ctx context.Context, store *postgres.PGStore) error {
+	status, err := store.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, m := range status {
+		state, at := "pending", ""
+		if m.AppliedAt != nil {
+			state, at = "applied", m.AppliedAt.Format(time.RFC3339)
+		}
+		if m.Unknown {
+			state = "applied (unknown to this build)"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", m.Version, m.Name, state, at)
+	}
+	return tw.Flush()
+}
+
+// previousVersion returns the version migrate down goes back to by default:
+// the applied one before the newest applied, or 0.
+func previousVersion(ctx context.Context, store *postgres.PGStore) (int, error) {
+	status, err := store.MigrationStatus(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var applied []int
+	for _, m := range status {
+		if m.AppliedAt != nil {
+			applied = append(applied, m.Version)
+		}
+	}
+	if len(applied) < 2 {
+		return 0, nil
+	}
+	return applied[len(applied)-2], nil
+}
+
+// report logs the migrations an up or down went through.
+func report(verb string, done []postgres.Migration) {
+	if len(done) == 0 {
+		log.Printf("nothing %s", verb)
+	}
+	for _, m := range done {
+		log.Printf("%s %d %s", verb, m.Version, m.Name)
+	}
+}
@@ -0,0 +1,160 @@
+package dag
+
+import "encoding/json"
+
+// ChainPrefix starts the ID of every super-node Condense makes; the rest
+// is the ID of the chain's first node.
+const ChainPrefix = "chain:"
+
+// CondenseOptions configures Condense.
+type CondenseOptions struct {
+	// MinLength is the fewest nodes a chain needs to be collapsed.
+	// Values below 2 mean 2.
+	MinLength int
+}
+
+// Chain is a run of nodes that Condense collapsed into one super-node.
+type Chain struct {
+	// Nodes are the chain's nodes, first to last.
+	Nodes []Node `json:"nodes"`
+	// Edges join each node of the chain to the next.
+	Edges []Edge `json:"edges"`
+}
+
+// Condensed is a DAG with its linear chains collapsed, and what Expand
+// needs to restore it.
+type Condensed struct {
+	DAG *DAG `json:"dag"`
+	// Chains maps each super-node's ID to the chain it stands for.
+	Chains map[string]Chain `json:"chains"`
+}
+
+// Condense collapses every chain of d into a super-node, so that a huge
+// generated DAG shows its branching structure in a viewer. A chain is a
+// longest run of nodes where each edge from one to the next is the only
+// edge out of the first and the only edge into the second; only its first
+// node may have other incoming edges and only its last other outgoing
+// ones. The super-node takes the first node's place in the node list and
+// its ID is ChainPrefix plus the first node's ID; its data is
+// {"chain": [node IDs], "length": n}. Edges into the first node and out of
+// the last keep their IDs, data and order_index, and point at the
+// super-node instead. d is not modified.
+func Condense(d *DAG, opts ...CondenseOptions) *Condensed {
+	var o CondenseOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	minLen := max(o.MinLength, 2)
+
+	in := make(map[string]int, len(d.Nodes))
+	out := make(map[string][]int, len(d.Nodes))
+	for i, e := range d.Edges {
+		in[e.ToNodeID]++
+		out[e.FromNodeID] = append(out[e.FromNodeID], i)
+	}
+	// link[u] is the index of u's edge to the next node of its chain.
+	link := make(map[string]int)
+	linked := make(map[string]bool)
+	for u, es := range out {
+		if e := d.Edges[es[0]]; len(es) == 1 && in[e.ToNodeID] == 1 && e.ToNodeID != u {
+			link[u] = es[0]
+			linked[e.ToNodeID] = true
+		}
+	}
+
+	byID := make(map[string]Node, len(d.Nodes))
+	for _, n := range d.Nodes {
+		byID[n.ID] = n
+	}
+	c := &Condensed{DAG: &DAG{}, Chains: make(map[string]Chain)}
+	*c.DAG = *d
+	c.DAG.Hash = ""
+	c.DAG.Nodes = nil
+	c.DAG.Edges = nil
+	// super maps each collapsed node to its super-node.
+	super := make(map[string]string)
+	inChain := make(map[int]bool)
+	for _, n := range d.Nodes {
+		if linked[n.ID] {
+			continue
+		}
+		if _, ok := link[n.ID]; !ok {
+			c.DAG.Nodes = append(c.DAG.Nodes, n)
+			continue
+		}
+		ch := Chain{Nodes: []Node{n}}
+		var edges []int
+		seen := map[string]bool{n.ID: true}
+		for id := n.ID; ; {
+			i, ok := link[id]
+			if !ok || seen[d.Edges[i].ToNodeID] {
+				break
+			}
+			id = d.Edges[i].ToNodeID
+			seen[id] = true
+			edges = append(edges, i)
+			ch.Edges = append(ch.Edges, d.Edges[i])
+			ch.Nodes = append(ch.Nodes, byID[id])
+		}
+		if len(ch.Nodes) < minLen {
+			c.DAG.Nodes = append(c.DAG.Nodes, ch.Nodes...)
+			continue
+		}
+		id := ChainPrefix + n.ID
+		ids := make([]string, len(ch.Nodes))
+		for i, m := range ch.Nodes {
+			ids[i] = m.ID
+			super[m.ID] = id
+		}
+		for _, i := range edges {
+			inChain[i] = true
+		}
+		data, _ := json.Marshal(map[string]any{"chain": ids, "length": len(ids)})
+		c.DAG.Nodes = append(c.DAG.Nodes, Node{ID: id, Data: data})
+		c.Chains[id] = ch
+	}
+	for i, e := range d.Edges {
+		if inChain[i] {
+			continue
+		}
+		if s, ok := super[e.FromNodeID]; ok {
+			e.FromNodeID = s
+		}
+		if s, ok := super[e.ToNodeID]; ok {
+			e.ToNodeID = s
+		}
+		c.DAG.Edges = append(c.DAG.Edges, e)
+	}
+	return c
+}
+
+// Expand undoes Condense: it puts every chain's nodes back in place of its
+// super-node and points the edges at the chain's first and last nodes
+// again. The result is Equal to the DAG Condense was given.
+func (c *Condensed) Expand() *DAG {
+	d := *c.DAG
+	d.Nodes = make([]Node, 0, len(c.DAG.Nodes))
+	d.Edges = make([]Edge, 0, len(c.DAG.Edges))
+	for _, n := range c.DAG.Nodes {
+		if ch, ok := c.Chains[n.ID]; ok {
+			d.Nodes = append(d.Nodes, ch.Nodes...)
+		} else {
+			d.Nodes = append(d.Nodes, n)
+		}
+	}
+	for _, e := range c.DAG.Edges {
+		if ch, ok := c.Chains[e.FromNodeID]; ok {
+			e.FromNodeID = ch.Nodes[len(ch.Nodes)-1].ID
+		}
+		if ch, ok := c.Chains[e.ToNodeID]; ok {
+			e.ToNodeID = ch.Nodes[0].ID
+		}
+		d.Edges = append(d.Edges, e)
+	}
+	for _, n := range c.DAG.Nodes {
+		if ch, ok := c.Chains[n.ID]; ok {
+			d.Edges = append(d.Edges, ch.Edges...)
+		}
+	}
+	return &d
+}
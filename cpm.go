@@ -0,0 +1,114 @@
+package dag
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Schedule is the critical path method's answer for a project-planning
+// DAG, where each node is a task and each edge says its source must
+// finish before its target starts.
+type Schedule struct {
+	// Duration is the length of the whole project: the latest finish.
+	Duration float64 `json:"duration"`
+	// CriticalPath lists the IDs of a longest chain of tasks, first to
+	// last; delaying any of them delays the project.
+	CriticalPath []string `json:"critical_path"`
+	// Nodes holds every task's times, in topological order.
+	Nodes []NodeSchedule `json:"nodes"`
+}
+
+// NodeSchedule is one task's times in a Schedule.
+type NodeSchedule struct {
+	ID             string  `json:"id"`
+	Duration       float64 `json:"duration"`
+	EarliestStart  float64 `json:"earliest_start"`
+	EarliestFinish float64 `json:"earliest_finish"`
+	LatestStart    float64 `json:"latest_start"`
+	LatestFinish   float64 `json:"latest_finish"`
+	// Slack is how long the task can slip without delaying the project;
+	// 0 on the critical path.
+	Slack float64 `json:"slack"`
+}
+
+// CriticalPath schedules d's nodes as tasks whose durations are the
+// numbers at the dotted data path durationField, such as "duration" or
+// "estimate.days". A node without a numeric duration there takes no time,
+// as a milestone does. Tasks start at 0 and as soon as all their
+// predecessors finish; the latest times are the last ones that keep the
+// project's length. Among equally long paths, the critical path follows
+// the first predecessor in TopoSort order. ErrInvalidDuration is returned
+// if durationField is empty or a duration is negative, and
+// ErrCycleDetected if d has a cycle.
+func CriticalPath(d *DAG, durationField string) (*Schedule, error) {
+	if durationField == "" {
+		return nil, fmt.Errorf("%w: no duration field", ErrInvalidDuration)
+	}
+	order, err := TopoSort(d)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int, len(order))
+	for i, id := range order {
+		index[id] = i
+	}
+	s := &Schedule{CriticalPath: []string{}, Nodes: make([]NodeSchedule, len(order))}
+	for _, n := range d.Nodes {
+		i := index[n.ID]
+		dur, _ := numberAt(n.Data, durationField)
+		if dur < 0 {
+			return nil, fmt.Errorf("%w: %s has duration %v", ErrInvalidDuration, n.ID, dur)
+		}
+		s.Nodes[i] = NodeSchedule{ID: n.ID, Duration: dur}
+	}
+
+	preds := make([][]int, len(order))
+	succs := make([][]int, len(order))
+	for _, e := range d.Edges {
+		from, ok1 := index[e.FromNodeID]
+		to, ok2 := index[e.ToNodeID]
+		if ok1 && ok2 {
+			preds[to] = append(preds[to], from)
+			succs[from] = append(succs[from], to)
+		}
+	}
+	for i := range preds {
+		slices.Sort(preds[i])
+	}
+
+	// Forward pass: earliest times, and the predecessor each start waits on.
+	waitsOn := make([]int, len(order))
+	last := -1
+	for i := range s.Nodes {
+		n := &s.Nodes[i]
+		waitsOn[i] = -1
+		for _, p := range preds[i] {
+			if waitsOn[i] < 0 || s.Nodes[p].EarliestFinish > n.EarliestStart {
+				n.EarliestStart = s.Nodes[p].EarliestFinish
+				waitsOn[i] = p
+			}
+		}
+		n.EarliestFinish = n.EarliestStart + n.Duration
+		if last < 0 || n.EarliestFinish > s.Duration {
+			s.Duration = n.EarliestFinish
+			last = i
+		}
+	}
+
+	// Backward pass: latest times.
+	for i := len(s.Nodes) - 1; i >= 0; i-- {
+		n := &s.Nodes[i]
+		n.LatestFinish = s.Duration
+		for _, c := range succs[i] {
+			n.LatestFinish = min(n.LatestFinish, s.Nodes[c].LatestStart)
+		}
+		n.LatestStart = n.LatestFinish - n.Duration
+		n.Slack = n.LatestStart - n.EarliestStart
+	}
+
+	for i := last; i >= 0; i = waitsOn[i] {
+		s.CriticalPath = append(s.CriticalPath, s.Nodes[i].ID)
+	}
+	slices.Reverse(s.CriticalPath)
+	return s, nil
+}
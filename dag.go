@@ -1,20 +1,37 @@
 package dag
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 // DAG represents a directed acyclic graph containing nodes and edges.
+// Name and Tags are optional metadata used by SearchDAGs.
+// If ExpiresAt is set, a Reaper deletes the DAG once that time has passed.
+// Status is read-only: it is filled in on reads and changed by PublishDAG
+// and ArchiveDAG. Settings are applied by CreateDAG and changed with
+// UpdateSettings. Hash is read-only too: the store fills it with Hash of
+// the DAG on reads and ignores it on writes.
 type DAG struct {
-	ID    string `json:"id"`
-	Nodes []Node `json:"nodes"`
-	Edges []Edge `json:"edges"`
+	ID        string     `json:"id"`
+	Name      string     `json:"name,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+	Status    Status     `json:"status,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Settings  Settings   `json:"settings,omitzero"`
+	Hash      string     `json:"hash,omitempty"`
+	Nodes     []Node     `json:"nodes"`
+	Edges     []Edge     `json:"edges"`
 }
 
 // Node represents a vertex in the DAG.
 // Ref is a temporary key used only during CreateDAG for edge wiring — it is never persisted.
+// Tags label nodes for FindNodesByTag (e.g. every "payment" question).
 type Node struct {
 	ID   string          `json:"id,omitempty"`
 	Ref  string          `json:"ref,omitempty"`
 	Data json.RawMessage `json:"data"`
+	Tags []string        `json:"tags,omitempty"`
 }
 
 // Edge represents a directed connection between two nodes.
@@ -26,4 +43,118 @@ type Edge struct {
 	FromNodeRef string          `json:"from_node_ref,omitempty"`
 	ToNodeRef   string          `json:"to_node_ref,omitempty"`
 	Data        json.RawMessage `json:"data"`
+	// OrderIndex is the edge's position among the edges leaving the same
+	// node. The store assigns it on insert; change it with ReorderEdges.
+	OrderIndex int `json:"order_index"`
+}
+
+// CreateDAGOptions changes how CreateDAG behaves. The zero value creates
+// the DAG, or fails with ErrDAGExists if one with that ID exists.
+type CreateDAGOptions struct {
+	// Replace deletes an existing DAG with the same ID, with all its nodes
+	// and edges, and creates d in its place.
+	Replace bool
+	// DryRun resolves refs and IDs and runs every check CreateDAG would —
+	// acyclicity, settings, quotas, schemas, node types and whether the DAG
+	// is frozen — then returns the resolved DAG without writing anything.
+	DryRun bool
+}
+
+// GetDAGOptions trims what GetDAG and GetDAGs load, for clients that need
+// less than the whole DAG, such as a layout engine that only wants the
+// topology. The zero value loads everything.
+type GetDAGOptions struct {
+	// SkipData leaves Data nil on every node and edge; the data columns,
+	// deduplicated data and blobs are not read at all.
+	SkipData bool
+	// SkipNodes returns Nodes empty, and SkipEdges returns Edges empty.
+	// A DAG without nodes is still reported as missing.
+	SkipNodes bool
+	SkipEdges bool
+	// Fields, if set, returns only these paths of each node's and edge's
+	// data; see Project.
+	Fields []string
+	// Locale, if set, resolves each node's and edge's localized variants
+	// for these locales, most preferred first, before Fields is applied;
+	// see Localize.
+	Locale []string
+	// Topological returns Nodes in topological order, as TopoSort gives
+	// it, and Edges grouped by source node in that order, so consumers
+	// that render or run a flow need not sort. The edges are read even
+	// under SkipEdges, since the order depends on them.
+	Topological bool
+}
+
+// Trim drops from d what o skips, and localizes and projects the data as o
+// asks, for stores that cannot do so while reading. d is modified and
+// returned.
+func (o GetDAGOptions) Trim(d *DAG) *DAG {
+	if d == nil {
+		return nil
+	}
+	if o.Topological {
+		// A stored DAG has no cycle, so this cannot fail.
+		_ = SortTopologically(d)
+	}
+	if o.SkipNodes {
+		d.Nodes = []Node{}
+	}
+	if o.SkipEdges {
+		d.Edges = []Edge{}
+	}
+	if o.SkipData {
+		for i := range d.Nodes {
+			d.Nodes[i].Data = nil
+		}
+		for i := range d.Edges {
+			d.Edges[i].Data = nil
+		}
+	} else if len(o.Fields) > 0 || len(o.Locale) > 0 {
+		for i := range d.Nodes {
+			d.Nodes[i].Data = o.Data(d.Nodes[i].Data)
+		}
+		for i := range d.Edges {
+			d.Edges[i].Data = o.Data(d.Edges[i].Data)
+		}
+	}
+	return d
+}
+
+// Unordered returns o without Topological and, if it was set, without
+// SkipEdges, for reading a DAG that Sorted then orders.
+func (o GetDAGOptions) Unordered() GetDAGOptions {
+	if o.Topological {
+		o.Topological, o.SkipEdges = false, false
+	}
+	return o
+}
+
+// Sorted orders d, read with o.Unordered(), as o.Topological asks and then
+// drops its edges if o skips them. d is modified and returned.
+func (o GetDAGOptions) Sorted(d *DAG) *DAG {
+	if d == nil || !o.Topological {
+		return d
+	}
+	// A stored DAG has no cycle, so this cannot fail.
+	_ = SortTopologically(d)
+	if o.SkipEdges {
+		d.Edges = []Edge{}
+	}
+	return d
+}
+
+// Data returns data localized to o.Locale and projected to o.Fields, either
+// of which may be unset. It ignores SkipData.
+func (o GetDAGOptions) Data(data json.RawMessage) json.RawMessage {
+	if len(o.Locale) > 0 {
+		data = Localize(data, o.Locale...)
+	}
+	return Project(data, o.Fields)
+}
+
+// BatchResult is the outcome of one item in AddNodes / AddEdges.
+// ID is set on success; Err is set if that item was rejected.
+type BatchResult struct {
+	ID  string
+	Err error
 }
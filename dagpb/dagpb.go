@@ -0,0 +1,274 @@
+// Package dagpb converts between the dag types and their Protocol Buffers
+// messages in proto/dag/v1, the wire schema for consumers in other
+// languages of the event stream and of exports.
+//
+// The conversions keep every field: a DAG, node, edge or event converted
+// to its message and back has the same values, with times in UTC. Data is
+// JSON text on the wire, as in the gRPC API; empty text converts to nil
+// Data. Ref fields, which are never persisted, are carried too, so a DAG
+// to be created can be sent as a message.
+package dagpb
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/meikuraledutech/dag"
+	dagv1 "github.com/meikuraledutech/dag/proto/dag/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// FromDAG returns d as a message, or nil if d is nil.
+func FromDAG(d *dag.DAG) *dagv1.DAG {
+	if d == nil {
+		return nil
+	}
+	out := &dagv1.DAG{
+		Id:       d.ID,
+		Name:     d.Name,
+		Tags:     d.Tags,
+		Status:   string(d.Status),
+		Settings: FromSettings(d.Settings),
+		Hash:     d.Hash,
+	}
+	if d.ExpiresAt != nil {
+		out.ExpiresAt = timestamppb.New(*d.ExpiresAt)
+	}
+	for _, n := range d.Nodes {
+		out.Nodes = append(out.Nodes, FromNode(n))
+	}
+	for _, e := range d.Edges {
+		out.Edges = append(out.Edges, FromEdge(e))
+	}
+	return out
+}
+
+// ToDAG returns the DAG m describes, or nil if m is nil. Nodes and Edges
+// are never nil, as on a DAG read from a store.
+func ToDAG(m *dagv1.DAG) *dag.DAG {
+	if m == nil {
+		return nil
+	}
+	out := &dag.DAG{
+		ID:       m.GetId(),
+		Name:     m.GetName(),
+		Tags:     m.GetTags(),
+		Status:   dag.Status(m.GetStatus()),
+		Settings: ToSettings(m.GetSettings()),
+		Hash:     m.GetHash(),
+		Nodes:    ToNodes(m.GetNodes()),
+		Edges:    ToEdges(m.GetEdges()),
+	}
+	if m.GetExpiresAt() != nil {
+		t := m.GetExpiresAt().AsTime()
+		out.ExpiresAt = &t
+	}
+	return out
+}
+
+// FromSettings returns s as a message.
+func FromSettings(s dag.Settings) *dagv1.Settings {
+	return &dagv1.Settings{
+		NoParallelEdges:    s.NoParallelEdges,
+		Tree:               s.Tree,
+		SingleRoot:         s.SingleRoot,
+		Connected:          s.Connected,
+		MaxDepth:           int32(s.MaxDepth),
+		DistinctConditions: s.DistinctConditions,
+	}
+}
+
+// ToSettings returns the settings m describes; nil gives the zero Settings.
+func ToSettings(m *dagv1.Settings) dag.Settings {
+	return dag.Settings{
+		NoParallelEdges:    m.GetNoParallelEdges(),
+		Tree:               m.GetTree(),
+		SingleRoot:         m.GetSingleRoot(),
+		Connected:          m.GetConnected(),
+		MaxDepth:           int(m.GetMaxDepth()),
+		DistinctConditions: m.GetDistinctConditions(),
+	}
+}
+
+// FromNode returns n as a message.
+func FromNode(n dag.Node) *dagv1.Node {
+	return &dagv1.Node{Id: n.ID, Ref: n.Ref, Data: string(n.Data), Tags: n.Tags}
+}
+
+// ToNode returns the node m describes.
+func ToNode(m *dagv1.Node) dag.Node {
+	return dag.Node{ID: m.GetId(), Ref: m.GetRef(), Data: rawData(m.GetData()), Tags: m.GetTags()}
+}
+
+// ToNodes converts a list of node messages; the result is never nil.
+func ToNodes(ms []*dagv1.Node) []dag.Node {
+	out := make([]dag.Node, 0, len(ms))
+	for _, m := range ms {
+		out = append(out, ToNode(m))
+	}
+	return out
+}
+
+// FromEdge returns e as a message.
+func FromEdge(e dag.Edge) *dagv1.Edge {
+	return &dagv1.Edge{
+		Id:          e.ID,
+		FromNodeId:  e.FromNodeID,
+		ToNodeId:    e.ToNodeID,
+		FromNodeRef: e.FromNodeRef,
+		ToNodeRef:   e.ToNodeRef,
+		Data:        string(e.Data),
+		OrderIndex:  int32(e.OrderIndex),
+	}
+}
+
+// ToEdge returns the edge m describes.
+func ToEdge(m *dagv1.Edge) dag.Edge {
+	return dag.Edge{
+		ID:          m.GetId(),
+		FromNodeID:  m.GetFromNodeId(),
+		ToNodeID:    m.GetToNodeId(),
+		FromNodeRef: m.GetFromNodeRef(),
+		ToNodeRef:   m.GetToNodeRef(),
+		Data:        rawData(m.GetData()),
+		OrderIndex:  int(m.GetOrderIndex()),
+	}
+}
+
+// ToEdges converts a list of edge messages; the result is never nil.
+func ToEdges(ms []*dagv1.Edge) []dag.Edge {
+	out := make([]dag.Edge, 0, len(ms))
+	for _, m := range ms {
+		out = append(out, ToEdge(m))
+	}
+	return out
+}
+
+// FromChangeSet returns cs as a message, or nil if cs is nil.
+func FromChangeSet(cs *dag.ChangeSet) *dagv1.ChangeSet {
+	if cs == nil {
+		return nil
+	}
+	out := &dagv1.ChangeSet{DeleteNodes: cs.DeleteNodes, DeleteEdges: cs.DeleteEdges}
+	for _, n := range cs.AddNodes {
+		out.AddNodes = append(out.AddNodes, FromNode(n))
+	}
+	for _, n := range cs.UpdateNodes {
+		out.UpdateNodes = append(out.UpdateNodes, FromNode(n))
+	}
+	for _, e := range cs.AddEdges {
+		out.AddEdges = append(out.AddEdges, FromEdge(e))
+	}
+	for _, e := range cs.UpdateEdges {
+		out.UpdateEdges = append(out.UpdateEdges, FromEdge(e))
+	}
+	return out
+}
+
+// ToChangeSet returns the change set m describes, or nil if m is nil.
+func ToChangeSet(m *dagv1.ChangeSet) *dag.ChangeSet {
+	if m == nil {
+		return nil
+	}
+	cs := &dag.ChangeSet{DeleteNodes: m.GetDeleteNodes(), DeleteEdges: m.GetDeleteEdges()}
+	for _, n := range m.GetAddNodes() {
+		cs.AddNodes = append(cs.AddNodes, ToNode(n))
+	}
+	for _, n := range m.GetUpdateNodes() {
+		cs.UpdateNodes = append(cs.UpdateNodes, ToNode(n))
+	}
+	for _, e := range m.GetAddEdges() {
+		cs.AddEdges = append(cs.AddEdges, ToEdge(e))
+	}
+	for _, e := range m.GetUpdateEdges() {
+		cs.UpdateEdges = append(cs.UpdateEdges, ToEdge(e))
+	}
+	return cs
+}
+
+// FromEvent returns e as a message.
+func FromEvent(e dag.Event) *dagv1.Event {
+	out := &dagv1.Event{
+		Seq:     e.Seq,
+		DagId:   e.DAGID,
+		Type:    string(e.Type),
+		Actor:   e.Actor,
+		Dag:     FromDAG(e.DAG),
+		Changes: FromChangeSet(e.Changes),
+		Tags:    e.Tags,
+		Status:  string(e.Status),
+	}
+	if !e.At.IsZero() {
+		out.At = timestamppb.New(e.At)
+	}
+	if e.Order != nil {
+		out.Order = &dagv1.EdgeOrder{FromNodeId: e.Order.FromNodeID, EdgeIds: e.Order.EdgeIDs}
+	}
+	if e.Settings != nil {
+		out.Settings = FromSettings(*e.Settings)
+	}
+	return out
+}
+
+// ToEvent returns the event m describes. At is in UTC.
+func ToEvent(m *dagv1.Event) dag.Event {
+	e := dag.Event{
+		Seq:     m.GetSeq(),
+		DAGID:   m.GetDagId(),
+		Type:    dag.EventType(m.GetType()),
+		Actor:   m.GetActor(),
+		DAG:     ToDAG(m.GetDag()),
+		Changes: ToChangeSet(m.GetChanges()),
+		Tags:    m.GetTags(),
+		Status:  dag.Status(m.GetStatus()),
+	}
+	if m.GetAt() != nil {
+		e.At = m.GetAt().AsTime()
+	}
+	if o := m.GetOrder(); o != nil {
+		e.Order = &dag.EdgeOrder{FromNodeID: o.GetFromNodeId(), EdgeIDs: o.GetEdgeIds()}
+	}
+	if s := m.GetSettings(); s != nil {
+		settings := ToSettings(s)
+		e.Settings = &settings
+	}
+	return e
+}
+
+// MarshalEvent encodes e in the Protocol Buffers binary format, for sinks
+// that publish events as protobuf rather than JSON.
+func MarshalEvent(e dag.Event) ([]byte, error) {
+	return proto.Marshal(FromEvent(e))
+}
+
+// UnmarshalEvent decodes an event MarshalEvent encoded.
+func UnmarshalEvent(b []byte) (dag.Event, error) {
+	var m dagv1.Event
+	if err := proto.Unmarshal(b, &m); err != nil {
+		return dag.Event{}, fmt.Errorf("dagpb: unmarshal event: %w", err)
+	}
+	return ToEvent(&m), nil
+}
+
+// MarshalDAG encodes d in the Protocol Buffers binary format.
+func MarshalDAG(d *dag.DAG) ([]byte, error) {
+	return proto.Marshal(FromDAG(d))
+}
+
+// UnmarshalDAG decodes a DAG MarshalDAG encoded.
+func UnmarshalDAG(b []byte) (*dag.DAG, error) {
+	var m dagv1.DAG
+	if err := proto.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("dagpb: unmarshal dag: %w", err)
+	}
+	return ToDAG(&m), nil
+}
+
+// rawData turns proto JSON text into a RawMessage; empty stays nil.
+func rawData(s string) json.RawMessage {
+	if s == "" {
+		return nil
+	}
+	return json.RawMessage(s)
+}
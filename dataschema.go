@@ -0,0 +1,138 @@
+package dag
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/meikuraledutech/dag/jsonschema"
+)
+
+// DefaultTypeKey is the data field DataSchemas reads a payload's type from.
+const DefaultTypeKey = "type"
+
+// DataSchemas validates node and edge data against JSON Schemas registered
+// per type. A payload's type is the string in its TypeKey field, e.g.
+// {"type":"question", ...}; payloads whose type has no schema are accepted.
+// Register every schema before the store starts serving writes.
+type DataSchemas struct {
+	// TypeKey is the data field holding the type. Empty means "type".
+	TypeKey string
+
+	nodes map[string]*jsonschema.Schema
+	edges map[string]*jsonschema.Schema
+}
+
+// NewDataSchemas returns an empty registry.
+func NewDataSchemas() *DataSchemas {
+	return &DataSchemas{
+		nodes: make(map[string]*jsonschema.Schema),
+		edges: make(map[string]*jsonschema.Schema),
+	}
+}
+
+// RegisterNode compiles schema and uses it for node data of type typ.
+// Registering "" covers nodes whose data has no type.
+func (s *DataSchemas) RegisterNode(typ string, schema []byte) error {
+	c, err := jsonschema.Compile(schema)
+	if err != nil {
+		return fmt.Errorf("dag: node type %q: %w", typ, err)
+	}
+	s.nodes[typ] = c
+	return nil
+}
+
+// RegisterEdge compiles schema and uses it for edge data of type typ.
+// Registering "" covers edges whose data has no type.
+func (s *DataSchemas) RegisterEdge(typ string, schema []byte) error {
+	c, err := jsonschema.Compile(schema)
+	if err != nil {
+		return fmt.Errorf("dag: edge type %q: %w", typ, err)
+	}
+	s.edges[typ] = c
+	return nil
+}
+
+// DataError reports node or edge data that does not match its schema. It
+// wraps ErrInvalidData.
+type DataError struct {
+	Kind string // "node" or "edge"
+	ID   string
+	Type string
+	// Index is the position of the node or edge in a CreateDAG body, or -1.
+	Index  int
+	Fields []jsonschema.Error
+}
+
+func (e *DataError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = "data" + f.Path + " " + f.Message
+	}
+	return fmt.Sprintf("%v: %s %s (type %q): %s", ErrInvalidData, e.Kind, e.ID, e.Type, strings.Join(msgs, "; "))
+}
+
+func (e *DataError) Unwrap() error { return ErrInvalidData }
+
+// CheckNode validates a node's data. A nil registry accepts everything.
+func (s *DataSchemas) CheckNode(n *Node) error {
+	if s == nil {
+		return nil
+	}
+	return s.check(s.nodes, "node", n.ID, -1, n.Data)
+}
+
+// CheckEdge validates an edge's data. A nil registry accepts everything.
+func (s *DataSchemas) CheckEdge(e *Edge) error {
+	if s == nil {
+		return nil
+	}
+	return s.check(s.edges, "edge", e.ID, -1, e.Data)
+}
+
+// CheckDAG validates every node and edge of a DAG as passed to CreateDAG.
+// The DataError's Index is the position in d.Nodes or d.Edges.
+func (s *DataSchemas) CheckDAG(d *DAG) error {
+	if s == nil {
+		return nil
+	}
+	for i, n := range d.Nodes {
+		if err := s.check(s.nodes, "node", n.ID, i, n.Data); err != nil {
+			return err
+		}
+	}
+	for i, e := range d.Edges {
+		if err := s.check(s.edges, "edge", e.ID, i, e.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *DataSchemas) check(schemas map[string]*jsonschema.Schema, kind, id string, index int, data json.RawMessage) error {
+	typ := s.typeOf(data)
+	schema, ok := schemas[typ]
+	if !ok {
+		return nil
+	}
+	if fields := schema.Validate(data); len(fields) > 0 {
+		return &DataError{Kind: kind, ID: id, Type: typ, Index: index, Fields: fields}
+	}
+	return nil
+}
+
+// typeOf returns the type named in data, or "" if data is not an object or
+// has no string type field.
+func (s *DataSchemas) typeOf(data json.RawMessage) string {
+	key := s.TypeKey
+	if key == "" {
+		key = DefaultTypeKey
+	}
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(data, &obj) != nil {
+		return ""
+	}
+	var typ string
+	json.Unmarshal(obj[key], &typ)
+	return typ
+}
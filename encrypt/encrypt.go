@@ -0,0 +1,599 @@
+// Package encrypt stores selected fields of node and edge data encrypted,
+// for flows whose payloads carry personal data.
+//
+//	keys, _ := encrypt.NewKeyring("2024-06", masterKey)
+//	s := encrypt.New(store, keys)
+//	s.NodePaths = []string{"answer", "contact.email", "people.*.phone"}
+//
+// Values at the configured paths are replaced by an "enc:v1:..." string
+// before they reach the wrapped store and decrypted again on every read.
+// Each write uses a fresh data key, wrapped by a KeyWrapper (envelope
+// encryption), so key-encryption keys can live in a KMS and be rotated
+// without rewriting data.
+package encrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// ErrDecrypt is returned when an encrypted value cannot be decrypted: it
+// is malformed, its key is unknown, or it was tampered with.
+var ErrDecrypt = errors.New("encrypt: cannot decrypt value")
+
+// prefix marks an encrypted value. The rest is
+// <key id>:<wrapped data key>:<nonce || ciphertext>, both base64url.
+const prefix = "enc:v1:"
+
+// KeyWrapper encrypts and decrypts data keys with a key-encryption key.
+// Keyring is an in-memory implementation; a KMS client fits too.
+type KeyWrapper interface {
+	// Wrap encrypts dek and returns the ID of the key used, which must
+	// not contain ':'.
+	Wrap(ctx context.Context, dek []byte) (keyID string, wrapped []byte, err error)
+	Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Store wraps a dag.Store, encrypting node data at NodePaths and edge data
+// at EdgePaths on writes and decrypting every read. Methods without data
+// go straight to the wrapped store.
+//
+// A path is a dot-separated list of object keys; "*" matches every member
+// of an object or element of an array, and a number picks one element.
+// Each path also applies to the localized variants under dag.LocalesKey.
+// Encrypted values are strings to the wrapped store, so data schemas, list
+// filters and full-text search only see ciphertext at those paths.
+type Store struct {
+	dag.Store
+	Keys      KeyWrapper
+	NodePaths []string
+	EdgePaths []string
+}
+
+// New wraps s so data is encrypted with keys. Set NodePaths and EdgePaths
+// to choose what is encrypted.
+func New(s dag.Store, keys KeyWrapper) *Store {
+	return &Store{Store: s, Keys: keys}
+}
+
+// EncryptData returns data with the values at paths, and at the same paths
+// in its localized variants, encrypted under a new data key. Values that
+// are already encrypted are left alone.
+func (s *Store) EncryptData(ctx context.Context, data json.RawMessage, paths []string) (json.RawMessage, error) {
+	if len(paths) == 0 || len(data) == 0 {
+		return data, nil
+	}
+	doc, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var dek []byte
+	var header string
+	seal1 := func(v any) (any, error) {
+		if str, ok := v.(string); ok && strings.HasPrefix(str, prefix) {
+			return v, nil
+		}
+		if dek == nil {
+			dek = make([]byte, 32)
+			if _, err := rand.Read(dek); err != nil {
+				return nil, fmt.Errorf("encrypt: data key: %w", err)
+			}
+			keyID, wrapped, err := s.Keys.Wrap(ctx, dek)
+			if err != nil {
+				return nil, fmt.Errorf("encrypt: wrap data key: %w", err)
+			}
+			if strings.Contains(keyID, ":") {
+				return nil, fmt.Errorf("encrypt: key id %q must not contain ':'", keyID)
+			}
+			header = prefix + keyID + ":" + b64.EncodeToString(wrapped) + ":"
+		}
+		plain, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt: %w", err)
+		}
+		sealed, err := seal(dek, plain)
+		if err != nil {
+			return nil, err
+		}
+		return header + b64.EncodeToString(sealed), nil
+	}
+
+	for _, p := range dag.LocalizedPaths(paths) {
+		if doc, err = at(doc, strings.Split(p, "."), seal1); err != nil {
+			return nil, err
+		}
+	}
+	if dek == nil {
+		return data, nil
+	}
+	return json.Marshal(doc)
+}
+
+// DecryptData returns data with every encrypted value decrypted, wherever
+// it is. Data without encrypted values is returned unchanged.
+func (s *Store) DecryptData(ctx context.Context, data json.RawMessage) (json.RawMessage, error) {
+	if !bytes.Contains(data, []byte(prefix)) {
+		return data, nil
+	}
+	doc, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	deks := map[string][]byte{}
+	if doc, err = s.decrypt(ctx, doc, deks); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+func (s *Store) decrypt(ctx context.Context, v any, deks map[string][]byte) (any, error) {
+	var err error
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if v[k], err = s.decrypt(ctx, child, deks); err != nil {
+				return nil, err
+			}
+		}
+	case []any:
+		for i, child := range v {
+			if v[i], err = s.decrypt(ctx, child, deks); err != nil {
+				return nil, err
+			}
+		}
+	case string:
+		if strings.HasPrefix(v, prefix) {
+			return s.open1(ctx, v, deks)
+		}
+	}
+	return v, nil
+}
+
+// open1 decrypts one "enc:v1:" value. deks caches unwrapped data keys by
+// their header, since every value written together shares one.
+func (s *Store) open1(ctx context.Context, v string, deks map[string][]byte) (any, error) {
+	parts := strings.Split(strings.TrimPrefix(v, prefix), ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed value", ErrDecrypt)
+	}
+	header := parts[0] + ":" + parts[1]
+	dek, ok := deks[header]
+	if !ok {
+		wrapped, err := b64.DecodeString(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed data key", ErrDecrypt)
+		}
+		if dek, err = s.Keys.Unwrap(ctx, parts[0], wrapped); err != nil {
+			return nil, err
+		}
+		deks[header] = dek
+	}
+	sealed, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed ciphertext", ErrDecrypt)
+	}
+	plain, err := open(dek, sealed)
+	if err != nil {
+		return nil, err
+	}
+	return decode(plain)
+}
+
+var b64 = base64.RawURLEncoding
+
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("encrypt: decode data: %w", err)
+	}
+	return v, nil
+}
+
+// at applies fn to the values of v at path and returns the updated v.
+// Missing keys and out-of-range indexes are skipped.
+func at(v any, path []string, fn func(any) (any, error)) (any, error) {
+	if len(path) == 0 {
+		return fn(v)
+	}
+	seg, rest := path[0], path[1:]
+	var err error
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if seg == "*" || seg == k {
+				if v[k], err = at(child, rest, fn); err != nil {
+					return nil, err
+				}
+			}
+		}
+	case []any:
+		i, convErr := strconv.Atoi(seg)
+		for j, child := range v {
+			if seg == "*" || (convErr == nil && i == j) {
+				if v[j], err = at(child, rest, fn); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return v, nil
+}
+
+// encNode returns a copy of n with its data encrypted.
+func (s *Store) encNode(ctx context.Context, n dag.Node) (dag.Node, error) {
+	data, err := s.EncryptData(ctx, n.Data, s.NodePaths)
+	if err != nil {
+		return n, fmt.Errorf("encrypt: node %s: %w", n.ID, err)
+	}
+	n.Data = data
+	return n, nil
+}
+
+// encEdge returns a copy of e with its data encrypted.
+func (s *Store) encEdge(ctx context.Context, e dag.Edge) (dag.Edge, error) {
+	data, err := s.EncryptData(ctx, e.Data, s.EdgePaths)
+	if err != nil {
+		return e, fmt.Errorf("encrypt: edge %s: %w", e.ID, err)
+	}
+	e.Data = data
+	return e, nil
+}
+
+func (s *Store) decNodes(ctx context.Context, nodes []dag.Node) error {
+	for i := range nodes {
+		data, err := s.DecryptData(ctx, nodes[i].Data)
+		if err != nil {
+			return fmt.Errorf("encrypt: node %s: %w", nodes[i].ID, err)
+		}
+		nodes[i].Data = data
+	}
+	return nil
+}
+
+func (s *Store) decEdges(ctx context.Context, edges []dag.Edge) error {
+	for i := range edges {
+		data, err := s.DecryptData(ctx, edges[i].Data)
+		if err != nil {
+			return fmt.Errorf("encrypt: edge %s: %w", edges[i].ID, err)
+		}
+		edges[i].Data = data
+	}
+	return nil
+}
+
+// decDAG decrypts the result of a wrapped call returning a DAG.
+func (s *Store) decDAG(ctx context.Context, d *dag.DAG, err error) (*dag.DAG, error) {
+	if err != nil || d == nil {
+		return d, err
+	}
+	if err := s.decNodes(ctx, d.Nodes); err != nil {
+		return nil, err
+	}
+	if err := s.decEdges(ctx, d.Edges); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// CreateDAG encrypts d's data, creates it and returns it decrypted. d
+// itself is not modified.
+func (s *Store) CreateDAG(ctx context.Context, d *dag.DAG, opts ...dag.CreateDAGOptions) (*dag.DAG, error) {
+	enc := *d
+	enc.Nodes = make([]dag.Node, len(d.Nodes))
+	enc.Edges = make([]dag.Edge, len(d.Edges))
+	var err error
+	for i, n := range d.Nodes {
+		if enc.Nodes[i], err = s.encNode(ctx, n); err != nil {
+			return nil, err
+		}
+	}
+	for i, e := range d.Edges {
+		if enc.Edges[i], err = s.encEdge(ctx, e); err != nil {
+			return nil, err
+		}
+	}
+	created, err := s.Store.CreateDAG(ctx, &enc, opts...)
+	return s.decDAG(ctx, created, err)
+}
+
+func (s *Store) GetDAG(ctx context.Context, dagID string, opts ...dag.GetDAGOptions) (*dag.DAG, error) {
+	d, err := s.Store.GetDAG(ctx, dagID, opts...)
+	return s.decDAG(ctx, d, err)
+}
+
+func (s *Store) GetDAGs(ctx context.Context, dagIDs []string, opts ...dag.GetDAGOptions) (map[string]*dag.DAG, error) {
+	dags, err := s.Store.GetDAGs(ctx, dagIDs, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range dags {
+		if _, err := s.decDAG(ctx, d, nil); err != nil {
+			return nil, err
+		}
+	}
+	return dags, nil
+}
+
+// StreamDAG decrypts each node and edge before passing it on.
+func (s *Store) StreamDAG(ctx context.Context, dagID string, onNode func(dag.Node) error, onEdge func(dag.Edge) error) error {
+	var decNode func(dag.Node) error
+	if onNode != nil {
+		decNode = func(n dag.Node) error {
+			nodes := []dag.Node{n}
+			if err := s.decNodes(ctx, nodes); err != nil {
+				return err
+			}
+			return onNode(nodes[0])
+		}
+	}
+	var decEdge func(dag.Edge) error
+	if onEdge != nil {
+		decEdge = func(e dag.Edge) error {
+			edges := []dag.Edge{e}
+			if err := s.decEdges(ctx, edges); err != nil {
+				return err
+			}
+			return onEdge(edges[0])
+		}
+	}
+	return s.Store.StreamDAG(ctx, dagID, decNode, decEdge)
+}
+
+func (s *Store) CreateDraft(ctx context.Context, dagID string) (*dag.DAG, error) {
+	d, err := s.Store.CreateDraft(ctx, dagID)
+	return s.decDAG(ctx, d, err)
+}
+
+func (s *Store) PromoteDraft(ctx context.Context, dagID string) (*dag.DAG, error) {
+	d, err := s.Store.PromoteDraft(ctx, dagID)
+	return s.decDAG(ctx, d, err)
+}
+
+// AddNode encrypts the node's data and sets node.ID; node.Data stays
+// plaintext.
+func (s *Store) AddNode(ctx context.Context, dagID string, node *dag.Node) (string, error) {
+	enc, err := s.encNode(ctx, *node)
+	if err != nil {
+		return "", err
+	}
+	id, err := s.Store.AddNode(ctx, dagID, &enc)
+	if err != nil {
+		return "", err
+	}
+	node.ID = id
+	return id, nil
+}
+
+func (s *Store) GetNode(ctx context.Context, nodeID string) (*dag.Node, error) {
+	n, err := s.Store.GetNode(ctx, nodeID)
+	if err != nil || n == nil {
+		return n, err
+	}
+	nodes := []dag.Node{*n}
+	if err := s.decNodes(ctx, nodes); err != nil {
+		return nil, err
+	}
+	return &nodes[0], nil
+}
+
+func (s *Store) UpdateNode(ctx context.Context, node *dag.Node) error {
+	enc, err := s.encNode(ctx, *node)
+	if err != nil {
+		return err
+	}
+	return s.Store.UpdateNode(ctx, &enc)
+}
+
+func (s *Store) ListNodes(ctx context.Context, dagID string) ([]dag.Node, error) {
+	nodes, err := s.Store.ListNodes(ctx, dagID)
+	return s.decNodeList(ctx, nodes, err)
+}
+
+func (s *Store) FindNodesByTag(ctx context.Context, dagID, tag string) ([]dag.Node, error) {
+	nodes, err := s.Store.FindNodesByTag(ctx, dagID, tag)
+	return s.decNodeList(ctx, nodes, err)
+}
+
+func (s *Store) ListNodesPage(ctx context.Context, dagID string, opts dag.ListOptions) (*dag.Page[dag.Node], error) {
+	p, err := s.Store.ListNodesPage(ctx, dagID, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decNodes(ctx, p.Items); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *Store) AddNodes(ctx context.Context, dagID string, nodes []dag.Node) ([]dag.BatchResult, error) {
+	enc := make([]dag.Node, len(nodes))
+	var err error
+	for i, n := range nodes {
+		if enc[i], err = s.encNode(ctx, n); err != nil {
+			return nil, err
+		}
+	}
+	return s.Store.AddNodes(ctx, dagID, enc)
+}
+
+// AddEdge encrypts the edge's data and sets edge.ID and edge.OrderIndex;
+// edge.Data stays plaintext.
+func (s *Store) AddEdge(ctx context.Context, dagID string, edge *dag.Edge) (string, error) {
+	enc, err := s.encEdge(ctx, *edge)
+	if err != nil {
+		return "", err
+	}
+	id, err := s.Store.AddEdge(ctx, dagID, &enc)
+	if err != nil {
+		return "", err
+	}
+	edge.ID, edge.OrderIndex = id, enc.OrderIndex
+	return id, nil
+}
+
+func (s *Store) GetEdge(ctx context.Context, edgeID string) (*dag.Edge, error) {
+	e, err := s.Store.GetEdge(ctx, edgeID)
+	if err != nil || e == nil {
+		return e, err
+	}
+	edges := []dag.Edge{*e}
+	if err := s.decEdges(ctx, edges); err != nil {
+		return nil, err
+	}
+	return &edges[0], nil
+}
+
+func (s *Store) UpdateEdge(ctx context.Context, edge *dag.Edge) error {
+	enc, err := s.encEdge(ctx, *edge)
+	if err != nil {
+		return err
+	}
+	return s.Store.UpdateEdge(ctx, &enc)
+}
+
+func (s *Store) ListEdges(ctx context.Context, dagID string) ([]dag.Edge, error) {
+	edges, err := s.Store.ListEdges(ctx, dagID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decEdges(ctx, edges); err != nil {
+		return nil, err
+	}
+	return edges, nil
+}
+
+// FindEdges matches filter against the stored data, so conditions on
+// encrypted paths see ciphertext.
+func (s *Store) FindEdges(ctx context.Context, dagID string, filter dag.EdgeFilter) ([]dag.Edge, error) {
+	edges, err := s.Store.FindEdges(ctx, dagID, filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decEdges(ctx, edges); err != nil {
+		return nil, err
+	}
+	return edges, nil
+}
+
+func (s *Store) ListEdgesPage(ctx context.Context, dagID string, opts dag.ListOptions) (*dag.Page[dag.Edge], error) {
+	p, err := s.Store.ListEdgesPage(ctx, dagID, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decEdges(ctx, p.Items); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *Store) AddEdges(ctx context.Context, dagID string, edges []dag.Edge) ([]dag.BatchResult, error) {
+	enc := make([]dag.Edge, len(edges))
+	var err error
+	for i, e := range edges {
+		if enc[i], err = s.encEdge(ctx, e); err != nil {
+			return nil, err
+		}
+	}
+	return s.Store.AddEdges(ctx, dagID, enc)
+}
+
+// ApplyChangeSet encrypts the data in cs and fills in IDs and order
+// indexes; the data in cs stays plaintext.
+func (s *Store) ApplyChangeSet(ctx context.Context, dagID string, cs *dag.ChangeSet) error {
+	enc := dag.ChangeSet{DeleteNodes: cs.DeleteNodes, DeleteEdges: cs.DeleteEdges}
+	var err error
+	if enc.AddNodes, err = s.encNodes(ctx, cs.AddNodes); err != nil {
+		return err
+	}
+	if enc.UpdateNodes, err = s.encNodes(ctx, cs.UpdateNodes); err != nil {
+		return err
+	}
+	if enc.AddEdges, err = s.encEdges(ctx, cs.AddEdges); err != nil {
+		return err
+	}
+	if enc.UpdateEdges, err = s.encEdges(ctx, cs.UpdateEdges); err != nil {
+		return err
+	}
+	if err := s.Store.ApplyChangeSet(ctx, dagID, &enc); err != nil {
+		return err
+	}
+	for i := range cs.AddNodes {
+		cs.AddNodes[i].ID = enc.AddNodes[i].ID
+	}
+	for i := range cs.AddEdges {
+		e := &cs.AddEdges[i]
+		e.ID, e.FromNodeID, e.ToNodeID, e.OrderIndex = enc.AddEdges[i].ID, enc.AddEdges[i].FromNodeID, enc.AddEdges[i].ToNodeID, enc.AddEdges[i].OrderIndex
+	}
+	for i := range cs.UpdateEdges {
+		e := &cs.UpdateEdges[i]
+		e.FromNodeID, e.ToNodeID, e.OrderIndex = enc.UpdateEdges[i].FromNodeID, enc.UpdateEdges[i].ToNodeID, enc.UpdateEdges[i].OrderIndex
+	}
+	return nil
+}
+
+// encNodes returns encrypted copies of nodes.
+func (s *Store) encNodes(ctx context.Context, nodes []dag.Node) ([]dag.Node, error) {
+	enc := make([]dag.Node, len(nodes))
+	var err error
+	for i, n := range nodes {
+		if enc[i], err = s.encNode(ctx, n); err != nil {
+			return nil, err
+		}
+	}
+	return enc, nil
+}
+
+// encEdges returns encrypted copies of edges.
+func (s *Store) encEdges(ctx context.Context, edges []dag.Edge) ([]dag.Edge, error) {
+	enc := make([]dag.Edge, len(edges))
+	var err error
+	for i, e := range edges {
+		if enc[i], err = s.encEdge(ctx, e); err != nil {
+			return nil, err
+		}
+	}
+	return enc, nil
+}
+
+func (s *Store) Ancestors(ctx context.Context, nodeID string) ([]dag.Node, error) {
+	nodes, err := s.Store.Ancestors(ctx, nodeID)
+	return s.decNodeList(ctx, nodes, err)
+}
+
+func (s *Store) Descendants(ctx context.Context, nodeID string) ([]dag.Node, error) {
+	nodes, err := s.Store.Descendants(ctx, nodeID)
+	return s.decNodeList(ctx, nodes, err)
+}
+
+func (s *Store) Neighborhood(ctx context.Context, nodeID string, k int, dir dag.Direction) (*dag.DAG, error) {
+	d, err := s.Store.Neighborhood(ctx, nodeID, k, dir)
+	return s.decDAG(ctx, d, err)
+}
+
+func (s *Store) Path(ctx context.Context, dagID, fromID, toID string) ([]dag.Node, error) {
+	nodes, err := s.Store.Path(ctx, dagID, fromID, toID)
+	return s.decNodeList(ctx, nodes, err)
+}
+
+// decNodeList decrypts the result of a wrapped call returning nodes.
+func (s *Store) decNodeList(ctx context.Context, nodes []dag.Node, err error) ([]dag.Node, error) {
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decNodes(ctx, nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
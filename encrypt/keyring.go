@@ -0,0 +1,111 @@
+package encrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"strings"
+)
+
+// Keyring is a KeyWrapper holding AES-256 key-encryption keys in memory.
+// New data keys are wrapped with Current; the others stay available to
+// unwrap values written before a rotation.
+//
+//	k := encrypt.NewKeyring("2024-06", masterKey) // 32 bytes
+//	k.Add("2023-01", oldKey)
+type Keyring struct {
+	Current string
+	Keys    map[string][]byte
+}
+
+// NewKeyring returns a Keyring whose current key is key, named id.
+func NewKeyring(id string, key []byte) (*Keyring, error) {
+	k := &Keyring{Keys: map[string][]byte{}}
+	if err := k.Add(id, key); err != nil {
+		return nil, err
+	}
+	k.Current = id
+	return k, nil
+}
+
+// Add makes key available for unwrapping under id. It does not change
+// Current.
+func (k *Keyring) Add(id string, key []byte) error {
+	if id == "" || strings.Contains(id, ":") {
+		return fmt.Errorf("encrypt: key id %q must be non-empty and must not contain ':'", id)
+	}
+	if len(key) != 32 {
+		return fmt.Errorf("encrypt: key %s must be 32 bytes, got %d", id, len(key))
+	}
+	if k.Keys == nil {
+		k.Keys = map[string][]byte{}
+	}
+	k.Keys[id] = key
+	return nil
+}
+
+// Wrap encrypts dek with the current key.
+func (k *Keyring) Wrap(_ context.Context, dek []byte) (string, []byte, error) {
+	key, ok := k.Keys[k.Current]
+	if !ok {
+		return "", nil, fmt.Errorf("encrypt: current key %q not in keyring", k.Current)
+	}
+	wrapped, err := seal(key, dek)
+	if err != nil {
+		return "", nil, err
+	}
+	return k.Current, wrapped, nil
+}
+
+// Unwrap decrypts a data key wrapped by the key named keyID.
+func (k *Keyring) Unwrap(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := k.Keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrDecrypt, keyID)
+	}
+	return open(key, wrapped)
+}
+
+// seal encrypts plain with AES-GCM under key and returns nonce || ciphertext.
+func seal(key, plain []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("encrypt: nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+// open reverses seal.
+func open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%w: ciphertext too short", ErrDecrypt)
+	}
+	nonce, ct := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ct, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt: %w", err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,298 @@
+package dag
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// EqualOptions says what Equal compares. The zero value compares nodes
+// and edges by ID, with their data, tags and order_index. DAG metadata
+// (ID, name, tags, status, settings) is never compared.
+type EqualOptions struct {
+	// IgnoreIDs matches nodes by what they hold instead of by ID: their
+	// data and tags, and the nodes and edges around them. Edges are then
+	// matched by their ends, data and order_index.
+	IgnoreIDs bool
+	// IgnoreData leaves node data, node tags and edge data out, so only
+	// the shape of the graph is compared.
+	IgnoreData bool
+	// IgnoreOrder leaves edges' order_index out.
+	IgnoreOrder bool
+}
+
+// Equal reports whether a and b hold the same flow. With IgnoreIDs it
+// looks for a one-to-one matching of a's nodes to b's under which every
+// node and edge of a has an equal counterpart in b, so a DAG created again
+// from the same refs, or copied to another environment with new IDs,
+// compares equal. Parallel edges count: a pair of edges between two nodes
+// is not equal to one.
+func Equal(a, b *DAG, opts ...EqualOptions) bool {
+	var o EqualOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if len(a.Nodes) != len(b.Nodes) || len(a.Edges) != len(b.Edges) {
+		return false
+	}
+	if o.IgnoreIDs {
+		return newMatcher(a, b, o).match()
+	}
+
+	nodes := make(map[string]string, len(a.Nodes))
+	for _, n := range a.Nodes {
+		nodes[n.ID] = o.nodeKey(n)
+	}
+	for _, n := range b.Nodes {
+		k, ok := nodes[n.ID]
+		if !ok || k != o.nodeKey(n) {
+			return false
+		}
+		delete(nodes, n.ID)
+	}
+	edges := make(map[string]int, len(a.Edges))
+	for _, e := range a.Edges {
+		edges[e.ID+"\x00"+e.FromNodeID+"\x00"+e.ToNodeID+"\x00"+o.edgeKey(e)]++
+	}
+	for _, e := range b.Edges {
+		k := e.ID + "\x00" + e.FromNodeID + "\x00" + e.ToNodeID + "\x00" + o.edgeKey(e)
+		if edges[k] == 0 {
+			return false
+		}
+		edges[k]--
+	}
+	return true
+}
+
+// Isomorphic reports whether a and b have the same shape: Equal with IDs,
+// data and edge order all ignored.
+func Isomorphic(a, b *DAG) bool {
+	return Equal(a, b, EqualOptions{IgnoreIDs: true, IgnoreData: true, IgnoreOrder: true})
+}
+
+func (o EqualOptions) nodeKey(n Node) string {
+	if o.IgnoreData {
+		return ""
+	}
+	tags := slices.Clone(n.Tags)
+	slices.Sort(tags)
+	return string(canonicalJSON(n.Data)) + "\x00" + strings.Join(tags, "\x00")
+}
+
+func (o EqualOptions) edgeKey(e Edge) string {
+	var k string
+	if !o.IgnoreData {
+		k = string(canonicalJSON(e.Data))
+	}
+	if !o.IgnoreOrder {
+		k += "\x00" + strconv.Itoa(e.OrderIndex)
+	}
+	return k
+}
+
+// graphSide is one DAG of a matcher, with nodes as indexes.
+type graphSide struct {
+	index map[string]int
+	keys  []string
+	out   [][]arc
+	in    [][]arc
+	// between holds the sorted edge keys from one node to another.
+	between map[[2]int][]string
+	// dangling holds the sorted keys of edges with an end outside the DAG.
+	dangling []string
+}
+
+type arc struct {
+	node int
+	key  string
+}
+
+// matcher searches for a node matching between two DAGs of equal size.
+type matcher struct {
+	a, b   *graphSide
+	colorA []int
+	colorB []int
+}
+
+func newMatcher(a, b *DAG, o EqualOptions) *matcher {
+	return &matcher{a: newGraphSide(a, o), b: newGraphSide(b, o)}
+}
+
+func newGraphSide(d *DAG, o EqualOptions) *graphSide {
+	g := &graphSide{
+		index:   make(map[string]int, len(d.Nodes)),
+		keys:    make([]string, len(d.Nodes)),
+		out:     make([][]arc, len(d.Nodes)),
+		in:      make([][]arc, len(d.Nodes)),
+		between: make(map[[2]int][]string),
+	}
+	for i, n := range d.Nodes {
+		g.index[n.ID] = i
+		g.keys[i] = o.nodeKey(n)
+	}
+	for _, e := range d.Edges {
+		from, ok1 := g.index[e.FromNodeID]
+		to, ok2 := g.index[e.ToNodeID]
+		if !ok1 || !ok2 {
+			g.dangling = append(g.dangling, o.edgeKey(e))
+			continue
+		}
+		k := o.edgeKey(e)
+		g.out[from] = append(g.out[from], arc{to, k})
+		g.in[to] = append(g.in[to], arc{from, k})
+		g.between[[2]int{from, to}] = append(g.between[[2]int{from, to}], k)
+	}
+	for _, ks := range g.between {
+		slices.Sort(ks)
+	}
+	slices.Sort(g.dangling)
+	return g
+}
+
+// match refines node colours until they stop splitting, then searches for
+// a colour-preserving matching that maps every edge onto an equal edge.
+func (m *matcher) match() bool {
+	if len(m.a.between) != len(m.b.between) || !slices.Equal(m.a.dangling, m.b.dangling) {
+		return false
+	}
+	ids := map[string]int{}
+	intern := func(s string) int {
+		id, ok := ids[s]
+		if !ok {
+			id = len(ids)
+			ids[s] = id
+		}
+		return id
+	}
+	m.colorA = make([]int, len(m.a.keys))
+	m.colorB = make([]int, len(m.b.keys))
+	for i := range m.a.keys {
+		m.colorA[i] = intern(m.a.keys[i])
+		m.colorB[i] = intern(m.b.keys[i])
+	}
+
+	classes := 0
+	for {
+		if !sameHistogram(m.colorA, m.colorB) {
+			return false
+		}
+		n := len(slices.Compact(slices.Sorted(slices.Values(m.colorA))))
+		if n == classes {
+			break
+		}
+		classes = n
+		ids = map[string]int{}
+		m.colorA = refine(m.a, m.colorA, intern)
+		m.colorB = refine(m.b, m.colorB, intern)
+	}
+
+	// Match the most constrained nodes first.
+	size := map[int]int{}
+	for _, c := range m.colorA {
+		size[c]++
+	}
+	order := make([]int, len(m.colorA))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(x, y int) int {
+		return cmp.Compare(size[m.colorA[x]], size[m.colorA[y]])
+	})
+	byColor := map[int][]int{}
+	for i, c := range m.colorB {
+		byColor[c] = append(byColor[c], i)
+	}
+
+	to := make([]int, len(order))
+	used := make([]bool, len(order))
+	for i := range to {
+		to[i] = -1
+	}
+	var assign func(k int) bool
+	assign = func(k int) bool {
+		if k == len(order) {
+			return true
+		}
+		u := order[k]
+		for _, v := range byColor[m.colorA[u]] {
+			if used[v] || !m.consistent(u, v, to, used) {
+				continue
+			}
+			to[u], used[v] = v, true
+			if assign(k + 1) {
+				return true
+			}
+			to[u], used[v] = -1, false
+		}
+		return false
+	}
+	return assign(0)
+}
+
+// consistent reports whether mapping a's node u to b's node v keeps the
+// edges between u and the nodes already mapped equal on both sides. to
+// maps a's nodes to b's, -1 if unmapped; used marks b's mapped nodes.
+func (m *matcher) consistent(u, v int, to []int, used []bool) bool {
+	if !slices.Equal(m.a.between[[2]int{u, u}], m.b.between[[2]int{v, v}]) {
+		return false
+	}
+	mapped := 0
+	for _, e := range m.a.out[u] {
+		if w := to[e.node]; w >= 0 {
+			mapped++
+			if !slices.Equal(m.a.between[[2]int{u, e.node}], m.b.between[[2]int{v, w}]) {
+				return false
+			}
+		}
+	}
+	for _, e := range m.a.in[u] {
+		if w := to[e.node]; w >= 0 {
+			mapped++
+			if !slices.Equal(m.a.between[[2]int{e.node, u}], m.b.between[[2]int{w, v}]) {
+				return false
+			}
+		}
+	}
+	// v may have edges to mapped nodes that u has no counterpart of.
+	for _, e := range m.b.out[v] {
+		if used[e.node] {
+			mapped--
+		}
+	}
+	for _, e := range m.b.in[v] {
+		if used[e.node] {
+			mapped--
+		}
+	}
+	return mapped == 0
+}
+
+// refine gives each node a colour made of its own colour and the colours
+// and edge keys of its neighbours.
+func refine(g *graphSide, color []int, intern func(string) int) []int {
+	next := make([]int, len(color))
+	var b strings.Builder
+	for i := range color {
+		sig := make([]string, 0, len(g.out[i])+len(g.in[i]))
+		for _, e := range g.out[i] {
+			sig = append(sig, ">"+strconv.Itoa(color[e.node])+"\x00"+e.key)
+		}
+		for _, e := range g.in[i] {
+			sig = append(sig, "<"+strconv.Itoa(color[e.node])+"\x00"+e.key)
+		}
+		slices.Sort(sig)
+		b.Reset()
+		b.WriteString(strconv.Itoa(color[i]))
+		for _, s := range sig {
+			b.WriteString("\x01")
+			b.WriteString(s)
+		}
+		next[i] = intern(b.String())
+	}
+	return next
+}
+
+func sameHistogram(a, b []int) bool {
+	return slices.Equal(slices.Sorted(slices.Values(a)), slices.Sorted(slices.Values(b)))
+}
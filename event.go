@@ -0,0 +1,168 @@
+package dag
+
+import (
+	"context"
+	_ "embed"
+	"slices"
+	"time"
+)
+
+// EventType says what an Event did to its DAG.
+type EventType string
+
+const (
+	// EventCreated sets the whole DAG to Event.DAG, replacing whatever was
+	// there. CreateDAG, CreateDraft and PromoteDraft record it.
+	EventCreated EventType = "created"
+	// EventDeleted removes the DAG.
+	EventDeleted EventType = "deleted"
+	// EventChanged applies Event.Changes to the nodes and edges. Every
+	// node and edge write records one, with IDs and OrderIndex resolved.
+	EventChanged EventType = "changed"
+	// EventReordered sets the order of the edges leaving
+	// Event.Order.FromNodeID.
+	EventReordered EventType = "reordered"
+	// EventTags sets the DAG's tags to Event.Tags.
+	EventTags EventType = "tags"
+	// EventSettings sets the DAG's settings to Event.Settings.
+	EventSettings EventType = "settings"
+	// EventStatus sets the DAG's status to Event.Status.
+	EventStatus EventType = "status"
+)
+
+// Event is one write to a DAG, as recorded in an event log. Seq orders the
+// events of a store; replaying a DAG's events in Seq order with Replay
+// gives its state after the last of them. Only the field for Type is set.
+// Actor is who made the write, as WithActor put it in the write's
+// context, or empty if unknown.
+type Event struct {
+	Seq      int64      `json:"seq"`
+	DAGID    string     `json:"dag_id"`
+	Type     EventType  `json:"type"`
+	At       time.Time  `json:"at"`
+	Actor    string     `json:"actor,omitempty"`
+	DAG      *DAG       `json:"dag,omitempty"`
+	Changes  *ChangeSet `json:"changes,omitempty"`
+	Order    *EdgeOrder `json:"order,omitempty"`
+	Tags     []string   `json:"tags,omitempty"`
+	Settings *Settings  `json:"settings,omitempty"`
+	Status   Status     `json:"status,omitempty"`
+}
+
+// EventSchemaVersion names the JSON encoding of Event that sinks publish,
+// described by EventSchema. It changes only if the encoding changes in a
+// way existing consumers can't read.
+const EventSchemaVersion = "dag.event.v1"
+
+// EventSchema is the JSON Schema (draft 2020-12) of an Event as encoding/json
+// writes it, for schema registries and consumers in other languages.
+//
+//go:embed event.schema.json
+var EventSchema string
+
+// EventSink receives DAG change events, e.g. to publish them to a message
+// broker. Publish gets events in Seq order and should return nil only once
+// all of them are durably accepted: a failed batch is offered again, so a
+// sink sees every event at least once.
+type EventSink interface {
+	Publish(ctx context.Context, events []Event) error
+}
+
+// EventSinkFunc adapts a function to EventSink.
+type EventSinkFunc func(ctx context.Context, events []Event) error
+
+func (f EventSinkFunc) Publish(ctx context.Context, events []Event) error { return f(ctx, events) }
+
+// EdgeOrder is the payload of an EventReordered.
+type EdgeOrder struct {
+	FromNodeID string   `json:"from_node_id"`
+	EdgeIDs    []string `json:"edge_ids"`
+}
+
+// Replay folds events, which must all belong to one DAG and be in Seq
+// order, into the DAG they leave behind. It returns nil if the DAG does
+// not exist after them: no event created it, or the last such was deleted.
+// A write to a DAG that does not exist creates it as a draft, as the
+// stores do.
+func Replay(events []Event) *DAG {
+	var d *DAG
+	for _, e := range events {
+		if d == nil && e.Type != EventCreated && e.Type != EventDeleted {
+			d = &DAG{ID: e.DAGID, Status: StatusDraft, Nodes: []Node{}, Edges: []Edge{}}
+		}
+		switch e.Type {
+		case EventCreated:
+			c := *e.DAG
+			c.Nodes, c.Edges = slices.Clone(c.Nodes), slices.Clone(c.Edges)
+			if c.Nodes == nil {
+				c.Nodes = []Node{}
+			}
+			if c.Edges == nil {
+				c.Edges = []Edge{}
+			}
+			d = &c
+		case EventDeleted:
+			d = nil
+		case EventChanged:
+			applyChanges(d, e.Changes)
+		case EventReordered:
+			for i, id := range e.Order.EdgeIDs {
+				for j := range d.Edges {
+					if d.Edges[j].ID == id && d.Edges[j].FromNodeID == e.Order.FromNodeID {
+						d.Edges[j].OrderIndex = i
+					}
+				}
+			}
+		case EventTags:
+			d.Tags = slices.Clone(e.Tags)
+		case EventSettings:
+			d.Settings = *e.Settings
+		case EventStatus:
+			d.Status = e.Status
+		}
+	}
+	if d != nil {
+		slices.SortStableFunc(d.Edges, func(a, b Edge) int { return a.OrderIndex - b.OrderIndex })
+	}
+	return d
+}
+
+// applyChanges applies cs to d in the order ApplyChangeSet writes it.
+func applyChanges(d *DAG, cs *ChangeSet) {
+	gone := make(map[string]bool, len(cs.DeleteNodes)+len(cs.DeleteEdges))
+	for _, id := range cs.DeleteNodes {
+		gone[id] = true
+	}
+	for _, id := range cs.DeleteEdges {
+		gone[id] = true
+	}
+	d.Nodes = slices.DeleteFunc(d.Nodes, func(n Node) bool { return gone[n.ID] })
+	d.Edges = slices.DeleteFunc(d.Edges, func(e Edge) bool {
+		return gone[e.ID] || gone[e.FromNodeID] || gone[e.ToNodeID]
+	})
+
+	for _, u := range cs.UpdateNodes {
+		for i := range d.Nodes {
+			if d.Nodes[i].ID == u.ID {
+				d.Nodes[i].Data = u.Data
+				if u.Tags != nil {
+					d.Nodes[i].Tags = u.Tags
+				}
+			}
+		}
+	}
+	for _, n := range cs.AddNodes {
+		n.Ref = ""
+		d.Nodes = append(d.Nodes, n)
+	}
+	for _, u := range cs.UpdateEdges {
+		for i := range d.Edges {
+			if d.Edges[i].ID == u.ID {
+				d.Edges[i] = Edge{ID: u.ID, FromNodeID: u.FromNodeID, ToNodeID: u.ToNodeID, Data: u.Data, OrderIndex: u.OrderIndex}
+			}
+		}
+	}
+	for _, e := range cs.AddEdges {
+		d.Edges = append(d.Edges, Edge{ID: e.ID, FromNodeID: e.FromNodeID, ToNodeID: e.ToNodeID, Data: e.Data, OrderIndex: e.OrderIndex})
+	}
+}
@@ -0,0 +1,65 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// VisitCSV writes raw visits as CSV, one row per visit, for loading into a
+// data warehouse. Timestamps are RFC 3339 in UTC.
+type VisitCSV struct {
+	cw *csv.Writer
+}
+
+// NewVisitCSV writes the header row to w and returns a VisitCSV writing to
+// it.
+func NewVisitCSV(w io.Writer) (*VisitCSV, error) {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"seq", "dag_id", "run_id", "node_id", "visited_at"})
+	return &VisitCSV{cw: cw}, cw.Error()
+}
+
+// Write adds one visit.
+func (v *VisitCSV) Write(x dag.Visit) error {
+	return v.cw.Write([]string{
+		strconv.FormatInt(x.Seq, 10), x.DAGID, x.RunID, x.NodeID, x.VisitedAt.UTC().Format(time.RFC3339Nano),
+	})
+}
+
+// Flush writes any buffered rows to the underlying writer.
+func (v *VisitCSV) Flush() error {
+	v.cw.Flush()
+	return v.cw.Error()
+}
+
+// WriteNodeHeatCSV writes the node half of a heatmap of DAG dagID as CSV,
+// one row per node.
+func WriteNodeHeatCSV(w io.Writer, dagID string, h *dag.Heatmap) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"dag_id", "node_id", "visits", "runs", "exits", "drop_off"})
+	for _, n := range h.Nodes {
+		cw.Write([]string{
+			dagID, n.NodeID,
+			strconv.FormatInt(n.Visits, 10), strconv.FormatInt(n.Runs, 10), strconv.FormatInt(n.Exits, 10),
+			strconv.FormatFloat(n.DropOff, 'f', -1, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteEdgeHeatCSV writes the edge half of a heatmap of DAG dagID as CSV,
+// one row per edge.
+func WriteEdgeHeatCSV(w io.Writer, dagID string, h *dag.Heatmap) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"dag_id", "edge_id", "from_node_id", "to_node_id", "count"})
+	for _, e := range h.Edges {
+		cw.Write([]string{dagID, e.EdgeID, e.FromNodeID, e.ToNodeID, strconv.FormatInt(e.Count, 10)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,50 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// AuditCSV writes audit trail events as CSV, one row per event, for
+// compliance reviews. The detail column holds the event's payload, such
+// as its change set or status, as JSON. Timestamps are RFC 3339 in UTC.
+type AuditCSV struct {
+	cw *csv.Writer
+}
+
+// NewAuditCSV writes the header row to w and returns an AuditCSV writing
+// to it.
+func NewAuditCSV(w io.Writer) (*AuditCSV, error) {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"seq", "at", "dag_id", "actor", "type", "detail"})
+	return &AuditCSV{cw: cw}, cw.Error()
+}
+
+// Write adds one event.
+func (a *AuditCSV) Write(e dag.Event) error {
+	detail, err := json.Marshal(struct {
+		DAG      *dag.DAG       `json:"dag,omitempty"`
+		Changes  *dag.ChangeSet `json:"changes,omitempty"`
+		Order    *dag.EdgeOrder `json:"order,omitempty"`
+		Tags     []string       `json:"tags,omitempty"`
+		Settings *dag.Settings  `json:"settings,omitempty"`
+		Status   dag.Status     `json:"status,omitempty"`
+	}{e.DAG, e.Changes, e.Order, e.Tags, e.Settings, e.Status})
+	if err != nil {
+		return err
+	}
+	return a.cw.Write([]string{
+		strconv.FormatInt(e.Seq, 10), e.At.UTC().Format(time.RFC3339Nano), e.DAGID, e.Actor, string(e.Type), string(detail),
+	})
+}
+
+// Flush writes any buffered rows to the underlying writer.
+func (a *AuditCSV) Flush() error {
+	a.cw.Flush()
+	return a.cw.Error()
+}
@@ -0,0 +1,181 @@
+package export
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// BundleVersion identifies the encoding of a signed bundle.
+const BundleVersion = "dag.bundle.v1"
+
+// Signature algorithms of a bundle.
+const (
+	HMACSHA256 = "hmac-sha256"
+	Ed25519    = "ed25519"
+)
+
+var (
+	// ErrNotBundle is returned by ReadBundle for input that is not a
+	// signed bundle, such as a plain JSON export.
+	ErrNotBundle = errors.New("export: not a signed bundle")
+	// ErrUnknownKey is returned by ReadBundle when no key given to it can
+	// check the bundle's signature.
+	ErrUnknownKey = errors.New("export: bundle signed with an unknown key")
+	// ErrBadSignature is returned by ReadBundle when the bundle's hash or
+	// signature does not match its DAG: it was altered after signing.
+	ErrBadSignature = errors.New("export: bundle signature does not verify")
+	// ErrCannotSign is returned by WriteBundle for a key that can only
+	// verify.
+	ErrCannotSign = errors.New("export: key cannot sign")
+)
+
+// Bundle is a DAG exported as JSON together with a SHA-256 hash of that
+// JSON and a signature over the hash. WriteBundle writes one and
+// ReadBundle checks it. The hash is of the DAG's JSON with whitespace
+// removed, so a bundle may be re-indented, but reordering its keys breaks
+// the signature.
+type Bundle struct {
+	Version   string          `json:"version"`
+	Alg       string          `json:"alg"`
+	KeyID     string          `json:"key_id,omitempty"`
+	Hash      string          `json:"hash"`
+	Signature string          `json:"signature"`
+	DAG       json.RawMessage `json:"dag"`
+}
+
+// Key signs or verifies bundles. An HMAC key and an Ed25519 private key
+// do both; an Ed25519 public key only verifies.
+type Key struct {
+	// ID names the key in the bundles it signs, so a verifier holding
+	// several keys, such as during rotation, knows which to use.
+	ID string
+
+	alg    string
+	secret []byte
+	priv   ed25519.PrivateKey
+	pub    ed25519.PublicKey
+}
+
+// HMACKey returns a key signing with HMAC-SHA256 under secret. Both sides
+// share the secret.
+func HMACKey(id string, secret []byte) *Key {
+	return &Key{ID: id, alg: HMACSHA256, secret: secret}
+}
+
+// Ed25519Key returns a key signing with priv, and verifying with its
+// public half.
+func Ed25519Key(id string, priv ed25519.PrivateKey) *Key {
+	return &Key{ID: id, alg: Ed25519, priv: priv, pub: priv.Public().(ed25519.PublicKey)}
+}
+
+// Ed25519PublicKey returns a key that only verifies, for environments
+// that import bundles but must not be able to produce them.
+func Ed25519PublicKey(id string, pub ed25519.PublicKey) *Key {
+	return &Key{ID: id, alg: Ed25519, pub: pub}
+}
+
+// Alg returns the key's algorithm, HMACSHA256 or Ed25519.
+func (k *Key) Alg() string { return k.alg }
+
+// CanSign reports whether the key can sign, and not only verify.
+func (k *Key) CanSign() bool { return k.secret != nil || k.priv != nil }
+
+// WriteBundle writes d to w as a Bundle signed with key.
+func WriteBundle(w io.Writer, d *dag.DAG, key *Key) error {
+	if !key.CanSign() {
+		return fmt.Errorf("%w: %s", ErrCannotSign, key.ID)
+	}
+	body, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("export: encode dag: %w", err)
+	}
+	b := Bundle{Version: BundleVersion, Alg: key.alg, KeyID: key.ID, Hash: bundleHash(body), DAG: body}
+	b.Signature = base64.StdEncoding.EncodeToString(key.sign(b.signed()))
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b)
+}
+
+// ReadBundle reads a Bundle from r, checks its hash and its signature
+// with the first of keys that has its key ID and algorithm, and returns
+// its DAG. A key with an empty ID matches bundles of any key ID.
+func ReadBundle(r io.Reader, keys ...*Key) (*dag.DAG, error) {
+	var b Bundle
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, fmt.Errorf("export: read bundle: %w", err)
+	}
+	if b.Version != BundleVersion || len(b.DAG) == 0 {
+		return nil, ErrNotBundle
+	}
+	var key *Key
+	for _, k := range keys {
+		if k.alg == b.Alg && (k.ID == "" || k.ID == b.KeyID) {
+			key = k
+			break
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("%w: %s key %q", ErrUnknownKey, b.Alg, b.KeyID)
+	}
+	sig, err := base64.StdEncoding.DecodeString(b.Signature)
+	if err != nil || b.Hash == "" || bundleHash(b.DAG) != b.Hash || !key.verify(b.signed(), sig) {
+		return nil, ErrBadSignature
+	}
+	var d dag.DAG
+	if err := json.Unmarshal(b.DAG, &d); err != nil {
+		return nil, fmt.Errorf("export: read bundle dag: %w", err)
+	}
+	return &d, nil
+}
+
+// IsBundle reports whether body is a signed bundle rather than a plain
+// export, without checking it.
+func IsBundle(body []byte) bool {
+	var b struct {
+		Version string `json:"version"`
+	}
+	return json.Unmarshal(body, &b) == nil && b.Version == BundleVersion
+}
+
+// bundleHash returns "sha256:" and the hex SHA-256 of body without
+// insignificant whitespace.
+func bundleHash(body []byte) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, body); err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// signed returns what the signature covers: the hash, bound to the
+// version, algorithm and key ID so none can be swapped.
+func (b *Bundle) signed() []byte {
+	return []byte(b.Version + "\n" + b.Alg + "\n" + b.KeyID + "\n" + b.Hash)
+}
+
+func (k *Key) sign(msg []byte) []byte {
+	if k.alg == Ed25519 {
+		return ed25519.Sign(k.priv, msg)
+	}
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write(msg)
+	return mac.Sum(nil)
+}
+
+func (k *Key) verify(msg, sig []byte) bool {
+	if k.alg == Ed25519 {
+		return len(k.pub) == ed25519.PublicKeySize && ed25519.Verify(k.pub, msg, sig)
+	}
+	return hmac.Equal(k.sign(msg), sig)
+}
@@ -0,0 +1,123 @@
+// Package export renders a dag.DAG in formats other tools understand:
+// JSON, Graphviz DOT, Mermaid, GraphML and CSV. JSON, GraphML and CSV can
+// also be read back with Read.
+//
+// It also writes traversal analytics (dag.Visit and dag.Heatmap) and the
+// audit trail as CSV, and signed bundles that prove an export unchanged
+// when it is imported elsewhere.
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// Format names an export representation.
+type Format string
+
+const (
+	JSON    Format = "json"
+	DOT     Format = "dot"
+	Mermaid Format = "mermaid"
+	GraphML Format = "graphml"
+	CSV     Format = "csv"
+)
+
+// Formats lists every supported format, in preference order.
+var Formats = []Format{JSON, DOT, Mermaid, GraphML, CSV}
+
+// ErrUnknownFormat is returned for a format name that isn't supported.
+var ErrUnknownFormat = errors.New("export: unknown format")
+
+// ParseFormat converts a name like "dot" or "GraphML" to a Format.
+func ParseFormat(s string) (Format, error) {
+	f := Format(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range Formats {
+		if f == known {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q", ErrUnknownFormat, s)
+}
+
+// ContentType returns the MIME type used when serving f over HTTP.
+func (f Format) ContentType() string {
+	switch f {
+	case DOT:
+		return "text/vnd.graphviz"
+	case Mermaid:
+		return "text/vnd.mermaid"
+	case GraphML:
+		return "application/graphml+xml"
+	case CSV:
+		return "text/csv"
+	default:
+		return "application/json"
+	}
+}
+
+// Extension returns the file extension for f, without the dot.
+func (f Format) Extension() string {
+	switch f {
+	case DOT:
+		return "dot"
+	case Mermaid:
+		return "mmd"
+	case GraphML:
+		return "graphml"
+	case CSV:
+		return "csv"
+	default:
+		return "json"
+	}
+}
+
+// Write renders d to w in format f.
+func Write(w io.Writer, d *dag.DAG, f Format) error {
+	switch f {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	case DOT:
+		return writeDOT(w, d)
+	case Mermaid:
+		return writeMermaid(w, d)
+	case GraphML:
+		return writeGraphML(w, d)
+	case CSV:
+		return writeCSV(w, d)
+	}
+	return fmt.Errorf("%w %q", ErrUnknownFormat, f)
+}
+
+// WriteRedacted renders d to w in format f with its data redacted by p.
+// d itself is not modified.
+func WriteRedacted(w io.Writer, d *dag.DAG, f Format, p *dag.RedactionPolicy) error {
+	return Write(w, p.RedactDAG(d), f)
+}
+
+// Label keys looked up in node / edge data, in order, to get a display name.
+var (
+	nodeLabelKeys = []string{"label", "name", "title", "question"}
+	edgeLabelKeys = []string{"label", "answer", "condition"}
+)
+
+// label returns the first string value in data under one of keys, or fallback.
+func label(data json.RawMessage, keys []string, fallback string) string {
+	var m map[string]any
+	if json.Unmarshal(data, &m) != nil {
+		return fallback
+	}
+	for _, k := range keys {
+		if s, ok := m[k].(string); ok && s != "" {
+			return s
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,117 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// ErrNotReadable is returned by Read for formats that don't carry node and
+// edge data (DOT, Mermaid) and so can't be turned back into a DAG.
+var ErrNotReadable = errors.New("export: format cannot be imported")
+
+// Read parses a DAG in format f, as produced by Write. JSON input may also
+// use refs, exactly like a CreateDAG body. Read only parses — it does not
+// check for cycles or dangling edges.
+func Read(r io.Reader, f Format) (*dag.DAG, error) {
+	switch f {
+	case JSON:
+		var d dag.DAG
+		if err := json.NewDecoder(r).Decode(&d); err != nil {
+			return nil, fmt.Errorf("export: read json: %w", err)
+		}
+		return &d, nil
+	case GraphML:
+		return readGraphML(r)
+	case CSV:
+		return readCSV(r)
+	case DOT, Mermaid:
+		return nil, fmt.Errorf("%w: %s", ErrNotReadable, f)
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownFormat, f)
+}
+
+// readGraphML parses the subset of GraphML written by writeGraphML.
+func readGraphML(r io.Reader) (*dag.DAG, error) {
+	type data struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+	var doc struct {
+		Graph struct {
+			ID    string `xml:"id,attr"`
+			Nodes []struct {
+				ID   string `xml:"id,attr"`
+				Data []data `xml:"data"`
+			} `xml:"node"`
+			Edges []struct {
+				ID     string `xml:"id,attr"`
+				Source string `xml:"source,attr"`
+				Target string `xml:"target,attr"`
+				Data   []data `xml:"data"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("export: read graphml: %w", err)
+	}
+
+	payload := func(ds []data) json.RawMessage {
+		for _, d := range ds {
+			if d.Key == "data" && d.Value != "" {
+				return json.RawMessage(d.Value)
+			}
+		}
+		return json.RawMessage(`{}`)
+	}
+
+	d := &dag.DAG{ID: doc.Graph.ID}
+	for _, n := range doc.Graph.Nodes {
+		d.Nodes = append(d.Nodes, dag.Node{ID: n.ID, Data: payload(n.Data)})
+	}
+	for _, e := range doc.Graph.Edges {
+		d.Edges = append(d.Edges, dag.Edge{ID: e.ID, FromNodeID: e.Source, ToNodeID: e.Target, Data: payload(e.Data)})
+	}
+	return d, nil
+}
+
+// readCSV parses the table written by writeCSV.
+func readCSV(r io.Reader) (*dag.DAG, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(csvHeader)
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("export: read csv header: %w", err)
+	}
+	if !slices.Equal(header, csvHeader) {
+		return nil, fmt.Errorf("export: read csv: header must be %v", csvHeader)
+	}
+
+	d := &dag.DAG{}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("export: read csv: %w", err)
+		}
+		switch rec[0] {
+		case "node":
+			d.Nodes = append(d.Nodes, dag.Node{ID: rec[1], Data: json.RawMessage(rec[4])})
+		case "edge":
+			d.Edges = append(d.Edges, dag.Edge{ID: rec[1], FromNodeID: rec[2], ToNodeID: rec[3], Data: json.RawMessage(rec[4])})
+		default:
+			line, _ := cr.FieldPos(0)
+			return nil, fmt.Errorf("export: read csv: line %d: unknown kind %q", line, rec[0])
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,144 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// writeDOT renders d as a Graphviz digraph. Node IDs are the DOT IDs;
+// labels come from node/edge data.
+func writeDOT(w io.Writer, d *dag.DAG) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "digraph %s {\n", strconv.Quote(d.ID))
+	for _, n := range d.Nodes {
+		fmt.Fprintf(bw, "  %s [label=%s];\n", strconv.Quote(n.ID), strconv.Quote(label(n.Data, nodeLabelKeys, n.ID)))
+	}
+	for _, e := range d.Edges {
+		fmt.Fprintf(bw, "  %s -> %s", strconv.Quote(e.FromNodeID), strconv.Quote(e.ToNodeID))
+		if l := label(e.Data, edgeLabelKeys, ""); l != "" {
+			fmt.Fprintf(bw, " [label=%s]", strconv.Quote(l))
+		}
+		bw.WriteString(";\n")
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// writeMermaid renders d as a Mermaid flowchart. Mermaid IDs can't contain
+// most punctuation, so nodes are numbered n0, n1, ... in order.
+func writeMermaid(w io.Writer, d *dag.DAG) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("flowchart TD\n")
+	ids := make(map[string]string, len(d.Nodes))
+	for i, n := range d.Nodes {
+		ids[n.ID] = "n" + strconv.Itoa(i)
+		fmt.Fprintf(bw, "  %s[\"%s\"]\n", ids[n.ID], mermaidEscape(label(n.Data, nodeLabelKeys, n.ID)))
+	}
+	for _, e := range d.Edges {
+		from, to := ids[e.FromNodeID], ids[e.ToNodeID]
+		if l := label(e.Data, edgeLabelKeys, ""); l != "" {
+			fmt.Fprintf(bw, "  %s -->|\"%s\"| %s\n", from, mermaidEscape(l), to)
+		} else {
+			fmt.Fprintf(bw, "  %s --> %s\n", from, to)
+		}
+	}
+	return bw.Flush()
+}
+
+// mermaidEscape replaces characters that end a quoted Mermaid label.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s)
+}
+
+// writeGraphML renders d as GraphML. Raw JSON data is kept in a "data" key.
+func writeGraphML(w io.Writer, d *dag.DAG) error {
+	type data struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+	type node struct {
+		ID   string `xml:"id,attr"`
+		Data []data `xml:"data"`
+	}
+	type edge struct {
+		ID     string `xml:"id,attr"`
+		Source string `xml:"source,attr"`
+		Target string `xml:"target,attr"`
+		Data   []data `xml:"data"`
+	}
+	type key struct {
+		ID       string `xml:"id,attr"`
+		For      string `xml:"for,attr"`
+		AttrName string `xml:"attr.name,attr"`
+		AttrType string `xml:"attr.type,attr"`
+	}
+	type graph struct {
+		ID          string `xml:"id,attr"`
+		EdgeDefault string `xml:"edgedefault,attr"`
+		Nodes       []node `xml:"node"`
+		Edges       []edge `xml:"edge"`
+	}
+	type graphML struct {
+		XMLName xml.Name `xml:"graphml"`
+		XMLNS   string   `xml:"xmlns,attr"`
+		Keys    []key    `xml:"key"`
+		Graph   graph    `xml:"graph"`
+	}
+
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []key{
+			{ID: "label", For: "all", AttrName: "label", AttrType: "string"},
+			{ID: "data", For: "all", AttrName: "data", AttrType: "string"},
+		},
+		Graph: graph{ID: d.ID, EdgeDefault: "directed"},
+	}
+	for _, n := range d.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node{ID: n.ID, Data: []data{
+			{Key: "label", Value: label(n.Data, nodeLabelKeys, n.ID)},
+			{Key: "data", Value: string(n.Data)},
+		}})
+	}
+	for _, e := range d.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, edge{ID: e.ID, Source: e.FromNodeID, Target: e.ToNodeID, Data: []data{
+			{Key: "label", Value: label(e.Data, edgeLabelKeys, "")},
+			{Key: "data", Value: string(e.Data)},
+		}})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// csvHeader is the column layout of CSV exports. Nodes leave the
+// from/to columns empty.
+var csvHeader = []string{"kind", "id", "from_node_id", "to_node_id", "data"}
+
+// writeCSV renders d as one CSV table: a row per node, then a row per edge.
+func writeCSV(w io.Writer, d *dag.DAG) error {
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader)
+	for _, n := range d.Nodes {
+		cw.Write([]string{"node", n.ID, "", "", string(n.Data)})
+	}
+	for _, e := range d.Edges {
+		cw.Write([]string{"edge", e.ID, e.FromNodeID, e.ToNodeID, string(e.Data)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,250 @@
+// Package formflow runs a dag.DAG as a branching form: nodes are
+// questions, and each edge's data says which answer to its source leads
+// along it, as {"answer": "Developer"}. An edge may name a Matcher to
+// compare with instead of equality, as {"answer": ["UK", "IE"], "match":
+// "one_of"}. An edge without an "answer" key is the default branch, taken
+// when no other edge of the question matches.
+//
+// Sibling edges marked as variants, as {"variant": "B", "weight": 30},
+// make their source an experiment: instead of asking, the flow splits
+// runs between the variants by weight, deterministically per
+// Options.Seed.
+package formflow
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// Status says where a simulation stopped.
+type Status string
+
+const (
+	// Complete means the flow reached a node without outgoing edges.
+	Complete Status = "complete"
+	// Pending means the flow reached a question no answer was given for.
+	Pending Status = "pending"
+	// NoMatch means an answer matched none of its question's edges.
+	NoMatch Status = "no_match"
+)
+
+// Simulation is the outcome of running a flow on a set of answers.
+type Simulation struct {
+	Status Status `json:"status"`
+	// Path lists the IDs of the nodes visited, from the start to the node
+	// the flow stopped at.
+	Path []string `json:"path"`
+	// Edges lists the IDs of the edges taken.
+	Edges []string `json:"edges"`
+	// Terminals lists the nodes without outgoing edges that can still be
+	// reached from where the flow stopped, in node order.
+	Terminals []string `json:"terminals"`
+	// Unanswered lists the questions that can still be reached from where
+	// the flow stopped, itself included, and have no answer, in node
+	// order.
+	Unanswered []string `json:"unanswered"`
+	// Invalid lists the answers the question the flow stopped at accepts,
+	// when Status is NoMatch.
+	Invalid *AnswerError `json:"invalid,omitempty"`
+	// Variants lists the variants given at the experiments along Path.
+	Variants []Assignment `json:"variants"`
+}
+
+// Simulate runs d from start on answers, which map question node IDs to
+// their answers, without storing anything. At each node it takes the first
+// edge, in order_index order, whose answer matches the node's answer, or
+// else its first default edge. A node whose only outgoing edge is a
+// default edge, such as an information screen, is passed without an
+// answer, and so is an experiment, where o.Seed picks the variant. An
+// empty start means d's only root. ErrNodeNotFound is returned if start is
+// not in d, and ErrMultipleRoots if start is empty and d does not have
+// exactly one root.
+func Simulate(d *dag.DAG, start string, answers map[string]json.RawMessage, opts ...Options) (*Simulation, error) {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	f := newFlow(d, o)
+	if start == "" {
+		var roots []string
+		for _, n := range d.Nodes {
+			if !f.hasParent[n.ID] {
+				roots = append(roots, n.ID)
+			}
+		}
+		if len(roots) != 1 {
+			return nil, fmt.Errorf("%w: found %d roots, give a start node", dag.ErrMultipleRoots, len(roots))
+		}
+		start = roots[0]
+	}
+	if !f.nodes[start] {
+		return nil, fmt.Errorf("%w: %s", dag.ErrNodeNotFound, start)
+	}
+
+	s := &Simulation{Path: []string{start}, Edges: []string{}, Variants: []Assignment{}}
+	for id := start; ; {
+		if len(f.out[id]) == 0 {
+			s.Status = Complete
+			break
+		}
+		answer, ok := answers[id]
+		if !ok && !f.passThrough(id) {
+			s.Status = Pending
+			break
+		}
+		e, err := f.choose(id, answer)
+		if err != nil {
+			return nil, err
+		}
+		if e == nil {
+			s.Status = NoMatch
+			s.Invalid = f.answerError(id, answer)
+			break
+		}
+		if len(s.Path) > len(d.Nodes) {
+			return nil, dag.ErrCycleDetected
+		}
+		if a, ok := f.assign(id, e); ok {
+			s.Variants = append(s.Variants, a)
+		}
+		id = e.ToNodeID
+		s.Path = append(s.Path, id)
+		s.Edges = append(s.Edges, e.ID)
+	}
+
+	stop := s.Path[len(s.Path)-1]
+	reach := f.reachable(stop)
+	s.Terminals, s.Unanswered = []string{}, []string{}
+	for _, n := range d.Nodes {
+		if !reach[n.ID] {
+			continue
+		}
+		switch _, answered := answers[n.ID]; {
+		case len(f.out[n.ID]) == 0:
+			s.Terminals = append(s.Terminals, n.ID)
+		case !answered && !f.passThrough(n.ID):
+			s.Unanswered = append(s.Unanswered, n.ID)
+		}
+	}
+	return s, nil
+}
+
+// flow indexes a DAG's edges by source, in order_index order.
+type flow struct {
+	nodes     map[string]bool
+	hasParent map[string]bool
+	out       map[string][]dag.Edge
+	opts      Options
+}
+
+func newFlow(d *dag.DAG, o Options) *flow {
+	f := &flow{
+		opts:      o,
+		nodes:     make(map[string]bool, len(d.Nodes)),
+		hasParent: make(map[string]bool, len(d.Edges)),
+		out:       make(map[string][]dag.Edge),
+	}
+	for _, n := range d.Nodes {
+		f.nodes[n.ID] = true
+	}
+	for _, e := range d.Edges {
+		if f.nodes[e.FromNodeID] && f.nodes[e.ToNodeID] {
+			f.hasParent[e.ToNodeID] = true
+			f.out[e.FromNodeID] = append(f.out[e.FromNodeID], e)
+		}
+	}
+	for _, es := range f.out {
+		slices.SortStableFunc(es, func(a, b dag.Edge) int { return cmp.Compare(a.OrderIndex, b.OrderIndex) })
+	}
+	return f
+}
+
+// passThrough reports whether id is passed without an answer: its only
+// outgoing edge is a default edge, or it is an experiment.
+func (f *flow) passThrough(id string) bool {
+	if f.experiment(id) {
+		return true
+	}
+	es := f.out[id]
+	if len(es) != 1 {
+		return false
+	}
+	_, conditional := edgeAnswer(es[0])
+	return !conditional
+}
+
+// choose returns the edge out of id that answer leads along, or nil. At an
+// experiment it returns the variant f's seed picks.
+func (f *flow) choose(id string, answer json.RawMessage) (*dag.Edge, error) {
+	if e := f.pick(id); e != nil {
+		return e, nil
+	}
+	var fallback *dag.Edge
+	for i, e := range f.out[id] {
+		want, conditional := edgeAnswer(e)
+		if !conditional {
+			if fallback == nil {
+				fallback = &f.out[id][i]
+			}
+			continue
+		}
+		match, err := f.opts.matcher(edgeMatch(e))
+		if err != nil {
+			return nil, fmt.Errorf("%w on edge %s", err, e.ID)
+		}
+		if answer != nil && match(want, answer) {
+			return &f.out[id][i], nil
+		}
+	}
+	return fallback, nil
+}
+
+// reachable returns the nodes reachable from id, itself included.
+func (f *flow) reachable(id string) map[string]bool {
+	seen := map[string]bool{id: true}
+	stack := []string{id}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, e := range f.out[id] {
+			if !seen[e.ToNodeID] {
+				seen[e.ToNodeID] = true
+				stack = append(stack, e.ToNodeID)
+			}
+		}
+	}
+	return seen
+}
+
+// edgeAnswer returns the "answer" of e's data; ok is false if it has none.
+func edgeAnswer(e dag.Edge) (answer json.RawMessage, ok bool) {
+	var data map[string]json.RawMessage
+	if json.Unmarshal(e.Data, &data) != nil {
+		return nil, false
+	}
+	answer, ok = data["answer"]
+	return answer, ok
+}
+
+// edgeMatch returns the "match" of e's data, or "" if it has none.
+func edgeMatch(e dag.Edge) string {
+	var data struct {
+		Match string `json:"match"`
+	}
+	json.Unmarshal(e.Data, &data)
+	return data.Match
+}
+
+// sameJSON reports whether a and b hold equal JSON values.
+func sameJSON(a, b json.RawMessage) bool {
+	var x, y any
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,122 @@
+package formflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// ErrNoMatch is wrapped by an AnswerError, returned when an answer
+// matches none of the question's edges and it has no default edge.
+var ErrNoMatch = errors.New("formflow: no edge matches the answer")
+
+// ErrUnknownMatcher is returned for an edge whose "match" names a matcher
+// that isn't built in or given in Options.
+var ErrUnknownMatcher = errors.New("formflow: unknown matcher")
+
+// A Matcher reports whether answer, given to a question, leads along an
+// edge whose "answer" is want.
+type Matcher func(want, answer json.RawMessage) bool
+
+// Matchers are the built-in matchers, by the name an edge gives in its
+// "match" key. An edge without "match" uses "equal".
+var Matchers = map[string]Matcher{
+	// equal matches an answer equal to want as JSON.
+	"equal": sameJSON,
+	// one_of matches an answer equal to any element of the array want.
+	"one_of": oneOf,
+	// contains matches an array answer, as from a multi-select question,
+	// with an element equal to want.
+	"contains": func(want, answer json.RawMessage) bool { return oneOf(answer, want) },
+	// range matches a number answer within {"min": x, "max": y}, both
+	// inclusive and either left out for no bound.
+	"range": inRange,
+}
+
+// Options configures how answers are matched against edges and how
+// experiments assign variants.
+type Options struct {
+	// Matchers adds matchers by name, or replaces built-in ones.
+	Matchers map[string]Matcher
+	// Seed identifies the run, such as its ID, so that it is always given
+	// the same variant at an experiment.
+	Seed string
+	// OnVariant, if set, is called with each variant a run is given, for
+	// analytics.
+	OnVariant func(Assignment)
+}
+
+func (o Options) matcher(name string) (Matcher, error) {
+	if name == "" {
+		name = "equal"
+	}
+	if m, ok := o.Matchers[name]; ok {
+		return m, nil
+	}
+	if m, ok := Matchers[name]; ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownMatcher, name)
+}
+
+// Next returns the node that answer to currentNodeID leads to: the target
+// of the first edge, in order_index order, whose answer matches, or else
+// of the question's first default edge. A nil answer takes the default
+// edge. At an experiment the answer is ignored and the variant edge picked
+// by o.Seed is taken. Next returns nil and no error if currentNodeID has no
+// outgoing edges, so the form is complete. ErrNodeNotFound is returned if
+// currentNodeID is not in d, and an *AnswerError if no edge is taken.
+func Next(d *dag.DAG, currentNodeID string, answer json.RawMessage, opts ...Options) (*dag.Node, error) {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	f := newFlow(d, o)
+	if !f.nodes[currentNodeID] {
+		return nil, fmt.Errorf("%w: %s", dag.ErrNodeNotFound, currentNodeID)
+	}
+	if len(f.out[currentNodeID]) == 0 {
+		return nil, nil
+	}
+	e, err := f.choose(currentNodeID, answer)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, f.answerError(currentNodeID, answer)
+	}
+	f.assign(currentNodeID, e)
+	for i := range d.Nodes {
+		if d.Nodes[i].ID == e.ToNodeID {
+			return &d.Nodes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", dag.ErrNodeNotFound, e.ToNodeID)
+}
+
+func oneOf(want, answer json.RawMessage) bool {
+	var options []json.RawMessage
+	if json.Unmarshal(want, &options) != nil {
+		return false
+	}
+	for _, w := range options {
+		if sameJSON(w, answer) {
+			return true
+		}
+	}
+	return false
+}
+
+func inRange(want, answer json.RawMessage) bool {
+	var bounds struct {
+		Min *float64 `json:"min"`
+		Max *float64 `json:"max"`
+	}
+	var n float64
+	if json.Unmarshal(want, &bounds) != nil || json.Unmarshal(answer, &n) != nil {
+		return false
+	}
+	return (bounds.Min == nil || n >= *bounds.Min) && (bounds.Max == nil || n <= *bounds.Max)
+}
@@ -0,0 +1,127 @@
+package formflow
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// Step is one edge of a decision path: a question and the answer that
+// leads on from it.
+type Step struct {
+	NodeID string `json:"node_id"`
+	EdgeID string `json:"edge_id"`
+	// Answer is the edge's answer, or nil for a default edge.
+	Answer json.RawMessage `json:"answer,omitempty"`
+	// Variant is the edge's variant, if its source is an experiment.
+	Variant string `json:"variant,omitempty"`
+}
+
+// Decision is one row of a decision table: a path from a root to a node
+// without outgoing edges.
+type Decision struct {
+	Steps []Step `json:"steps"`
+	// Outcome is the ID of the node the path ends at.
+	Outcome string `json:"outcome"`
+}
+
+// DecisionTable lists every path from a root of d to a node without
+// outgoing edges, with the answers along it, so every possible outcome of
+// a form can be reviewed. Roots are taken in node order and edges in
+// order_index order. It stops after maxRows rows (<= 0 for no limit) and
+// reports whether it did. The number of paths can grow exponentially with
+// the width of d.
+func DecisionTable(d *dag.DAG, maxRows int) (rows []Decision, truncated bool) {
+	f := newFlow(d, Options{})
+	rows = []Decision{}
+	var steps []Step
+	onPath := make(map[string]bool)
+	var walk func(id string) bool
+	walk = func(id string) bool {
+		if len(f.out[id]) == 0 {
+			if maxRows > 0 && len(rows) == maxRows {
+				truncated = true
+				return false
+			}
+			rows = append(rows, Decision{Steps: append([]Step{}, steps...), Outcome: id})
+			return true
+		}
+		onPath[id] = true
+		defer delete(onPath, id)
+		for _, e := range f.out[id] {
+			if onPath[e.ToNodeID] {
+				continue
+			}
+			answer, _ := edgeAnswer(e)
+			variant, _, _ := edgeVariant(e)
+			steps = append(steps, Step{NodeID: id, EdgeID: e.ID, Answer: answer, Variant: variant})
+			ok := walk(e.ToNodeID)
+			steps = steps[:len(steps)-1]
+			if !ok {
+				return false
+			}
+		}
+		return true
+	}
+	for _, n := range d.Nodes {
+		if !f.hasParent[n.ID] && !walk(n.ID) {
+			break
+		}
+	}
+	return rows, truncated
+}
+
+// WriteDecisionTable writes rows as CSV for a spreadsheet: a row number,
+// the outcome, the number of steps, then one column per step reading
+// "question: answer", with (default) for a default edge and "variant B"
+// for a variant edge. Rows with fewer steps leave the last columns empty.
+func WriteDecisionTable(w io.Writer, rows []Decision) error {
+	width := 0
+	for _, r := range rows {
+		width = max(width, len(r.Steps))
+	}
+	cw := csv.NewWriter(w)
+	header := []string{"row", "outcome", "steps"}
+	for i := range width {
+		header = append(header, "step_"+strconv.Itoa(i+1))
+	}
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("formflow: write decision table: %w", err)
+	}
+	for i, r := range rows {
+		rec := make([]string, len(header))
+		rec[0], rec[1], rec[2] = strconv.Itoa(i+1), r.Outcome, strconv.Itoa(len(r.Steps))
+		for j, s := range r.Steps {
+			cell := answerText(s.Answer)
+			if s.Variant != "" {
+				cell = "variant " + s.Variant
+			}
+			rec[3+j] = s.NodeID + ": " + cell
+		}
+		if err := cw.Write(rec); err != nil {
+			return fmt.Errorf("formflow: write decision table: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("formflow: write decision table: %w", err)
+	}
+	return nil
+}
+
+// answerText renders an answer for a CSV cell: strings without quotes,
+// other values as JSON.
+func answerText(answer json.RawMessage) string {
+	if answer == nil {
+		return "(default)"
+	}
+	var s string
+	if json.Unmarshal(answer, &s) == nil {
+		return s
+	}
+	return string(answer)
+}
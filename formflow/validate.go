@@ -0,0 +1,97 @@
+package formflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// Choice is an answer a question accepts: the "answer" of one of its
+// conditional edges and the matcher it is compared with.
+type Choice struct {
+	EdgeID string          `json:"edge_id"`
+	Answer json.RawMessage `json:"answer"`
+	Match  string          `json:"match"`
+}
+
+// AnswerError is returned when an answer leads along none of a question's
+// edges. It lists the answers the question accepts, and errors.Is(err,
+// ErrNoMatch) holds for it.
+type AnswerError struct {
+	NodeID  string          `json:"node_id"`
+	Answer  json.RawMessage `json:"answer"`
+	Choices []Choice        `json:"choices"`
+}
+
+func (e *AnswerError) Error() string {
+	accepts := make([]string, len(e.Choices))
+	for i, c := range e.Choices {
+		accepts[i] = string(c.Answer)
+		if c.Match != "equal" {
+			accepts[i] = c.Match + " " + accepts[i]
+		}
+	}
+	return fmt.Sprintf("%v: question %s accepts %s", ErrNoMatch, e.NodeID, strings.Join(accepts, ", "))
+}
+
+func (e *AnswerError) Unwrap() error { return ErrNoMatch }
+
+// Choices returns the answers nodeID accepts, one per conditional edge in
+// order_index order. Default edges are left out: a question with one
+// accepts any answer. ErrNodeNotFound is returned if nodeID is not in d.
+func Choices(d *dag.DAG, nodeID string) ([]Choice, error) {
+	f := newFlow(d, Options{})
+	if !f.nodes[nodeID] {
+		return nil, fmt.Errorf("%w: %s", dag.ErrNodeNotFound, nodeID)
+	}
+	return f.choices(nodeID), nil
+}
+
+// ValidateAnswer checks that answer to nodeID leads along one of its
+// edges, as Next would take it, and returns an *AnswerError listing the
+// accepted answers if it does not. An answer to a node without outgoing
+// edges, or to a question with a default edge, is always valid.
+// ErrNodeNotFound is returned if nodeID is not in d.
+func ValidateAnswer(d *dag.DAG, nodeID string, answer json.RawMessage, opts ...Options) error {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	f := newFlow(d, o)
+	if !f.nodes[nodeID] {
+		return fmt.Errorf("%w: %s", dag.ErrNodeNotFound, nodeID)
+	}
+	if len(f.out[nodeID]) == 0 {
+		return nil
+	}
+	e, err := f.choose(nodeID, answer)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return f.answerError(nodeID, answer)
+	}
+	return nil
+}
+
+func (f *flow) choices(id string) []Choice {
+	choices := []Choice{}
+	for _, e := range f.out[id] {
+		want, conditional := edgeAnswer(e)
+		if !conditional {
+			continue
+		}
+		match := edgeMatch(e)
+		if match == "" {
+			match = "equal"
+		}
+		choices = append(choices, Choice{EdgeID: e.ID, Answer: want, Match: match})
+	}
+	return choices
+}
+
+func (f *flow) answerError(id string, answer json.RawMessage) *AnswerError {
+	return &AnswerError{NodeID: id, Answer: answer, Choices: f.choices(id)}
+}
@@ -0,0 +1,97 @@
+package formflow
+
+import (
+	"encoding/json"
+	"hash/fnv"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// Assignment records the variant a run was given at an experiment: a node
+// whose outgoing edges are variants, as {"variant": "B", "weight": 30}.
+type Assignment struct {
+	NodeID  string `json:"node_id"`
+	EdgeID  string `json:"edge_id"`
+	Variant string `json:"variant"`
+}
+
+// experiment reports whether any edge out of id is a variant edge.
+func (f *flow) experiment(id string) bool {
+	for _, e := range f.out[id] {
+		if _, _, ok := edgeVariant(e); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// pick returns the variant edge out of id that f's seed selects: each
+// variant gets a share of seeds in proportion to its weight, and the same
+// seed and node always give the same share. If every weight is zero the
+// first variant is taken. pick returns nil if id has no variant edges.
+func (f *flow) pick(id string) *dag.Edge {
+	var edges []*dag.Edge
+	var weights []float64
+	total := 0.0
+	for i, e := range f.out[id] {
+		if _, w, ok := edgeVariant(e); ok {
+			edges = append(edges, &f.out[id][i])
+			weights = append(weights, w)
+			total += w
+		}
+	}
+	if len(edges) == 0 {
+		return nil
+	}
+	if total == 0 {
+		return edges[0]
+	}
+	h := fnv.New64a()
+	h.Write([]byte(f.opts.Seed))
+	h.Write([]byte{0})
+	h.Write([]byte(id))
+	x := float64(h.Sum64()>>11) / (1 << 53) * total
+	for i, w := range weights {
+		if x < w {
+			return edges[i]
+		}
+		x -= w
+	}
+	// Rounding can leave x at the very top of the range.
+	for i := len(edges) - 1; ; i-- {
+		if weights[i] > 0 {
+			return edges[i]
+		}
+	}
+}
+
+// assign returns the Assignment for taking e out of id, and reports it to
+// OnVariant, if e is a variant edge.
+func (f *flow) assign(id string, e *dag.Edge) (Assignment, bool) {
+	name, _, ok := edgeVariant(*e)
+	if !ok {
+		return Assignment{}, false
+	}
+	a := Assignment{NodeID: id, EdgeID: e.ID, Variant: name}
+	if f.opts.OnVariant != nil {
+		f.opts.OnVariant(a)
+	}
+	return a, true
+}
+
+// edgeVariant returns the "variant" and "weight" of e's data; ok is false
+// if it has no variant. A missing weight is 1, and a negative one 0.
+func edgeVariant(e dag.Edge) (name string, weight float64, ok bool) {
+	var data struct {
+		Variant *string  `json:"variant"`
+		Weight  *float64 `json:"weight"`
+	}
+	if json.Unmarshal(e.Data, &data) != nil || data.Variant == nil {
+		return "", 0, false
+	}
+	weight = 1
+	if data.Weight != nil {
+		weight = max(*data.Weight, 0)
+	}
+	return *data.Variant, weight, true
+}
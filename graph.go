@@ -0,0 +1,471 @@
+package dag
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Direction says which edges a traversal follows from a node.
+type Direction string
+
+const (
+	// Out follows edges from their source to their target.
+	Out Direction = "out"
+	// In follows edges from their target back to their source.
+	In Direction = "in"
+	// Both follows edges either way.
+	Both Direction = "both"
+)
+
+// Valid reports whether d is Out, In or Both.
+func (d Direction) Valid() bool {
+	return d == Out || d == In || d == Both
+}
+
+// AllPaths returns every distinct path from from to to, each as the edges
+// it follows in order. Parallel edges make distinct paths. Paths come out
+// depth-first, following each node's edges in the order of d.Edges, and
+// stop after maxPaths; a result of exactly maxPaths paths may be
+// incomplete. maxPaths <= 0 means no limit. from == to gives one empty
+// path; an unknown node or no path at all gives nil.
+func AllPaths(d *DAG, from, to string, maxPaths int) [][]Edge {
+	known := make(map[string]bool, len(d.Nodes))
+	for _, n := range d.Nodes {
+		known[n.ID] = true
+	}
+	if !known[from] || !known[to] {
+		return nil
+	}
+
+	out := make(map[string][]Edge)
+	in := make(map[string][]Edge)
+	for _, e := range d.Edges {
+		out[e.FromNodeID] = append(out[e.FromNodeID], e)
+		in[e.ToNodeID] = append(in[e.ToNodeID], e)
+	}
+
+	// Only nodes that can reach to are worth walking into.
+	reaches := map[string]bool{to: true}
+	queue := []string{to}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, e := range in[id] {
+			if !reaches[e.FromNodeID] {
+				reaches[e.FromNodeID] = true
+				queue = append(queue, e.FromNodeID)
+			}
+		}
+	}
+	if !reaches[from] {
+		return nil
+	}
+
+	var paths [][]Edge
+	var path []Edge
+	onPath := map[string]bool{}
+	var walk func(id string) bool
+	walk = func(id string) bool {
+		if id == to {
+			paths = append(paths, append([]Edge{}, path...))
+			return maxPaths <= 0 || len(paths) < maxPaths
+		}
+		// onPath guards against cycles in a DAG built by hand.
+		onPath[id] = true
+		defer delete(onPath, id)
+		for _, e := range out[id] {
+			if !reaches[e.ToNodeID] || onPath[e.ToNodeID] {
+				continue
+			}
+			path = append(path, e)
+			more := walk(e.ToNodeID)
+			path = path[:len(path)-1]
+			if !more {
+				return false
+			}
+		}
+		return true
+	}
+	walk(from)
+	return paths
+}
+
+// LCA returns the lowest common ancestors of a and b: the nodes that can
+// reach both (a node counts as its own ancestor) and have no child that
+// can. A DAG may have several, e.g. where two branches of a questionnaire
+// both merge before a and b; they come out in the order of d.Nodes. If a
+// is an ancestor of b the result is just a. Nodes with no common ancestor
+// give an empty result; ErrNodeNotFound is returned if either is not in d.
+func LCA(d *DAG, a, b string) ([]Node, error) {
+	known := make(map[string]bool, len(d.Nodes))
+	for _, n := range d.Nodes {
+		known[n.ID] = true
+	}
+	for _, id := range []string{a, b} {
+		if !known[id] {
+			return nil, fmt.Errorf("%w: %s", ErrNodeNotFound, id)
+		}
+	}
+
+	in := make(map[string][]string)
+	for _, e := range d.Edges {
+		in[e.ToNodeID] = append(in[e.ToNodeID], e.FromNodeID)
+	}
+	ancestors := func(id string) map[string]bool {
+		seen := map[string]bool{id: true}
+		queue := []string{id}
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			for _, p := range in[cur] {
+				if !seen[p] {
+					seen[p] = true
+					queue = append(queue, p)
+				}
+			}
+		}
+		return seen
+	}
+	ofA, ofB := ancestors(a), ancestors(b)
+	common := make(map[string]bool)
+	for id := range ofA {
+		if ofB[id] {
+			common[id] = true
+		}
+	}
+
+	// Any common ancestor below c has a parent on the way down from c
+	// that is itself common, so c is lowest iff no child of c is common.
+	notLowest := make(map[string]bool)
+	for _, e := range d.Edges {
+		if common[e.FromNodeID] && common[e.ToNodeID] {
+			notLowest[e.FromNodeID] = true
+		}
+	}
+	out := []Node{}
+	for _, n := range d.Nodes {
+		if common[n.ID] && !notLowest[n.ID] {
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
+
+// Reverse returns a copy of d with every edge flipped, so that following
+// the edges out of a node leads to the nodes that depend on it. Node and
+// edge IDs and data are kept. Each node's new outgoing edges are numbered
+// in order_index in the order they appear in d.Edges. The copy has no
+// hash.
+func Reverse(d *DAG) *DAG {
+	r := *d
+	r.Hash = ""
+	r.Tags = slices.Clone(d.Tags)
+	r.Nodes = slices.Clone(d.Nodes)
+	r.Edges = make([]Edge, len(d.Edges))
+	next := make(map[string]int)
+	for i, e := range d.Edges {
+		e.FromNodeID, e.ToNodeID = e.ToNodeID, e.FromNodeID
+		e.FromNodeRef, e.ToNodeRef = e.ToNodeRef, e.FromNodeRef
+		e.OrderIndex = next[e.FromNodeID]
+		next[e.FromNodeID]++
+		r.Edges[i] = e
+	}
+	return &r
+}
+
+// PruneOptions changes what PruneOrphans treats as an orphan.
+type PruneOptions struct {
+	// Roots are the entry nodes of the flow; every node they cannot reach
+	// is an orphan, including disconnected fragments. Empty means every
+	// node with outgoing edges and no incoming ones, so only nodes without
+	// any edges are orphans.
+	Roots []string
+	// DryRun reports the orphans without deleting them.
+	DryRun bool
+}
+
+// Orphans returns the IDs of d's nodes that no root reaches, in the order
+// of d.Nodes; see PruneOptions for the default roots. A DAG without edges
+// has no orphans unless roots are given. ErrNodeNotFound is returned for
+// a root not in d.
+func Orphans(d *DAG, roots ...string) ([]string, error) {
+	known := make(map[string]bool, len(d.Nodes))
+	for _, n := range d.Nodes {
+		known[n.ID] = true
+	}
+	for _, id := range roots {
+		if !known[id] {
+			return nil, fmt.Errorf("%w: %s", ErrNodeNotFound, id)
+		}
+	}
+
+	out := make(map[string][]string)
+	hasIn := make(map[string]bool)
+	for _, e := range d.Edges {
+		out[e.FromNodeID] = append(out[e.FromNodeID], e.ToNodeID)
+		hasIn[e.ToNodeID] = true
+	}
+	if len(roots) == 0 {
+		if len(d.Edges) == 0 {
+			return []string{}, nil
+		}
+		for _, n := range d.Nodes {
+			if !hasIn[n.ID] && len(out[n.ID]) > 0 {
+				roots = append(roots, n.ID)
+			}
+		}
+	}
+
+	reached := make(map[string]bool, len(d.Nodes))
+	queue := slices.Clone(roots)
+	for _, id := range roots {
+		reached[id] = true
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range out[id] {
+			if !reached[next] {
+				reached[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	orphans := []string{}
+	for _, n := range d.Nodes {
+		if !reached[n.ID] {
+			orphans = append(orphans, n.ID)
+		}
+	}
+	return orphans, nil
+}
+
+// DeleteSubgraphOptions changes what DeleteSubgraph does.
+type DeleteSubgraphOptions struct {
+	// Rewire adds an edge from each node with an edge into the root to
+	// each surviving node the subgraph had an edge to, so the flow skips
+	// the deleted part. A new edge carries the data of the edge into the
+	// root; pairs that are already joined are left alone.
+	Rewire bool
+	// DryRun reports what would change without writing anything.
+	DryRun bool
+}
+
+// SubgraphDeletion is what DeleteSubgraph removes and adds.
+type SubgraphDeletion struct {
+	// NodeIDs are the root and every node reachable only through it, in
+	// the order of the DAG's nodes.
+	NodeIDs []string `json:"node_ids"`
+	// CutEdges are the deleted edges with one end outside NodeIDs: the
+	// edges into the root, and those to nodes that are also reached
+	// some other way.
+	CutEdges []Edge `json:"cut_edges"`
+	// AddedEdges are the edges Rewire adds.
+	AddedEdges []Edge `json:"added_edges"`
+}
+
+// PlanSubgraphDeletion works out what DeleteSubgraph does to d: a node is
+// deleted with the root if every edge into it comes from a deleted node.
+// ErrNodeNotFound is returned if rootID is not in d.
+func PlanSubgraphDeletion(d *DAG, rootID string, rewire bool) (*SubgraphDeletion, error) {
+	inDegree := make(map[string]int, len(d.Nodes))
+	out := make(map[string][]Edge)
+	found := false
+	for _, n := range d.Nodes {
+		found = found || n.ID == rootID
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrNodeNotFound, rootID)
+	}
+	for _, e := range d.Edges {
+		inDegree[e.ToNodeID]++
+		out[e.FromNodeID] = append(out[e.FromNodeID], e)
+	}
+
+	deleted := map[string]bool{rootID: true}
+	queue := []string{rootID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, e := range out[id] {
+			inDegree[e.ToNodeID]--
+			if inDegree[e.ToNodeID] == 0 && !deleted[e.ToNodeID] {
+				deleted[e.ToNodeID] = true
+				queue = append(queue, e.ToNodeID)
+			}
+		}
+	}
+
+	res := &SubgraphDeletion{NodeIDs: []string{}, CutEdges: []Edge{}, AddedEdges: []Edge{}}
+	for _, n := range d.Nodes {
+		if deleted[n.ID] {
+			res.NodeIDs = append(res.NodeIDs, n.ID)
+		}
+	}
+	var into []Edge
+	var exits []string
+	joined := make(map[[2]string]bool)
+	for _, e := range d.Edges {
+		joined[[2]string{e.FromNodeID, e.ToNodeID}] = true
+		switch from, to := deleted[e.FromNodeID], deleted[e.ToNodeID]; {
+		case from && !to:
+			res.CutEdges = append(res.CutEdges, e)
+			if !slices.Contains(exits, e.ToNodeID) {
+				exits = append(exits, e.ToNodeID)
+			}
+		case !from && to:
+			res.CutEdges = append(res.CutEdges, e)
+			into = append(into, e)
+		}
+	}
+	if rewire {
+		for _, e := range into {
+			for _, to := range exits {
+				pair := [2]string{e.FromNodeID, to}
+				if joined[pair] {
+					continue
+				}
+				joined[pair] = true
+				res.AddedEdges = append(res.AddedEdges, Edge{FromNodeID: e.FromNodeID, ToNodeID: to, Data: e.Data})
+			}
+		}
+	}
+	return res, nil
+}
+
+// PlanReplaceSubgraph returns the change set that swaps the region
+// DeleteSubgraph would delete from rootID for the nodes and edges of
+// replacement. Every replacement node needs an ID; replacement edges may
+// name their ends by ID or, for replacement nodes, by Ref, and may join a
+// replacement node to a node outside the region. The boundary is rewired
+// as follows:
+//
+//   - Edges into the region, which all point at rootID, point at the
+//     replacement's entry instead: its one node without incoming edges
+//     from other replacement nodes. They keep their IDs and data.
+//   - Each node outside the region that the region had edges to gets an
+//     edge from every replacement node without outgoing edges to other
+//     replacement nodes, carrying the data of a cut edge to it, unless a
+//     replacement edge already leads to it.
+//
+// Pairs of nodes already joined are not joined again. Replacement nodes
+// may reuse the IDs of nodes in the region. The change set is not checked
+// for cycles. ErrNodeNotFound is returned if rootID is not in d or an
+// edge names a node that is neither in replacement nor outside the
+// region, ErrMultipleRoots if the region has edges into it and the
+// replacement has no single entry, and ErrInvalidSubgraph if a node has
+// no ID or one already used outside the region, an edge uses an unknown
+// ref, or an edge has no end in the replacement.
+func PlanReplaceSubgraph(d *DAG, rootID string, replacement *DAG) (*ChangeSet, error) {
+	del, err := PlanSubgraphDeletion(d, rootID, false)
+	if err != nil {
+		return nil, err
+	}
+	region := make(map[string]bool, len(del.NodeIDs))
+	for _, id := range del.NodeIDs {
+		region[id] = true
+	}
+	outside := make(map[string]bool, len(d.Nodes))
+	for _, n := range d.Nodes {
+		if !region[n.ID] {
+			outside[n.ID] = true
+		}
+	}
+
+	cs := &ChangeSet{DeleteNodes: del.NodeIDs, AddNodes: []Node{}}
+	frag := make(map[string]bool, len(replacement.Nodes))
+	refs := make(map[string]string)
+	for _, n := range replacement.Nodes {
+		switch {
+		case n.ID == "":
+			return nil, fmt.Errorf("%w: node with ref %q has no id", ErrInvalidSubgraph, n.Ref)
+		case frag[n.ID] || outside[n.ID]:
+			return nil, fmt.Errorf("%w: node %s is already in the dag", ErrInvalidSubgraph, n.ID)
+		}
+		frag[n.ID] = true
+		if n.Ref != "" {
+			refs[n.Ref] = n.ID
+		}
+		n.Ref = ""
+		cs.AddNodes = append(cs.AddNodes, n)
+	}
+	end := func(id, ref string) (string, error) {
+		if ref != "" {
+			if id, ok := refs[ref]; ok {
+				return id, nil
+			}
+			return "", fmt.Errorf("%w: unknown node ref %q", ErrInvalidSubgraph, ref)
+		}
+		if !frag[id] && !outside[id] {
+			return "", fmt.Errorf("%w: %s", ErrNodeNotFound, id)
+		}
+		return id, nil
+	}
+
+	joined := make(map[[2]string]bool)
+	for _, e := range d.Edges {
+		if !region[e.FromNodeID] && !region[e.ToNodeID] {
+			joined[[2]string{e.FromNodeID, e.ToNodeID}] = true
+		}
+	}
+	add := func(e Edge) {
+		pair := [2]string{e.FromNodeID, e.ToNodeID}
+		if !joined[pair] {
+			joined[pair] = true
+			cs.AddEdges = append(cs.AddEdges, e)
+		}
+	}
+	// hasIn and hasOut only count edges from and to replacement nodes, so
+	// a replacement node wired to a node outside can still be the entry
+	// or a sink; hasIn of an outside node is set by any edge to it.
+	hasIn := make(map[string]bool)
+	hasOut := make(map[string]bool)
+	for _, e := range replacement.Edges {
+		if e.FromNodeID, err = end(e.FromNodeID, e.FromNodeRef); err != nil {
+			return nil, err
+		}
+		if e.ToNodeID, err = end(e.ToNodeID, e.ToNodeRef); err != nil {
+			return nil, err
+		}
+		if !frag[e.FromNodeID] && !frag[e.ToNodeID] {
+			return nil, fmt.Errorf("%w: edge %s -> %s has no end in the replacement", ErrInvalidSubgraph, e.FromNodeID, e.ToNodeID)
+		}
+		e.FromNodeRef, e.ToNodeRef = "", ""
+		if frag[e.FromNodeID] {
+			hasIn[e.ToNodeID] = true
+		}
+		if frag[e.ToNodeID] {
+			hasOut[e.FromNodeID] = true
+		}
+		add(e)
+	}
+
+	var entries, sinks []string
+	for _, n := range cs.AddNodes {
+		if !hasIn[n.ID] {
+			entries = append(entries, n.ID)
+		}
+		if !hasOut[n.ID] {
+			sinks = append(sinks, n.ID)
+		}
+	}
+	for _, e := range del.CutEdges {
+		cs.DeleteEdges = append(cs.DeleteEdges, e.ID)
+		if region[e.FromNodeID] {
+			continue
+		}
+		if len(entries) != 1 {
+			return nil, fmt.Errorf("%w: replacement must have one entry node, has %d", ErrMultipleRoots, len(entries))
+		}
+		add(Edge{ID: e.ID, FromNodeID: e.FromNodeID, ToNodeID: entries[0], Data: e.Data})
+	}
+	for _, e := range del.CutEdges {
+		if !region[e.FromNodeID] || hasIn[e.ToNodeID] {
+			continue
+		}
+		for _, s := range sinks {
+			add(Edge{FromNodeID: s, ToNodeID: e.ToNodeID, Data: e.Data})
+		}
+	}
+	return cs, nil
+}
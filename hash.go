@@ -0,0 +1,120 @@
+package dag
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"slices"
+)
+
+// Hash returns a hex SHA-256 of d's structure and data: every node's ID,
+// data and tags and every edge's ID, ends, data and order_index. It does
+// not depend on the order of d.Nodes, d.Edges or node tags, nor on key
+// order or whitespace in data, so two environments holding the same DAG
+// get the same hash. DAG metadata (name, tags, status, expiry, settings)
+// is not part of it.
+func Hash(d *DAG) string {
+	nodes := slices.Clone(d.Nodes)
+	slices.SortFunc(nodes, func(a, b Node) int {
+		// IDs only tie in a DAG that was never saved.
+		return cmp.Or(cmp.Compare(a.ID, b.ID), bytes.Compare(canonicalJSON(a.Data), canonicalJSON(b.Data)))
+	})
+	edges := slices.Clone(d.Edges)
+	slices.SortFunc(edges, func(a, b Edge) int {
+		return cmp.Or(cmp.Compare(a.ID, b.ID), cmp.Compare(a.FromNodeID, b.FromNodeID),
+			cmp.Compare(a.ToNodeID, b.ToNodeID), cmp.Compare(a.OrderIndex, b.OrderIndex),
+			bytes.Compare(canonicalJSON(a.Data), canonicalJSON(b.Data)))
+	})
+
+	h := NewHasher()
+	for _, n := range nodes {
+		h.Node(n)
+	}
+	for _, e := range edges {
+		h.Edge(e)
+	}
+	return h.Sum()
+}
+
+// Hasher computes Hash one node and edge at a time, for DAGs too large to
+// hold in memory. Pass every node in ID order, then every edge in ID
+// order, then call Sum once.
+type Hasher struct {
+	h     hash.Hash
+	edges bool
+	sep   string
+}
+
+// NewHasher returns a Hasher for an empty DAG.
+func NewHasher() *Hasher {
+	h := &Hasher{h: sha256.New()}
+	h.h.Write([]byte(`{"nodes":[`))
+	return h
+}
+
+// Node adds n.
+func (h *Hasher) Node(n Node) {
+	tags := slices.Clone(n.Tags)
+	slices.Sort(tags)
+	h.item(struct {
+		ID   string          `json:"id"`
+		Data json.RawMessage `json:"data"`
+		Tags []string        `json:"tags,omitempty"`
+	}{n.ID, canonicalJSON(n.Data), tags})
+}
+
+// Edge adds e. Add every node before the first edge: a node added later
+// lands among the edges and gives a different hash.
+func (h *Hasher) Edge(e Edge) {
+	h.startEdges()
+	h.item(struct {
+		ID         string          `json:"id"`
+		FromNodeID string          `json:"from"`
+		ToNodeID   string          `json:"to"`
+		Data       json.RawMessage `json:"data"`
+		OrderIndex int             `json:"order"`
+	}{e.ID, e.FromNodeID, e.ToNodeID, canonicalJSON(e.Data), e.OrderIndex})
+}
+
+// Sum returns the hash of everything added.
+func (h *Hasher) Sum() string {
+	h.startEdges()
+	h.h.Write([]byte(`]}`))
+	return hex.EncodeToString(h.h.Sum(nil))
+}
+
+func (h *Hasher) startEdges() {
+	if !h.edges {
+		h.edges = true
+		h.sep = ""
+		h.h.Write([]byte(`],"edges":[`))
+	}
+}
+
+func (h *Hasher) item(v any) {
+	// Every field is plain JSON, so encoding cannot fail.
+	b, _ := json.Marshal(v)
+	h.h.Write([]byte(h.sep))
+	h.h.Write(b)
+	h.sep = ","
+}
+
+// canonicalJSON re-encodes data with object keys sorted and no
+// insignificant whitespace. Numbers keep their literal form. Missing or
+// invalid data becomes null.
+func canonicalJSON(data json.RawMessage) json.RawMessage {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return json.RawMessage("null")
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return out
+}
@@ -0,0 +1,406 @@
+// Package jsonschema validates JSON documents against a practical subset of
+// JSON Schema (draft 2020-12): the keywords that describe the shape of form
+// data. Schemas using anything else, such as $ref or if/then/else, are
+// rejected by Compile rather than silently half-checked.
+//
+//	s, err := jsonschema.Compile([]byte(`{"type":"object","required":["question"]}`))
+//	errs := s.Validate(json.RawMessage(`{"text":"hi"}`))
+//	// errs[0] = {Path: ".question", Message: "is required"}
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Error is one violation found by Validate. Path locates the offending value
+// inside the document: "" is the root, ".a.b" an object member and "[2]" an
+// array element.
+type Error struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Schema is a compiled JSON Schema. The zero value accepts everything.
+type Schema struct {
+	reject bool // the "false" schema
+
+	types      []string
+	properties map[string]*Schema
+	required   []string
+	additional *Schema
+	items      *Schema
+	enum       []any
+	constVal   any
+	hasConst   bool
+	minLength  *int
+	maxLength  *int
+	pattern    *regexp.Regexp
+	minimum    *float64
+	maximum    *float64
+	exclMin    *float64
+	exclMax    *float64
+	minItems   *int
+	maxItems   *int
+	allOf      []*Schema
+	anyOf      []*Schema
+	oneOf      []*Schema
+	not        *Schema
+}
+
+// annotations are keywords that carry no validation rule.
+var annotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true, "description": true,
+	"default": true, "examples": true, "format": true, "deprecated": true,
+	"readOnly": true, "writeOnly": true,
+}
+
+// Compile parses a schema document. It returns an error for invalid JSON,
+// malformed keyword values and keywords outside the supported subset.
+func Compile(doc []byte) (*Schema, error) {
+	return compile(json.RawMessage(doc), "")
+}
+
+// MustCompile is Compile that panics on error, for schemas in source code.
+func MustCompile(doc string) *Schema {
+	s, err := Compile([]byte(doc))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func compile(raw json.RawMessage, at string) (*Schema, error) {
+	switch string(bytes.TrimSpace(raw)) {
+	case "true":
+		return &Schema{}, nil
+	case "false":
+		return &Schema{reject: true}, nil
+	}
+
+	var kw map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &kw); err != nil {
+		return nil, fmt.Errorf("jsonschema: %s: schema must be an object or boolean", where(at))
+	}
+
+	s := &Schema{}
+	keys := make([]string, 0, len(kw))
+	for k := range kw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := kw[k]
+		var err error
+		switch k {
+		case "type":
+			var one string
+			if json.Unmarshal(v, &one) == nil {
+				s.types = []string{one}
+			} else {
+				err = json.Unmarshal(v, &s.types)
+			}
+			for _, t := range s.types {
+				if !slices.Contains([]string{"null", "boolean", "object", "array", "number", "integer", "string"}, t) {
+					err = fmt.Errorf("unknown type %q", t)
+				}
+			}
+		case "properties":
+			var props map[string]json.RawMessage
+			if err = json.Unmarshal(v, &props); err == nil {
+				s.properties = make(map[string]*Schema, len(props))
+				for name, p := range props {
+					if s.properties[name], err = compile(p, at+"/properties/"+name); err != nil {
+						return nil, err
+					}
+				}
+			}
+		case "required":
+			err = json.Unmarshal(v, &s.required)
+		case "additionalProperties":
+			s.additional, err = compile(v, at+"/"+k)
+		case "items":
+			s.items, err = compile(v, at+"/"+k)
+		case "enum":
+			err = unmarshal(v, &s.enum)
+		case "const":
+			s.hasConst = true
+			err = unmarshal(v, &s.constVal)
+		case "minLength":
+			s.minLength, err = count(v)
+		case "maxLength":
+			s.maxLength, err = count(v)
+		case "minItems":
+			s.minItems, err = count(v)
+		case "maxItems":
+			s.maxItems, err = count(v)
+		case "pattern":
+			var p string
+			if err = json.Unmarshal(v, &p); err == nil {
+				s.pattern, err = regexp.Compile(p)
+			}
+		case "minimum":
+			s.minimum, err = number(v)
+		case "maximum":
+			s.maximum, err = number(v)
+		case "exclusiveMinimum":
+			s.exclMin, err = number(v)
+		case "exclusiveMaximum":
+			s.exclMax, err = number(v)
+		case "allOf", "anyOf", "oneOf":
+			var subs []json.RawMessage
+			if err = json.Unmarshal(v, &subs); err == nil {
+				list := make([]*Schema, len(subs))
+				for i, sub := range subs {
+					if list[i], err = compile(sub, at+"/"+k+"/"+strconv.Itoa(i)); err != nil {
+						return nil, err
+					}
+				}
+				switch k {
+				case "allOf":
+					s.allOf = list
+				case "anyOf":
+					s.anyOf = list
+				default:
+					s.oneOf = list
+				}
+			}
+		case "not":
+			s.not, err = compile(v, at+"/"+k)
+		default:
+			if !annotations[k] {
+				err = fmt.Errorf("unsupported keyword")
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jsonschema: %s/%s: %v", where(at), k, err)
+		}
+	}
+	return s, nil
+}
+
+func where(at string) string {
+	if at == "" {
+		return "#"
+	}
+	return "#" + at
+}
+
+// unmarshal decodes v the way Validate decodes documents, so enum and const
+// compare equal to the values they are checked against.
+func unmarshal(v json.RawMessage, dst any) error {
+	dec := json.NewDecoder(bytes.NewReader(v))
+	dec.UseNumber()
+	if err := dec.Decode(dst); err != nil {
+		return err
+	}
+	return nil
+}
+
+func count(v json.RawMessage) (*int, error) {
+	var n int
+	if err := json.Unmarshal(v, &n); err != nil || n < 0 {
+		return nil, fmt.Errorf("must be a non-negative integer")
+	}
+	return &n, nil
+}
+
+func number(v json.RawMessage) (*float64, error) {
+	var f float64
+	if err := json.Unmarshal(v, &f); err != nil {
+		return nil, fmt.Errorf("must be a number")
+	}
+	return &f, nil
+}
+
+// Validate checks a JSON document against s and returns every violation,
+// or nil if it is valid. A document that is not valid JSON yields one
+// error at the root.
+func (s *Schema) Validate(doc json.RawMessage) []Error {
+	var v any
+	if err := unmarshal(doc, &v); err != nil {
+		return []Error{{Path: "", Message: "is not valid JSON"}}
+	}
+	return s.validate(v, "")
+}
+
+func (s *Schema) validate(v any, path string) []Error {
+	if s == nil {
+		return nil
+	}
+	if s.reject {
+		return []Error{{path, "is not allowed"}}
+	}
+
+	var errs []Error
+	fail := func(format string, args ...any) {
+		errs = append(errs, Error{path, fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(t string) bool { return hasType(v, t) }) {
+		if len(s.types) == 1 {
+			fail("must be of type %s", s.types[0])
+		} else {
+			fail("must be one of the types %v", s.types)
+		}
+		return errs
+	}
+	if s.enum != nil && !slices.ContainsFunc(s.enum, func(e any) bool { return equal(e, v) }) {
+		fail("must be one of %s", list(s.enum))
+	}
+	if s.hasConst && !equal(s.constVal, v) {
+		fail("must be %s", list([]any{s.constVal}))
+	}
+
+	switch v := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.minLength != nil && n < *s.minLength {
+			fail("must be at least %d characters", *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			fail("must be at most %d characters", *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("must match %s", s.pattern)
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if s.minimum != nil && f < *s.minimum {
+			fail("must be >= %v", *s.minimum)
+		}
+		if s.maximum != nil && f > *s.maximum {
+			fail("must be <= %v", *s.maximum)
+		}
+		if s.exclMin != nil && f <= *s.exclMin {
+			fail("must be > %v", *s.exclMin)
+		}
+		if s.exclMax != nil && f >= *s.exclMax {
+			fail("must be < %v", *s.exclMax)
+		}
+	case []any:
+		if s.minItems != nil && len(v) < *s.minItems {
+			fail("must have at least %d items", *s.minItems)
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			fail("must have at most %d items", *s.maxItems)
+		}
+		if s.items != nil {
+			for i, item := range v {
+				errs = append(errs, s.items.validate(item, path+"["+strconv.Itoa(i)+"]")...)
+			}
+		}
+	case map[string]any:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				errs = append(errs, Error{path + "." + name, "is required"})
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if p, ok := s.properties[name]; ok {
+				errs = append(errs, p.validate(v[name], path+"."+name)...)
+			} else if s.additional != nil {
+				errs = append(errs, s.additional.validate(v[name], path+"."+name)...)
+			}
+		}
+	}
+
+	for _, sub := range s.allOf {
+		errs = append(errs, sub.validate(v, path)...)
+	}
+	if s.anyOf != nil && !slices.ContainsFunc(s.anyOf, func(sub *Schema) bool { return sub.validate(v, path) == nil }) {
+		fail("must match at least one of the anyOf schemas")
+	}
+	if s.oneOf != nil {
+		n := 0
+		for _, sub := range s.oneOf {
+			if sub.validate(v, path) == nil {
+				n++
+			}
+		}
+		if n != 1 {
+			fail("must match exactly one of the oneOf schemas, matched %d", n)
+		}
+	}
+	if s.not != nil && s.not.validate(v, path) == nil {
+		fail("must not match the not schema")
+	}
+	return errs
+}
+
+func hasType(v any, t string) bool {
+	switch v := v.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case []any:
+		return t == "array"
+	case map[string]any:
+		return t == "object"
+	case json.Number:
+		if t == "number" {
+			return true
+		}
+		f, err := v.Float64()
+		return t == "integer" && err == nil && f == math.Trunc(f)
+	}
+	return false
+}
+
+// equal compares decoded JSON values; numbers compare by value, so 1 and
+// 1.0 are equal.
+func equal(a, b any) bool {
+	an, aok := a.(json.Number)
+	bn, bok := b.(json.Number)
+	if aok && bok {
+		af, _ := an.Float64()
+		bf, _ := bn.Float64()
+		return af == bf
+	}
+	if aok != bok {
+		return false
+	}
+	switch a := a.(type) {
+	case []any:
+		b, ok := b.([]any)
+		return ok && slices.EqualFunc(a, b, equal)
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, av := range a {
+			if bv, ok := b[k]; !ok || !equal(av, bv) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// list renders values as compact JSON for error messages.
+func list(vs []any) string {
+	b, _ := json.Marshal(vs)
+	if len(vs) == 1 {
+		b = b[1 : len(b)-1]
+	}
+	return string(b)
+}
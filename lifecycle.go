@@ -0,0 +1,30 @@
+package dag
+
+// Status is where a DAG is in its release lifecycle:
+// draft → published → archived. Only drafts can be changed.
+type Status string
+
+const (
+	// StatusDraft is the status of every new DAG. Drafts are editable.
+	StatusDraft Status = "draft"
+	// StatusPublished DAGs are live and immutable; writes return ErrDAGFrozen.
+	StatusPublished Status = "published"
+	// StatusArchived DAGs are retired. They stay readable and immutable,
+	// and unlike published DAGs can be deleted.
+	StatusArchived Status = "archived"
+)
+
+// Frozen reports whether DAGs in this status reject writes.
+func (s Status) Frozen() bool {
+	return s == StatusPublished || s == StatusArchived
+}
+
+// DraftSuffix is appended to a live DAG's ID, and to the IDs of its nodes
+// and edges, to name its draft copy. PromoteDraft strips it again, so nodes
+// that existed before the draft keep their IDs.
+const DraftSuffix = "~draft"
+
+// DraftID returns the ID of the draft copy of dagID made by CreateDraft.
+func DraftID(dagID string) string {
+	return dagID + DraftSuffix
+}
@@ -0,0 +1,105 @@
+package dag
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+)
+
+// ListOptions narrows and pages ListNodesPage / ListEdgesPage.
+// The zero value lists everything ordered by created_at.
+type ListOptions struct {
+	// Limit caps the number of items returned. 0 means no limit.
+	Limit int
+	// Cursor continues a previous listing; pass the NextCursor of the last
+	// page. Cursors are opaque and only valid with the same Sort and Filter.
+	// They hold the position of the last row, not an offset, so rows
+	// written between pages don't shift the next one.
+	Cursor string
+	// Sort is a field name, optionally prefixed with "-" for descending:
+	// "created_at" (default), "updated_at", "id", "label" (the data's
+	// "label" key) or "data." followed by a dotted data path, such as
+	// "data.meta.rank". Data values sort as JSON: missing values first,
+	// then strings, numbers, booleans, arrays and objects.
+	Sort string
+	// Filter keeps only items whose data has each key equal to the value
+	// (compared as text against top-level keys).
+	Filter map[string]string
+	// Fields, if set, returns only these paths of each item's data; see
+	// Project. The projection is done in SQL where the store can.
+	Fields []string
+}
+
+// EdgeFilter selects edges for FindEdges. Every condition that is set must
+// hold; the zero value matches every edge.
+type EdgeFilter struct {
+	// Contains keeps edges whose data contains this JSON, as with JSONB @>:
+	// {"condition": {"answer": "q1"}} matches every edge whose
+	// condition.answer is "q1", whatever else its data holds.
+	Contains json.RawMessage
+	// Match keeps edges whose data satisfies this SQL/JSON path predicate,
+	// as with JSONB @@, e.g. `$.condition.key == "role"` or
+	// `exists($.rules[*] ? (@.ref == "q1"))`.
+	Match string
+	// FromNodeID and ToNodeID keep edges leaving or entering that node.
+	FromNodeID string
+	ToNodeID   string
+}
+
+// Page is one page of a listing. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// iteratePageSize is the page size of IterateNodes / IterateEdges when
+// ListOptions.Limit is unset.
+const iteratePageSize = 500
+
+// IterateNodes yields every node of dagID that opts selects, fetching
+// pages from s with ListNodesPage and following their cursors, so callers
+// can walk a DAG of any size without handling cursors. opts.Limit sets the
+// page size; opts.Cursor, if set, is where the walk starts. A failed page
+// is yielded once as the error and ends the walk.
+func IterateNodes(ctx context.Context, s Store, dagID string, opts ...ListOptions) iter.Seq2[Node, error] {
+	return iterate(ctx, opts, func(ctx context.Context, o ListOptions) (*Page[Node], error) {
+		return s.ListNodesPage(ctx, dagID, o)
+	})
+}
+
+// IterateEdges is IterateNodes for edges, using ListEdgesPage.
+func IterateEdges(ctx context.Context, s Store, dagID string, opts ...ListOptions) iter.Seq2[Edge, error] {
+	return iterate(ctx, opts, func(ctx context.Context, o ListOptions) (*Page[Edge], error) {
+		return s.ListEdgesPage(ctx, dagID, o)
+	})
+}
+
+func iterate[T any](ctx context.Context, opts []ListOptions, page func(context.Context, ListOptions) (*Page[T], error)) iter.Seq2[T, error] {
+	var o ListOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Limit <= 0 {
+		o.Limit = iteratePageSize
+	}
+	return func(yield func(T, error) bool) {
+		o := o
+		for {
+			p, err := page(ctx, o)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range p.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if p.NextCursor == "" {
+				return
+			}
+			o.Cursor = p.NextCursor
+		}
+	}
+}
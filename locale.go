@@ -0,0 +1,87 @@
+package dag
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// LocalesKey is the data key that holds localized variants of a node's or
+// edge's data, by locale:
+//
+//	{"question": "Role?", "_locales": {"fr": {"question": "Rôle ?"}}}
+//
+// The variants are stored and versioned with the data; reads resolve them
+// with GetDAGOptions.Locale.
+const LocalesKey = "_locales"
+
+// LocalizedPaths returns paths followed by the same paths inside every
+// variant under LocalesKey, so that "contact.email" also reaches
+// "_locales.*.contact.email". Redaction and encryption apply their paths
+// through it: a translation carries the same fields as the data it
+// translates. Paths that already start at LocalesKey or "*" are not
+// repeated.
+func LocalizedPaths(paths []string) []string {
+	out := slices.Clip(paths)
+	for _, p := range paths {
+		first, _, _ := strings.Cut(p, ".")
+		if first != LocalesKey && first != "*" {
+			out = append(out, LocalesKey+".*."+p)
+		}
+	}
+	return out
+}
+
+// Fallbacks expands a list of locales, most preferred first, into the
+// chain Localize tries: each locale is followed by its parents, so
+// ["fr-CA", "de"] becomes ["fr-CA", "fr", "de"]. Empty and repeated
+// locales are dropped.
+func Fallbacks(locales ...string) []string {
+	var chain []string
+	for _, l := range locales {
+		for l = strings.TrimSpace(l); l != ""; {
+			if !slices.Contains(chain, l) {
+				chain = append(chain, l)
+			}
+			i := strings.LastIndexByte(l, '-')
+			if i < 0 {
+				break
+			}
+			l = l[:i]
+		}
+	}
+	return chain
+}
+
+// Localize returns data with its variants for locales laid over it and
+// LocalesKey removed. Locales are tried in Fallbacks order and the data
+// itself comes last, so a variant only needs the keys it translates: each
+// one is merged in key by key at every level, as MergeDeep does, and a
+// more preferred locale wins where two have a key. Data that is not an
+// object, or has no LocalesKey, is returned as is.
+func Localize(data json.RawMessage, locales ...string) json.RawMessage {
+	var obj map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if dec.Decode(&obj) != nil || obj == nil {
+		return data
+	}
+	raw, ok := obj[LocalesKey]
+	if !ok {
+		return data
+	}
+	delete(obj, LocalesKey)
+	variants, _ := raw.(map[string]any)
+	chain := Fallbacks(locales...)
+	for i := len(chain) - 1; i >= 0; i-- {
+		if v, ok := variants[chain[i]].(map[string]any); ok {
+			obj = mergeObjects(v, obj, true)
+		}
+	}
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return data
+	}
+	return out
+}
@@ -0,0 +1,42 @@
+package dag
+
+import (
+	"fmt"
+	"time"
+)
+
+// Lock is an advisory edit lock on a DAG, or on one node of it, held by
+// an owner such as an editor session until it expires. Locks are
+// advisory: writes are not refused while one is held. Editors take a lock
+// before editing, renew it while they work and check Locks before opening
+// a DAG, so two people don't overwrite each other's changes.
+//
+// A lock on the whole DAG conflicts with every lock on it or its nodes
+// held by another owner; a node lock conflicts with another owner's lock
+// on the same node or on the whole DAG.
+type Lock struct {
+	DAGID string `json:"dag_id"`
+	// NodeID is the locked node, or empty for a lock on the whole DAG.
+	NodeID     string    `json:"node_id,omitempty"`
+	Owner      string    `json:"owner"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// LockError is returned when a lock cannot be taken or released because
+// another owner holds a conflicting one. errors.Is(err, ErrLocked) holds
+// for it.
+type LockError struct {
+	// Held is the conflicting lock.
+	Held Lock `json:"held"`
+}
+
+func (e *LockError) Error() string {
+	what := "dag " + e.Held.DAGID
+	if e.Held.NodeID != "" {
+		what = "node " + e.Held.NodeID
+	}
+	return fmt.Sprintf("%v: %s is locked by %s until %s", ErrLocked, what, e.Held.Owner, e.Held.ExpiresAt.Format(time.RFC3339))
+}
+
+func (e *LockError) Unwrap() error { return ErrLocked }
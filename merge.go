@@ -0,0 +1,137 @@
+package dag
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// MergeStrategy says how MergeNodes combines the data of the two nodes.
+type MergeStrategy string
+
+const (
+	// MergeKeep keeps the kept node's data as it is.
+	MergeKeep MergeStrategy = "keep"
+	// MergeReplace gives the kept node the dropped node's data.
+	MergeReplace MergeStrategy = "replace"
+	// MergeShallow takes the top-level keys of both objects; the kept
+	// node's value wins where both have a key.
+	MergeShallow MergeStrategy = "shallow"
+	// MergeDeep merges objects key by key at every level; the kept node's
+	// value wins where both have a key that is not an object in both.
+	MergeDeep MergeStrategy = "deep"
+)
+
+// Valid reports whether s is one of the merge strategies.
+func (s MergeStrategy) Valid() bool {
+	return s == MergeKeep || s == MergeReplace || s == MergeShallow || s == MergeDeep
+}
+
+// MergeData combines keep and drop as s says. With MergeShallow and
+// MergeDeep, data that is not an object on either side is kept as is.
+func MergeData(keep, drop json.RawMessage, s MergeStrategy) (json.RawMessage, error) {
+	switch s {
+	case MergeKeep:
+		return keep, nil
+	case MergeReplace:
+		return drop, nil
+	case MergeShallow, MergeDeep:
+	default:
+		return nil, fmt.Errorf("%w: unknown strategy %q", ErrInvalidMerge, s)
+	}
+	var k, d map[string]any
+	if json.Unmarshal(keep, &k) != nil || json.Unmarshal(drop, &d) != nil || k == nil || d == nil {
+		return keep, nil
+	}
+	return json.Marshal(mergeObjects(k, d, s == MergeDeep))
+}
+
+// mergeObjects adds drop's keys to keep, recursing into objects both have
+// if deep is set.
+func mergeObjects(keep, drop map[string]any, deep bool) map[string]any {
+	for key, dv := range drop {
+		kv, ok := keep[key]
+		if !ok {
+			keep[key] = dv
+			continue
+		}
+		km, kIsObj := kv.(map[string]any)
+		dm, dIsObj := dv.(map[string]any)
+		if deep && kIsObj && dIsObj {
+			keep[key] = mergeObjects(km, dm, true)
+		}
+	}
+	return keep
+}
+
+// PlanMerge returns the change set that merges dropID into keepID: the
+// kept node gets the merged data and the tags of both, every edge of the
+// dropped node is moved to the kept node under the same ID, and the
+// dropped node is deleted. A moved edge is deleted instead if it would
+// join the kept node to itself or two nodes that are already joined, so
+// merging does not add parallel edges. The change set is not checked for
+// cycles. ErrNodeNotFound is returned if either node is not in d, and
+// ErrInvalidMerge if they are the same node or s is unknown.
+func PlanMerge(d *DAG, keepID, dropID string, s MergeStrategy) (*ChangeSet, error) {
+	if keepID == dropID {
+		return nil, fmt.Errorf("%w: cannot merge %s into itself", ErrInvalidMerge, keepID)
+	}
+	var keep, drop *Node
+	for i := range d.Nodes {
+		switch d.Nodes[i].ID {
+		case keepID:
+			keep = &d.Nodes[i]
+		case dropID:
+			drop = &d.Nodes[i]
+		}
+	}
+	if keep == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNodeNotFound, keepID)
+	}
+	if drop == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNodeNotFound, dropID)
+	}
+	data, err := MergeData(keep.Data, drop.Data, s)
+	if err != nil {
+		return nil, err
+	}
+	tags := slices.Clone(keep.Tags)
+	for _, t := range drop.Tags {
+		if !slices.Contains(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+
+	cs := &ChangeSet{
+		UpdateNodes: []Node{{ID: keepID, Data: data, Tags: tags}},
+		DeleteNodes: []string{dropID},
+	}
+	joined := make(map[[2]string]bool)
+	for _, e := range d.Edges {
+		if e.FromNodeID != dropID && e.ToNodeID != dropID {
+			joined[[2]string{e.FromNodeID, e.ToNodeID}] = true
+		}
+	}
+	for _, e := range d.Edges {
+		if e.FromNodeID != dropID && e.ToNodeID != dropID {
+			continue
+		}
+		cs.DeleteEdges = append(cs.DeleteEdges, e.ID)
+		if e.FromNodeID == dropID {
+			e.FromNodeID = keepID
+		}
+		if e.ToNodeID == dropID {
+			e.ToNodeID = keepID
+		}
+		pair := [2]string{e.FromNodeID, e.ToNodeID}
+		if e.FromNodeID == e.ToNodeID || joined[pair] {
+			continue
+		}
+		joined[pair] = true
+		cs.AddEdges = append(cs.AddEdges, Edge{ID: e.ID, FromNodeID: e.FromNodeID, ToNodeID: e.ToNodeID, Data: e.Data})
+	}
+	return cs, nil
+}
@@ -0,0 +1,101 @@
+// Package natssink publishes DAG change events to NATS. It is a
+// dag.EventSink, so a postgres.Relay can feed it from the outbox:
+//
+//	nc, _ := nats.Connect(nats.DefaultURL)
+//	js, _ := jetstream.New(nc)
+//	r := &postgres.Relay{Store: store, Sink: natssink.NewJetStream(js, "dag.events")}
+//	go r.Run(ctx)
+//
+// Each event is one message on subject <prefix>.<type>, e.g.
+// "dag.events.changed", with the event as JSON in the body, described by
+// dag.EventSchema.
+package natssink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/meikuraledutech/dag"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Message headers set on every message.
+const (
+	// HeaderSchema carries dag.EventSchemaVersion.
+	HeaderSchema = "Dag-Event-Schema"
+	// HeaderType carries the event's Type.
+	HeaderType = "Dag-Event-Type"
+	// HeaderDAGID carries the event's DAGID, which is not safe to put in a
+	// subject.
+	HeaderDAGID = "Dag-Id"
+	// HeaderContentType is "application/json".
+	HeaderContentType = "Content-Type"
+)
+
+// Sink writes events to NATS. Set JetStream for durable, acknowledged
+// publishing, or Conn for plain core NATS.
+type Sink struct {
+	// JetStream, if set, publishes to a stream, waiting for each ack. The
+	// message ID is <dag_id>:<seq>, so the stream drops events the relay
+	// offers again within its duplicate window.
+	JetStream jetstream.JetStream
+	// Conn is used when JetStream is nil. Publish then returns once the
+	// server has the events, but core NATS keeps nothing for subscribers
+	// that are not connected.
+	Conn *nats.Conn
+	// Subject picks each event's subject.
+	Subject func(e dag.Event) string
+}
+
+// NewJetStream returns a Sink publishing to js on <prefix>.<type>. A stream
+// must capture those subjects, e.g. "dag.events.>".
+func NewJetStream(js jetstream.JetStream, prefix string) *Sink {
+	return &Sink{JetStream: js, Subject: SubjectPerType(prefix)}
+}
+
+// NewCore returns a Sink publishing to nc on <prefix>.<type>.
+func NewCore(nc *nats.Conn, prefix string) *Sink {
+	return &Sink{Conn: nc, Subject: SubjectPerType(prefix)}
+}
+
+// SubjectPerType sends each event to prefix, a dot and its type.
+func SubjectPerType(prefix string) func(e dag.Event) string {
+	return func(e dag.Event) string { return prefix + "." + string(e.Type) }
+}
+
+// Publish sends events in order and returns once JetStream has acked each
+// of them, or with Conn, once the server has received them all.
+func (s *Sink) Publish(ctx context.Context, events []dag.Event) error {
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("natssink: encode event %d: %w", e.Seq, err)
+		}
+		msg := nats.NewMsg(s.Subject(e))
+		msg.Data = data
+		msg.Header.Set(HeaderSchema, dag.EventSchemaVersion)
+		msg.Header.Set(HeaderType, string(e.Type))
+		msg.Header.Set(HeaderDAGID, e.DAGID)
+		msg.Header.Set(HeaderContentType, "application/json")
+
+		if s.JetStream != nil {
+			id := e.DAGID + ":" + strconv.FormatInt(e.Seq, 10)
+			if _, err := s.JetStream.PublishMsg(ctx, msg, jetstream.WithMsgID(id)); err != nil {
+				return fmt.Errorf("natssink: publish event %d: %w", e.Seq, err)
+			}
+			continue
+		}
+		if err := s.Conn.PublishMsg(msg); err != nil {
+			return fmt.Errorf("natssink: publish event %d: %w", e.Seq, err)
+		}
+	}
+	if s.JetStream == nil {
+		if err := s.Conn.FlushWithContext(ctx); err != nil {
+			return fmt.Errorf("natssink: flush: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package dag
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// NodeType describes a kind of node, such as a question, a condition, an
+// end screen or a webhook call. The type of a node is the string in its
+// data's "type" field.
+type NodeType struct {
+	Name string `json:"name"`
+	// Schema is a JSON Schema the node's data must match. Optional.
+	Schema json.RawMessage `json:"schema,omitempty"`
+	// Terminal nodes may not have outgoing edges.
+	Terminal bool `json:"terminal,omitempty"`
+	// MaxOut caps the number of outgoing edges. 0 means unlimited.
+	MaxOut int `json:"max_out,omitempty"`
+	// Display is free-form presentation metadata for editors.
+	Display NodeDisplay `json:"display,omitzero"`
+}
+
+// NodeDisplay is how an editor presents a node type. The store never
+// interprets it.
+type NodeDisplay struct {
+	Label       string `json:"label,omitempty"`
+	Description string `json:"description,omitempty"`
+	Icon        string `json:"icon,omitempty"`
+	Color       string `json:"color,omitempty"`
+}
+
+// NodeTypes is a registry of node types. Stores configured with one
+// validate each node's data against its type's schema and cap outgoing
+// edges per type. Register every type before the store starts serving
+// writes.
+type NodeTypes struct {
+	// Strict rejects nodes whose type is not registered, including nodes
+	// without a type. Otherwise such nodes are accepted unchecked.
+	Strict bool
+
+	types   map[string]NodeType
+	schemas *DataSchemas
+}
+
+// NewNodeTypes returns an empty registry.
+func NewNodeTypes() *NodeTypes {
+	return &NodeTypes{types: make(map[string]NodeType), schemas: NewDataSchemas()}
+}
+
+// Register adds or replaces a node type. It fails if the name is empty or
+// the schema does not compile.
+func (r *NodeTypes) Register(t NodeType) error {
+	if t.Name == "" {
+		return fmt.Errorf("dag: node type name is required")
+	}
+	if t.MaxOut < 0 {
+		return fmt.Errorf("dag: node type %q: max_out must not be negative", t.Name)
+	}
+	if len(t.Schema) > 0 {
+		if err := r.schemas.RegisterNode(t.Name, t.Schema); err != nil {
+			return err
+		}
+	} else {
+		delete(r.schemas.nodes, t.Name)
+	}
+	r.types[t.Name] = t
+	return nil
+}
+
+// Lookup returns the registered type called name.
+func (r *NodeTypes) Lookup(name string) (NodeType, bool) {
+	t, ok := r.types[name]
+	return t, ok
+}
+
+// All returns every registered type, sorted by name.
+func (r *NodeTypes) All() []NodeType {
+	out := make([]NodeType, 0, len(r.types))
+	for _, t := range r.types {
+		out = append(out, t)
+	}
+	slices.SortFunc(out, func(a, b NodeType) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
+
+// TypeOf returns the type named in a node's data, or "".
+func (r *NodeTypes) TypeOf(n *Node) string {
+	return r.schemas.typeOf(n.Data)
+}
+
+// CheckNode validates a node against its registered type. It returns
+// ErrUnknownNodeType in Strict mode for an unregistered type and a
+// *DataError for data that does not match the type's schema. A nil
+// registry accepts everything.
+func (r *NodeTypes) CheckNode(n *Node) error {
+	if r == nil {
+		return nil
+	}
+	return r.checkNode(n, -1)
+}
+
+func (r *NodeTypes) checkNode(n *Node, index int) error {
+	typ := r.TypeOf(n)
+	if _, ok := r.types[typ]; !ok {
+		if r.Strict {
+			return fmt.Errorf("%w: node %s has type %q", ErrUnknownNodeType, n.ID, typ)
+		}
+		return nil
+	}
+	return r.schemas.check(r.schemas.nodes, "node", n.ID, index, n.Data)
+}
+
+// CheckOutDegree returns ErrOutDegree if a node of type typ may not have
+// out outgoing edges.
+func (r *NodeTypes) CheckOutDegree(nodeID, typ string, out int) error {
+	if r == nil || out == 0 {
+		return nil
+	}
+	t, ok := r.types[typ]
+	switch {
+	case !ok:
+		return nil
+	case t.Terminal:
+		return fmt.Errorf("%w: %s is a terminal %s node", ErrOutDegree, nodeID, typ)
+	case t.MaxOut > 0 && out > t.MaxOut:
+		return fmt.Errorf("%w: %s node %s may have at most %d outgoing edges", ErrOutDegree, typ, nodeID, t.MaxOut)
+	}
+	return nil
+}
+
+// CheckEdges checks the out-degree of node fromID given all of a DAG's
+// nodes and edges, as an edge write would leave them.
+func (r *NodeTypes) CheckEdges(nodes []Node, edges []Edge, fromID string) error {
+	if r == nil {
+		return nil
+	}
+	out := 0
+	for _, e := range edges {
+		if e.FromNodeID == fromID {
+			out++
+		}
+	}
+	for i := range nodes {
+		if nodes[i].ID == fromID {
+			return r.CheckOutDegree(fromID, r.TypeOf(&nodes[i]), out)
+		}
+	}
+	return nil
+}
+
+// CheckDAG validates every node of a DAG as passed to CreateDAG, and the
+// out-degree of every node.
+func (r *NodeTypes) CheckDAG(d *DAG) error {
+	if r == nil {
+		return nil
+	}
+	out := make(map[string]int)
+	for _, e := range d.Edges {
+		out[e.FromNodeID]++
+	}
+	for i := range d.Nodes {
+		n := &d.Nodes[i]
+		if err := r.checkNode(n, i); err != nil {
+			return err
+		}
+		if err := r.CheckOutDegree(n.ID, r.TypeOf(n), out[n.ID]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package dag
+
+import (
+	"bytes"
+	"cmp"
+	"container/heap"
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// TopoOptions says how TopoSort breaks ties between nodes that are ready
+// at the same time. Priorities are numbers at dotted data paths, such as
+// "priority" or "meta.weight"; a missing or non-numeric value counts as 0.
+type TopoOptions struct {
+	// NodePriority is the path of a node's priority. Higher comes first.
+	NodePriority string
+	// EdgePriority is the path of an edge's priority. Among nodes of equal
+	// priority, the one with the higher-priority incoming edge comes
+	// first.
+	EdgePriority string
+}
+
+// TopoSort returns the IDs of d's nodes in an order where every edge goes
+// from an earlier node to a later one. Whenever several nodes could come
+// next, it picks by node priority, then by the highest priority of the
+// node's incoming edges, then by ID, so the order depends only on d's
+// contents and is the same every time. Edges with an end outside d are
+// ignored. ErrCycleDetected is returned if d has a cycle.
+func TopoSort(d *DAG, opts ...TopoOptions) ([]string, error) {
+	var o TopoOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	q := &topoQueue{}
+	index := make(map[string]int, len(d.Nodes))
+	for _, n := range d.Nodes {
+		index[n.ID] = len(q.items)
+		p, _ := numberAt(n.Data, o.NodePriority)
+		q.items = append(q.items, topoItem{id: n.ID, node: p})
+	}
+
+	inDegree := make([]int, len(q.items))
+	out := make([][]int, len(q.items))
+	for _, e := range d.Edges {
+		from, ok1 := index[e.FromNodeID]
+		to, ok2 := index[e.ToNodeID]
+		if !ok1 || !ok2 {
+			continue
+		}
+		inDegree[to]++
+		out[from] = append(out[from], to)
+		if o.EdgePriority != "" {
+			p, _ := numberAt(e.Data, o.EdgePriority)
+			if it := &q.items[to]; inDegree[to] == 1 || p > it.edge {
+				it.edge = p
+			}
+		}
+	}
+
+	for i := range q.items {
+		if inDegree[i] == 0 {
+			q.ready = append(q.ready, i)
+		}
+	}
+	heap.Init(q)
+	order := make([]string, 0, len(q.items))
+	for q.Len() > 0 {
+		i := heap.Pop(q).(int)
+		order = append(order, q.items[i].id)
+		for _, j := range out[i] {
+			if inDegree[j]--; inDegree[j] == 0 {
+				heap.Push(q, j)
+			}
+		}
+	}
+	if len(order) < len(q.items) {
+		return nil, ErrCycleDetected
+	}
+	return order, nil
+}
+
+// SortTopologically sorts d.Nodes into the order TopoSort gives and
+// d.Edges by the position of their source node in it, keeping the order of
+// the edges leaving each node. Edges whose source is not in d go last.
+// ErrCycleDetected is returned, and d left as it is, if d has a cycle.
+func SortTopologically(d *DAG, opts ...TopoOptions) error {
+	order, err := TopoSort(d, opts...)
+	if err != nil {
+		return err
+	}
+	pos := make(map[string]int, len(order))
+	for i, id := range order {
+		pos[id] = i
+	}
+	at := func(id string) int {
+		if i, ok := pos[id]; ok {
+			return i
+		}
+		return len(order)
+	}
+	slices.SortStableFunc(d.Nodes, func(a, b Node) int { return cmp.Compare(at(a.ID), at(b.ID)) })
+	slices.SortStableFunc(d.Edges, func(a, b Edge) int { return cmp.Compare(at(a.FromNodeID), at(b.FromNodeID)) })
+	return nil
+}
+
+type topoItem struct {
+	id         string
+	node, edge float64
+}
+
+// topoQueue is a heap of the indexes of ready nodes, best first.
+type topoQueue struct {
+	items []topoItem
+	ready []int
+}
+
+func (q *topoQueue) Len() int { return len(q.ready) }
+
+func (q *topoQueue) Less(i, j int) bool {
+	a, b := q.items[q.ready[i]], q.items[q.ready[j]]
+	return cmp.Or(cmp.Compare(b.node, a.node), cmp.Compare(b.edge, a.edge), cmp.Compare(a.id, b.id)) < 0
+}
+
+func (q *topoQueue) Swap(i, j int) { q.ready[i], q.ready[j] = q.ready[j], q.ready[i] }
+
+func (q *topoQueue) Push(x any) { q.ready = append(q.ready, x.(int)) }
+
+func (q *topoQueue) Pop() any {
+	i := q.ready[len(q.ready)-1]
+	q.ready = q.ready[:len(q.ready)-1]
+	return i
+}
+
+// numberAt returns the number at the dotted path in data. ok is false if
+// path is empty or does not lead to a number.
+func numberAt(data json.RawMessage, path string) (f float64, ok bool) {
+	if path == "" {
+		return 0, false
+	}
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if dec.Decode(&doc) != nil {
+		return 0, false
+	}
+	n, ok := lookup(doc, strings.Split(path, ".")).(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
@@ -0,0 +1,331 @@
+// Package pgstd runs the Postgres store on a *sql.DB, for codebases that
+// standardize on database/sql: shared pools, connection middleware, or
+// sqlmock in tests.
+//
+//	db, _ := sql.Open("pgx", os.Getenv("DATABASE_URL")) // github.com/jackc/pgx/v5/stdlib
+//	store := pgstd.New(db, postgres.WithQuota(q))
+//
+// The store is the same *postgres.PGStore with the same options; only the
+// connection underneath differs. Values are decoded with pgx's type map, so
+// JSONB, TEXT[] and the rest scan as they do on a pgx pool.
+package pgstd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/meikuraledutech/dag/postgres"
+)
+
+// ErrUnsupported is returned by the pgx.Tx methods that have no
+// database/sql equivalent and that the store does not use.
+var ErrUnsupported = errors.New("pgstd: not supported over database/sql")
+
+// New returns a Postgres store running on db.
+func New(db *sql.DB, opts ...postgres.Option) *postgres.PGStore {
+	return postgres.New(Wrap(db), opts...)
+}
+
+// Wrap adapts db to postgres.DB, e.g. for postgres.WithReplica.
+func Wrap(db *sql.DB) postgres.DB {
+	return &DB{db: db}
+}
+
+// WrapTx adapts a transaction of your own to postgres.DB, for
+// PGStore.Using. The store's own transactions become savepoints inside it.
+func WrapTx(tx *sql.Tx) pgx.Tx {
+	return &Tx{tx: tx}
+}
+
+// querier is what *sql.DB and *sql.Tx have in common.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// DB is a *sql.DB seen as a postgres.DB.
+type DB struct {
+	db *sql.DB
+}
+
+func (d *DB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return exec(ctx, d.db, sql, args)
+}
+
+func (d *DB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return query(ctx, d.db, sql, args)
+}
+
+func (d *DB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	r, err := query(ctx, d.db, sql, args)
+	return &row{rows: r, err: err}
+}
+
+func (d *DB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return d.BeginTx(ctx, pgx.TxOptions{})
+}
+
+// BeginTx maps the isolation level and access mode of opts to database/sql.
+func (d *DB) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	o := &sql.TxOptions{ReadOnly: opts.AccessMode == pgx.ReadOnly}
+	switch opts.IsoLevel {
+	case pgx.ReadCommitted:
+		o.Isolation = sql.LevelReadCommitted
+	case pgx.RepeatableRead:
+		o.Isolation = sql.LevelRepeatableRead
+	case pgx.Serializable:
+		o.Isolation = sql.LevelSerializable
+	}
+	tx, err := d.db.BeginTx(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{tx: tx}, nil
+}
+
+// Tx is a *sql.Tx, or a savepoint inside one, seen as a pgx.Tx.
+type Tx struct {
+	tx *sql.Tx
+	// savepoint names the savepoint this Tx stands for; "" is the
+	// transaction itself.
+	savepoint string
+	depth     int
+	done      bool
+}
+
+func (t *Tx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return exec(ctx, t.tx, sql, args)
+}
+
+func (t *Tx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return query(ctx, t.tx, sql, args)
+}
+
+func (t *Tx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	r, err := query(ctx, t.tx, sql, args)
+	return &row{rows: r, err: err}
+}
+
+// Begin opens a savepoint, as pgx.Tx.Begin does.
+func (t *Tx) Begin(ctx context.Context) (pgx.Tx, error) {
+	name := fmt.Sprintf("pgstd_sp_%d", t.depth+1)
+	if _, err := t.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return nil, err
+	}
+	return &Tx{tx: t.tx, savepoint: name, depth: t.depth + 1}, nil
+}
+
+func (t *Tx) Commit(ctx context.Context) error {
+	if t.done {
+		return pgx.ErrTxClosed
+	}
+	t.done = true
+	if t.savepoint == "" {
+		return t.tx.Commit()
+	}
+	_, err := t.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+t.savepoint)
+	return err
+}
+
+// Rollback returns pgx.ErrTxClosed after Commit or Rollback, so it is safe
+// to defer.
+func (t *Tx) Rollback(ctx context.Context) error {
+	if t.done {
+		return pgx.ErrTxClosed
+	}
+	t.done = true
+	if t.savepoint == "" {
+		return t.tx.Rollback()
+	}
+	_, err := t.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+t.savepoint+"; RELEASE SAVEPOINT "+t.savepoint)
+	return err
+}
+
+func (t *Tx) CopyFrom(context.Context, pgx.Identifier, []string, pgx.CopyFromSource) (int64, error) {
+	return 0, ErrUnsupported
+}
+
+func (t *Tx) SendBatch(context.Context, *pgx.Batch) pgx.BatchResults {
+	return errBatch{}
+}
+
+func (t *Tx) LargeObjects() pgx.LargeObjects {
+	return pgx.LargeObjects{}
+}
+
+func (t *Tx) Prepare(context.Context, string, string) (*pgconn.StatementDescription, error) {
+	return nil, ErrUnsupported
+}
+
+// Conn returns nil: there is no *pgx.Conn behind database/sql.
+func (t *Tx) Conn() *pgx.Conn {
+	return nil
+}
+
+// errBatch is the result of SendBatch.
+type errBatch struct{}
+
+func (errBatch) Exec() (pgconn.CommandTag, error) { return pgconn.CommandTag{}, ErrUnsupported }
+func (errBatch) Query() (pgx.Rows, error)         { return nil, ErrUnsupported }
+func (errBatch) QueryRow() pgx.Row                { return &row{err: ErrUnsupported} }
+func (errBatch) Close() error                     { return ErrUnsupported }
+
+func exec(ctx context.Context, q querier, sql string, args []any) (pgconn.CommandTag, error) {
+	res, err := q.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	// Only RowsAffected survives database/sql, so that is all the tag holds.
+	n, err := res.RowsAffected()
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return pgconn.NewCommandTag(fmt.Sprintf("EXEC %d", n)), nil
+}
+
+func query(ctx context.Context, q querier, sql string, args []any) (*rows, error) {
+	r, err := q.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	cols, err := r.ColumnTypes()
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	return &rows{rows: r, cols: cols}, nil
+}
+
+// rows is a *sql.Rows seen as pgx.Rows.
+type rows struct {
+	rows *sql.Rows
+	cols []*sql.ColumnType
+	err  error
+}
+
+func (r *rows) Close()                        { r.rows.Close() }
+func (r *rows) CommandTag() pgconn.CommandTag { return pgconn.CommandTag{} }
+func (r *rows) RawValues() [][]byte           { return nil }
+func (r *rows) Conn() *pgx.Conn               { return nil }
+
+func (r *rows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Err()
+}
+
+func (r *rows) FieldDescriptions() []pgconn.FieldDescription {
+	fds := make([]pgconn.FieldDescription, len(r.cols))
+	for i, c := range r.cols {
+		fds[i].Name = c.Name()
+	}
+	return fds
+}
+
+func (r *rows) Next() bool {
+	if r.err != nil {
+		return false
+	}
+	return r.rows.Next()
+}
+
+func (r *rows) Scan(dest ...any) error {
+	m := typeMaps.Get().(*pgtype.Map)
+	defer typeMaps.Put(m)
+	wrapped := make([]any, len(dest))
+	for i, d := range dest {
+		wrapped[i] = d
+		if i >= len(r.cols) {
+			continue
+		}
+		name := strings.ToLower(r.cols[i].DatabaseTypeName())
+		if binaryTypes[name] {
+			continue
+		}
+		if t, ok := m.TypeForName(name); ok {
+			wrapped[i] = &field{m: m, oid: t.OID, dest: d}
+		}
+	}
+	if err := r.rows.Scan(wrapped...); err != nil {
+		r.err = err
+		return err
+	}
+	return nil
+}
+
+func (r *rows) Values() ([]any, error) {
+	vals := make([]any, len(r.cols))
+	ptrs := make([]any, len(vals))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	if err := r.rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+	return vals, nil
+}
+
+// row is the first row of a query, as pgx.Row.
+type row struct {
+	rows *rows
+	err  error
+}
+
+// Scan returns pgx.ErrNoRows if the query had no rows.
+func (r *row) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return pgx.ErrNoRows
+	}
+	if err := r.rows.Scan(dest...); err != nil {
+		return err
+	}
+	r.rows.Close()
+	return r.rows.Err()
+}
+
+// binaryTypes are the types the pgx stdlib driver already decodes into Go
+// values; database/sql converts those itself. Everything else arrives in
+// Postgres text format and is decoded by field.
+var binaryTypes = map[string]bool{
+	"bool": true, "bytea": true, "cid": true, "date": true, "float4": true, "float8": true,
+	"int2": true, "int4": true, "int8": true, "oid": true, "timestamp": true, "timestamptz": true, "xid": true,
+}
+
+// typeMaps holds pgtype.Maps for reuse; a Map caches scan plans and is not
+// safe for concurrent use.
+var typeMaps = sync.Pool{New: func() any { return pgtype.NewMap() }}
+
+// field decodes a text-format value of type oid into dest.
+type field struct {
+	m    *pgtype.Map
+	oid  uint32
+	dest any
+}
+
+func (f *field) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		return f.m.Scan(f.oid, pgtype.TextFormatCode, nil, f.dest)
+	case string:
+		return f.m.Scan(f.oid, pgtype.TextFormatCode, []byte(v), f.dest)
+	case []byte:
+		return f.m.Scan(f.oid, pgtype.TextFormatCode, v, f.dest)
+	default:
+		return fmt.Errorf("pgstd: unexpected %T for type %d", src, f.oid)
+	}
+}
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// QueryAudit returns the events of the event log that f selects, across
+// all DAGs unless f.DAGID is set, oldest first. Page through the trail by
+// passing the Seq of the last event returned as the next f.AfterSeq. Only
+// writes made under WithEventLog are in the log, and only those made
+// under dag.WithActor have an Actor.
+func (s *PGStore) QueryAudit(ctx context.Context, f dag.AuditFilter) ([]dag.Event, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := []string{`seq > ` + arg(f.AfterSeq)}
+	if f.DAGID != "" {
+		where = append(where, `dag_id = `+arg(f.DAGID))
+	}
+	if f.Actor != "" {
+		where = append(where, `actor = `+arg(f.Actor))
+	}
+	if len(f.Types) > 0 {
+		types := make([]string, len(f.Types))
+		for i, t := range f.Types {
+			types[i] = string(t)
+		}
+		where = append(where, `type = ANY(`+arg(types)+`::text[])`)
+	}
+	if !f.From.IsZero() {
+		where = append(where, `created_at >= `+arg(f.From))
+	}
+	if !f.To.IsZero() {
+		where = append(where, `created_at < `+arg(f.To))
+	}
+
+	sql := `SELECT ` + eventColumns + ` FROM dag_events WHERE ` + strings.Join(where, ` AND `) + ` ORDER BY seq`
+	if f.Limit > 0 {
+		sql += ` LIMIT ` + arg(f.Limit)
+	}
+	rows, err := s.reader(ctx, f.DAGID).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("dag: query audit: %w", err)
+	}
+	return s.scanEvents(ctx, rows)
+}
@@ -0,0 +1,215 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/meikuraledutech/dag"
+)
+
+// AddNodes inserts many nodes into a DAG in one transaction.
+// Each node is inserted under its own savepoint, so one bad item (e.g. a
+// duplicate ID) doesn't reject the others. Returns one BatchResult per
+// input node, in order. The returned error is only for transaction failures.
+func (s *PGStore) AddNodes(ctx context.Context, dagID string, nodes []dag.Node) ([]dag.BatchResult, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("dag: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := ensureDAGInfo(ctx, tx, dagID); err != nil {
+		return nil, err
+	}
+	if _, err := s.onTx(tx).lockDAG(ctx, dagID); err != nil {
+		return nil, err
+	}
+
+	q := s.quotaFor(ctx, dagID)
+	count := 0
+	if q.MaxNodes > 0 {
+		if count, err = s.countNodes(ctx, dagID); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]dag.BatchResult, len(nodes))
+	var added []dag.Node
+	for i := range nodes {
+		n := &nodes[i]
+		if n.ID == "" {
+			n.ID = uuid.NewString()
+		}
+		if err := q.CheckData(n.Data); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := s.schemas.CheckNode(n); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := s.nodeTypes.CheckNode(n); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := q.CheckNodes(count + 1); err != nil {
+			results[i].Err = err
+			continue
+		}
+		err := withSavepoint(ctx, tx, func(sp pgx.Tx) error {
+			p, err := s.packNode(ctx, sp, n.Data)
+			if err != nil {
+				return err
+			}
+			_, err = sp.Exec(ctx,
+				`INSERT INTO dag_nodes (id, dag_id, data, tags, compressed, blob_key, data_hash) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+				n.ID, dagID, p.Data, nodeTags(n), p.Compressed, p.BlobKey, p.DataHash,
+			)
+			return err
+		})
+		if err != nil {
+			results[i].Err = fmt.Errorf("dag: insert node %s: %w", n.ID, err)
+			continue
+		}
+		count++
+		results[i].ID = n.ID
+		added = append(added, *n)
+	}
+
+	if err := s.recordVersion(ctx, tx, dagID); err != nil {
+		return nil, err
+	}
+	if len(added) > 0 {
+		if err := s.recordChanged(ctx, tx, dagID, &dag.ChangeSet{AddNodes: added}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("dag: commit: %w", err)
+	}
+	return results, nil
+}
+
+// AddEdges inserts many edges into a DAG in one transaction.
+// Edges are applied in order; each is checked for cycles against the DAG
+// plus the edges accepted before it, then inserted under its own savepoint.
+// Rejected edges get ErrCycleDetected or the DB error in their BatchResult.
+func (s *PGStore) AddEdges(ctx context.Context, dagID string, edges []dag.Edge) ([]dag.BatchResult, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("dag: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Read the graph under the DAG's lock, so no other write changes it
+	// between the checks and the inserts.
+	settings, err := s.onTx(tx).lockDAG(ctx, dagID)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.ListNodes(s.graphCtx(ctx), dagID)
+	if err != nil {
+		return nil, err
+	}
+	accepted, err := s.ListEdges(Primary(ctx), dagID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := s.quotaFor(ctx, dagID)
+
+	results := make([]dag.BatchResult, len(edges))
+	var added []dag.Edge
+	for i := range edges {
+		e := &edges[i]
+		if e.ID == "" {
+			e.ID = uuid.NewString()
+		}
+		if err := checkEndpoints(nodes, e); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := q.CheckData(e.Data); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := s.schemas.CheckEdge(e); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := q.CheckEdges(len(accepted) + 1); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		after := append(slices.Clip(accepted), *e)
+		if err := dag.ValidateAcyclic(nodes, after); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := settings.CheckChange(nodes, accepted, after); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := s.nodeTypes.CheckEdges(nodes, after, e.FromNodeID); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := checkDepth(ctx, tx, dagID, settings, e.FromNodeID, e.ToNodeID, ""); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		data, packed := s.pack(e.Data)
+		err := withSavepoint(ctx, tx, func(sp pgx.Tx) error {
+			return sp.QueryRow(ctx,
+				`INSERT INTO dag_edges (id, dag_id, from_node_id, to_node_id, data, order_index, unique_pair, compressed)
+				VALUES ($1, $2, $3, $4, $5, `+nextOrderIndex+`, `+uniquePairOf+`, $6) RETURNING order_index`,
+				e.ID, dagID, e.FromNodeID, e.ToNodeID, data, packed,
+			).Scan(&e.OrderIndex)
+		})
+		if err != nil {
+			results[i].Err = fmt.Errorf("dag: insert edge %s: %w", e.ID, parallelEdgeErr(err))
+			continue
+		}
+		accepted = append(accepted, *e)
+		results[i].ID = e.ID
+		added = append(added, *e)
+	}
+
+	if err := s.recordVersion(ctx, tx, dagID); err != nil {
+		return nil, err
+	}
+	if len(added) > 0 {
+		if err := s.recordChanged(ctx, tx, dagID, &dag.ChangeSet{AddEdges: added}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("dag: commit: %w", err)
+	}
+	return results, nil
+}
+
+// withSavepoint runs fn inside a savepoint of tx, rolling back only the
+// savepoint if fn fails so the outer transaction stays usable.
+func withSavepoint(ctx context.Context, tx pgx.Tx, fn func(pgx.Tx) error) error {
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(sp); err != nil {
+		sp.Rollback(ctx)
+		return err
+	}
+	return sp.Commit(ctx)
+}
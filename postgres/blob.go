@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// BlobStore is object storage for node data too large to keep in a row.
+// archive.Bucket implementations such as archive.Dir satisfy it.
+type BlobStore interface {
+	Put(ctx context.Context, key string, body []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// WithBlobStore moves node data larger than threshold bytes to b and keeps
+// only a reference in the row. Reads resolve references eagerly unless the
+// context is marked with LazyBlobs.
+//
+// Blobs are content-addressed ("blobs/<sha256>.json"), so identical payloads
+// are stored once and a write never overwrites data another row still
+// points to. The store never deletes blobs; expire unreferenced ones with
+// bucket lifecycle rules or by comparing against dag_nodes.blob_key.
+func WithBlobStore(b BlobStore, threshold int) Option {
+	return func(s *PGStore) {
+		s.blobs = b
+		s.blobAbove = threshold
+	}
+}
+
+// blobPrefix starts the JSON string that replaces offloaded data:
+// "blob:<object key>".
+const blobPrefix = `"blob:`
+
+type lazyBlobsKey struct{}
+
+// LazyBlobs returns a context whose reads leave blob references in node
+// data instead of fetching the payloads. Resolve them with ResolveBlob
+// when, and if, they are needed.
+func LazyBlobs(ctx context.Context) context.Context {
+	return context.WithValue(ctx, lazyBlobsKey{}, true)
+}
+
+func lazyBlobs(ctx context.Context) bool {
+	lazy, _ := ctx.Value(lazyBlobsKey{}).(bool)
+	return lazy
+}
+
+// IsBlobRef reports whether node data is a reference left by LazyBlobs.
+func IsBlobRef(data json.RawMessage) bool {
+	return bytes.HasPrefix(data, []byte(blobPrefix))
+}
+
+// ResolveBlob returns the payload a blob reference points to. Any other
+// data is returned unchanged.
+func (s *PGStore) ResolveBlob(ctx context.Context, data json.RawMessage) (json.RawMessage, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	if !IsBlobRef(data) {
+		return data, nil
+	}
+	var ref string
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return nil, fmt.Errorf("dag: blob reference: %w", err)
+	}
+	if s.blobs == nil {
+		return nil, fmt.Errorf("dag: blob %s: no blob store configured", ref)
+	}
+	key := ref[len(blobPrefix)-1:]
+	body, err := s.blobs.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("dag: get blob %s: %w", key, err)
+	}
+	return body, nil
+}
+
+// storedData is node data as written to its row.
+type storedData struct {
+	Data       json.RawMessage
+	Compressed bool
+	BlobKey    *string
+	DataHash   *string
+}
+
+// packNode prepares node data for its row: moved to the blob store if it
+// is over the WithBlobStore threshold, else to dag_node_data under
+// WithDedup, else possibly compressed. db is where dag_node_data is written.
+func (s *PGStore) packNode(ctx context.Context, db execer, data json.RawMessage) (storedData, error) {
+	if s.blobs == nil || s.blobAbove <= 0 || len(data) <= s.blobAbove {
+		if stored, ok, err := s.dedupNode(ctx, db, data); ok || err != nil {
+			return stored, err
+		}
+		data, compressed := s.pack(data)
+		return storedData{Data: data, Compressed: compressed}, nil
+	}
+	sum := sha256.Sum256(data)
+	key := "blobs/" + hex.EncodeToString(sum[:]) + ".json"
+	if err := s.blobs.Put(ctx, key, data); err != nil {
+		return storedData{}, fmt.Errorf("dag: put blob %s: %w", key, err)
+	}
+	return storedData{Data: json.RawMessage(blobPrefix + key + `"`), BlobKey: &key}, nil
+}
+
+// unpackNode expands node data in place: decompressed, and fetched from
+// the blob store unless ctx is marked with LazyBlobs.
+func (s *PGStore) unpackNode(ctx context.Context, data *json.RawMessage) error {
+	if err := unpack(data); err != nil {
+		return err
+	}
+	if !IsBlobRef(*data) || lazyBlobs(ctx) {
+		return nil
+	}
+	body, err := s.ResolveBlob(ctx, *data)
+	if err != nil {
+		return err
+	}
+	*data = body
+	return nil
+}
+
+// blobKeyOf returns the object key of a stored blob reference, or nil.
+func blobKeyOf(data json.RawMessage) *string {
+	if !IsBlobRef(data) {
+		return nil
+	}
+	var ref string
+	if json.Unmarshal(data, &ref) != nil {
+		return nil
+	}
+	key := ref[len(blobPrefix)-1:]
+	return &key
+}
+
+// graphCtx is the context for reads that only need the shape of the graph
+// to validate a write. They go to the primary, and blobs stay unresolved
+// unless node types, which read each node's type from its data, are
+// enforced.
+func (s *PGStore) graphCtx(ctx context.Context) context.Context {
+	ctx = Primary(ctx)
+	if s.nodeTypes != nil {
+		return ctx
+	}
+	return LazyBlobs(ctx)
+}
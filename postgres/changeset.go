@@ -0,0 +1,329 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/meikuraledutech/dag"
+)
+
+// ApplyChangeSet applies cs to a DAG in one transaction: edge deletes, node
+// deletes, node updates, node adds, edge updates, then edge adds. The graph
+// cs leaves behind is validated once, for cycles, settings, quotas, data
+// schemas and node types; if any check fails nothing is written.
+//
+// Updating a node or edge that is not in the DAG returns ErrNodeNotFound or
+// ErrEdgeNotFound, as does an edge to a node that will not exist. Deleting
+// one is a no-op, as with DeleteNode and DeleteEdge; deleting a node
+// deletes its edges. Generated IDs and the order_index of written edges are
+// filled in in cs.
+func (s *PGStore) ApplyChangeSet(ctx context.Context, dagID string, cs *dag.ChangeSet) error {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	if cs.Empty() {
+		return nil
+	}
+	return s.changeDAG(ctx, dagID, func([]dag.Node, []dag.Edge) (*dag.ChangeSet, error) { return cs, nil })
+}
+
+// changeDAG locks a DAG, passes its nodes and edges to build, and applies
+// the change set build returns as ApplyChangeSet does. An empty change set
+// writes nothing.
+func (s *PGStore) changeDAG(ctx context.Context, dagID string, build func([]dag.Node, []dag.Edge) (*dag.ChangeSet, error)) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("dag: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := ensureDAGInfo(ctx, tx, dagID); err != nil {
+		return err
+	}
+	// Lock the DAG so that concurrent change sets are validated one after
+	// the other against the graph the previous one left.
+	settings, err := s.onTx(tx).lockDAG(ctx, dagID)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := s.ListNodes(s.graphCtx(ctx), dagID)
+	if err != nil {
+		return err
+	}
+	edges, err := s.ListEdges(Primary(ctx), dagID)
+	if err != nil {
+		return err
+	}
+	cs, err := build(nodes, edges)
+	if err != nil || cs.Empty() {
+		return err
+	}
+	if err := resolveChangeSet(cs); err != nil {
+		return err
+	}
+	if err := s.checkChangeSet(ctx, dagID, settings, nodes, edges, cs); err != nil {
+		return err
+	}
+	if err := s.writeChangeSet(ctx, tx, dagID, cs); err != nil {
+		return err
+	}
+	if err := s.recordVersion(ctx, tx, dagID); err != nil {
+		return err
+	}
+	if err := s.recordChanged(ctx, tx, dagID, cs); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// resolveChangeSet assigns IDs to new nodes and edges and resolves edge
+// refs to the new nodes.
+func resolveChangeSet(cs *dag.ChangeSet) error {
+	refs := make(map[string]string)
+	for i := range cs.AddNodes {
+		n := &cs.AddNodes[i]
+		if n.ID == "" {
+			n.ID = uuid.NewString()
+		}
+		if n.Ref != "" {
+			refs[n.Ref] = n.ID
+		}
+	}
+	resolve := func(e *dag.Edge) error {
+		if e.FromNodeRef != "" {
+			id, ok := refs[e.FromNodeRef]
+			if !ok {
+				return fmt.Errorf("dag: unknown from_node_ref %q", e.FromNodeRef)
+			}
+			e.FromNodeID = id
+		}
+		if e.ToNodeRef != "" {
+			id, ok := refs[e.ToNodeRef]
+			if !ok {
+				return fmt.Errorf("dag: unknown to_node_ref %q", e.ToNodeRef)
+			}
+			e.ToNodeID = id
+		}
+		return nil
+	}
+	for i := range cs.AddEdges {
+		e := &cs.AddEdges[i]
+		if e.ID == "" {
+			e.ID = uuid.NewString()
+		}
+		if err := resolve(e); err != nil {
+			return err
+		}
+	}
+	for i := range cs.UpdateEdges {
+		if err := resolve(&cs.UpdateEdges[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkChangeSet validates the graph that cs turns nodes and edges into.
+func (s *PGStore) checkChangeSet(ctx context.Context, dagID string, settings dag.Settings, nodes []dag.Node, edges []dag.Edge, cs *dag.ChangeSet) error {
+	q := s.quotaFor(ctx, dagID)
+	deleted := make(map[string]bool, len(cs.DeleteNodes)+len(cs.DeleteEdges))
+	for _, id := range cs.DeleteNodes {
+		deleted[id] = true
+	}
+	for _, id := range cs.DeleteEdges {
+		deleted[id] = true
+	}
+
+	// Nodes after the change, and the edges before it that still have
+	// both ends, for the settings rules that compare before and after.
+	var after []dag.Node
+	at := make(map[string]int, len(nodes)+len(cs.AddNodes))
+	for _, n := range nodes {
+		if !deleted[n.ID] {
+			at[n.ID] = len(after)
+			after = append(after, n)
+		}
+	}
+	var before, afterEdges []dag.Edge
+	edgeAt := make(map[string]int, len(edges)+len(cs.AddEdges))
+	for _, e := range edges {
+		if _, ok := at[e.FromNodeID]; !ok {
+			continue
+		}
+		if _, ok := at[e.ToNodeID]; !ok {
+			continue
+		}
+		before = append(before, e)
+		if !deleted[e.ID] {
+			edgeAt[e.ID] = len(afterEdges)
+			afterEdges = append(afterEdges, e)
+		}
+	}
+
+	// sources are the nodes whose out-degree the change can affect.
+	sources := make(map[string]bool)
+	for i := range cs.UpdateNodes {
+		n := &cs.UpdateNodes[i]
+		j, ok := at[n.ID]
+		if !ok {
+			return fmt.Errorf("%w: %s", dag.ErrNodeNotFound, n.ID)
+		}
+		after[j].Data = n.Data
+		if n.Tags != nil {
+			after[j].Tags = n.Tags
+		}
+		sources[n.ID] = true
+	}
+	for i := range cs.AddNodes {
+		n := &cs.AddNodes[i]
+		at[n.ID] = len(after)
+		after = append(after, *n)
+		sources[n.ID] = true
+	}
+	for i := range cs.UpdateEdges {
+		e := &cs.UpdateEdges[i]
+		j, ok := edgeAt[e.ID]
+		if !ok {
+			return fmt.Errorf("%w: %s", dag.ErrEdgeNotFound, e.ID)
+		}
+		sources[afterEdges[j].FromNodeID] = true
+		afterEdges[j].FromNodeID, afterEdges[j].ToNodeID, afterEdges[j].Data = e.FromNodeID, e.ToNodeID, e.Data
+		sources[e.FromNodeID] = true
+	}
+	for i := range cs.AddEdges {
+		afterEdges = append(afterEdges, cs.AddEdges[i])
+		sources[cs.AddEdges[i].FromNodeID] = true
+	}
+
+	for _, list := range [][]dag.Node{cs.UpdateNodes, cs.AddNodes} {
+		for i := range list {
+			n := &list[i]
+			if err := q.CheckData(n.Data); err != nil {
+				return err
+			}
+			if err := s.schemas.CheckNode(n); err != nil {
+				return err
+			}
+			if err := s.nodeTypes.CheckNode(n); err != nil {
+				return err
+			}
+		}
+	}
+	for _, list := range [][]dag.Edge{cs.UpdateEdges, cs.AddEdges} {
+		for i := range list {
+			e := &list[i]
+			for _, id := range []string{e.FromNodeID, e.ToNodeID} {
+				if _, ok := at[id]; !ok {
+					return fmt.Errorf("%w: %s", dag.ErrNodeNotFound, id)
+				}
+			}
+			if err := q.CheckData(e.Data); err != nil {
+				return err
+			}
+			if err := s.schemas.CheckEdge(e); err != nil {
+				return err
+			}
+		}
+	}
+	if len(after) > len(nodes) {
+		if err := q.CheckNodes(len(after)); err != nil {
+			return err
+		}
+	}
+	if len(afterEdges) > len(edges) {
+		if err := q.CheckEdges(len(afterEdges)); err != nil {
+			return err
+		}
+	}
+
+	if err := dag.ValidateAcyclic(after, afterEdges); err != nil {
+		return err
+	}
+	if err := settings.CheckChange(after, before, afterEdges); err != nil {
+		return err
+	}
+	if settings.MaxDepth > 0 {
+		if n := dag.Depth(afterEdges); n > settings.MaxDepth {
+			return fmt.Errorf("%w: longest path would have %d edges, max is %d", dag.ErrTooDeep, n, settings.MaxDepth)
+		}
+	}
+	for id := range sources {
+		if err := s.nodeTypes.CheckEdges(after, afterEdges, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeChangeSet runs the statements for a validated change set.
+func (s *PGStore) writeChangeSet(ctx context.Context, tx pgx.Tx, dagID string, cs *dag.ChangeSet) error {
+	if len(cs.DeleteEdges) > 0 {
+		if _, err := tx.Exec(ctx, `DELETE FROM dag_edges WHERE dag_id = $1 AND id = ANY($2)`, dagID, cs.DeleteEdges); err != nil {
+			return fmt.Errorf("dag: delete edges: %w", err)
+		}
+	}
+	if len(cs.DeleteNodes) > 0 {
+		if _, err := tx.Exec(ctx, `DELETE FROM dag_nodes WHERE dag_id = $1 AND id = ANY($2)`, dagID, cs.DeleteNodes); err != nil {
+			return fmt.Errorf("dag: delete nodes: %w", err)
+		}
+	}
+
+	for i := range cs.UpdateNodes {
+		n := &cs.UpdateNodes[i]
+		p, err := s.packNode(ctx, tx, n.Data)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx,
+			`UPDATE dag_nodes SET data = $1, compressed = $4, blob_key = $5, data_hash = $6, tags = COALESCE($3, tags) WHERE dag_id = $7 AND id = $2`,
+			p.Data, n.ID, n.Tags, p.Compressed, p.BlobKey, p.DataHash, dagID,
+		); err != nil {
+			return fmt.Errorf("dag: update node %s: %w", n.ID, err)
+		}
+	}
+	for i := range cs.AddNodes {
+		n := &cs.AddNodes[i]
+		p, err := s.packNode(ctx, tx, n.Data)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO dag_nodes (id, dag_id, data, tags, compressed, blob_key, data_hash) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			n.ID, dagID, p.Data, nodeTags(n), p.Compressed, p.BlobKey, p.DataHash,
+		); err != nil {
+			return fmt.Errorf("dag: insert node %s: %w", n.ID, err)
+		}
+	}
+
+	// As in UpdateEdge, an edge moved to another source node goes last
+	// among its new siblings.
+	for i := range cs.UpdateEdges {
+		e := &cs.UpdateEdges[i]
+		data, packed := s.pack(e.Data)
+		err := tx.QueryRow(ctx, `
+			UPDATE dag_edges SET to_node_id = $2, data = $4, compressed = $5,
+				order_index = CASE WHEN from_node_id = $3 THEN order_index ELSE `+nextOrderIndex+` END,
+				from_node_id = $3
+			WHERE dag_id = $6 AND id = $1 RETURNING order_index`,
+			e.ID, e.ToNodeID, e.FromNodeID, data, packed, dagID,
+		).Scan(&e.OrderIndex)
+		if err != nil {
+			return fmt.Errorf("dag: update edge %s: %w", e.ID, parallelEdgeErr(err))
+		}
+	}
+	for i := range cs.AddEdges {
+		e := &cs.AddEdges[i]
+		data, packed := s.pack(e.Data)
+		err := tx.QueryRow(ctx,
+			`INSERT INTO dag_edges (id, dag_id, from_node_id, to_node_id, data, order_index, unique_pair, compressed)
+			VALUES ($1, $2, $3, $4, $5, `+nextOrderIndex+`, `+uniquePairOf+`, $6) RETURNING order_index`,
+			e.ID, dagID, e.FromNodeID, e.ToNodeID, data, packed,
+		).Scan(&e.OrderIndex)
+		if err != nil {
+			return fmt.Errorf("dag: insert edge %s: %w", e.ID, parallelEdgeErr(err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/meikuraledutech/dag"
+)
+
+// WithCompression stores node and edge data larger than threshold bytes
+// zstd-compressed, and sets the row's compressed flag. Reads decompress
+// transparently whether or not the option is set, so it can be turned on
+// and off at any time. Compressed data is opaque to SQL: ListOptions.Filter
+// and full-text search over node data do not see into it.
+func WithCompression(threshold int) Option {
+	return func(s *PGStore) { s.compressAbove = threshold }
+}
+
+// packedPrefix starts the JSON string that replaces compressed data:
+// "zstd:<base64 of the zstd frame>".
+const packedPrefix = `"zstd:`
+
+var zstdCodec = sync.OnceValues(func() (*zstd.Encoder, *zstd.Decoder) {
+	enc, _ := zstd.NewWriter(nil)
+	dec, _ := zstd.NewReader(nil)
+	return enc, dec
+})
+
+// pack returns data as it should be stored and whether it was compressed.
+// Data that already looks packed or like a blob reference is always
+// compressed, so every stored value with either prefix is one the store
+// wrote.
+func (s *PGStore) pack(data json.RawMessage) (json.RawMessage, bool) {
+	trimmed := bytes.TrimSpace(data)
+	if !isPacked(trimmed) && !IsBlobRef(trimmed) && (s.compressAbove <= 0 || len(data) <= s.compressAbove) {
+		return data, false
+	}
+	enc, _ := zstdCodec()
+	z := enc.EncodeAll(data, nil)
+	return json.RawMessage(packedPrefix + base64.StdEncoding.EncodeToString(z) + `"`), true
+}
+
+// unpack expands data in place if it was stored compressed.
+func unpack(data *json.RawMessage) error {
+	if !isPacked(*data) {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(*data, &s); err != nil {
+		return fmt.Errorf("dag: decompress data: %w", err)
+	}
+	z, err := base64.StdEncoding.DecodeString(s[len(packedPrefix)-1:])
+	if err != nil {
+		return fmt.Errorf("dag: decompress data: %w", err)
+	}
+	_, dec := zstdCodec()
+	plain, err := dec.DecodeAll(z, nil)
+	if err != nil {
+		return fmt.Errorf("dag: decompress data: %w", err)
+	}
+	*data = plain
+	return nil
+}
+
+func isPacked(data json.RawMessage) bool {
+	return bytes.HasPrefix(data, []byte(packedPrefix))
+}
+
+// unpackNodes expands the data of every node in place.
+func (s *PGStore) unpackNodes(ctx context.Context, nodes []dag.Node) error {
+	for i := range nodes {
+		if err := s.unpackNode(ctx, &nodes[i].Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unpackEdges expands the data of every edge in place.
+func unpackEdges(edges []dag.Edge) error {
+	for i := range edges {
+		if err := unpack(&edges[i].Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// DAGExists reports whether dagID has a metadata row or any nodes, the
+// same test CreateDAG uses for a taken ID.
+func (s *PGStore) DAGExists(ctx context.Context, dagID string) (bool, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	var exists bool
+	err := s.reader(ctx, dagID).QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM dags WHERE id = $1)
+			OR EXISTS (SELECT 1 FROM dag_nodes WHERE dag_id = $1)`, dagID,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("dag: check dag exists: %w", err)
+	}
+	return exists, nil
+}
+
+// CountDAGs returns the number of DAGs SearchDAGs would find for q without
+// its Limit.
+func (s *PGStore) CountDAGs(ctx context.Context, q dag.SearchQuery) (int, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	where, _ := searchFilter(q, arg)
+	sql := `SELECT COUNT(*) FROM dags d`
+	if len(where) > 0 {
+		sql += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	var n int
+	if err := s.reader(ctx, "").QueryRow(ctx, sql, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("dag: count dags: %w", err)
+	}
+	return n, nil
+}
+
+// CountNodes returns the number of nodes in dagID, read from its stats
+// (see DAGStats). Returns 0 for an unknown DAG.
+func (s *PGStore) CountNodes(ctx context.Context, dagID string) (int, error) {
+	return s.countStat(ctx, dagID, "node_count")
+}
+
+// CountEdges returns the number of edges in dagID, read from its stats.
+// Returns 0 for an unknown DAG.
+func (s *PGStore) CountEdges(ctx context.Context, dagID string) (int, error) {
+	return s.countStat(ctx, dagID, "edge_count")
+}
+
+func (s *PGStore) countStat(ctx context.Context, dagID, column string) (int, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	var n int
+	err := s.reader(ctx, dagID).QueryRow(ctx,
+		`SELECT COALESCE((SELECT `+column+` FROM dags WHERE id = $1), 0)`, dagID,
+	).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("dag: count %s: %w", strings.TrimSuffix(column, "_count")+"s", err)
+	}
+	return n, nil
+}
@@ -3,16 +3,26 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 
-	"github.com/meikuraledutech/dag"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/meikuraledutech/dag"
 )
 
 // CreateDAG saves a full DAG (nodes + edges) in one transaction.
 // Nodes/edges without IDs get auto-generated UUIDs.
 // Edge refs (FromNodeRef/ToNodeRef) are resolved to real node IDs.
-// Returns the DAG with all IDs filled in.
-func (s *PGStore) CreateDAG(ctx context.Context, d *dag.DAG) (*dag.DAG, error) {
+// Returns the DAG with all IDs filled in, or ErrDAGExists if a DAG with
+// that ID exists and opts do not set Replace. With DryRun set in opts it
+// writes nothing and returns the DAG as it would have been created.
+func (s *PGStore) CreateDAG(ctx context.Context, d *dag.DAG, opts ...dag.CreateDAGOptions) (*dag.DAG, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	var o dag.CreateDAGOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
 	// Build ref → UUID mapping and assign IDs to nodes.
 	refMap := make(map[string]string)
 	for i := range d.Nodes {
@@ -49,11 +59,44 @@ func (s *PGStore) CreateDAG(ctx context.Context, d *dag.DAG) (*dag.DAG, error) {
 		}
 	}
 
+	if err := s.quotaFor(ctx, d.ID).CheckDAG(d); err != nil {
+		return nil, err
+	}
+	if err := s.schemas.CheckDAG(d); err != nil {
+		return nil, err
+	}
+	if err := s.nodeTypes.CheckDAG(d); err != nil {
+		return nil, err
+	}
+
 	// Validate acyclic.
-	if err := validateAcyclic(d.Nodes, d.Edges); err != nil {
+	if err := dag.ValidateAcyclic(d.Nodes, d.Edges); err != nil {
+		return nil, err
+	}
+	if err := d.Settings.CheckGraph(d.Nodes, d.Edges); err != nil {
 		return nil, err
 	}
 
+	// Siblings are ordered as they appear in d.Edges.
+	next := make(map[string]int)
+	for i := range d.Edges {
+		e := &d.Edges[i]
+		e.OrderIndex = next[e.FromNodeID]
+		next[e.FromNodeID]++
+	}
+
+	if o.DryRun {
+		if !o.Replace {
+			if err := checkAbsent(ctx, s.db, d.ID); err != nil {
+				return nil, err
+			}
+		}
+		if err := checkMutable(ctx, s.db, d.ID); err != nil {
+			return nil, err
+		}
+		return created(d), nil
+	}
+
 	// Persist in a single transaction.
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
@@ -61,7 +104,19 @@ func (s *PGStore) CreateDAG(ctx context.Context, d *dag.DAG) (*dag.DAG, error) {
 	}
 	defer tx.Rollback(ctx)
 
-	// Delete existing DAG data if any (replace semantics).
+	if !o.Replace {
+		if err := claimDAGID(ctx, tx, d.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkMutable(ctx, tx, d.ID); err != nil {
+		return nil, err
+	}
+	if err := upsertDAGInfo(ctx, tx, d); err != nil {
+		return nil, err
+	}
+
+	// Delete existing DAG data if any (Replace).
 	if _, err := tx.Exec(ctx, `DELETE FROM dag_edges WHERE dag_id = $1`, d.ID); err != nil {
 		return nil, fmt.Errorf("dag: delete edges: %w", err)
 	}
@@ -71,9 +126,13 @@ func (s *PGStore) CreateDAG(ctx context.Context, d *dag.DAG) (*dag.DAG, error) {
 
 	// Insert nodes.
 	for _, n := range d.Nodes {
+		p, err := s.packNode(ctx, tx, n.Data)
+		if err != nil {
+			return nil, err
+		}
 		if _, err := tx.Exec(ctx,
-			`INSERT INTO dag_nodes (id, dag_id, data) VALUES ($1, $2, $3)`,
-			n.ID, d.ID, n.Data,
+			`INSERT INTO dag_nodes (id, dag_id, data, tags, compressed, blob_key, data_hash) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			n.ID, d.ID, p.Data, nodeTags(&n), p.Compressed, p.BlobKey, p.DataHash,
 		); err != nil {
 			return nil, fmt.Errorf("dag: insert node %s: %w", n.ID, err)
 		}
@@ -81,17 +140,33 @@ func (s *PGStore) CreateDAG(ctx context.Context, d *dag.DAG) (*dag.DAG, error) {
 
 	// Insert edges.
 	for _, e := range d.Edges {
+		data, packed := s.pack(e.Data)
 		if _, err := tx.Exec(ctx,
-			`INSERT INTO dag_edges (id, dag_id, from_node_id, to_node_id, data) VALUES ($1, $2, $3, $4, $5)`,
-			e.ID, d.ID, e.FromNodeID, e.ToNodeID, e.Data,
+			`INSERT INTO dag_edges (id, dag_id, from_node_id, to_node_id, data, order_index, unique_pair, compressed) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			e.ID, d.ID, e.FromNodeID, e.ToNodeID, data, e.OrderIndex, d.Settings.NoParallelEdges, packed,
 		); err != nil {
 			return nil, fmt.Errorf("dag: insert edge %s: %w", e.ID, err)
 		}
 	}
 
+	if err := s.recordVersion(ctx, tx, d.ID); err != nil {
+		return nil, err
+	}
+	if err := s.recordCreated(ctx, tx, d.ID); err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("dag: commit: %w", err)
 	}
+	return created(d), nil
+}
+
+// created fills in d as CreateDAG returns it.
+func created(d *dag.DAG) *dag.DAG {
+	if d.Status == "" {
+		d.Status = dag.StatusDraft
+	}
 
 	// Clear ref fields from response — they are not persisted.
 	for i := range d.Nodes {
@@ -101,128 +176,477 @@ func (s *PGStore) CreateDAG(ctx context.Context, d *dag.DAG) (*dag.DAG, error) {
 		d.Edges[i].FromNodeRef = ""
 		d.Edges[i].ToNodeRef = ""
 	}
+	d.Hash = dag.Hash(d)
+	return d
+}
+
+// GetDAG retrieves a full DAG (nodes + edges) by its ID, or the parts opts
+// ask for. Returns nil, nil if no nodes exist for the dagID.
+func (s *PGStore) GetDAG(ctx context.Context, dagID string, opts ...dag.GetDAGOptions) (*dag.DAG, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	o, err := getOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if o.Topological {
+		d, err := s.GetDAG(ctx, dagID, o.Unordered())
+		return o.Sorted(d), err
+	}
+	d := &dag.DAG{ID: dagID}
+	db := s.reader(ctx, dagID)
+
+	if o.SkipNodes {
+		var exists bool
+		if err := db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM dag_nodes WHERE dag_id = $1)`, dagID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("dag: check dag exists: %w", err)
+		}
+		if !exists {
+			return nil, nil
+		}
+		d.Nodes = []dag.Node{}
+	} else {
+		rows, err := db.Query(ctx,
+			`SELECT id, `+s.nodeColumn(o)+`, tags FROM dag_nodes WHERE dag_id = $1 ORDER BY created_at`, dagID)
+		if err != nil {
+			return nil, fmt.Errorf("dag: query nodes: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var n dag.Node
+			if err := rows.Scan(&n.ID, &n.Data, &n.Tags); err != nil {
+				return nil, fmt.Errorf("dag: scan node: %w", err)
+			}
+			if !o.SkipData {
+				if err := s.unpackNode(ctx, &n.Data); err != nil {
+					return nil, err
+				}
+				n.Data = o.Data(n.Data)
+			}
+			d.Nodes = append(d.Nodes, n)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("dag: rows nodes: %w", err)
+		}
+
+		if len(d.Nodes) == 0 {
+			return nil, nil
+		}
+	}
+
+	if err := db.QueryRow(ctx,
+		`SELECT name, tags, status, expires_at, settings, COALESCE(content_hash, '') FROM dags WHERE id = $1`, dagID,
+	).Scan(&d.Name, &d.Tags, &d.Status, &d.ExpiresAt, &d.Settings, &d.Hash); err != nil && !isNoRows(err) {
+		return nil, fmt.Errorf("dag: get dag info: %w", err)
+	}
+
+	if o.SkipEdges {
+		d.Edges = []dag.Edge{}
+		return d, nil
+	}
+	rows, err := db.Query(ctx,
+		`SELECT id, from_node_id, to_node_id, `+edgeColumn(o)+`, order_index FROM dag_edges WHERE dag_id = $1 ORDER BY order_index, created_at, id`, dagID)
+	if err != nil {
+		return nil, fmt.Errorf("dag: query edges: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e dag.Edge
+		if err := rows.Scan(&e.ID, &e.FromNodeID, &e.ToNodeID, &e.Data, &e.OrderIndex); err != nil {
+			return nil, fmt.Errorf("dag: scan edge: %w", err)
+		}
+		if err := unpack(&e.Data); err != nil {
+			return nil, err
+		}
+		e.Data = o.Data(e.Data)
+		d.Edges = append(d.Edges, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dag: rows edges: %w", err)
+	}
 
+	// The nodes were read before the dags row, so a write in between
+	// could make this hash stale; it is returned but not stored.
+	if d.Hash == "" && whole(o) {
+		d.Hash = dag.Hash(d)
+	}
 	return d, nil
 }
 
-// GetDAG retrieves a full DAG (nodes + edges) by its ID.
-// Returns nil, nil if no nodes exist for the dagID.
-func (s *PGStore) GetDAG(ctx context.Context, dagID string) (*dag.DAG, error) {
-	d := &dag.DAG{ID: dagID}
+// whole reports whether o loads the whole DAG, so dag.Hash of the result
+// is the DAG's hash.
+func whole(o dag.GetDAGOptions) bool {
+	return !o.SkipData && !o.SkipNodes && !o.SkipEdges && o.Fields == nil && o.Locale == nil
+}
+
+// contentHash computes dag.Hash of dagID from one snapshot, streaming the
+// rows in ID order so the DAG is never held in memory. Returns "" if the
+// DAG has no nodes.
+func (s *PGStore) contentHash(ctx context.Context, dagID string) (string, error) {
+	tx, err := beginSnapshot(ctx, s.reader(ctx, dagID))
+	if err != nil {
+		return "", fmt.Errorf("dag: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// COLLATE "C" sorts IDs by bytes, as dag.Hash does.
+	h := dag.NewHasher()
+	nodes := 0
+	if err := s.streamNodes(ctx, tx, dagID, `id COLLATE "C"`, func(n dag.Node) error {
+		nodes++
+		h.Node(n)
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	if nodes == 0 {
+		return "", nil
+	}
+	if err := streamEdges(ctx, tx, dagID, `id COLLATE "C"`, func(e dag.Edge) error {
+		h.Edge(e)
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	return h.Sum(), nil
+}
+
+// storeHash caches a DAG's content hash unless the DAG was written after
+// modifiedAt; like max_depth, the stats triggers clear it on every node or
+// edge write.
+func (s *PGStore) storeHash(ctx context.Context, dagID, hash string, modifiedAt time.Time) error {
+	_, err := s.db.Exec(ctx,
+		`UPDATE dags SET content_hash = $2 WHERE id = $1 AND content_hash IS NULL AND modified_at = $3`,
+		dagID, hash, modifiedAt)
+	if err != nil {
+		return fmt.Errorf("dag: store hash: %w", err)
+	}
+	return nil
+}
+
+// getOptions returns the options passed to GetDAG or GetDAGs, if any, or
+// ErrInvalidField if their Fields are invalid.
+func getOptions(opts []dag.GetDAGOptions) (dag.GetDAGOptions, error) {
+	if len(opts) == 0 {
+		return dag.GetDAGOptions{}, nil
+	}
+	return opts[0], dag.ValidateFields(opts[0].Fields)
+}
+
+// nodeColumn is the data column of dag_nodes as o asks for it: NULL under
+// SkipData, so neither the row's data nor dag_node_data is read, and
+// projected to o.Fields if set. With o.Locale the variants must be read
+// too, so projection is left to o.Data.
+func (s *PGStore) nodeColumn(o dag.GetDAGOptions) string {
+	if o.SkipData {
+		return `NULL::jsonb`
+	}
+	return projectSQL(nodeData("dag_nodes"), sqlFields(o))
+}
+
+// edgeColumn is nodeColumn for dag_edges.
+func edgeColumn(o dag.GetDAGOptions) string {
+	if o.SkipData {
+		return `NULL::jsonb`
+	}
+	return projectSQL(`data`, sqlFields(o))
+}
 
-	rows, err := s.db.Query(ctx,
-		`SELECT id, data FROM dag_nodes WHERE dag_id = $1 ORDER BY created_at`, dagID)
+// sqlFields returns the fields that can be projected in SQL under o.
+func sqlFields(o dag.GetDAGOptions) []string {
+	if len(o.Locale) > 0 {
+		return nil
+	}
+	return o.Fields
+}
+
+// GetDAGs retrieves several DAGs in three queries, one each for nodes,
+// metadata and edges, instead of three per DAG. The map holds each ID that
+// has nodes, as GetDAG would return it with opts; unknown IDs are left out.
+func (s *PGStore) GetDAGs(ctx context.Context, dagIDs []string, opts ...dag.GetDAGOptions) (map[string]*dag.DAG, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	o, err := getOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if o.Topological {
+		dags, err := s.GetDAGs(ctx, dagIDs, o.Unordered())
+		for _, d := range dags {
+			o.Sorted(d)
+		}
+		return dags, err
+	}
+	out := make(map[string]*dag.DAG, len(dagIDs))
+	if len(dagIDs) == 0 {
+		return out, nil
+	}
+	db := s.readerAll(ctx, dagIDs)
+
+	sql := `SELECT dag_id, id, ` + s.nodeColumn(o) + `, tags FROM dag_nodes WHERE dag_id = ANY($1) ORDER BY dag_id, created_at`
+	if o.SkipNodes {
+		sql = `SELECT DISTINCT dag_id, '', NULL::jsonb, '{}'::text[] FROM dag_nodes WHERE dag_id = ANY($1)`
+	}
+	rows, err := db.Query(ctx, sql, dagIDs)
 	if err != nil {
 		return nil, fmt.Errorf("dag: query nodes: %w", err)
 	}
 	defer rows.Close()
-
 	for rows.Next() {
+		var dagID string
 		var n dag.Node
-		if err := rows.Scan(&n.ID, &n.Data); err != nil {
+		if err := rows.Scan(&dagID, &n.ID, &n.Data, &n.Tags); err != nil {
 			return nil, fmt.Errorf("dag: scan node: %w", err)
 		}
+		d := out[dagID]
+		if d == nil {
+			d = &dag.DAG{ID: dagID, Nodes: []dag.Node{}}
+			out[dagID] = d
+		}
+		if o.SkipNodes {
+			continue
+		}
+		if !o.SkipData {
+			if err := s.unpackNode(ctx, &n.Data); err != nil {
+				return nil, err
+			}
+			n.Data = o.Data(n.Data)
+		}
 		d.Nodes = append(d.Nodes, n)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("dag: rows nodes: %w", err)
 	}
+	if len(out) == 0 {
+		return out, nil
+	}
+	found := make([]string, 0, len(out))
+	for id := range out {
+		found = append(found, id)
+	}
 
-	if len(d.Nodes) == 0 {
-		return nil, nil
+	rows, err = db.Query(ctx,
+		`SELECT id, name, tags, status, expires_at, settings, COALESCE(content_hash, '') FROM dags WHERE id = ANY($1)`, found)
+	if err != nil {
+		return nil, fmt.Errorf("dag: get dag info: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var info dag.DAG
+		if err := rows.Scan(&id, &info.Name, &info.Tags, &info.Status, &info.ExpiresAt, &info.Settings, &info.Hash); err != nil {
+			return nil, fmt.Errorf("dag: scan dag info: %w", err)
+		}
+		d := out[id]
+		d.Name, d.Tags, d.Status, d.ExpiresAt, d.Settings, d.Hash = info.Name, info.Tags, info.Status, info.ExpiresAt, info.Settings, info.Hash
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dag: rows dag info: %w", err)
 	}
 
-	rows, err = s.db.Query(ctx,
-		`SELECT id, from_node_id, to_node_id, data FROM dag_edges WHERE dag_id = $1 ORDER BY created_at`, dagID)
+	if o.SkipEdges {
+		for _, d := range out {
+			d.Edges = []dag.Edge{}
+		}
+		return out, nil
+	}
+	rows, err = db.Query(ctx,
+		`SELECT dag_id, id, from_node_id, to_node_id, `+edgeColumn(o)+`, order_index FROM dag_edges WHERE dag_id = ANY($1)
+		ORDER BY dag_id, order_index, created_at, id`, found)
 	if err != nil {
 		return nil, fmt.Errorf("dag: query edges: %w", err)
 	}
 	defer rows.Close()
-
 	for rows.Next() {
+		var dagID string
 		var e dag.Edge
-		if err := rows.Scan(&e.ID, &e.FromNodeID, &e.ToNodeID, &e.Data); err != nil {
+		if err := rows.Scan(&dagID, &e.ID, &e.FromNodeID, &e.ToNodeID, &e.Data, &e.OrderIndex); err != nil {
 			return nil, fmt.Errorf("dag: scan edge: %w", err)
 		}
-		d.Edges = append(d.Edges, e)
+		if err := unpack(&e.Data); err != nil {
+			return nil, err
+		}
+		e.Data = o.Data(e.Data)
+		out[dagID].Edges = append(out[dagID].Edges, e)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("dag: rows edges: %w", err)
 	}
 
-	return d, nil
+	// Hashes missing from dags are computed but not cached; GetDAG and
+	// GetDAGInfo cache them.
+	if whole(o) {
+		for _, d := range out {
+			if d.Hash == "" {
+				d.Hash = dag.Hash(d)
+			}
+		}
+	}
+	return out, nil
 }
 
-// DeleteDAG removes all nodes and edges for a dagID.
-// No error if the dagID doesn't exist.
-func (s *PGStore) DeleteDAG(ctx context.Context, dagID string) error {
-	tx, err := s.db.Begin(ctx)
+// StreamDAG reads a DAG's nodes and then its edges in one read-only snapshot,
+// calling onNode / onEdge for each row as it is scanned instead of building
+// a *dag.DAG in memory. Rows arrive in the same order as GetDAG.
+// A nil callback skips its query. A non-nil error from a callback stops the
+// scan and is returned as-is. An unknown DAG streams nothing.
+func (s *PGStore) StreamDAG(ctx context.Context, dagID string, onNode func(dag.Node) error, onEdge func(dag.Edge) error) error {
+	tx, err := beginSnapshot(ctx, s.reader(ctx, dagID))
 	if err != nil {
 		return fmt.Errorf("dag: begin tx: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	if _, err := tx.Exec(ctx, `DELETE FROM dag_edges WHERE dag_id = $1`, dagID); err != nil {
-		return fmt.Errorf("dag: delete edges: %w", err)
+	if onNode != nil {
+		if err := s.streamNodes(ctx, tx, dagID, `created_at`, onNode); err != nil {
+			return err
+		}
 	}
-	if _, err := tx.Exec(ctx, `DELETE FROM dag_nodes WHERE dag_id = $1`, dagID); err != nil {
-		return fmt.Errorf("dag: delete nodes: %w", err)
+	if onEdge == nil {
+		return nil
 	}
-
-	return tx.Commit(ctx)
+	return streamEdges(ctx, tx, dagID, `order_index, created_at, id`, onEdge)
 }
 
-// validateAcyclic checks that the edges don't form a cycle using DFS.
-func validateAcyclic(nodes []dag.Node, edges []dag.Edge) error {
-	adj := make(map[string][]string)
-	for _, e := range edges {
-		adj[e.FromNodeID] = append(adj[e.FromNodeID], e.ToNodeID)
+// streamEdges calls onEdge for each edge of dagID, sorted by order.
+func streamEdges(ctx context.Context, tx pgx.Tx, dagID, order string, onEdge func(dag.Edge) error) error {
+	rows, err := tx.Query(ctx,
+		`SELECT id, from_node_id, to_node_id, data, order_index FROM dag_edges WHERE dag_id = $1 ORDER BY `+order, dagID)
+	if err != nil {
+		return fmt.Errorf("dag: query edges: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e dag.Edge
+		if err := rows.Scan(&e.ID, &e.FromNodeID, &e.ToNodeID, &e.Data, &e.OrderIndex); err != nil {
+			return fmt.Errorf("dag: scan edge: %w", err)
+		}
+		if err := unpack(&e.Data); err != nil {
+			return err
+		}
+		if err := onEdge(e); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("dag: rows edges: %w", err)
 	}
 
-	const (
-		unvisited = 0
-		visiting  = 1
-		visited   = 2
-	)
+	return nil
+}
 
-	state := make(map[string]int)
-	for _, n := range nodes {
-		state[n.ID] = unvisited
+// streamNodes calls onNode for each node of dagID, sorted by order.
+func (s *PGStore) streamNodes(ctx context.Context, tx pgx.Tx, dagID, order string, onNode func(dag.Node) error) error {
+	rows, err := tx.Query(ctx,
+		`SELECT id, `+nodeData("dag_nodes")+`, tags FROM dag_nodes WHERE dag_id = $1 ORDER BY `+order, dagID)
+	if err != nil {
+		return fmt.Errorf("dag: query nodes: %w", err)
 	}
-	// Also include nodes referenced only in edges.
-	for _, e := range edges {
-		if _, ok := state[e.FromNodeID]; !ok {
-			state[e.FromNodeID] = unvisited
+	defer rows.Close()
+	for rows.Next() {
+		var n dag.Node
+		if err := rows.Scan(&n.ID, &n.Data, &n.Tags); err != nil {
+			return fmt.Errorf("dag: scan node: %w", err)
+		}
+		if err := s.unpackNode(ctx, &n.Data); err != nil {
+			return err
 		}
-		if _, ok := state[e.ToNodeID]; !ok {
-			state[e.ToNodeID] = unvisited
+		if err := onNode(n); err != nil {
+			return err
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("dag: rows nodes: %w", err)
+	}
+	return nil
+}
 
-	var dfs func(id string) bool
-	dfs = func(id string) bool {
-		state[id] = visiting
-		for _, next := range adj[id] {
-			switch state[next] {
-			case visiting:
-				return true
-			case unvisited:
-				if dfs(next) {
-					return true
-				}
-			}
-		}
-		state[id] = visited
-		return false
+// DAGFingerprint returns a hash of a DAG's nodes and edges computed in the
+// database, so callers can detect changes without loading the DAG.
+// Returns "" if no nodes exist for the dagID.
+func (s *PGStore) DAGFingerprint(ctx context.Context, dagID string) (string, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	var fp *string
+	err := s.db.QueryRow(ctx, `
+		SELECT CASE WHEN EXISTS (SELECT 1 FROM dag_nodes WHERE dag_id = $1) THEN md5(
+			(SELECT COALESCE(string_agg(id || ':' || data::text || ':' || COALESCE(data_hash, '') || ':' || array_to_string(tags, ','), ',' ORDER BY created_at, id), '')
+			   FROM dag_nodes WHERE dag_id = $1)
+			|| '|' ||
+			(SELECT COALESCE(string_agg(id || ':' || from_node_id || ':' || to_node_id || ':' || data::text || ':' || order_index, ',' ORDER BY created_at, id), '')
+			   FROM dag_edges WHERE dag_id = $1)
+			|| '|' ||
+			COALESCE((SELECT name || ':' || array_to_string(tags, ',') || ':' || status || ':' || COALESCE(expires_at::text, '') || ':' || settings::text FROM dags WHERE id = $1), '')
+		) END`, dagID,
+	).Scan(&fp)
+	if err != nil {
+		return "", fmt.Errorf("dag: fingerprint: %w", err)
+	}
+	if fp == nil {
+		return "", nil
 	}
+	return *fp, nil
+}
 
-	for id, s := range state {
-		if s == unvisited {
-			if dfs(id) {
-				return dag.ErrCycleDetected
-			}
-		}
+// DeleteDAG removes all nodes, edges and metadata for a dagID.
+// No error if the dagID doesn't exist. Published DAGs return ErrDAGFrozen;
+// archive them first.
+func (s *PGStore) DeleteDAG(ctx context.Context, dagID string) error {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("dag: begin tx: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	return nil
+	var status dag.Status
+	err = tx.QueryRow(ctx, `SELECT status FROM dags WHERE id = $1 FOR UPDATE`, dagID).Scan(&status)
+	if err != nil && !isNoRows(err) {
+		return fmt.Errorf("dag: get status: %w", err)
+	}
+	if status == dag.StatusPublished {
+		return fmt.Errorf("%w: %s is published", dag.ErrDAGFrozen, dagID)
+	}
+	if err := s.onTx(tx).checkPrecondition(ctx); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM dag_edges WHERE dag_id = $1`, dagID); err != nil {
+		return fmt.Errorf("dag: delete edges: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM dag_nodes WHERE dag_id = $1`, dagID); err != nil {
+		return fmt.Errorf("dag: delete nodes: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM dags WHERE id = $1`, dagID); err != nil {
+		return fmt.Errorf("dag: delete dag info: %w", err)
+	}
+	if err := s.recordVersion(ctx, tx, dagID); err != nil {
+		return err
+	}
+	if err := s.recordEvent(ctx, tx, dagID, dag.Event{Type: dag.EventDeleted}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// beginSnapshot starts a read-only repeatable-read transaction on db. On a
+// pgx.Tx it opens a savepoint instead, which reads in the caller's
+// transaction.
+func beginSnapshot(ctx context.Context, db DB) (pgx.Tx, error) {
+	return beginTx(ctx, db, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+}
+
+// beginTx starts a transaction with opts if db supports them, and a plain
+// one otherwise.
+func beginTx(ctx context.Context, db DB, opts pgx.TxOptions) (pgx.Tx, error) {
+	if b, ok := db.(interface {
+		BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)
+	}); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return db.Begin(ctx)
 }
@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// WithDedup stores node data larger than threshold bytes once per distinct
+// content in dag_node_data, keyed by its SHA-256, and points nodes at it
+// through dag_nodes.data_hash. Cloned DAGs, drafts and nodes built from
+// the same template then share one copy of each payload.
+//
+// Data is hashed byte for byte as written, so the same JSON formatted
+// differently is stored twice. Rows no node references any more are
+// removed by PruneNodeData.
+func WithDedup(threshold int) Option {
+	return func(s *PGStore) {
+		s.dedup = true
+		s.dedupAbove = threshold
+	}
+}
+
+// nodeData is the SQL for a node's data, read through dag_node_data when
+// the row is deduplicated. table is the name or alias of dag_nodes.
+func nodeData(table string) string {
+	return `COALESCE((SELECT c.data FROM dag_node_data c WHERE c.hash = ` + table + `.data_hash), ` + table + `.data)`
+}
+
+// dedupNode stores data in dag_node_data if WithDedup applies to it and
+// returns the row for the node, with an empty data object and the hash set.
+// ok is false if the data should be stored in the node row. The upsert
+// locks an existing row until the write commits, so a concurrent
+// PruneNodeData can't remove it in between.
+func (s *PGStore) dedupNode(ctx context.Context, db execer, data json.RawMessage) (stored storedData, ok bool, err error) {
+	if !s.dedup || len(data) <= s.dedupAbove {
+		return storedData{}, false, nil
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	packed, compressed := s.pack(data)
+	if _, err := db.Exec(ctx, `
+		INSERT INTO dag_node_data (hash, data, compressed) VALUES ($1, $2, $3)
+		ON CONFLICT (hash) DO UPDATE SET hash = EXCLUDED.hash`, hash, packed, compressed,
+	); err != nil {
+		return storedData{}, false, fmt.Errorf("dag: store node data: %w", err)
+	}
+	return storedData{Data: json.RawMessage(`{}`), DataHash: &hash}, true, nil
+}
+
+// PruneNodeData deletes dag_node_data rows that no node references and
+// returns how many were deleted. Run it periodically when WithDedup is on.
+func (s *PGStore) PruneNodeData(ctx context.Context) (int64, error) {
+	ct, err := s.db.Exec(ctx, `
+		DELETE FROM dag_node_data c
+		WHERE NOT EXISTS (SELECT 1 FROM dag_nodes n WHERE n.data_hash = c.hash)`)
+	if err != nil {
+		return 0, fmt.Errorf("dag: prune node data: %w", err)
+	}
+	return ct.RowsAffected(), nil
+}
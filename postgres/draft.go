@@ -0,0 +1,201 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/meikuraledutech/dag"
+)
+
+// CreateDraft copies the DAG into dag.DraftID(dagID), where it can be
+// edited while the live DAG keeps serving — even a published one. Node and
+// edge IDs get dag.DraftSuffix appended. If the draft already exists it is
+// returned unchanged. Returns ErrDAGNotFound if the live DAG has no nodes.
+func (s *PGStore) CreateDraft(ctx context.Context, dagID string) (*dag.DAG, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	draftID := dag.DraftID(dagID)
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("dag: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the live row so a concurrent PromoteDraft can't interleave.
+	var exists bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM dags WHERE id = $2)
+		FROM dags WHERE id = $1 AND EXISTS (SELECT 1 FROM dag_nodes WHERE dag_id = $1)
+		FOR UPDATE`, dagID, draftID,
+	).Scan(&exists)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, dag.ErrDAGNotFound
+		}
+		return nil, fmt.Errorf("dag: find dag: %w", err)
+	}
+
+	if !exists {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO dags (id, name, tags, settings, draft_of)
+			SELECT $2, name, tags, settings, id FROM dags WHERE id = $1`, dagID, draftID,
+		); err != nil {
+			return nil, fmt.Errorf("dag: create draft: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO dag_nodes (id, dag_id, data, tags, compressed, blob_key, data_hash, created_at, updated_at)
+			SELECT id || $3, $2, data, tags, compressed, blob_key, data_hash, created_at, updated_at FROM dag_nodes WHERE dag_id = $1`,
+			dagID, draftID, dag.DraftSuffix,
+		); err != nil {
+			return nil, fmt.Errorf("dag: copy nodes: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO dag_edges (id, dag_id, from_node_id, to_node_id, data, order_index, unique_pair, compressed, created_at, updated_at)
+			SELECT id || $3, $2, from_node_id || $3, to_node_id || $3, data, order_index, unique_pair, compressed, created_at, updated_at
+			FROM dag_edges WHERE dag_id = $1`,
+			dagID, draftID, dag.DraftSuffix,
+		); err != nil {
+			return nil, fmt.Errorf("dag: copy edges: %w", err)
+		}
+		if err := s.recordVersion(ctx, tx, draftID); err != nil {
+			return nil, err
+		}
+		if err := s.recordCreated(ctx, tx, draftID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("dag: commit: %w", err)
+	}
+	return s.GetDAG(Primary(ctx), draftID)
+}
+
+// PromoteDraft atomically replaces the live DAG's nodes, edges, name and
+// tags with those of its draft, then deletes the draft. IDs lose
+// dag.DraftSuffix, so sessions holding node IDs from before the draft still
+// resolve. The live DAG keeps its status: promoting into a published DAG is
+// how published flows change. Returns ErrDAGNotFound if there is no draft
+// or it has no nodes, and ErrDAGFrozen if the live DAG is archived.
+func (s *PGStore) PromoteDraft(ctx context.Context, dagID string) (*dag.DAG, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	draftID := dag.DraftID(dagID)
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("dag: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var status dag.Status
+	err = tx.QueryRow(ctx, `
+		SELECT l.status FROM dags l JOIN dags d ON d.id = $2 AND d.draft_of = l.id
+		WHERE l.id = $1 FOR UPDATE`, dagID, draftID,
+	).Scan(&status)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, fmt.Errorf("%w: no draft of %s", dag.ErrDAGNotFound, dagID)
+		}
+		return nil, fmt.Errorf("dag: find draft: %w", err)
+	}
+	if status == dag.StatusArchived {
+		return nil, fmt.Errorf("%w: %s is archived", dag.ErrDAGFrozen, dagID)
+	}
+	// An emptied draft would leave the live ID serving nothing.
+	if n, err := s.countNodes(ctx, draftID); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, fmt.Errorf("%w: draft of %s has no nodes", dag.ErrDAGNotFound, dagID)
+	}
+
+	// Edges reference node IDs, so they are lifted out before the nodes are
+	// renamed and put back afterwards.
+	edges, err := collectEdges(ctx, tx, draftID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM dag_edges WHERE dag_id = $1 OR dag_id = $2`, dagID, draftID); err != nil {
+		return nil, fmt.Errorf("dag: delete edges: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM dag_nodes WHERE dag_id = $1`, dagID); err != nil {
+		return nil, fmt.Errorf("dag: delete nodes: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE dag_nodes SET dag_id = $1,
+			id = CASE WHEN right(id, length($3)) = $3 THEN left(id, -length($3)) ELSE id END
+		WHERE dag_id = $2`, dagID, draftID, dag.DraftSuffix,
+	); err != nil {
+		return nil, fmt.Errorf("dag: promote nodes: %w", err)
+	}
+	for _, e := range edges {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO dag_edges (id, dag_id, from_node_id, to_node_id, data, order_index, compressed, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			liveID(e.ID), dagID, liveID(e.FromNodeID), liveID(e.ToNodeID), e.Data, e.OrderIndex, isPacked(e.Data), e.CreatedAt, e.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("dag: promote edge %s: %w", e.ID, err)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE dags l SET name = d.name, tags = d.tags, settings = d.settings, updated_at = NOW()
+		FROM dags d WHERE l.id = $1 AND d.id = $2`, dagID, draftID,
+	); err != nil {
+		return nil, fmt.Errorf("dag: promote dag info: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM dags WHERE id = $1`, draftID); err != nil {
+		return nil, fmt.Errorf("dag: delete draft: %w", err)
+	}
+	if err := syncUniquePair(ctx, tx, dagID); err != nil {
+		return nil, err
+	}
+	if err := s.recordVersion(ctx, tx, dagID); err != nil {
+		return nil, err
+	}
+	if err := s.recordVersion(ctx, tx, draftID); err != nil {
+		return nil, err
+	}
+	if err := s.recordCreated(ctx, tx, dagID); err != nil {
+		return nil, err
+	}
+	if err := s.recordEvent(ctx, tx, draftID, dag.Event{Type: dag.EventDeleted}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("dag: commit: %w", err)
+	}
+	return s.GetDAG(Primary(ctx), dagID)
+}
+
+// liveID strips dag.DraftSuffix from a draft node or edge ID.
+func liveID(id string) string {
+	return strings.TrimSuffix(id, dag.DraftSuffix)
+}
+
+// collectEdges reads every edge row of a DAG, including its timestamps.
+func collectEdges(ctx context.Context, tx pgx.Tx, dagID string) ([]dumpRow, error) {
+	rows, err := tx.Query(ctx,
+		`SELECT id, dag_id, from_node_id, to_node_id, data, order_index, created_at, updated_at FROM dag_edges WHERE dag_id = $1`, dagID)
+	if err != nil {
+		return nil, fmt.Errorf("dag: query edges: %w", err)
+	}
+	defer rows.Close()
+
+	var edges []dumpRow
+	for rows.Next() {
+		var e dumpRow
+		if err := rows.Scan(&e.ID, &e.DAGID, &e.FromNodeID, &e.ToNodeID, &e.Data, &e.OrderIndex, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("dag: scan edge: %w", err)
+		}
+		edges = append(edges, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dag: rows edges: %w", err)
+	}
+	return edges, nil
+}
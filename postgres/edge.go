@@ -2,10 +2,16 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
-	"github.com/meikuraledutech/dag"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/meikuraledutech/dag"
 )
 
 // AddEdge inserts a single edge into a DAG.
@@ -13,44 +19,88 @@ import (
 // Validates that adding this edge does not create a cycle.
 // Returns the edge ID (generated or provided).
 func (s *PGStore) AddEdge(ctx context.Context, dagID string, edge *dag.Edge) (string, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
 	if edge.ID == "" {
 		edge.ID = uuid.NewString()
 	}
+	if err := s.inWriteTx(ctx, func(s *PGStore) error { return s.addEdge(ctx, dagID, edge) }); err != nil {
+		return "", err
+	}
+	return edge.ID, nil
+}
+
+// addEdge is AddEdge on s.db, with edge.ID set. s.db must be a transaction:
+// the graph is read and checked under the DAG's lock.
+func (s *PGStore) addEdge(ctx context.Context, dagID string, edge *dag.Edge) error {
+	settings, err := s.lockDAG(ctx, dagID)
+	if err != nil {
+		return err
+	}
 
 	// Fetch existing edges + nodes for cycle detection.
-	nodes, err := s.ListNodes(ctx, dagID)
+	nodes, err := s.ListNodes(s.graphCtx(ctx), dagID)
 	if err != nil {
-		return "", err
+		return err
 	}
-	edges, err := s.ListEdges(ctx, dagID)
+	edges, err := s.ListEdges(Primary(ctx), dagID)
 	if err != nil {
-		return "", err
+		return err
+	}
+
+	if err := checkEndpoints(nodes, edge); err != nil {
+		return err
+	}
+	q := s.quotaFor(ctx, dagID)
+	if err := q.CheckData(edge.Data); err != nil {
+		return err
+	}
+	if err := s.schemas.CheckEdge(edge); err != nil {
+		return err
+	}
+	if err := q.CheckEdges(len(edges) + 1); err != nil {
+		return err
 	}
 
 	// Append the new edge and validate.
-	edges = append(edges, *edge)
-	if err := validateAcyclic(nodes, edges); err != nil {
-		return "", err
+	after := append(edges, *edge)
+	if err := dag.ValidateAcyclic(nodes, after); err != nil {
+		return err
+	}
+	if err := settings.CheckChange(nodes, edges, after); err != nil {
+		return err
+	}
+	if err := s.nodeTypes.CheckEdges(nodes, after, edge.FromNodeID); err != nil {
+		return err
+	}
+	if err := checkDepth(ctx, s.db, dagID, settings, edge.FromNodeID, edge.ToNodeID, ""); err != nil {
+		return err
 	}
 
-	_, err = s.db.Exec(ctx,
-		`INSERT INTO dag_edges (id, dag_id, from_node_id, to_node_id, data) VALUES ($1, $2, $3, $4, $5)`,
-		edge.ID, dagID, edge.FromNodeID, edge.ToNodeID, edge.Data,
-	)
+	data, packed := s.pack(edge.Data)
+	err = s.db.QueryRow(ctx,
+		`INSERT INTO dag_edges (id, dag_id, from_node_id, to_node_id, data, order_index, unique_pair, compressed)
+		VALUES ($1, $2, $3, $4, $5, `+nextOrderIndex+`, `+uniquePairOf+`, $6) RETURNING order_index`,
+		edge.ID, dagID, edge.FromNodeID, edge.ToNodeID, data, packed,
+	).Scan(&edge.OrderIndex)
 	if err != nil {
-		return "", fmt.Errorf("dag: insert edge: %w", err)
+		return fmt.Errorf("dag: insert edge: %w", parallelEdgeErr(err))
 	}
-
-	return edge.ID, nil
+	if err := s.recordVersion(ctx, s.db, dagID); err != nil {
+		return err
+	}
+	return s.recordChanged(ctx, s.db, dagID, &dag.ChangeSet{AddEdges: []dag.Edge{*edge}})
 }
 
 // GetEdge fetches a single edge by its ID.
 // Returns nil, nil if not found.
 func (s *PGStore) GetEdge(ctx context.Context, edgeID string) (*dag.Edge, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
 	var e dag.Edge
-	err := s.db.QueryRow(ctx,
-		`SELECT id, from_node_id, to_node_id, data FROM dag_edges WHERE id = $1`, edgeID,
-	).Scan(&e.ID, &e.FromNodeID, &e.ToNodeID, &e.Data)
+	err := s.reader(ctx, "").QueryRow(ctx,
+		`SELECT id, from_node_id, to_node_id, data, order_index FROM dag_edges WHERE `+s.idMatch("dag_edges", "dag_edges", "$1"), edgeID,
+	).Scan(&e.ID, &e.FromNodeID, &e.ToNodeID, &e.Data, &e.OrderIndex)
 
 	if err != nil {
 		if isNoRows(err) {
@@ -58,77 +108,154 @@ func (s *PGStore) GetEdge(ctx context.Context, edgeID string) (*dag.Edge, error)
 		}
 		return nil, fmt.Errorf("dag: get edge: %w", err)
 	}
+	if err := unpack(&e.Data); err != nil {
+		return nil, err
+	}
 
 	return &e, nil
 }
 
+// checkEndpoints returns ErrNodeNotFound if e leaves or enters a node that
+// is not one of nodes, such as a node of another DAG.
+func checkEndpoints(nodes []dag.Node, e *dag.Edge) error {
+	for _, id := range []string{e.FromNodeID, e.ToNodeID} {
+		if !slices.ContainsFunc(nodes, func(n dag.Node) bool { return n.ID == id }) {
+			return fmt.Errorf("%w: %s", dag.ErrNodeNotFound, id)
+		}
+	}
+	return nil
+}
+
+// nextOrderIndex is the SQL for the order_index of a new last edge leaving
+// the node in parameter $3.
+const nextOrderIndex = `COALESCE((SELECT MAX(order_index) + 1 FROM dag_edges WHERE from_node_id = $3), 0)`
+
 // UpdateEdge updates an existing edge's from_node_id, to_node_id, and data.
 // Validates that the update does not create a cycle.
 // Returns ErrEdgeNotFound if the edge doesn't exist.
 func (s *PGStore) UpdateEdge(ctx context.Context, edge *dag.Edge) error {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	return s.inWriteTx(ctx, func(s *PGStore) error { return s.updateEdge(ctx, edge) })
+}
+
+// updateEdge is UpdateEdge on s.db. s.db must be a transaction: the graph
+// is read and checked under the DAG's lock.
+func (s *PGStore) updateEdge(ctx context.Context, edge *dag.Edge) error {
 	// First find the edge's dag_id.
-	var dagID string
-	err := s.db.QueryRow(ctx,
-		`SELECT dag_id FROM dag_edges WHERE id = $1`, edge.ID,
-	).Scan(&dagID)
+	dagID, found, err := s.mutableDAGOf(ctx, s.db, "dag_edges", edge.ID)
 	if err != nil {
-		if isNoRows(err) {
-			return dag.ErrEdgeNotFound
-		}
-		return fmt.Errorf("dag: find edge: %w", err)
+		return err
+	}
+	if !found {
+		return dag.ErrEdgeNotFound
+	}
+	settings, err := s.lockDAG(ctx, dagID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.quotaFor(ctx, dagID).CheckData(edge.Data); err != nil {
+		return err
+	}
+	if err := s.schemas.CheckEdge(edge); err != nil {
+		return err
 	}
 
 	// Fetch existing data for cycle detection.
-	nodes, err := s.ListNodes(ctx, dagID)
+	nodes, err := s.ListNodes(s.graphCtx(ctx), dagID)
 	if err != nil {
 		return err
 	}
-	existingEdges, err := s.ListEdges(ctx, dagID)
+	existingEdges, err := s.ListEdges(Primary(ctx), dagID)
 	if err != nil {
 		return err
 	}
+	if err := checkEndpoints(nodes, edge); err != nil {
+		return err
+	}
 
-	// Replace the updated edge in the list.
-	for i, e := range existingEdges {
+	// Replace the updated edge in a copy of the list.
+	updated := slices.Clone(existingEdges)
+	for i, e := range updated {
 		if e.ID == edge.ID {
-			existingEdges[i].FromNodeID = edge.FromNodeID
-			existingEdges[i].ToNodeID = edge.ToNodeID
+			updated[i].FromNodeID = edge.FromNodeID
+			updated[i].ToNodeID = edge.ToNodeID
+			updated[i].Data = edge.Data
 			break
 		}
 	}
 
-	if err := validateAcyclic(nodes, existingEdges); err != nil {
+	if err := dag.ValidateAcyclic(nodes, updated); err != nil {
+		return err
+	}
+	if err := settings.CheckChange(nodes, existingEdges, updated); err != nil {
+		return err
+	}
+	if err := s.nodeTypes.CheckEdges(nodes, updated, edge.FromNodeID); err != nil {
+		return err
+	}
+	if err := checkDepth(ctx, s.db, dagID, settings, edge.FromNodeID, edge.ToNodeID, edge.ID); err != nil {
 		return err
 	}
 
-	ct, err := s.db.Exec(ctx,
-		`UPDATE dag_edges SET from_node_id = $1, to_node_id = $2, data = $3 WHERE id = $4`,
-		edge.FromNodeID, edge.ToNodeID, edge.Data, edge.ID,
-	)
+	// An edge moved to another source node goes last among its new siblings.
+	data, packed := s.pack(edge.Data)
+	err = s.db.QueryRow(ctx, `
+		UPDATE dag_edges SET to_node_id = $2, data = $4, compressed = $5,
+			order_index = CASE WHEN from_node_id = $3 THEN order_index ELSE `+nextOrderIndex+` END,
+			from_node_id = $3
+		WHERE `+s.idMatch("dag_edges", "dag_edges", "$1")+` RETURNING order_index`,
+		edge.ID, edge.ToNodeID, edge.FromNodeID, data, packed,
+	).Scan(&edge.OrderIndex)
 	if err != nil {
-		return fmt.Errorf("dag: update edge: %w", err)
+		if isNoRows(err) {
+			return dag.ErrEdgeNotFound
+		}
+		return fmt.Errorf("dag: update edge: %w", parallelEdgeErr(err))
 	}
-	if ct.RowsAffected() == 0 {
-		return dag.ErrEdgeNotFound
+	if err := s.recordVersion(ctx, s.db, dagID); err != nil {
+		return err
 	}
-	return nil
+	return s.recordChanged(ctx, s.db, dagID, &dag.ChangeSet{UpdateEdges: []dag.Edge{*edge}})
 }
 
 // DeleteEdge deletes an edge by its ID.
 // No error if the edge doesn't exist.
 func (s *PGStore) DeleteEdge(ctx context.Context, edgeID string) error {
-	_, err := s.db.Exec(ctx, `DELETE FROM dag_edges WHERE id = $1`, edgeID)
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	return s.inWriteTx(ctx, func(s *PGStore) error { return s.deleteEdge(ctx, edgeID) })
+}
+
+// deleteEdge is DeleteEdge on s.db, which must be a transaction.
+func (s *PGStore) deleteEdge(ctx context.Context, edgeID string) error {
+	if err := s.lockOwner(ctx, "dag_edges", edgeID); err != nil {
+		return err
+	}
+
+	var dagID string
+	err := s.db.QueryRow(ctx, `DELETE FROM dag_edges WHERE `+s.idMatch("dag_edges", "dag_edges", "$1")+` RETURNING dag_id`, edgeID).Scan(&dagID)
 	if err != nil {
+		if isNoRows(err) {
+			return nil
+		}
 		return fmt.Errorf("dag: delete edge: %w", err)
 	}
-	return nil
+	if err := s.recordVersion(ctx, s.db, dagID); err != nil {
+		return err
+	}
+	return s.recordChanged(ctx, s.db, dagID, &dag.ChangeSet{DeleteEdges: []string{edgeID}})
 }
 
-// ListEdges returns all edges for a dagID, ordered by created_at.
+// ListEdges returns all edges for a dagID, ordered by order_index, then
+// created_at, so each node's outgoing edges come out in author order.
 // Returns an empty slice (not nil) if none found.
 func (s *PGStore) ListEdges(ctx context.Context, dagID string) ([]dag.Edge, error) {
-	rows, err := s.db.Query(ctx,
-		`SELECT id, from_node_id, to_node_id, data FROM dag_edges WHERE dag_id = $1 ORDER BY created_at`, dagID)
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	rows, err := s.reader(ctx, dagID).Query(ctx,
+		`SELECT id, from_node_id, to_node_id, data, order_index FROM dag_edges WHERE dag_id = $1 ORDER BY order_index, created_at, id`, dagID)
 	if err != nil {
 		return nil, fmt.Errorf("dag: list edges: %w", err)
 	}
@@ -137,9 +264,12 @@ func (s *PGStore) ListEdges(ctx context.Context, dagID string) ([]dag.Edge, erro
 	edges := []dag.Edge{}
 	for rows.Next() {
 		var e dag.Edge
-		if err := rows.Scan(&e.ID, &e.FromNodeID, &e.ToNodeID, &e.Data); err != nil {
+		if err := rows.Scan(&e.ID, &e.FromNodeID, &e.ToNodeID, &e.Data, &e.OrderIndex); err != nil {
 			return nil, fmt.Errorf("dag: scan edge: %w", err)
 		}
+		if err := unpack(&e.Data); err != nil {
+			return nil, err
+		}
 		edges = append(edges, e)
 	}
 	if err := rows.Err(); err != nil {
@@ -148,3 +278,137 @@ func (s *PGStore) ListEdges(ctx context.Context, dagID string) ([]dag.Edge, erro
 
 	return edges, nil
 }
+
+// FindEdges returns the edges of a DAG that match filter, in ListEdges
+// order. Contains and Match are answered by the GIN index on data.
+// Returns ErrInvalidFilter if Contains is not JSON or Match is not a
+// JSON path, and an empty slice (not nil) if none match.
+func (s *PGStore) FindEdges(ctx context.Context, dagID string, filter dag.EdgeFilter) ([]dag.Edge, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	if filter.Contains != nil && !json.Valid(filter.Contains) {
+		return nil, fmt.Errorf("%w: contains is not valid JSON", dag.ErrInvalidFilter)
+	}
+
+	var b strings.Builder
+	args := []any{dagID}
+	b.WriteString(`SELECT id, from_node_id, to_node_id, data, order_index FROM dag_edges WHERE dag_id = $1`)
+	if filter.Contains != nil {
+		args = append(args, string(filter.Contains))
+		fmt.Fprintf(&b, ` AND data @> $%d::jsonb`, len(args))
+	}
+	if filter.Match != "" {
+		args = append(args, filter.Match)
+		fmt.Fprintf(&b, ` AND data @@ $%d::jsonpath`, len(args))
+	}
+	if filter.FromNodeID != "" {
+		args = append(args, filter.FromNodeID)
+		fmt.Fprintf(&b, ` AND from_node_id = $%d`, len(args))
+	}
+	if filter.ToNodeID != "" {
+		args = append(args, filter.ToNodeID)
+		fmt.Fprintf(&b, ` AND to_node_id = $%d`, len(args))
+	}
+	b.WriteString(` ORDER BY order_index, created_at, id`)
+
+	rows, err := s.reader(ctx, dagID).Query(ctx, b.String(), args...)
+	if err != nil {
+		return nil, findEdgesError(err)
+	}
+	defer rows.Close()
+
+	edges := []dag.Edge{}
+	for rows.Next() {
+		var e dag.Edge
+		if err := rows.Scan(&e.ID, &e.FromNodeID, &e.ToNodeID, &e.Data, &e.OrderIndex); err != nil {
+			return nil, fmt.Errorf("dag: scan edge: %w", err)
+		}
+		if err := unpack(&e.Data); err != nil {
+			return nil, err
+		}
+		edges = append(edges, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, findEdgesError(err)
+	}
+	return edges, nil
+}
+
+// findEdgesError reports a JSON path Postgres can't parse as
+// ErrInvalidFilter.
+func findEdgesError(err error) error {
+	// 42601 is syntax_error, which casting a bad path to jsonpath raises.
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "42601" {
+		return fmt.Errorf("%w: %s", dag.ErrInvalidFilter, pgErr.Message)
+	}
+	return fmt.Errorf("dag: find edges: %w", err)
+}
+
+// ReorderEdges sets the order of the edges leaving fromNodeID to the order
+// of edgeIDs, which must list every one of them exactly once.
+// Returns ErrNodeNotFound if the node doesn't exist and ErrInvalidOrder if
+// edgeIDs is not a permutation of its outgoing edges.
+func (s *PGStore) ReorderEdges(ctx context.Context, fromNodeID string, edgeIDs []string) error {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("dag: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	dagID, found, err := s.mutableDAGOf(ctx, tx, "dag_nodes", fromNodeID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return dag.ErrNodeNotFound
+	}
+
+	// Lock the siblings so a concurrent AddEdge can't slip in between the
+	// check and the update.
+	rows, err := tx.Query(ctx,
+		`SELECT id FROM dag_edges WHERE dag_id = $1 AND from_node_id = $2 FOR UPDATE`, dagID, fromNodeID)
+	if err != nil {
+		return fmt.Errorf("dag: list sibling edges: %w", err)
+	}
+	current, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("dag: list sibling edges: %w", err)
+	}
+
+	if len(edgeIDs) != len(current) {
+		return fmt.Errorf("%w: node %s has %d outgoing edges, got %d", dag.ErrInvalidOrder, fromNodeID, len(current), len(edgeIDs))
+	}
+	pos := make(map[string]int, len(edgeIDs))
+	for i, id := range edgeIDs {
+		if _, dup := pos[id]; dup {
+			return fmt.Errorf("%w: edge %s listed twice", dag.ErrInvalidOrder, id)
+		}
+		pos[id] = i
+	}
+	for _, id := range current {
+		if _, ok := pos[id]; !ok {
+			return fmt.Errorf("%w: edge %s missing", dag.ErrInvalidOrder, id)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE dag_edges e SET order_index = o.ord - 1
+		FROM unnest($2::text[]) WITH ORDINALITY AS o(id, ord)
+		WHERE e.dag_id = $3 AND e.id = o.id AND e.from_node_id = $1`,
+		fromNodeID, edgeIDs, dagID,
+	); err != nil {
+		return fmt.Errorf("dag: reorder edges: %w", err)
+	}
+	if err := s.recordVersion(ctx, tx, dagID); err != nil {
+		return err
+	}
+	if err := s.recordEvent(ctx, tx, dagID, dag.Event{
+		Type: dag.EventReordered, Order: &dag.EdgeOrder{FromNodeID: fromNodeID, EdgeIDs: edgeIDs},
+	}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
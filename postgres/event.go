@@ -0,0 +1,260 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/meikuraledutech/dag"
+)
+
+// WithEventLog appends every write to a DAG to dag_events as a dag.Event,
+// in the transaction of the write, so the log is the complete history of
+// each DAG. The tables stay the materialized current state that reads use;
+// ReplayDAG rebuilds any earlier state from the log, and Events reads it.
+// Writes that are otherwise a single statement run in a transaction under
+// this option.
+func WithEventLog() Option {
+	return func(s *PGStore) { s.eventLog = true }
+}
+
+// eventPayload is the part of a dag.Event stored in the payload column.
+type eventPayload struct {
+	DAG      *dag.DAG       `json:"dag,omitempty"`
+	Changes  *dag.ChangeSet `json:"changes,omitempty"`
+	Order    *dag.EdgeOrder `json:"order,omitempty"`
+	Tags     []string       `json:"tags,omitempty"`
+	Settings *dag.Settings  `json:"settings,omitempty"`
+	Status   dag.Status     `json:"status,omitempty"`
+}
+
+// eventTables returns the tables each write's event goes to: dag_events
+// under WithEventLog and dag_outbox under WithOutbox.
+func (s *PGStore) eventTables() []string {
+	var tables []string
+	if s.eventLog {
+		tables = append(tables, "dag_events")
+	}
+	if s.outbox {
+		tables = append(tables, "dag_outbox")
+	}
+	return tables
+}
+
+// recordEvent appends e to the event log and the outbox, as enabled. Node
+// and edge data in e is stored as the tables store it, compressed under
+// WithCompression, but never moved to a blob store or deduplicated: the
+// log must outlive PruneNodeData and blob deletion.
+func (s *PGStore) recordEvent(ctx context.Context, db execer, dagID string, e dag.Event) error {
+	tables := s.eventTables()
+	if len(tables) == 0 {
+		return nil
+	}
+	p := eventPayload{Order: e.Order, Tags: e.Tags, Settings: e.Settings, Status: e.Status}
+	if cs := e.Changes; cs != nil {
+		c := *cs
+		c.AddNodes, c.UpdateNodes = s.packedNodes(c.AddNodes), s.packedNodes(c.UpdateNodes)
+		c.AddEdges, c.UpdateEdges = s.packedEdges(c.AddEdges), s.packedEdges(c.UpdateEdges)
+		p.Changes = &c
+	}
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("dag: encode event: %w", err)
+	}
+	for _, table := range tables {
+		if _, err := db.Exec(ctx,
+			`INSERT INTO `+table+` (dag_id, type, payload, actor) VALUES ($1, $2, $3, NULLIF($4, ''))`,
+			dagID, string(e.Type), payload, dag.ActorFrom(ctx),
+		); err != nil {
+			return fmt.Errorf("dag: record event: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordCreated appends an EventCreated holding dagID as it is now in db.
+func (s *PGStore) recordCreated(ctx context.Context, db execer, dagID string) error {
+	for _, table := range s.eventTables() {
+		_, err := db.Exec(ctx, `
+			INSERT INTO `+table+` (dag_id, type, payload, actor)
+			SELECT $1::text, 'created', jsonb_build_object('dag', `+dagSnapshot+` || jsonb_build_object('status', COALESCE(d.status, 'draft'))),
+				NULLIF($2::text, '')
+			FROM (SELECT 1) one LEFT JOIN dags d ON d.id = $1::text`, dagID, dag.ActorFrom(ctx))
+		if err != nil {
+			return fmt.Errorf("dag: record event: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordChanged appends an EventChanged for cs.
+func (s *PGStore) recordChanged(ctx context.Context, db execer, dagID string, cs *dag.ChangeSet) error {
+	return s.recordEvent(ctx, db, dagID, dag.Event{Type: dag.EventChanged, Changes: cs})
+}
+
+func (s *PGStore) packedNodes(nodes []dag.Node) []dag.Node {
+	if len(nodes) == 0 {
+		return nil
+	}
+	out := make([]dag.Node, len(nodes))
+	for i, n := range nodes {
+		n.Ref = ""
+		n.Data, _ = s.pack(n.Data)
+		out[i] = n
+	}
+	return out
+}
+
+func (s *PGStore) packedEdges(edges []dag.Edge) []dag.Edge {
+	if len(edges) == 0 {
+		return nil
+	}
+	out := make([]dag.Edge, len(edges))
+	for i, e := range edges {
+		e.FromNodeRef, e.ToNodeRef = "", ""
+		e.Data, _ = s.pack(e.Data)
+		out[i] = e
+	}
+	return out
+}
+
+// inTx runs f on s, or under WithEventLog or WithOutbox on a copy of s
+// bound to a new transaction, so that a write and its event commit
+// together.
+func (s *PGStore) inTx(ctx context.Context, f func(s *PGStore) error) error {
+	if len(s.eventTables()) == 0 {
+		return f(s)
+	}
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("dag: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	if err := f(s.onTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// inWriteTx is inTx for writes that lock their DAG with lockDAG: it always
+// runs f in a transaction, so the lock is held until the write commits.
+func (s *PGStore) inWriteTx(ctx context.Context, f func(s *PGStore) error) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("dag: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	if err := f(s.onTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// onTx returns a copy of s that runs every query, reads included, on tx.
+// Unlike Using it doesn't wrap tx again: tx was begun on s.db.
+func (s *PGStore) onTx(tx DB) *PGStore {
+	c := *s
+	c.db, c.replica = tx, nil
+	return &c
+}
+
+// Events returns up to limit events of dagID with a Seq greater than
+// afterSeq, oldest first. A limit of 0 or less returns them all.
+func (s *PGStore) Events(ctx context.Context, dagID string, afterSeq int64, limit int) ([]dag.Event, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	return s.events(ctx, dagID, afterSeq, 0, limit)
+}
+
+// ReplayDAG rebuilds dagID from its event log as it was right after the
+// event with Seq upToSeq, or as it is now if upToSeq is 0. Returns
+// dag.ErrNoVersion if the DAG did not exist with any nodes at that point,
+// which includes every DAG written before WithEventLog was turned on.
+func (s *PGStore) ReplayDAG(ctx context.Context, dagID string, upToSeq int64) (*dag.DAG, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	events, err := s.events(ctx, dagID, 0, upToSeq, 0)
+	if err != nil {
+		return nil, err
+	}
+	d := dag.Replay(events)
+	if d == nil || len(d.Nodes) == 0 {
+		return nil, dag.ErrNoVersion
+	}
+	return d, nil
+}
+
+// events reads dagID's events with afterSeq < seq <= upToSeq (no upper
+// bound if upToSeq is 0) and unpacks their data.
+func (s *PGStore) events(ctx context.Context, dagID string, afterSeq, upToSeq int64, limit int) ([]dag.Event, error) {
+	sql := `SELECT ` + eventColumns + ` FROM dag_events
+		WHERE dag_id = $1 AND seq > $2 AND ($3 = 0 OR seq <= $3) ORDER BY seq`
+	args := []any{dagID, afterSeq, upToSeq}
+	if limit > 0 {
+		sql += ` LIMIT $4`
+		args = append(args, limit)
+	}
+	rows, err := s.reader(ctx, dagID).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("dag: list events: %w", err)
+	}
+	return s.scanEvents(ctx, rows)
+}
+
+// eventColumns are the columns of dag_events and dag_outbox that
+// scanEvents reads.
+const eventColumns = `seq, dag_id, type, payload, created_at, COALESCE(actor, '')`
+
+// scanEvents reads rows of eventColumns and unpacks the events' data.
+func (s *PGStore) scanEvents(ctx context.Context, rows pgx.Rows) ([]dag.Event, error) {
+	defer rows.Close()
+	events := []dag.Event{}
+	for rows.Next() {
+		var e dag.Event
+		var payload []byte
+		if err := rows.Scan(&e.Seq, &e.DAGID, &e.Type, &payload, &e.At, &e.Actor); err != nil {
+			return nil, fmt.Errorf("dag: scan event: %w", err)
+		}
+		var p eventPayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return nil, fmt.Errorf("dag: decode event %d: %w", e.Seq, err)
+		}
+		e.DAG, e.Changes, e.Order, e.Tags, e.Settings, e.Status = p.DAG, p.Changes, p.Order, p.Tags, p.Settings, p.Status
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dag: rows events: %w", err)
+	}
+
+	for i := range events {
+		if err := s.unpackEvent(ctx, &events[i]); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// unpackEvent expands the node and edge data in e.
+func (s *PGStore) unpackEvent(ctx context.Context, e *dag.Event) error {
+	var nodes [][]dag.Node
+	var edges [][]dag.Edge
+	if e.DAG != nil {
+		e.DAG.ID = e.DAGID
+		nodes, edges = append(nodes, e.DAG.Nodes), append(edges, e.DAG.Edges)
+	}
+	if cs := e.Changes; cs != nil {
+		nodes, edges = append(nodes, cs.AddNodes, cs.UpdateNodes), append(edges, cs.AddEdges, cs.UpdateEdges)
+	}
+	for _, list := range nodes {
+		if err := s.unpackNodes(ctx, list); err != nil {
+			return err
+		}
+	}
+	for _, list := range edges {
+		if err := unpackEdges(list); err != nil {
+			return err
+		}
+	}
+	return nil
+}
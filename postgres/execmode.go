@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// WithQueryExecMode runs every statement of the store in mode, whatever the
+// pool's default. Behind pgbouncer in transaction pooling mode, where a
+// statement prepared on one server connection is unknown on the next, use
+// pgx.QueryExecModeExec (no statement cache, binary values) or
+// pgx.QueryExecModeSimpleProtocol (no prepare at all).
+//
+// The mode is passed to pgx as the first query argument, so db must be a
+// pgx pool, connection or transaction, or a database/sql connection through
+// the pgx stdlib driver, which hands it on.
+func WithQueryExecMode(mode pgx.QueryExecMode) Option {
+	return func(s *PGStore) { s.execMode = &mode }
+}
+
+// moded wraps db in the WithQueryExecMode mode, if one is set.
+func (s *PGStore) moded(db DB) DB {
+	if s.execMode == nil || db == nil {
+		return db
+	}
+	return &modedDB{db: db, mode: *s.execMode}
+}
+
+// modedDB is a DB that runs its statements in mode.
+type modedDB struct {
+	db   DB
+	mode pgx.QueryExecMode
+}
+
+func (d *modedDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return d.db.Exec(ctx, sql, withMode(d.mode, args)...)
+}
+
+func (d *modedDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return d.db.Query(ctx, sql, withMode(d.mode, args)...)
+}
+
+func (d *modedDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return d.db.QueryRow(ctx, sql, withMode(d.mode, args)...)
+}
+
+func (d *modedDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return d.tx(d.db.Begin(ctx))
+}
+
+// BeginTx lets beginSnapshot see through the wrapper.
+func (d *modedDB) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	return d.tx(beginTx(ctx, d.db, opts))
+}
+
+func (d *modedDB) tx(tx pgx.Tx, err error) (pgx.Tx, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &modedTx{Tx: tx, mode: d.mode}, nil
+}
+
+// modedTx is a pgx.Tx that runs its statements in mode.
+type modedTx struct {
+	pgx.Tx
+	mode pgx.QueryExecMode
+}
+
+func (t *modedTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return t.Tx.Exec(ctx, sql, withMode(t.mode, args)...)
+}
+
+func (t *modedTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return t.Tx.Query(ctx, sql, withMode(t.mode, args)...)
+}
+
+func (t *modedTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return t.Tx.QueryRow(ctx, sql, withMode(t.mode, args)...)
+}
+
+func (t *modedTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := t.Tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &modedTx{Tx: tx, mode: t.mode}, nil
+}
+
+// withMode prepends mode to args, which is how pgx takes a per-statement
+// exec mode.
+func withMode(mode pgx.QueryExecMode, args []any) []any {
+	return append([]any{mode}, args...)
+}
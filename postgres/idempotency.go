@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// idempotencyLeaseSQL adds claimed_at, when a key was last claimed, to
+// dag_idempotency_keys. It is migration 3.
+const idempotencyLeaseSQL = `
+ALTER TABLE dag_idempotency_keys ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ NOT NULL DEFAULT NOW();`
+
+// IdempotencyLease is how long a claim holds a key before a retry may take
+// it over, in case the process that claimed it died before completing or
+// releasing it. A request that may run longer must renew its claim with
+// RenewIdempotencyKey well within the lease.
+const IdempotencyLease = time.Minute
+
+// IdempotencyRecord is the stored outcome of a request made with an
+// Idempotency-Key. Status is 0 while the original request is still running.
+type IdempotencyRecord struct {
+	Key         string
+	Fingerprint string
+	Status      int
+	Body        []byte
+}
+
+// ClaimIdempotencyKey reserves key for a request with the given fingerprint.
+// Records older than ttl are discarded first so keys can eventually be reused.
+// A claim still in progress and not renewed within IdempotencyLease is taken
+// over by a request with the same fingerprint, so a key is not stuck if its
+// first request never finished. Both ages are measured by the database's
+// clock, which every server sharing it agrees on. Returns claimed=true if the caller now owns the key; otherwise
+// returns the existing record so the caller can replay or reject it.
+func (s *PGStore) ClaimIdempotencyKey(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	if _, err := s.db.Exec(ctx,
+		`DELETE FROM dag_idempotency_keys WHERE key = $1 AND created_at < NOW() - make_interval(secs => $2)`,
+		key, ttl.Seconds(),
+	); err != nil {
+		return nil, false, fmt.Errorf("dag: expire idempotency key: %w", err)
+	}
+
+	ct, err := s.db.Exec(ctx,
+		`INSERT INTO dag_idempotency_keys (key, fingerprint) VALUES ($1, $2) ON CONFLICT (key) DO NOTHING`,
+		key, fingerprint,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("dag: claim idempotency key: %w", err)
+	}
+	if ct.RowsAffected() == 1 {
+		return nil, true, nil
+	}
+
+	ct, err = s.db.Exec(ctx,
+		`UPDATE dag_idempotency_keys SET claimed_at = NOW()
+		WHERE key = $1 AND fingerprint = $2 AND status = 0 AND claimed_at < NOW() - make_interval(secs => $3)`,
+		key, fingerprint, IdempotencyLease.Seconds(),
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("dag: take over idempotency key: %w", err)
+	}
+	if ct.RowsAffected() == 1 {
+		return nil, true, nil
+	}
+
+	rec := &IdempotencyRecord{Key: key}
+	err = s.db.QueryRow(ctx,
+		`SELECT fingerprint, status, body FROM dag_idempotency_keys WHERE key = $1`, key,
+	).Scan(&rec.Fingerprint, &rec.Status, &rec.Body)
+	if err != nil {
+		if isNoRows(err) {
+			// Released between our insert and select — try again.
+			return s.ClaimIdempotencyKey(ctx, key, fingerprint, ttl)
+		}
+		return nil, false, fmt.Errorf("dag: get idempotency key: %w", err)
+	}
+
+	return rec, false, nil
+}
+
+// RenewIdempotencyKey restarts the lease of a claimed key whose request is
+// still running, so a retry doesn't take it over. No error if the key has
+// been completed, released or doesn't exist.
+func (s *PGStore) RenewIdempotencyKey(ctx context.Context, key string) error {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	_, err := s.db.Exec(ctx,
+		`UPDATE dag_idempotency_keys SET claimed_at = NOW() WHERE key = $1 AND status = 0`, key)
+	if err != nil {
+		return fmt.Errorf("dag: renew idempotency key: %w", err)
+	}
+	return nil
+}
+
+// CompleteIdempotencyKey stores the final response for a claimed key.
+func (s *PGStore) CompleteIdempotencyKey(ctx context.Context, key string, status int, body []byte) error {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	_, err := s.db.Exec(ctx,
+		`UPDATE dag_idempotency_keys SET status = $1, body = $2 WHERE key = $3`,
+		status, body, key,
+	)
+	if err != nil {
+		return fmt.Errorf("dag: complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey forgets a claimed key so the request can be retried.
+// No error if the key doesn't exist.
+func (s *PGStore) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	_, err := s.db.Exec(ctx, `DELETE FROM dag_idempotency_keys WHERE key = $1`, key)
+	if err != nil {
+		return fmt.Errorf("dag: release idempotency key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/meikuraledutech/dag"
+)
+
+// execer is the subset of DB and pgx.Tx used by helpers that run
+// either inside or outside a transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// GetDAGInfo returns a DAG's metadata without loading nodes or edges.
+// If a write has cleared the stored content hash since it was last
+// computed, the rows are streamed once to compute and store it again.
+// Returns nil, nil if the DAG has no metadata row.
+func (s *PGStore) GetDAGInfo(ctx context.Context, dagID string) (*dag.DAGInfo, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	var info dag.DAGInfo
+	var modifiedAt time.Time
+	err := s.reader(ctx, dagID).QueryRow(ctx,
+		`SELECT id, name, tags, status, created_at, updated_at, expires_at, COALESCE(draft_of, ''), settings, COALESCE(content_hash, ''), modified_at FROM dags WHERE id = $1`, dagID,
+	).Scan(&info.ID, &info.Name, &info.Tags, &info.Status, &info.CreatedAt, &info.UpdatedAt, &info.ExpiresAt, &info.DraftOf, &info.Settings, &info.Hash, &modifiedAt)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("dag: get dag info: %w", err)
+	}
+	if info.Hash == "" {
+		if info.Hash, err = s.contentHash(ctx, dagID); err != nil {
+			return nil, err
+		}
+		if info.Hash != "" {
+			if err := s.storeHash(ctx, dagID, info.Hash, modifiedAt); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &info, nil
+}
+
+// ExpiredDAGs returns up to limit IDs of DAGs whose expires_at is at or
+// before the given time, oldest expiry first. Published DAGs are skipped
+// because they cannot be deleted.
+func (s *PGStore) ExpiredDAGs(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	rows, err := s.db.Query(ctx,
+		`SELECT id FROM dags WHERE expires_at <= $1 AND status <> 'published' ORDER BY expires_at LIMIT $2`, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("dag: query expired: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("dag: scan expired: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dag: rows expired: %w", err)
+	}
+	return ids, nil
+}
+
+// upsertDAGInfo writes d's metadata, keeping created_at of an existing row.
+func upsertDAGInfo(ctx context.Context, tx pgx.Tx, d *dag.DAG) error {
+	tags := d.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO dags (id, name, tags, expires_at, settings) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, tags = EXCLUDED.tags,
+			expires_at = EXCLUDED.expires_at, settings = EXCLUDED.settings, updated_at = NOW()`,
+		d.ID, d.Name, tags, d.ExpiresAt, d.Settings,
+	)
+	if err != nil {
+		return fmt.Errorf("dag: upsert dag info: %w", err)
+	}
+	return nil
+}
+
+// claimDAGID inserts the metadata row of a new DAG, or returns ErrDAGExists
+// if dagID is taken. A concurrent create of the same ID waits on the row
+// until this transaction ends, then fails.
+func claimDAGID(ctx context.Context, tx pgx.Tx, dagID string) error {
+	tag, err := tx.Exec(ctx, `INSERT INTO dags (id) VALUES ($1) ON CONFLICT (id) DO NOTHING`, dagID)
+	if err != nil {
+		return fmt.Errorf("dag: claim dag id: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", dag.ErrDAGExists, dagID)
+	}
+	// Nodes written before the dags table existed have no metadata row.
+	var orphans bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM dag_nodes WHERE dag_id = $1)`, dagID).Scan(&orphans)
+	if err != nil {
+		return fmt.Errorf("dag: check dag exists: %w", err)
+	}
+	if orphans {
+		return fmt.Errorf("%w: %s", dag.ErrDAGExists, dagID)
+	}
+	return nil
+}
+
+// checkAbsent returns ErrDAGExists if dagID has metadata or nodes.
+func checkAbsent(ctx context.Context, db queryRower, dagID string) error {
+	var exists bool
+	err := db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM dags WHERE id = $1)
+			OR EXISTS (SELECT 1 FROM dag_nodes WHERE dag_id = $1)`, dagID,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("dag: check dag exists: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("%w: %s", dag.ErrDAGExists, dagID)
+	}
+	return nil
+}
+
+// ensureDAGInfo creates an empty metadata row for dagID if none exists, so
+// DAGs built node by node are still searchable.
+func ensureDAGInfo(ctx context.Context, db execer, dagID string) error {
+	_, err := db.Exec(ctx,
+		`INSERT INTO dags (id) VALUES ($1) ON CONFLICT (id) DO NOTHING`, dagID)
+	if err != nil {
+		return fmt.Errorf("dag: ensure dag info: %w", err)
+	}
+	return nil
+}
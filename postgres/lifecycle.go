@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/meikuraledutech/dag"
+)
+
+// queryRower is the subset of DB and pgx.Tx used by helpers that
+// read a single row either inside or outside a transaction.
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// PublishDAG moves a draft DAG to published, after which it rejects writes
+// with ErrDAGFrozen. Publishing a published DAG is a no-op. Returns
+// ErrDAGNotFound if the DAG has no nodes, and ErrDAGFrozen if it is archived.
+func (s *PGStore) PublishDAG(ctx context.Context, dagID string) error {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	return s.setStatus(ctx, dagID, dag.StatusPublished, dag.StatusDraft)
+}
+
+// ArchiveDAG retires a draft or published DAG. Archived DAGs stay readable
+// and frozen, but can be deleted. Archiving an archived DAG is a no-op.
+func (s *PGStore) ArchiveDAG(ctx context.Context, dagID string) error {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	return s.setStatus(ctx, dagID, dag.StatusArchived, dag.StatusDraft, dag.StatusPublished)
+}
+
+// setStatus moves dagID to status `to` if it is currently in one of `from`.
+func (s *PGStore) setStatus(ctx context.Context, dagID string, to dag.Status, from ...dag.Status) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("dag: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var cur dag.Status
+	err = tx.QueryRow(ctx, `
+		SELECT d.status FROM dags d
+		WHERE d.id = $1 AND EXISTS (SELECT 1 FROM dag_nodes n WHERE n.dag_id = d.id)
+		FOR UPDATE`, dagID,
+	).Scan(&cur)
+	if err != nil {
+		if isNoRows(err) {
+			return dag.ErrDAGNotFound
+		}
+		return fmt.Errorf("dag: get status: %w", err)
+	}
+	if cur == to {
+		return nil
+	}
+
+	allowed := false
+	for _, f := range from {
+		allowed = allowed || cur == f
+	}
+	if !allowed {
+		return fmt.Errorf("%w: %s is %s", dag.ErrDAGFrozen, dagID, cur)
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE dags SET status = $2, updated_at = NOW() WHERE id = $1`, dagID, string(to),
+	); err != nil {
+		return fmt.Errorf("dag: set status: %w", err)
+	}
+	if err := s.recordEvent(ctx, tx, dagID, dag.Event{Type: dag.EventStatus, Status: to}); err != nil {
+		return err
+	}
+	s.recent.note(dagID)
+	return tx.Commit(ctx)
+}
+
+// checkMutable returns ErrDAGFrozen if dagID is published or archived.
+// Unknown DAGs are mutable: the write will create them.
+func checkMutable(ctx context.Context, db queryRower, dagID string) error {
+	var status dag.Status
+	err := db.QueryRow(ctx, `SELECT status FROM dags WHERE id = $1`, dagID).Scan(&status)
+	if err != nil {
+		if isNoRows(err) {
+			return nil
+		}
+		return fmt.Errorf("dag: get status: %w", err)
+	}
+	if status.Frozen() {
+		return fmt.Errorf("%w: %s is %s", dag.ErrDAGFrozen, dagID, status)
+	}
+	return nil
+}
+
+// lockDAG locks dagID's dags row until the end of the transaction s.db is
+// bound to, then runs the check set with WithPrecondition. It returns the
+// DAG's settings, or ErrDAGFrozen if it is published or archived. Writes
+// that check the graph before changing it (quotas, settings, node types)
+// take the lock first, so that concurrent writes to one DAG are checked one
+// after the other against the graph the previous one left. An unknown DAG
+// has no row to lock and default settings.
+func (s *PGStore) lockDAG(ctx context.Context, dagID string) (dag.Settings, error) {
+	var status dag.Status
+	var settings dag.Settings
+	err := s.db.QueryRow(ctx, `SELECT status, settings FROM dags WHERE id = $1 FOR UPDATE`, dagID).Scan(&status, &settings)
+	if err != nil && !isNoRows(err) {
+		return dag.Settings{}, fmt.Errorf("dag: lock dag: %w", err)
+	}
+	if status.Frozen() {
+		return dag.Settings{}, fmt.Errorf("%w: %s is %s", dag.ErrDAGFrozen, dagID, status)
+	}
+	if err := s.checkPrecondition(ctx); err != nil {
+		return dag.Settings{}, err
+	}
+	return settings, nil
+}
+
+// mutableDAGOf returns the DAG that owns a node or edge (table is
+// "dag_nodes" or "dag_edges"), or ErrDAGFrozen if that DAG is frozen.
+// found is false if no row has that ID.
+func (s *PGStore) mutableDAGOf(ctx context.Context, db queryRower, table, id string) (dagID string, found bool, err error) {
+	var status *dag.Status
+	err = db.QueryRow(ctx,
+		`SELECT r.dag_id, d.status FROM `+table+` r LEFT JOIN dags d ON d.id = r.dag_id WHERE `+s.idMatch(table, "r", "$1"), id,
+	).Scan(&dagID, &status)
+	if err != nil {
+		if isNoRows(err) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("dag: find %s: %w", table, err)
+	}
+	if status != nil && status.Frozen() {
+		return dagID, true, fmt.Errorf("%w: %s is %s", dag.ErrDAGFrozen, dagID, *status)
+	}
+	return dagID, true, nil
+}
+
+// lockOwner is lockDAG for the DAG that owns a node or edge, for deletes:
+// if there is no such row, nothing is locked, but the check set with
+// WithPrecondition still runs, since it may expect the row to exist.
+func (s *PGStore) lockOwner(ctx context.Context, table, id string) error {
+	dagID, found, err := s.mutableDAGOf(ctx, s.db, table, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return s.checkPrecondition(ctx)
+	}
+	_, err = s.lockDAG(ctx, dagID)
+	return err
+}
+
+// NodeDAG returns the ID of the DAG that owns nodeID, or "" if there is no
+// such node. It lets wrappers such as authz.Store check the DAG of calls
+// that only name a node.
+func (s *PGStore) NodeDAG(ctx context.Context, nodeID string) (string, error) {
+	return s.ownerOf(ctx, "dag_nodes", nodeID)
+}
+
+// EdgeDAG is NodeDAG for an edge.
+func (s *PGStore) EdgeDAG(ctx context.Context, edgeID string) (string, error) {
+	return s.ownerOf(ctx, "dag_edges", edgeID)
+}
+
+func (s *PGStore) ownerOf(ctx context.Context, table, id string) (string, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	var dagID string
+	err := s.db.QueryRow(ctx, `SELECT r.dag_id FROM `+table+` r WHERE `+s.idMatch(table, "r", "$1"), id).Scan(&dagID)
+	if err != nil && !isNoRows(err) {
+		return "", fmt.Errorf("dag: find %s: %w", table, err)
+	}
+	return dagID, nil
+}
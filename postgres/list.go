@@ -0,0 +1,222 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/meikuraledutech/dag"
+)
+
+// sortKey returns the SQL for the value rows are sorted by under
+// ListOptions.Sort field, and the SQL that turns $n, the value as JSON, back
+// into its type. data is the SQL for the row's data. Data values are
+// compared as jsonb, with a missing value as JSON null, so every row has a
+// key and numbers sort as numbers.
+func sortKey(field, data string) (expr string, param func(n int) string, ok bool) {
+	asJSONB := func(n int) string { return fmt.Sprintf(`$%d::jsonb`, n) }
+	switch field {
+	case "", "created_at", "updated_at":
+		return cmp.Or(field, "created_at"), func(n int) string { return fmt.Sprintf(`($%d::jsonb #>> '{}')::timestamptz`, n) }, true
+	case "id":
+		return "id", nil, true
+	case "label":
+		return `COALESCE(` + data + ` -> 'label', 'null'::jsonb)`, asJSONB, true
+	}
+	path, ok := strings.CutPrefix(field, "data.")
+	keys := strings.Split(path, ".")
+	if !ok || slices.Contains(keys, "") {
+		return "", nil, false
+	}
+	for i, k := range keys {
+		keys[i] = literal(k)
+	}
+	return `COALESCE(` + data + ` #> ARRAY[` + strings.Join(keys, ", ") + `]::text[], 'null'::jsonb)`, asJSONB, true
+}
+
+// ListNodesPage returns one page of a DAG's nodes, filtered and sorted per opts.
+// Returns ErrInvalidCursor / ErrInvalidSort / ErrInvalidField for bad options.
+func (s *PGStore) ListNodesPage(ctx context.Context, dagID string, opts dag.ListOptions) (*dag.Page[dag.Node], error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	q, err := listQuery(`id, `+projectSQL(nodeData("dag_nodes"), opts.Fields)+`, tags FROM dag_nodes`, nodeData("dag_nodes"), dagID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return listPage(ctx, s.reader(ctx, dagID), q, opts.Limit, func(rows pgx.Rows) (dag.Node, cursor, error) {
+		var n dag.Node
+		var key json.RawMessage
+		if err := rows.Scan(&key, &n.ID, &n.Data, &n.Tags); err != nil {
+			return n, cursor{}, err
+		}
+		if err := s.unpackNode(ctx, &n.Data); err != nil {
+			return n, cursor{}, err
+		}
+		n.Data = dag.Project(n.Data, opts.Fields)
+		return n, cursor{Key: key, ID: n.ID}, nil
+	})
+}
+
+// ListEdgesPage returns one page of a DAG's edges, filtered and sorted per opts.
+// Returns ErrInvalidCursor / ErrInvalidSort / ErrInvalidField for bad options.
+func (s *PGStore) ListEdgesPage(ctx context.Context, dagID string, opts dag.ListOptions) (*dag.Page[dag.Edge], error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	q, err := listQuery(`id, from_node_id, to_node_id, `+projectSQL("data", opts.Fields)+`, order_index FROM dag_edges`, "data", dagID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return listPage(ctx, s.reader(ctx, dagID), q, opts.Limit, func(rows pgx.Rows) (dag.Edge, cursor, error) {
+		var e dag.Edge
+		var key json.RawMessage
+		if err := rows.Scan(&key, &e.ID, &e.FromNodeID, &e.ToNodeID, &e.Data, &e.OrderIndex); err != nil {
+			return e, cursor{}, err
+		}
+		if err := unpack(&e.Data); err != nil {
+			return e, cursor{}, err
+		}
+		e.Data = dag.Project(e.Data, opts.Fields)
+		return e, cursor{Key: key, ID: e.ID}, nil
+	})
+}
+
+// list is a query built by listQuery.
+type list struct {
+	sql  string
+	args []any
+	sort string // the normalized sort, recorded in cursors
+}
+
+// listQuery builds SELECT <sort key>, <cols> with WHERE / ORDER BY / LIMIT
+// clauses, cols being the column list and FROM clause; the sort key comes
+// first, as JSON, for the cursor. data is the SQL for the row's data, which
+// Filter and data sorts read. The cursor becomes a keyset condition on the
+// sort key and id, so a page costs the same however deep it is.
+// One row more than the limit is requested to learn whether a next page exists.
+func listQuery(cols, data, dagID string, opts dag.ListOptions) (list, error) {
+	if err := dag.ValidateFields(opts.Fields); err != nil {
+		return list{}, err
+	}
+
+	field, dir, op := strings.TrimPrefix(opts.Sort, "-"), "ASC", ">"
+	if strings.HasPrefix(opts.Sort, "-") {
+		dir, op = "DESC", "<"
+	}
+	key, param, ok := sortKey(field, data)
+	if !ok {
+		return list{}, fmt.Errorf("%w %q", dag.ErrInvalidSort, opts.Sort)
+	}
+	field = cmp.Or(field, "created_at")
+	q := list{sort: field}
+	if dir == "DESC" {
+		q.sort = "-" + field
+	}
+	after, err := decodeCursor(opts.Cursor, q.sort)
+	if err != nil {
+		return list{}, err
+	}
+
+	var b strings.Builder
+	q.args = []any{dagID}
+	fmt.Fprintf(&b, `SELECT to_jsonb(%s), %s WHERE dag_id = $1`, key, cols)
+
+	keys := make([]string, 0, len(opts.Filter))
+	for k := range opts.Filter {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		q.args = append(q.args, k, opts.Filter[k])
+		fmt.Fprintf(&b, ` AND %s ->> $%d = $%d`, data, len(q.args)-1, len(q.args))
+	}
+
+	switch {
+	case after == nil:
+	case param == nil:
+		q.args = append(q.args, after.ID)
+		fmt.Fprintf(&b, ` AND id %s $%d`, op, len(q.args))
+	default:
+		q.args = append(q.args, string(after.Key), after.ID)
+		fmt.Fprintf(&b, ` AND (%s, id) %s (%s, $%d)`, key, op, param(len(q.args)-1), len(q.args))
+	}
+
+	// id breaks ties so pages are stable.
+	if param == nil {
+		fmt.Fprintf(&b, ` ORDER BY id %s`, dir)
+	} else {
+		fmt.Fprintf(&b, ` ORDER BY %s %s, id %s`, key, dir, dir)
+	}
+	if opts.Limit > 0 {
+		q.args = append(q.args, opts.Limit+1)
+		fmt.Fprintf(&b, ` LIMIT $%d`, len(q.args))
+	}
+
+	q.sql = b.String()
+	return q, nil
+}
+
+// listPage runs a listQuery and cuts the extra look-ahead row into
+// NextCursor, which points after the last row kept.
+func listPage[T any](ctx context.Context, db DB, q list, limit int, scan func(pgx.Rows) (T, cursor, error)) (*dag.Page[T], error) {
+	rows, err := db.Query(ctx, q.sql, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("dag: list: %w", err)
+	}
+	defer rows.Close()
+
+	page := &dag.Page[T]{Items: []T{}}
+	var last cursor
+	for rows.Next() {
+		if limit > 0 && len(page.Items) == limit {
+			page.NextCursor = encodeCursor(last)
+			break
+		}
+		item, pos, err := scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("dag: scan: %w", err)
+		}
+		page.Items = append(page.Items, item)
+		last = pos
+		last.Sort = q.sort
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dag: rows: %w", err)
+	}
+	return page, nil
+}
+
+// cursor is the position of a row in a listing: the sort it was listed by,
+// and its sort key, as JSON, and id.
+type cursor struct {
+	Sort string          `json:"s"`
+	Key  json.RawMessage `json:"k"`
+	ID   string          `json:"i"`
+}
+
+// encodeCursor / decodeCursor turn a position into an opaque token.
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor returns nil for an empty token. A token made for another
+// sort is invalid, since its position means nothing in this order.
+func decodeCursor(token, sort string) (*cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, dag.ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Sort != sort || c.ID == "" || !json.Valid(c.Key) {
+		return nil, dag.ErrInvalidCursor
+	}
+	return &c, nil
+}
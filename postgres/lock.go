@@ -0,0 +1,184 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/meikuraledutech/dag"
+)
+
+// locksSQL creates dag_locks, which holds the advisory edit locks. It is
+// migration 2.
+const locksSQL = `
+-- Advisory edit locks; node_id is '' for a lock on the whole DAG.
+CREATE TABLE IF NOT EXISTS dag_locks (
+    dag_id      TEXT NOT NULL,
+    node_id     TEXT NOT NULL DEFAULT '',
+    owner       TEXT NOT NULL,
+    acquired_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at  TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (dag_id, node_id)
+);
+`
+
+const lockColumns = `dag_id, node_id, owner, acquired_at, expires_at`
+
+func scanLock(row pgx.Row) (dag.Lock, error) {
+	var l dag.Lock
+	err := row.Scan(&l.DAGID, &l.NodeID, &l.Owner, &l.AcquiredAt, &l.ExpiresAt)
+	return l, err
+}
+
+// AcquireLock takes owner's advisory lock on the whole of dagID for ttl,
+// or renews it if owner already holds it, keeping AcquiredAt. It returns a
+// *dag.LockError if another owner holds a lock on the DAG or any of its
+// nodes, and dag.ErrDAGNotFound if there is no such DAG. See dag.Lock.
+func (s *PGStore) AcquireLock(ctx context.Context, dagID, owner string, ttl time.Duration) (*dag.Lock, error) {
+	return s.acquireLock(ctx, dagID, "", owner, ttl)
+}
+
+// AcquireNodeLock takes owner's advisory lock on nodeID of dagID for ttl,
+// or renews it. It returns a *dag.LockError if another owner holds a lock
+// on the node or the whole DAG, and dag.ErrNodeNotFound if nodeID is not a
+// node of dagID.
+func (s *PGStore) AcquireNodeLock(ctx context.Context, dagID, nodeID, owner string, ttl time.Duration) (*dag.Lock, error) {
+	if nodeID == "" {
+		return nil, fmt.Errorf("%w: empty node ID", dag.ErrNodeNotFound)
+	}
+	return s.acquireLock(ctx, dagID, nodeID, owner, ttl)
+}
+
+func (s *PGStore) acquireLock(ctx context.Context, dagID, nodeID, owner string, ttl time.Duration) (*dag.Lock, error) {
+	if owner == "" || ttl <= 0 {
+		return nil, fmt.Errorf("dag: acquire lock: owner and a positive ttl are required")
+	}
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("dag: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockDAGLocks(ctx, tx, dagID); err != nil {
+		return nil, err
+	}
+	var exists bool
+	if nodeID == "" {
+		err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM dags WHERE id = $1)`, dagID).Scan(&exists)
+	} else {
+		err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM dag_nodes WHERE dag_id = $1 AND id = $2)`, dagID, nodeID).Scan(&exists)
+	}
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("dag: check lock target: %w", err)
+	case !exists && nodeID == "":
+		return nil, fmt.Errorf("%w: %s", dag.ErrDAGNotFound, dagID)
+	case !exists:
+		return nil, fmt.Errorf("%w: %s", dag.ErrNodeNotFound, nodeID)
+	}
+
+	if err := conflictingLock(ctx, tx, dagID, nodeID, owner); err != nil {
+		return nil, err
+	}
+	l, err := scanLock(tx.QueryRow(ctx, `
+		INSERT INTO dag_locks (dag_id, node_id, owner, expires_at)
+		VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))
+		ON CONFLICT (dag_id, node_id) DO UPDATE SET expires_at = EXCLUDED.expires_at
+		RETURNING `+lockColumns,
+		dagID, nodeID, owner, ttl.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("dag: acquire lock: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("dag: commit: %w", err)
+	}
+	return &l, nil
+}
+
+// ReleaseLock releases owner's lock on the whole of dagID. Releasing a
+// lock that is not held, or has expired, is not an error; one held by
+// another owner gives a *dag.LockError.
+func (s *PGStore) ReleaseLock(ctx context.Context, dagID, owner string) error {
+	return s.releaseLock(ctx, dagID, "", owner)
+}
+
+// ReleaseNodeLock releases owner's lock on nodeID of dagID, as ReleaseLock
+// does for the whole DAG.
+func (s *PGStore) ReleaseNodeLock(ctx context.Context, dagID, nodeID, owner string) error {
+	return s.releaseLock(ctx, dagID, nodeID, owner)
+}
+
+func (s *PGStore) releaseLock(ctx context.Context, dagID, nodeID, owner string) error {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	l, err := scanLock(s.db.QueryRow(ctx, `
+		WITH held AS (
+			SELECT `+lockColumns+` FROM dag_locks
+			WHERE dag_id = $1 AND node_id = $2 AND expires_at > NOW()
+		), released AS (
+			DELETE FROM dag_locks WHERE dag_id = $1 AND node_id = $2
+				AND (owner = $3 OR expires_at <= NOW())
+		)
+		SELECT `+lockColumns+` FROM held WHERE owner <> $3`,
+		dagID, nodeID, owner))
+	switch {
+	case isNoRows(err):
+		return nil
+	case err != nil:
+		return fmt.Errorf("dag: release lock: %w", err)
+	}
+	return &dag.LockError{Held: l}
+}
+
+// Locks lists the unexpired locks on dagID and its nodes, the DAG's own
+// first, then by node ID.
+func (s *PGStore) Locks(ctx context.Context, dagID string) ([]dag.Lock, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	rows, err := s.db.Query(ctx, `
+		SELECT `+lockColumns+` FROM dag_locks
+		WHERE dag_id = $1 AND expires_at > NOW() ORDER BY node_id`, dagID)
+	if err != nil {
+		return nil, fmt.Errorf("dag: query locks: %w", err)
+	}
+	locks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (dag.Lock, error) {
+		return scanLock(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dag: scan locks: %w", err)
+	}
+	return locks, nil
+}
+
+// lockDAGLocks serialises lock changes on dagID for the rest of tx, and
+// clears its expired locks.
+func lockDAGLocks(ctx context.Context, tx pgx.Tx, dagID string) error {
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended('dag_locks:' || $1, 0))`, dagID); err != nil {
+		return fmt.Errorf("dag: lock dag locks: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM dag_locks WHERE dag_id = $1 AND expires_at <= NOW()`, dagID); err != nil {
+		return fmt.Errorf("dag: expire locks: %w", err)
+	}
+	return nil
+}
+
+// conflictingLock returns a *dag.LockError for the first lock another
+// owner holds that conflicts with owner locking nodeID, or the whole DAG
+// if nodeID is empty.
+func conflictingLock(ctx context.Context, tx pgx.Tx, dagID, nodeID, owner string) error {
+	l, err := scanLock(tx.QueryRow(ctx, `
+		SELECT `+lockColumns+` FROM dag_locks
+		WHERE dag_id = $1 AND owner <> $3 AND ($2 = '' OR node_id IN ('', $2))
+		ORDER BY node_id LIMIT 1`,
+		dagID, nodeID, owner))
+	switch {
+	case isNoRows(err):
+		return nil
+	case err != nil:
+		return fmt.Errorf("dag: check locks: %w", err)
+	}
+	return &dag.LockError{Held: l}
+}
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// MergeNodes merges dropID into keepID in one change set under the DAG's
+// lock, as dag.PlanMerge describes: the dropped node's edges move to the
+// kept node, skipping self-loops and duplicates, its data is combined
+// with the kept node's as strategy says, and it is deleted. Moved edges
+// keep their IDs but go to the end of the kept node's outgoing edges. The
+// graph is checked like any change set, so a merge that would close a
+// cycle returns ErrCycleDetected and writes nothing. Returns the merged
+// node.
+func (s *PGStore) MergeNodes(ctx context.Context, dagID, keepID, dropID string, strategy dag.MergeStrategy) (*dag.Node, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	var cs *dag.ChangeSet
+	err := s.changeDAG(ctx, dagID, func(nodes []dag.Node, edges []dag.Edge) (*dag.ChangeSet, error) {
+		var err error
+		cs, err = dag.PlanMerge(&dag.DAG{Nodes: nodes, Edges: edges}, keepID, dropID, strategy)
+		return cs, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cs.UpdateNodes[0], nil
+}
@@ -0,0 +1,239 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// migrationsSQL creates the table that records which migrations have run.
+const migrationsSQL = `
+CREATE TABLE IF NOT EXISTS dag_schema_migrations (
+    version    INT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);`
+
+// migrateLockKey is the advisory lock each migration step holds, so two
+// migrators running at once take turns.
+const migrateLockKey = 0x646167 // "dag"
+
+// migration is one versioned schema change. up and down get the store so
+// they can follow its options, such as WithPartitions.
+type migration struct {
+	version  int
+	name     string
+	up, down func(s *PGStore) string
+}
+
+// migrations lists the schema changes in version order. Version 1 is the
+// schema as CreateSchema wrote it before versioning; it is idempotent, so
+// running it adopts a database CreateSchema set up. A later change is
+// appended here with its own up and down, and CreateSchema, which creates
+// the latest schema in one step, runs its up after the baseline, so an up
+// must be idempotent too.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "baseline",
+		up:      (*PGStore).baselineSQL,
+		down:    func(*PGStore) string { return dropSQL },
+	},
+	{
+		version: 2,
+		name:    "locks",
+		up:      func(*PGStore) string { return locksSQL },
+		down:    func(*PGStore) string { return `DROP TABLE IF EXISTS dag_locks;` },
+	},
+	{
+		version: 3,
+		name:    "idempotency_lease",
+		up:      func(*PGStore) string { return idempotencyLeaseSQL },
+		down: func(*PGStore) string {
+			return `ALTER TABLE dag_idempotency_keys DROP COLUMN IF EXISTS claimed_at;`
+		},
+	},
+}
+
+// Migration is one schema version and whether it has been applied.
+type Migration struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	// AppliedAt is when the migration ran, or nil if it is pending.
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	// Unknown is set for an applied version this build has no migration
+	// for, left by a newer build.
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+// SchemaVersion returns the version of the newest migration this build
+// knows.
+func SchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// MigrationStatus lists every migration this build knows, applied or
+// pending, and any applied version it does not know, in version order.
+func (s *PGStore) MigrationStatus(ctx context.Context) ([]Migration, error) {
+	var exists bool
+	if err := s.db.QueryRow(ctx, `SELECT to_regclass('dag_schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("dag: migration status: %w", err)
+	}
+	applied := map[int]Migration{}
+	if exists {
+		rows, err := s.db.Query(ctx, `SELECT version, name, applied_at FROM dag_schema_migrations`)
+		if err != nil {
+			return nil, fmt.Errorf("dag: migration status: %w", err)
+		}
+		list, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Migration, error) {
+			var m Migration
+			var at time.Time
+			err := row.Scan(&m.Version, &m.Name, &at)
+			m.AppliedAt = &at
+			return m, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("dag: migration status: %w", err)
+		}
+		for _, m := range list {
+			applied[m.Version] = m
+		}
+	}
+
+	out := make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		st := Migration{Version: m.version, Name: m.name}
+		if a, ok := applied[m.version]; ok {
+			st.AppliedAt = a.AppliedAt
+			delete(applied, m.version)
+		}
+		out = append(out, st)
+	}
+	for _, a := range applied {
+		a.Unknown = true
+		out = append(out, a)
+	}
+	slices.SortFunc(out, func(a, b Migration) int { return a.Version - b.Version })
+	return out, nil
+}
+
+// MigrateUp applies the pending migrations up to version to, oldest first,
+// each in its own transaction, and returns those it applied. to 0 means
+// the newest version this build knows.
+func (s *PGStore) MigrateUp(ctx context.Context, to int) ([]Migration, error) {
+	if to == 0 {
+		to = SchemaVersion()
+	}
+	if to < 0 || to > SchemaVersion() {
+		return nil, fmt.Errorf("dag: migrate up: no migration %d", to)
+	}
+	status, err := s.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i, m := range migrations {
+		if m.version > to || status[i].AppliedAt != nil {
+			continue
+		}
+		if err := s.migrate(ctx, m, true); err != nil {
+			return done, err
+		}
+		done = append(done, Migration{Version: m.version, Name: m.name})
+	}
+	return done, nil
+}
+
+// MigrateDown reverts the applied migrations above version to, newest
+// first, each in its own transaction, and returns those it reverted. to 0
+// reverts them all, dropping every table. A version this build does not
+// know cannot be reverted; run a newer build's dagctl instead.
+func (s *PGStore) MigrateDown(ctx context.Context, to int) ([]Migration, error) {
+	if to < 0 {
+		return nil, fmt.Errorf("dag: migrate down: no migration %d", to)
+	}
+	status, err := s.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, st := range slices.Backward(status) {
+		if st.Version <= to || st.AppliedAt == nil {
+			continue
+		}
+		if st.Unknown {
+			return done, fmt.Errorf("dag: migrate down: migration %d is not known to this build", st.Version)
+		}
+		i := slices.IndexFunc(migrations, func(m migration) bool { return m.version == st.Version })
+		if err := s.migrate(ctx, migrations[i], false); err != nil {
+			return done, err
+		}
+		done = append(done, Migration{Version: st.Version, Name: st.Name})
+	}
+	return done, nil
+}
+
+// migrate applies m, or reverts it if up is false, and records the change,
+// under the migration lock. It does nothing if another migrator got there
+// first.
+func (s *PGStore) migrate(ctx context.Context, m migration, up bool) error {
+	verb := "apply"
+	if !up {
+		verb = "revert"
+	}
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("dag: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, migrateLockKey); err != nil {
+		return fmt.Errorf("dag: lock migrations: %w", err)
+	}
+	if _, err := tx.Exec(ctx, migrationsSQL); err != nil {
+		return fmt.Errorf("dag: create migrations table: %w", err)
+	}
+	var applied bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM dag_schema_migrations WHERE version = $1)`,
+		m.version).Scan(&applied); err != nil {
+		return fmt.Errorf("dag: read migrations: %w", err)
+	}
+	if applied == up {
+		return nil
+	}
+
+	sql, record, args := m.up(s), `INSERT INTO dag_schema_migrations (version, name) VALUES ($1, $2)`, []any{m.version, m.name}
+	if !up {
+		sql, record, args = m.down(s), `DELETE FROM dag_schema_migrations WHERE version = $1`, []any{m.version}
+	}
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return fmt.Errorf("dag: %s migration %d %s: %w", verb, m.version, m.name, err)
+	}
+	if _, err := tx.Exec(ctx, record, args...); err != nil {
+		return fmt.Errorf("dag: record migration %d: %w", m.version, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("dag: commit: %w", err)
+	}
+	return nil
+}
+
+// recordMigrationsSQL marks every migration this build knows as applied,
+// for CreateSchema, which writes the latest schema in one step.
+func recordMigrationsSQL() string {
+	var b strings.Builder
+	b.WriteString(migrationsSQL)
+	b.WriteString("\nINSERT INTO dag_schema_migrations (version, name) VALUES ")
+	for i, m := range migrations {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "(%d, '%s')", m.version, m.name)
+	}
+	b.WriteString("\nON CONFLICT (version) DO NOTHING;")
+	return b.String()
+}
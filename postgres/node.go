@@ -4,36 +4,81 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/meikuraledutech/dag"
 	"github.com/google/uuid"
+	"github.com/meikuraledutech/dag"
 )
 
 // AddNode inserts a single node into a DAG.
 // If node.ID is empty, a UUID is auto-generated.
 // Returns the node ID (generated or provided).
 func (s *PGStore) AddNode(ctx context.Context, dagID string, node *dag.Node) (string, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
 	if node.ID == "" {
 		node.ID = uuid.NewString()
 	}
+	if err := s.inWriteTx(ctx, func(s *PGStore) error { return s.addNode(ctx, dagID, node) }); err != nil {
+		return "", err
+	}
+	return node.ID, nil
+}
+
+// addNode is AddNode on s.db, with node.ID set. s.db must be a transaction:
+// the node quota is counted under the DAG's lock.
+func (s *PGStore) addNode(ctx context.Context, dagID string, node *dag.Node) error {
+	if err := ensureDAGInfo(ctx, s.db, dagID); err != nil {
+		return err
+	}
+	if _, err := s.lockDAG(ctx, dagID); err != nil {
+		return err
+	}
 
-	_, err := s.db.Exec(ctx,
-		`INSERT INTO dag_nodes (id, dag_id, data) VALUES ($1, $2, $3)`,
-		node.ID, dagID, node.Data,
+	q := s.quotaFor(ctx, dagID)
+	if err := q.CheckData(node.Data); err != nil {
+		return err
+	}
+	if err := s.schemas.CheckNode(node); err != nil {
+		return err
+	}
+	if err := s.nodeTypes.CheckNode(node); err != nil {
+		return err
+	}
+	if q.MaxNodes > 0 {
+		n, err := s.countNodes(ctx, dagID)
+		if err != nil {
+			return err
+		}
+		if err := q.CheckNodes(n + 1); err != nil {
+			return err
+		}
+	}
+
+	p, err := s.packNode(ctx, s.db, node.Data)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(ctx,
+		`INSERT INTO dag_nodes (id, dag_id, data, tags, compressed, blob_key, data_hash) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		node.ID, dagID, p.Data, nodeTags(node), p.Compressed, p.BlobKey, p.DataHash,
 	)
 	if err != nil {
-		return "", fmt.Errorf("dag: insert node: %w", err)
+		return fmt.Errorf("dag: insert node: %w", err)
 	}
-
-	return node.ID, nil
+	if err := s.recordVersion(ctx, s.db, dagID); err != nil {
+		return err
+	}
+	return s.recordChanged(ctx, s.db, dagID, &dag.ChangeSet{AddNodes: []dag.Node{*node}})
 }
 
 // GetNode fetches a single node by its ID.
 // Returns nil, nil if not found.
 func (s *PGStore) GetNode(ctx context.Context, nodeID string) (*dag.Node, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
 	var n dag.Node
-	err := s.db.QueryRow(ctx,
-		`SELECT id, data FROM dag_nodes WHERE id = $1`, nodeID,
-	).Scan(&n.ID, &n.Data)
+	err := s.reader(ctx, "").QueryRow(ctx,
+		`SELECT id, `+nodeData("dag_nodes")+`, tags FROM dag_nodes WHERE `+s.idMatch("dag_nodes", "dag_nodes", "$1"), nodeID,
+	).Scan(&n.ID, &n.Data, &n.Tags)
 
 	if err != nil {
 		if isNoRows(err) {
@@ -41,42 +86,110 @@ func (s *PGStore) GetNode(ctx context.Context, nodeID string) (*dag.Node, error)
 		}
 		return nil, fmt.Errorf("dag: get node: %w", err)
 	}
+	if err := s.unpackNode(ctx, &n.Data); err != nil {
+		return nil, err
+	}
 
 	return &n, nil
 }
 
-// UpdateNode updates the data of an existing node.
+// UpdateNode updates the data of an existing node, and its tags if
+// node.Tags is non-nil (an empty slice clears them).
 // Returns ErrNodeNotFound if the node doesn't exist.
 func (s *PGStore) UpdateNode(ctx context.Context, node *dag.Node) error {
-	ct, err := s.db.Exec(ctx,
-		`UPDATE dag_nodes SET data = $1 WHERE id = $2`,
-		node.Data, node.ID,
-	)
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	return s.inWriteTx(ctx, func(s *PGStore) error { return s.updateNode(ctx, node) })
+}
+
+// updateNode is UpdateNode on s.db, which must be a transaction.
+func (s *PGStore) updateNode(ctx context.Context, node *dag.Node) error {
+	dagID, found, err := s.mutableDAGOf(ctx, s.db, "dag_nodes", node.ID)
 	if err != nil {
-		return fmt.Errorf("dag: update node: %w", err)
+		return err
 	}
-	if ct.RowsAffected() == 0 {
+	if !found {
 		return dag.ErrNodeNotFound
 	}
-	return nil
+	if _, err := s.lockDAG(ctx, dagID); err != nil {
+		return err
+	}
+	if err := s.quotaFor(ctx, dagID).CheckData(node.Data); err != nil {
+		return err
+	}
+	if err := s.schemas.CheckNode(node); err != nil {
+		return err
+	}
+	if s.nodeTypes != nil {
+		if err := s.nodeTypes.CheckNode(node); err != nil {
+			return err
+		}
+		// A type change must fit the edges the node already has.
+		var out int
+		if err := s.db.QueryRow(ctx, `SELECT COUNT(*) FROM dag_edges WHERE from_node_id = $1`, node.ID).Scan(&out); err != nil {
+			return fmt.Errorf("dag: count edges: %w", err)
+		}
+		if err := s.nodeTypes.CheckOutDegree(node.ID, s.nodeTypes.TypeOf(node), out); err != nil {
+			return err
+		}
+	}
+
+	p, err := s.packNode(ctx, s.db, node.Data)
+	if err != nil {
+		return err
+	}
+	err = s.db.QueryRow(ctx,
+		`UPDATE dag_nodes SET data = $1, compressed = $4, blob_key = $5, data_hash = $6, tags = COALESCE($3, tags) WHERE `+s.idMatch("dag_nodes", "dag_nodes", "$2")+` RETURNING dag_id`,
+		p.Data, node.ID, node.Tags, p.Compressed, p.BlobKey, p.DataHash,
+	).Scan(&dagID)
+	if err != nil {
+		if isNoRows(err) {
+			return dag.ErrNodeNotFound
+		}
+		return fmt.Errorf("dag: update node: %w", err)
+	}
+	if err := s.recordVersion(ctx, s.db, dagID); err != nil {
+		return err
+	}
+	return s.recordChanged(ctx, s.db, dagID, &dag.ChangeSet{UpdateNodes: []dag.Node{*node}})
 }
 
 // DeleteNode deletes a node by its ID.
 // Associated edges are cascade-deleted by the DB.
 // No error if the node doesn't exist.
 func (s *PGStore) DeleteNode(ctx context.Context, nodeID string) error {
-	_, err := s.db.Exec(ctx, `DELETE FROM dag_nodes WHERE id = $1`, nodeID)
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	return s.inWriteTx(ctx, func(s *PGStore) error { return s.deleteNode(ctx, nodeID) })
+}
+
+// deleteNode is DeleteNode on s.db, which must be a transaction.
+func (s *PGStore) deleteNode(ctx context.Context, nodeID string) error {
+	if err := s.lockOwner(ctx, "dag_nodes", nodeID); err != nil {
+		return err
+	}
+
+	var dagID string
+	err := s.db.QueryRow(ctx, `DELETE FROM dag_nodes WHERE `+s.idMatch("dag_nodes", "dag_nodes", "$1")+` RETURNING dag_id`, nodeID).Scan(&dagID)
 	if err != nil {
+		if isNoRows(err) {
+			return nil
+		}
 		return fmt.Errorf("dag: delete node: %w", err)
 	}
-	return nil
+	if err := s.recordVersion(ctx, s.db, dagID); err != nil {
+		return err
+	}
+	return s.recordChanged(ctx, s.db, dagID, &dag.ChangeSet{DeleteNodes: []string{nodeID}})
 }
 
 // ListNodes returns all nodes for a dagID, ordered by created_at.
 // Returns an empty slice (not nil) if none found.
 func (s *PGStore) ListNodes(ctx context.Context, dagID string) ([]dag.Node, error) {
-	rows, err := s.db.Query(ctx,
-		`SELECT id, data FROM dag_nodes WHERE dag_id = $1 ORDER BY created_at`, dagID)
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	rows, err := s.reader(ctx, dagID).Query(ctx,
+		`SELECT id, `+nodeData("dag_nodes")+`, tags FROM dag_nodes WHERE dag_id = $1 ORDER BY created_at`, dagID)
 	if err != nil {
 		return nil, fmt.Errorf("dag: list nodes: %w", err)
 	}
@@ -85,9 +198,12 @@ func (s *PGStore) ListNodes(ctx context.Context, dagID string) ([]dag.Node, erro
 	nodes := []dag.Node{}
 	for rows.Next() {
 		var n dag.Node
-		if err := rows.Scan(&n.ID, &n.Data); err != nil {
+		if err := rows.Scan(&n.ID, &n.Data, &n.Tags); err != nil {
 			return nil, fmt.Errorf("dag: scan node: %w", err)
 		}
+		if err := s.unpackNode(ctx, &n.Data); err != nil {
+			return nil, err
+		}
 		nodes = append(nodes, n)
 	}
 	if err := rows.Err(); err != nil {
@@ -97,6 +213,54 @@ func (s *PGStore) ListNodes(ctx context.Context, dagID string) ([]dag.Node, erro
 	return nodes, nil
 }
 
+// FindNodesByTag returns the nodes of a DAG carrying tag, ordered by
+// created_at. Uses the GIN index on tags rather than scanning data.
+// Returns an empty slice (not nil) if none match.
+func (s *PGStore) FindNodesByTag(ctx context.Context, dagID, tag string) ([]dag.Node, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	rows, err := s.reader(ctx, dagID).Query(ctx,
+		`SELECT id, `+nodeData("dag_nodes")+`, tags FROM dag_nodes WHERE dag_id = $1 AND tags @> ARRAY[$2]::text[] ORDER BY created_at`,
+		dagID, tag)
+	if err != nil {
+		return nil, fmt.Errorf("dag: find nodes by tag: %w", err)
+	}
+	defer rows.Close()
+
+	nodes := []dag.Node{}
+	for rows.Next() {
+		var n dag.Node
+		if err := rows.Scan(&n.ID, &n.Data, &n.Tags); err != nil {
+			return nil, fmt.Errorf("dag: scan node: %w", err)
+		}
+		if err := s.unpackNode(ctx, &n.Data); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dag: rows nodes: %w", err)
+	}
+	return nodes, nil
+}
+
+// countNodes returns the number of nodes in a DAG.
+func (s *PGStore) countNodes(ctx context.Context, dagID string) (int, error) {
+	var n int
+	if err := s.db.QueryRow(ctx, `SELECT COUNT(*) FROM dag_nodes WHERE dag_id = $1`, dagID).Scan(&n); err != nil {
+		return 0, fmt.Errorf("dag: count nodes: %w", err)
+	}
+	return n, nil
+}
+
+// nodeTags returns n's tags for insertion; the column is NOT NULL.
+func nodeTags(n *dag.Node) []string {
+	if n.Tags == nil {
+		return []string{}
+	}
+	return n.Tags
+}
+
 // isNoRows checks if the error is a "no rows" error from pgx.
 func isNoRows(err error) bool {
 	return err != nil && err.Error() == "no rows in result set"
@@ -0,0 +1,226 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// QueryEvent describes one statement the store ran.
+type QueryEvent struct {
+	SQL string
+	// Args describes each argument by type and size, e.g. "text(12)" or
+	// "jsonb(2048)", never by value: arguments carry node data.
+	Args     []string
+	Duration time.Duration
+	// Err is the statement's error, or nil.
+	Err error
+}
+
+// QueryObserver is told about every statement a store runs, including
+// those in its transactions, once the statement finishes. For a query,
+// that is when its rows are closed, so Duration includes reading them.
+// ObserveQuery is called on the goroutine that ran the statement and
+// should return quickly.
+type QueryObserver interface {
+	ObserveQuery(ctx context.Context, e QueryEvent)
+}
+
+// QueryObserverFunc adapts a function to QueryObserver.
+type QueryObserverFunc func(ctx context.Context, e QueryEvent)
+
+func (f QueryObserverFunc) ObserveQuery(ctx context.Context, e QueryEvent) { f(ctx, e) }
+
+// WithQueryObserver reports every statement to o, e.g. to log slow
+// queries:
+//
+//	postgres.WithQueryObserver(postgres.QueryObserverFunc(func(ctx context.Context, e postgres.QueryEvent) {
+//		if e.Duration > time.Second {
+//			slog.WarnContext(ctx, "slow query", "sql", e.SQL, "args", e.Args, "took", e.Duration)
+//		}
+//	}))
+func WithQueryObserver(o QueryObserver) Option {
+	return func(s *PGStore) { s.observer = o }
+}
+
+// observed wraps db so that s.observer sees its statements, if one is set.
+func (s *PGStore) observed(db DB) DB {
+	if s.observer == nil || db == nil {
+		return db
+	}
+	return &observedDB{db: db, o: s.observer}
+}
+
+// observedDB is a DB that reports its statements to o.
+type observedDB struct {
+	db DB
+	o  QueryObserver
+}
+
+func (d *observedDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return observeExec(ctx, d.o, d.db, sql, args)
+}
+
+func (d *observedDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return observeQuery(ctx, d.o, d.db, sql, args)
+}
+
+func (d *observedDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return &observedRow{row: d.db.QueryRow(ctx, sql, args...), ctx: ctx, o: d.o, e: QueryEvent{SQL: sql, Args: redact(args)}, start: time.Now()}
+}
+
+func (d *observedDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return observeBegin(ctx, d.o, "BEGIN", func() (pgx.Tx, error) { return d.db.Begin(ctx) })
+}
+
+// BeginTx lets beginSnapshot see through the wrapper.
+func (d *observedDB) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	return observeBegin(ctx, d.o, "BEGIN", func() (pgx.Tx, error) { return beginTx(ctx, d.db, opts) })
+}
+
+// observedTx is a pgx.Tx that reports its statements to o.
+type observedTx struct {
+	pgx.Tx
+	o QueryObserver
+}
+
+func (t *observedTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return observeExec(ctx, t.o, t.Tx, sql, args)
+}
+
+func (t *observedTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return observeQuery(ctx, t.o, t.Tx, sql, args)
+}
+
+func (t *observedTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return &observedRow{row: t.Tx.QueryRow(ctx, sql, args...), ctx: ctx, o: t.o, e: QueryEvent{SQL: sql, Args: redact(args)}, start: time.Now()}
+}
+
+func (t *observedTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return observeBegin(ctx, t.o, "SAVEPOINT", func() (pgx.Tx, error) { return t.Tx.Begin(ctx) })
+}
+
+func (t *observedTx) Commit(ctx context.Context) error {
+	start := time.Now()
+	err := t.Tx.Commit(ctx)
+	t.o.ObserveQuery(ctx, QueryEvent{SQL: "COMMIT", Duration: time.Since(start), Err: err})
+	return err
+}
+
+func (t *observedTx) Rollback(ctx context.Context) error {
+	start := time.Now()
+	err := t.Tx.Rollback(ctx)
+	// A deferred Rollback after Commit is not a statement.
+	if !errors.Is(err, pgx.ErrTxClosed) {
+		t.o.ObserveQuery(ctx, QueryEvent{SQL: "ROLLBACK", Duration: time.Since(start), Err: err})
+	}
+	return err
+}
+
+func observeExec(ctx context.Context, o QueryObserver, db DB, sql string, args []any) (pgconn.CommandTag, error) {
+	start := time.Now()
+	tag, err := db.Exec(ctx, sql, args...)
+	o.ObserveQuery(ctx, QueryEvent{SQL: sql, Args: redact(args), Duration: time.Since(start), Err: err})
+	return tag, err
+}
+
+func observeQuery(ctx context.Context, o QueryObserver, db DB, sql string, args []any) (pgx.Rows, error) {
+	e := QueryEvent{SQL: sql, Args: redact(args)}
+	start := time.Now()
+	rows, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		e.Duration, e.Err = time.Since(start), err
+		o.ObserveQuery(ctx, e)
+		return nil, err
+	}
+	return &observedRows{Rows: rows, ctx: ctx, o: o, e: e, start: start}, nil
+}
+
+func observeBegin(ctx context.Context, o QueryObserver, sql string, begin func() (pgx.Tx, error)) (pgx.Tx, error) {
+	start := time.Now()
+	tx, err := begin()
+	o.ObserveQuery(ctx, QueryEvent{SQL: sql, Duration: time.Since(start), Err: err})
+	if err != nil {
+		return nil, err
+	}
+	return &observedTx{Tx: tx, o: o}, nil
+}
+
+// observedRows reports its query when the rows are done.
+type observedRows struct {
+	pgx.Rows
+	ctx   context.Context
+	o     QueryObserver
+	e     QueryEvent
+	start time.Time
+	done  bool
+}
+
+func (r *observedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.report()
+	return false
+}
+
+func (r *observedRows) Close() {
+	r.Rows.Close()
+	r.report()
+}
+
+func (r *observedRows) report() {
+	if r.done {
+		return
+	}
+	r.done = true
+	r.e.Duration, r.e.Err = time.Since(r.start), r.Rows.Err()
+	r.o.ObserveQuery(r.ctx, r.e)
+}
+
+// observedRow reports its query after Scan. A pgx.ErrNoRows from Scan is
+// reported as an error, as the caller sees it.
+type observedRow struct {
+	row   pgx.Row
+	ctx   context.Context
+	o     QueryObserver
+	e     QueryEvent
+	start time.Time
+}
+
+func (r *observedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	r.e.Duration, r.e.Err = time.Since(r.start), err
+	r.o.ObserveQuery(r.ctx, r.e)
+	return err
+}
+
+// redact describes args without their values.
+func redact(args []any) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	out := make([]string, len(args))
+	for i, a := range args {
+		switch v := a.(type) {
+		case nil:
+			out[i] = "null"
+		case string:
+			out[i] = fmt.Sprintf("text(%d)", len(v))
+		case json.RawMessage:
+			out[i] = fmt.Sprintf("jsonb(%d)", len(v))
+		case []byte:
+			out[i] = fmt.Sprintf("bytea(%d)", len(v))
+		case []string:
+			out[i] = fmt.Sprintf("text[](%d)", len(v))
+		default:
+			out[i] = fmt.Sprintf("%T", v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// WithOutbox writes every write's dag.Event to dag_outbox in the write's
+// transaction, for a Relay to publish. An event is in the outbox if and
+// only if its write committed, so downstream systems see every change even
+// if the process dies right after the commit. Writes that are otherwise a
+// single statement run in a transaction under this option. Restore records
+// an EventCreated for each DAG it restores.
+func WithOutbox() Option {
+	return func(s *PGStore) { s.outbox = true }
+}
+
+// outboxLock is the advisory lock key that makes one RelayOutbox run at a
+// time, so each batch is published in order.
+const outboxLock = `hashtext('dag_outbox')`
+
+// RelayOutbox publishes up to limit of the oldest events in dag_outbox to
+// sink and deletes them once Publish returns nil. It returns how many
+// events it published. If Publish fails the events stay for the next call.
+// If another RelayOutbox is running, it returns 0 without waiting.
+func (s *PGStore) RelayOutbox(ctx context.Context, sink dag.EventSink, limit int) (int, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("dag: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var locked bool
+	if err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock(`+outboxLock+`)`).Scan(&locked); err != nil {
+		return 0, fmt.Errorf("dag: lock outbox: %w", err)
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	rows, err := tx.Query(ctx,
+		`SELECT `+eventColumns+` FROM dag_outbox ORDER BY seq LIMIT $1`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("dag: read outbox: %w", err)
+	}
+	events, err := s.scanEvents(ctx, rows)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := sink.Publish(ctx, events); err != nil {
+		return 0, fmt.Errorf("dag: publish events: %w", err)
+	}
+	// Delete exactly the published rows: seq is taken at insert, not at
+	// commit, so a lower seq may commit after this read and is not yet sent.
+	seqs := make([]int64, len(events))
+	for i, e := range events {
+		seqs[i] = e.Seq
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM dag_outbox WHERE seq = ANY($1)`, seqs); err != nil {
+		return 0, fmt.Errorf("dag: delete published events: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("dag: commit: %w", err)
+	}
+	return len(events), nil
+}
+
+// Relay publishes the events WithOutbox writes to Sink, oldest first. Run
+// it in a goroutine; it stops when ctx is cancelled. Several processes may
+// run one against the same database: only one publishes at a time.
+//
+//	r := &postgres.Relay{Store: store, Sink: sink, Interval: time.Second}
+//	go r.Run(ctx)
+type Relay struct {
+	Store *PGStore
+	Sink  dag.EventSink
+	// Interval between sweeps. 0 means one second.
+	Interval time.Duration
+	// BatchSize caps how many events one Publish call gets. 0 means 100.
+	BatchSize int
+	// OnError, if set, receives errors from sweeps, including Publish
+	// errors.
+	OnError func(err error)
+}
+
+// Run sweeps immediately and then every Interval until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		r.Sweep(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Sweep publishes batches until the outbox is empty or a batch fails, and
+// returns how many events it published.
+func (r *Relay) Sweep(ctx context.Context) int {
+	limit := r.BatchSize
+	if limit <= 0 {
+		limit = 100
+	}
+	total := 0
+	for ctx.Err() == nil {
+		n, err := r.Store.RelayOutbox(ctx, r.Sink, limit)
+		if err != nil && r.OnError != nil {
+			r.OnError(err)
+		}
+		total += n
+		if err != nil || n < limit {
+			break
+		}
+	}
+	return total
+}
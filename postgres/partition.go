@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// WithPartitions makes CreateSchema create dag_nodes and dag_edges
+// hash-partitioned by dag_id into n partitions, and routes lookups by node
+// or edge ID to the one partition that holds the row. Use it for
+// installations expected to grow to hundreds of millions of rows; per-DAG
+// reads and writes then touch a single partition.
+//
+// It must be set on every store that shares the database, and n must match
+// the partition count the tables were created with. Existing unpartitioned
+// tables are converted with PartitionTables.
+func WithPartitions(n int) Option {
+	return func(s *PGStore) { s.partitions = n }
+}
+
+// partitionSQL creates the partitioned node and edge tables. It runs before
+// schemaSQL, whose CREATE TABLE IF NOT EXISTS statements then leave them
+// alone.
+//
+// A primary key on a partitioned table must include the partition key, so
+// the tables are keyed by (dag_id, id). dag_node_ids and dag_edge_ids keep
+// IDs unique across DAGs, as they are without partitioning, and map each ID
+// to its DAG. Triggers maintain them.
+func partitionSQL(n int) string {
+	var b strings.Builder
+	b.WriteString(`
+CREATE TABLE IF NOT EXISTS dag_node_data (
+    hash       TEXT PRIMARY KEY,
+    data       JSONB NOT NULL,
+    compressed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS dag_nodes (
+    id         TEXT NOT NULL,
+    dag_id     TEXT NOT NULL,
+    data       JSONB NOT NULL DEFAULT '{}',
+    tags       TEXT[] NOT NULL DEFAULT '{}',
+    compressed BOOLEAN NOT NULL DEFAULT FALSE,
+    blob_key   TEXT,
+    data_hash  TEXT REFERENCES dag_node_data(hash),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (dag_id, id)
+) PARTITION BY HASH (dag_id);
+
+CREATE TABLE IF NOT EXISTS dag_edges (
+    id           TEXT NOT NULL,
+    dag_id       TEXT NOT NULL,
+    from_node_id TEXT NOT NULL,
+    to_node_id   TEXT NOT NULL,
+    data         JSONB NOT NULL DEFAULT '{}',
+    order_index  INT NOT NULL DEFAULT 0,
+    unique_pair  BOOLEAN NOT NULL DEFAULT FALSE,
+    compressed   BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (dag_id, id),
+    FOREIGN KEY (dag_id, from_node_id) REFERENCES dag_nodes(dag_id, id) ON DELETE CASCADE,
+    FOREIGN KEY (dag_id, to_node_id) REFERENCES dag_nodes(dag_id, id) ON DELETE CASCADE
+) PARTITION BY HASH (dag_id);
+`)
+	for i := range n {
+		fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS dag_nodes_p%d PARTITION OF dag_nodes FOR VALUES WITH (MODULUS %d, REMAINDER %d);\n", i, n, i)
+		fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS dag_edges_p%d PARTITION OF dag_edges FOR VALUES WITH (MODULUS %d, REMAINDER %d);\n", i, n, i)
+	}
+	b.WriteString(`
+-- Unique indexes on partitioned tables must include dag_id. Both ends of an
+-- edge are in the edge's DAG, so this is the same constraint.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dag_edges_unique_pair
+    ON dag_edges(dag_id, from_node_id, to_node_id) WHERE unique_pair;
+
+CREATE TABLE IF NOT EXISTS dag_node_ids (
+    id     TEXT PRIMARY KEY,
+    dag_id TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS dag_edge_ids (
+    id     TEXT PRIMARY KEY,
+    dag_id TEXT NOT NULL
+);
+
+-- sync_dag_ids keeps the ID table named by its argument in step with the
+-- rows of the partitioned table it fires on. A row moved to another DAG
+-- (PromoteDraft) fires as a delete followed by an insert.
+CREATE OR REPLACE FUNCTION sync_dag_ids() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('DELETE', 'UPDATE') THEN
+        EXECUTE format('DELETE FROM %I WHERE id = $1 AND dag_id = $2', TG_ARGV[0]) USING OLD.id, OLD.dag_id;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        EXECUTE format('INSERT INTO %I (id, dag_id) VALUES ($1, $2)', TG_ARGV[0]) USING NEW.id, NEW.dag_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER trg_dag_nodes_ids
+    AFTER INSERT OR DELETE OR UPDATE OF id, dag_id ON dag_nodes
+    FOR EACH ROW EXECUTE FUNCTION sync_dag_ids('dag_node_ids');
+
+CREATE OR REPLACE TRIGGER trg_dag_edges_ids
+    AFTER INSERT OR DELETE OR UPDATE OF id, dag_id ON dag_edges
+    FOR EACH ROW EXECUTE FUNCTION sync_dag_ids('dag_edge_ids');
+`)
+	return b.String()
+}
+
+// idTable returns the ID table for dag_nodes or dag_edges.
+func idTable(table string) string {
+	return strings.TrimSuffix(table, "s") + "_ids"
+}
+
+// idMatch is the SQL condition selecting the row of table (or its alias
+// ref) whose ID is the parameter param. With WithPartitions it also pins
+// dag_id through the ID table, so only one partition is read.
+func (s *PGStore) idMatch(table, ref, param string) string {
+	cond := ref + ".id = " + param
+	if s.partitions > 0 {
+		cond += " AND " + ref + ".dag_id = (SELECT dag_id FROM " + idTable(table) + " WHERE id = " + param + ")"
+	}
+	return cond
+}
+
+// PartitionTables converts unpartitioned dag_nodes and dag_edges tables to
+// the layout created under WithPartitions, copying every row, in a single
+// transaction. It holds exclusive locks on both tables until it finishes,
+// so run it in a maintenance window. Tables that are already partitioned
+// are left as they are. Requires WithPartitions.
+func (s *PGStore) PartitionTables(ctx context.Context) error {
+	if s.partitions <= 0 {
+		return fmt.Errorf("dag: partition tables: WithPartitions is not set")
+	}
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("dag: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var kind string
+	err = tx.QueryRow(ctx, `SELECT relkind::text FROM pg_class WHERE oid = to_regclass('dag_nodes')`).Scan(&kind)
+	if err != nil {
+		if isNoRows(err) {
+			return s.CreateSchema(ctx)
+		}
+		return fmt.Errorf("dag: inspect dag_nodes: %w", err)
+	}
+	if kind == "p" {
+		return nil
+	}
+
+	// Bring the old tables up to date so every column exists to copy.
+	if _, err := tx.Exec(ctx, schemaSQL); err != nil {
+		return fmt.Errorf("dag: update schema: %w", err)
+	}
+	// Move the old tables aside and free the index names they hold.
+	if _, err := tx.Exec(ctx, `
+		LOCK TABLE dag_nodes, dag_edges IN ACCESS EXCLUSIVE MODE;
+		ALTER TABLE dag_edges RENAME TO dag_edges_unpartitioned;
+		ALTER TABLE dag_nodes RENAME TO dag_nodes_unpartitioned;
+		DROP INDEX idx_dag_nodes_dag_id, idx_dag_nodes_tags, idx_dag_nodes_blob, idx_dag_nodes_hash,
+			idx_dag_nodes_list, idx_dag_nodes_updated, idx_dag_edges_dag_id, idx_dag_edges_from, idx_dag_edges_to,
+			idx_dag_edges_unique_pair, idx_dag_edges_list, idx_dag_edges_updated, idx_dag_edges_data;
+		ALTER TABLE dag_edges_unpartitioned DROP CONSTRAINT dag_edges_pkey;
+		ALTER TABLE dag_nodes_unpartitioned DROP CONSTRAINT dag_nodes_pkey CASCADE;`,
+	); err != nil {
+		return fmt.Errorf("dag: set aside tables: %w", err)
+	}
+	if _, err := tx.Exec(ctx, partitionSQL(s.partitions)+schemaSQL); err != nil {
+		return fmt.Errorf("dag: create partitions: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO dag_nodes (id, dag_id, data, tags, compressed, blob_key, data_hash, created_at, updated_at)
+		SELECT id, dag_id, data, tags, compressed, blob_key, data_hash, created_at, updated_at FROM dag_nodes_unpartitioned;
+		INSERT INTO dag_edges (id, dag_id, from_node_id, to_node_id, data, order_index, unique_pair, compressed, created_at, updated_at)
+		SELECT id, dag_id, from_node_id, to_node_id, data, order_index, unique_pair, compressed, created_at, updated_at FROM dag_edges_unpartitioned;
+		DROP TABLE dag_edges_unpartitioned, dag_nodes_unpartitioned;`,
+	); err != nil {
+		return fmt.Errorf("dag: copy rows: %w", err)
+	}
+	// The copy fired the stats triggers of the new tables on top of the
+	// counts already there.
+	if _, err := tx.Exec(ctx, recountSQL); err != nil {
+		return fmt.Errorf("dag: recount stats: %w", err)
+	}
+	return tx.Commit(ctx)
+}
@@ -1,15 +1,130 @@
 package postgres
 
 import (
-	"github.com/jackc/pgx/v5/pgxpool"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/meikuraledutech/dag"
 )
 
+// DB is the connection a PGStore runs its queries on. *pgxpool.Pool,
+// *pgx.Conn and pgx.Tx all satisfy it.
+//
+// Given a pgx.Tx, the store's own transactions become savepoints inside
+// it, so its writes commit or roll back with the caller's transaction.
+// A *pgx.Conn or pgx.Tx is a single connection: don't share the store
+// between goroutines then.
+type DB interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
 // PGStore implements dag.Store using PostgreSQL via pgx.
 type PGStore struct {
-	db *pgxpool.Pool
+	db         DB
+	quota      func(ctx context.Context, dagID string) dag.Quota
+	versioning bool
+	schemas    *dag.DataSchemas
+	nodeTypes  *dag.NodeTypes
+	// compressAbove is the WithCompression threshold; 0 disables it.
+	compressAbove int
+	// blobs and blobAbove are set by WithBlobStore.
+	blobs     BlobStore
+	blobAbove int
+	// dedup and dedupAbove are set by WithDedup.
+	dedup      bool
+	dedupAbove int
+	// partitions is the WithPartitions count; 0 means unpartitioned tables.
+	partitions int
+	// replica serves reads if set (WithReplica); recent tracks writes for
+	// WithReadYourWrites.
+	replica DB
+	recent  *recentWrites
+	// stmtTimeout and callTimeout are set by WithStatementTimeout and
+	// WithCallTimeout.
+	stmtTimeout time.Duration
+	callTimeout time.Duration
+	// observer is set by WithQueryObserver.
+	observer QueryObserver
+	// execMode is set by WithQueryExecMode.
+	execMode *pgx.QueryExecMode
+	// eventLog and outbox are set by WithEventLog and WithOutbox.
+	eventLog bool
+	outbox   bool
+}
+
+// Option configures a PGStore.
+type Option func(*PGStore)
+
+// New creates a new PGStore backed by db, usually a *pgxpool.Pool.
+func New(db DB, opts ...Option) *PGStore {
+	s := &PGStore{db: db}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.db = s.wrap(s.db)
+	s.replica = s.wrap(s.replica)
+	return s
+}
+
+// Using returns a copy of s, with the same options, that runs on db
+// instead. Use it to make DAG operations part of a transaction of your own:
+//
+//	tx, _ := pool.Begin(ctx)
+//	defer tx.Rollback(ctx)
+//	// ... your writes ...
+//	store.Using(tx).AddNode(ctx, "intake", n)
+//	tx.Commit(ctx)
+//
+// Every read of the copy goes to db too, even with WithReplica.
+func (s *PGStore) Using(db DB) *PGStore {
+	c := *s
+	c.db = c.wrap(db)
+	c.replica = nil
+	return &c
+}
+
+// wrap applies the options that decorate the connection to db.
+func (s *PGStore) wrap(db DB) DB {
+	return s.timed(s.observed(s.moded(db)))
+}
+
+// WithQuota applies the same size quota to every DAG in the store.
+func WithQuota(q dag.Quota) Option {
+	return WithQuotaFunc(func(context.Context, string) dag.Quota { return q })
+}
+
+// WithQuotaFunc looks up the quota for each write, e.g. per tenant from a
+// value stored in ctx, or per DAG ID prefix.
+func WithQuotaFunc(f func(ctx context.Context, dagID string) dag.Quota) Option {
+	return func(s *PGStore) { s.quota = f }
+}
+
+// WithDataSchemas validates node and edge data against s on every write.
+// Invalid data is rejected with a *dag.DataError.
+func WithDataSchemas(s *dag.DataSchemas) Option {
+	return func(st *PGStore) { st.schemas = s }
+}
+
+// WithNodeTypes validates nodes against their registered type on every
+// write and enforces each type's out-degree on edge writes.
+func WithNodeTypes(r *dag.NodeTypes) Option {
+	return func(s *PGStore) { s.nodeTypes = r }
+}
+
+// NodeTypes returns the registry set with WithNodeTypes, or nil.
+func (s *PGStore) NodeTypes() *dag.NodeTypes {
+	return s.nodeTypes
 }
 
-// New creates a new PGStore backed by the given pgx connection pool.
-func New(db *pgxpool.Pool) *PGStore {
-	return &PGStore{db: db}
+// quotaFor returns the quota that applies to dagID (zero = unlimited).
+func (s *PGStore) quotaFor(ctx context.Context, dagID string) dag.Quota {
+	if s.quota == nil {
+		return dag.Quota{}
+	}
+	return s.quota(ctx, dagID)
 }
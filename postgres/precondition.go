@@ -0,0 +1,29 @@
+package postgres
+
+import "context"
+
+type preconditionKey struct{}
+
+// WithPrecondition returns a copy of ctx under which a write calls check
+// once it holds its DAG's lock, before it writes anything. An error from
+// check fails the write with that error, and nothing is written. check gets
+// a copy of the store bound to the write's transaction, so it reads the DAG
+// exactly as the write will change it: other writes to the DAG wait for
+// the lock. The server checks If-Match this way.
+//
+// The writes that run it are AddNode, AddNodes, UpdateNode, DeleteNode,
+// AddEdge, AddEdges, UpdateEdge, DeleteEdge, ApplyChangeSet and DeleteDAG.
+func WithPrecondition(ctx context.Context, check func(ctx context.Context, s *PGStore) error) context.Context {
+	return context.WithValue(ctx, preconditionKey{}, check)
+}
+
+// checkPrecondition runs the check set with WithPrecondition, if any, on s.
+// Writes call it once they hold their DAG's lock, with s bound to their
+// transaction.
+func (s *PGStore) checkPrecondition(ctx context.Context) error {
+	check, _ := ctx.Value(preconditionKey{}).(func(context.Context, *PGStore) error)
+	if check == nil {
+		return nil
+	}
+	return check(ctx, s)
+}
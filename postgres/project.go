@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"slices"
+	"strings"
+)
+
+// projectSQL wraps data, the SQL for a row's data, so only fields are
+// read out of it, as dag.Project would return them. Data that is not an
+// object, which includes compressed data and blob references, is returned
+// whole for the caller to unpack and project in Go. fields must have passed
+// dag.ValidateFields; keys are inlined as string literals.
+func projectSQL(data string, fields []string) string {
+	if len(fields) == 0 {
+		return data
+	}
+	root := &projection{}
+	for _, f := range fields {
+		root.add(strings.Split(f, "."))
+	}
+	return `(SELECT CASE WHEN jsonb_typeof(v) = 'object' THEN ` + root.sql(nil) +
+		` ELSE v END FROM (SELECT ` + data + `) AS p(v))`
+}
+
+// projection is a tree of the requested paths; leaves have no children.
+type projection struct {
+	children map[string]*projection
+}
+
+func (p *projection) add(path []string) {
+	if len(path) == 0 {
+		return
+	}
+	if p.children == nil {
+		p.children = map[string]*projection{}
+	}
+	c, ok := p.children[path[0]]
+	if !ok {
+		c = &projection{}
+		p.children[path[0]] = c
+	}
+	c.add(path[1:])
+}
+
+// sql builds the object for p, whose value in v lies at path.
+func (p *projection) sql(path []string) string {
+	if p.children == nil {
+		lits := make([]string, len(path))
+		for i, k := range path {
+			lits[i] = literal(k)
+		}
+		return `(v #> ARRAY[` + strings.Join(lits, ", ") + `]::text[])`
+	}
+	keys := make([]string, 0, len(p.children))
+	for k := range p.children {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	args := make([]string, 0, 2*len(keys))
+	for _, k := range keys {
+		args = append(args, literal(k), p.children[k].sql(append(slices.Clone(path), k)))
+	}
+	return `jsonb_build_object(` + strings.Join(args, ", ") + `)`
+}
+
+// literal quotes s as an SQL string literal.
+func literal(s string) string {
+	return `'` + strings.ReplaceAll(s, `'`, `''`) + `'`
+}
@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/meikuraledutech/dag"
+)
+
+// PruneOrphans deletes the nodes of a DAG that its roots cannot reach, with
+// their edges, and returns their IDs; see dag.PruneOptions for the roots.
+// The orphans are found and deleted under the DAG's lock, as one change
+// set, so a concurrent write cannot reconnect one in between. With DryRun
+// it only returns the IDs. An unknown DAG has no orphans.
+func (s *PGStore) PruneOrphans(ctx context.Context, dagID string, opts ...dag.PruneOptions) ([]string, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	var o dag.PruneOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if o.DryRun {
+		d, err := s.GetDAG(ctx, dagID, dag.GetDAGOptions{SkipData: true})
+		if err != nil {
+			return nil, err
+		}
+		if d == nil {
+			return []string{}, nil
+		}
+		return dag.Orphans(d, o.Roots...)
+	}
+
+	var pruned []string
+	err := s.changeDAG(ctx, dagID, func(nodes []dag.Node, edges []dag.Edge) (*dag.ChangeSet, error) {
+		var err error
+		pruned, err = dag.Orphans(&dag.DAG{Nodes: nodes, Edges: edges}, o.Roots...)
+		return &dag.ChangeSet{DeleteNodes: pruned}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pruned, nil
+}
+
+// DeleteSubgraph deletes rootNodeID and every node reachable only through
+// it, with their edges, in one change set under the DAG's lock; see
+// dag.PlanSubgraphDeletion. The result lists the deleted nodes, the cut
+// edges that joined them to the rest of the DAG, and with Rewire the edges
+// added in their place, with IDs. With DryRun nothing is written.
+// ErrNodeNotFound is returned if the node is not in the DAG.
+func (s *PGStore) DeleteSubgraph(ctx context.Context, dagID, rootNodeID string, opts ...dag.DeleteSubgraphOptions) (*dag.SubgraphDeletion, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	var o dag.DeleteSubgraphOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if o.DryRun {
+		d, err := s.GetDAG(ctx, dagID)
+		if err != nil {
+			return nil, err
+		}
+		if d == nil {
+			return nil, fmt.Errorf("%w: %s", dag.ErrNodeNotFound, rootNodeID)
+		}
+		return dag.PlanSubgraphDeletion(d, rootNodeID, o.Rewire)
+	}
+
+	var res *dag.SubgraphDeletion
+	var cs *dag.ChangeSet
+	err := s.changeDAG(ctx, dagID, func(nodes []dag.Node, edges []dag.Edge) (*dag.ChangeSet, error) {
+		var err error
+		if res, err = dag.PlanSubgraphDeletion(&dag.DAG{Nodes: nodes, Edges: edges}, rootNodeID, o.Rewire); err != nil {
+			return nil, err
+		}
+		cs = &dag.ChangeSet{DeleteNodes: res.NodeIDs, AddEdges: res.AddedEdges}
+		return cs, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.AddedEdges = cs.AddEdges
+	return res, nil
+}
+
+// ReplaceSubgraph swaps the region DeleteSubgraph would delete from
+// rootNodeID for the nodes and edges of replacement, rewiring the boundary
+// as dag.PlanReplaceSubgraph describes, in one change set under the DAG's
+// lock. Replacement nodes without an ID get one, filled in in replacement.
+// The graph is checked like any change set, so a replacement that would
+// close a cycle returns ErrCycleDetected and writes nothing. Returns the
+// applied change set.
+func (s *PGStore) ReplaceSubgraph(ctx context.Context, dagID, rootNodeID string, replacement *dag.DAG) (*dag.ChangeSet, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	for i := range replacement.Nodes {
+		if replacement.Nodes[i].ID == "" {
+			replacement.Nodes[i].ID = uuid.NewString()
+		}
+	}
+	var cs *dag.ChangeSet
+	err := s.changeDAG(ctx, dagID, func(nodes []dag.Node, edges []dag.Edge) (*dag.ChangeSet, error) {
+		var err error
+		cs, err = dag.PlanReplaceSubgraph(&dag.DAG{Nodes: nodes, Edges: edges}, rootNodeID, replacement)
+		return cs, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
@@ -0,0 +1,270 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// Ancestors returns every node that can reach nodeID by following edges,
+// ordered by created_at. Computed with a recursive query in the database.
+// Returns ErrNodeNotFound if the node doesn't exist.
+func (s *PGStore) Ancestors(ctx context.Context, nodeID string) ([]dag.Node, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	return s.reachable(ctx, nodeID, `
+		WITH RECURSIVE reach(id) AS (
+			SELECT from_node_id FROM dag_edges WHERE dag_id = $2 AND to_node_id = $1
+			UNION
+			SELECT e.from_node_id FROM dag_edges e JOIN reach r ON e.to_node_id = r.id WHERE e.dag_id = $2
+		)
+		SELECT n.id, `+nodeData("n")+`, n.tags FROM dag_nodes n JOIN reach r ON r.id = n.id WHERE n.dag_id = $2 ORDER BY n.created_at`)
+}
+
+// Descendants returns every node reachable from nodeID by following edges,
+// ordered by created_at. Computed with a recursive query in the database.
+// Returns ErrNodeNotFound if the node doesn't exist.
+func (s *PGStore) Descendants(ctx context.Context, nodeID string) ([]dag.Node, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	return s.reachable(ctx, nodeID, `
+		WITH RECURSIVE reach(id) AS (
+			SELECT to_node_id FROM dag_edges WHERE dag_id = $2 AND from_node_id = $1
+			UNION
+			SELECT e.to_node_id FROM dag_edges e JOIN reach r ON e.from_node_id = r.id WHERE e.dag_id = $2
+		)
+		SELECT n.id, `+nodeData("n")+`, n.tags FROM dag_nodes n JOIN reach r ON r.id = n.id WHERE n.dag_id = $2 ORDER BY n.created_at`)
+}
+
+// reachable runs a recursive ancestors/descendants query for nodeID, with
+// the node's DAG as $2 so the query stays within it.
+func (s *PGStore) reachable(ctx context.Context, nodeID, query string) ([]dag.Node, error) {
+	db := s.reader(ctx, "")
+	dagID, err := s.nodeDAG(ctx, db, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	return s.queryReachable(ctx, db, query, nodeID, dagID)
+}
+
+// nodeDAG returns the DAG of nodeID, or ErrNodeNotFound.
+func (s *PGStore) nodeDAG(ctx context.Context, db DB, nodeID string) (string, error) {
+	var dagID string
+	if err := db.QueryRow(ctx,
+		`SELECT dag_id FROM dag_nodes WHERE `+s.idMatch("dag_nodes", "dag_nodes", "$1"), nodeID,
+	).Scan(&dagID); err != nil {
+		if isNoRows(err) {
+			return "", dag.ErrNodeNotFound
+		}
+		return "", fmt.Errorf("dag: find node: %w", err)
+	}
+	return dagID, nil
+}
+
+// queryReachable runs a reachable query for nodeID in dagID.
+func (s *PGStore) queryReachable(ctx context.Context, db DB, query, nodeID, dagID string) ([]dag.Node, error) {
+	rows, err := db.Query(ctx, query, nodeID, dagID)
+	if err != nil {
+		return nil, fmt.Errorf("dag: query reachable: %w", err)
+	}
+	defer rows.Close()
+
+	nodes := []dag.Node{}
+	for rows.Next() {
+		var n dag.Node
+		if err := rows.Scan(&n.ID, &n.Data, &n.Tags); err != nil {
+			return nil, fmt.Errorf("dag: scan node: %w", err)
+		}
+		if err := s.unpackNode(ctx, &n.Data); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dag: rows nodes: %w", err)
+	}
+
+	return nodes, nil
+}
+
+// Path returns the nodes on a shortest path from fromID to toID, inclusive.
+// The edges reachable from fromID are fetched with one recursive query and
+// searched breadth-first in memory.
+// Returns ErrNodeNotFound if either node isn't in the DAG, and nil, nil if
+// toID can't be reached from fromID.
+func (s *PGStore) Path(ctx context.Context, dagID, fromID, toID string) ([]dag.Node, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	db := s.reader(ctx, dagID)
+	var found int
+	if err := db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM dag_nodes WHERE dag_id = $1 AND id IN ($2, $3)`, dagID, fromID, toID,
+	).Scan(&found); err != nil {
+		return nil, fmt.Errorf("dag: find nodes: %w", err)
+	}
+	if (fromID == toID && found != 1) || (fromID != toID && found != 2) {
+		return nil, dag.ErrNodeNotFound
+	}
+
+	rows, err := db.Query(ctx, `
+		WITH RECURSIVE reach(from_node_id, to_node_id, created_at) AS (
+			SELECT from_node_id, to_node_id, created_at FROM dag_edges WHERE dag_id = $2 AND from_node_id = $1
+			UNION
+			SELECT e.from_node_id, e.to_node_id, e.created_at FROM dag_edges e JOIN reach r ON e.from_node_id = r.to_node_id
+			WHERE e.dag_id = $2
+		)
+		SELECT from_node_id, to_node_id FROM reach ORDER BY created_at`, fromID, dagID)
+	if err != nil {
+		return nil, fmt.Errorf("dag: query path: %w", err)
+	}
+	defer rows.Close()
+
+	adj := make(map[string][]string)
+	for rows.Next() {
+		var from, to string
+		if err := rows.Scan(&from, &to); err != nil {
+			return nil, fmt.Errorf("dag: scan edge: %w", err)
+		}
+		adj[from] = append(adj[from], to)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dag: rows edges: %w", err)
+	}
+
+	// BFS for the shortest path.
+	prev := map[string]string{fromID: ""}
+	queue := []string{fromID}
+	for len(queue) > 0 && !hasKey(prev, toID) {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range adj[id] {
+			if !hasKey(prev, next) {
+				prev[next] = id
+				queue = append(queue, next)
+			}
+		}
+	}
+	if !hasKey(prev, toID) {
+		return nil, nil
+	}
+
+	var ids []string
+	for id := toID; id != ""; id = prev[id] {
+		ids = append([]string{id}, ids...)
+	}
+
+	byID, err := s.nodesByID(ctx, dagID, ids)
+	if err != nil {
+		return nil, err
+	}
+	path := make([]dag.Node, len(ids))
+	for i, id := range ids {
+		path[i] = byID[id]
+	}
+	return path, nil
+}
+
+// Neighborhood returns the subgraph within k hops of nodeID, following
+// edges in direction dir: the nodes, nodeID included, ordered by created_at,
+// and every edge between two of them, in ListEdges order. The nodes are
+// found with one recursive query that stops after k steps. The DAG has
+// only its ID set. Returns ErrNodeNotFound if the node doesn't exist and
+// ErrInvalidHops if k is negative or dir is not a Direction.
+func (s *PGStore) Neighborhood(ctx context.Context, nodeID string, k int, dir dag.Direction) (*dag.DAG, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	if k < 0 || !dir.Valid() {
+		return nil, fmt.Errorf("%w: k %d, direction %q", dag.ErrInvalidHops, k, dir)
+	}
+	step := map[dag.Direction]string{
+		dag.Out: `SELECT e.to_node_id, h.depth + 1 FROM hood h JOIN dag_edges e ON e.from_node_id = h.id`,
+		dag.In:  `SELECT e.from_node_id, h.depth + 1 FROM hood h JOIN dag_edges e ON e.to_node_id = h.id`,
+		dag.Both: `SELECT CASE WHEN e.from_node_id = h.id THEN e.to_node_id ELSE e.from_node_id END, h.depth + 1
+			FROM hood h JOIN dag_edges e ON h.id IN (e.from_node_id, e.to_node_id)`,
+	}[dir]
+	db := s.reader(ctx, "")
+	dagID, err := s.nodeDAG(ctx, db, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.queryReachable(ctx, db, `
+		WITH RECURSIVE hood(id, depth) AS (
+			SELECT $1::text, 0
+			UNION
+			`+step+` WHERE e.dag_id = $2 AND h.depth < `+strconv.Itoa(k)+`
+		)
+		SELECT n.id, `+nodeData("n")+`, n.tags FROM dag_nodes n
+		WHERE n.dag_id = $2 AND n.id IN (SELECT id FROM hood) ORDER BY n.created_at`, nodeID, dagID)
+	if err != nil {
+		return nil, err
+	}
+	d := &dag.DAG{ID: dagID, Nodes: nodes}
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID
+	}
+	if d.Edges, err = s.edgesBetween(ctx, db, dagID, ids); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// edgesBetween returns the edges of dagID whose ends are both in ids.
+func (s *PGStore) edgesBetween(ctx context.Context, db DB, dagID string, ids []string) ([]dag.Edge, error) {
+	rows, err := db.Query(ctx, `
+		SELECT id, from_node_id, to_node_id, data, order_index FROM dag_edges
+		WHERE dag_id = $1 AND from_node_id = ANY($2) AND to_node_id = ANY($2)
+		ORDER BY order_index, created_at, id`, dagID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("dag: query edges: %w", err)
+	}
+	defer rows.Close()
+
+	edges := []dag.Edge{}
+	for rows.Next() {
+		var e dag.Edge
+		if err := rows.Scan(&e.ID, &e.FromNodeID, &e.ToNodeID, &e.Data, &e.OrderIndex); err != nil {
+			return nil, fmt.Errorf("dag: scan edge: %w", err)
+		}
+		if err := unpack(&e.Data); err != nil {
+			return nil, err
+		}
+		edges = append(edges, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dag: rows edges: %w", err)
+	}
+	return edges, nil
+}
+
+// nodesByID fetches the given nodes of dagID keyed by ID.
+func (s *PGStore) nodesByID(ctx context.Context, dagID string, ids []string) (map[string]dag.Node, error) {
+	rows, err := s.reader(ctx, dagID).Query(ctx, `SELECT id, `+nodeData("dag_nodes")+`, tags FROM dag_nodes WHERE dag_id = $1 AND id = ANY($2)`, dagID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("dag: query nodes: %w", err)
+	}
+	defer rows.Close()
+
+	byID := make(map[string]dag.Node, len(ids))
+	for rows.Next() {
+		var n dag.Node
+		if err := rows.Scan(&n.ID, &n.Data, &n.Tags); err != nil {
+			return nil, fmt.Errorf("dag: scan node: %w", err)
+		}
+		if err := s.unpackNode(ctx, &n.Data); err != nil {
+			return nil, err
+		}
+		byID[n.ID] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dag: rows nodes: %w", err)
+	}
+	return byID, nil
+}
+
+func hasKey(m map[string]string, k string) bool {
+	_, ok := m[k]
+	return ok
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithReplica sends reads to replica, a pool connected to a streaming
+// replica of the primary New was given. Writes, and the reads a write
+// does to validate itself, always use the primary. Use Primary, or
+// WithReadYourWrites, where replication lag would surprise the caller.
+func WithReplica(replica DB) Option {
+	return func(s *PGStore) { s.replica = replica }
+}
+
+// WithReadYourWrites keeps reads of a DAG on the primary for window after
+// this store last wrote to it, so a client that writes and then reads sees
+// its write despite replication lag. Reads by node or edge ID alone
+// (GetNode, GetEdge, Ancestors, Descendants, Neighborhood) stay on the
+// primary for window after any write. Writes made through other processes
+// are not seen; set window above the usual replication lag. Only matters
+// with WithReplica.
+func WithReadYourWrites(window time.Duration) Option {
+	return func(s *PGStore) {
+		s.recent = &recentWrites{window: window, at: make(map[string]time.Time)}
+	}
+}
+
+type primaryKey struct{}
+
+// Primary returns a context whose reads go to the primary even when a
+// replica is configured.
+func Primary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// reader returns the connection for a read of dagID, or of an unknown DAG if
+// dagID is "".
+func (s *PGStore) reader(ctx context.Context, dagID string) DB {
+	if s.replica == nil {
+		return s.db
+	}
+	if primary, _ := ctx.Value(primaryKey{}).(bool); primary || s.recent.has(dagID) {
+		return s.db
+	}
+	return s.replica
+}
+
+// readerAll is reader for a read spanning several DAGs: it goes to the
+// primary if any of them would.
+func (s *PGStore) readerAll(ctx context.Context, dagIDs []string) DB {
+	for _, id := range dagIDs {
+		if db := s.reader(ctx, id); db == s.db {
+			return db
+		}
+	}
+	return s.reader(ctx, "")
+}
+
+// recentWrites remembers when this store last wrote to each DAG.
+type recentWrites struct {
+	window time.Duration
+	mu     sync.Mutex
+	at     map[string]time.Time
+	last   time.Time
+}
+
+// note records a write to dagID.
+func (r *recentWrites) note(dagID string) {
+	if r == nil {
+		return
+	}
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.at[dagID] = now
+	r.last = now
+	// Forget expired entries now and then so the map stays small.
+	if len(r.at) > 1024 {
+		for id, t := range r.at {
+			if now.Sub(t) > r.window {
+				delete(r.at, id)
+			}
+		}
+	}
+}
+
+// has reports whether dagID, or any DAG if dagID is "", was written within
+// the window.
+func (r *recentWrites) has(dagID string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := r.last
+	if dagID != "" {
+		t = r.at[dagID]
+	}
+	return time.Since(t) <= r.window
+}
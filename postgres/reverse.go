@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// ReverseDAG stores dag.Reverse of dagID as a new DAG with ID newID, with
+// new node and edge IDs, the same name and tags, and default settings and
+// status: a single_root or tree DAG rarely stays one when reversed. opts
+// are passed to CreateDAG, so Replace overwrites newID and DryRun writes
+// nothing. Returns ErrDAGNotFound if dagID has no nodes.
+func (s *PGStore) ReverseDAG(ctx context.Context, dagID, newID string, opts ...dag.CreateDAGOptions) (*dag.DAG, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	d, err := s.GetDAG(ctx, dagID)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, dag.ErrDAGNotFound
+	}
+	r := dag.Reverse(d)
+	*r = dag.DAG{ID: newID, Name: r.Name, Tags: r.Tags, Nodes: r.Nodes, Edges: r.Edges}
+	// The old IDs become refs, so CreateDAG wires the copy with new ones.
+	for i := range r.Nodes {
+		r.Nodes[i].Ref, r.Nodes[i].ID = r.Nodes[i].ID, ""
+	}
+	for i := range r.Edges {
+		e := &r.Edges[i]
+		e.FromNodeRef, e.ToNodeRef = e.FromNodeID, e.ToNodeID
+		e.ID, e.FromNodeID, e.ToNodeID = "", "", ""
+	}
+	return s.CreateDAG(ctx, r, opts...)
+}
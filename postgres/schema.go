@@ -3,11 +3,58 @@ package postgres
 import "context"
 
 const schemaSQL = `
+CREATE TABLE IF NOT EXISTS dags (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL DEFAULT '',
+    tags       TEXT[] NOT NULL DEFAULT '{}',
+    status     TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'published', 'archived')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ,
+    draft_of   TEXT,
+    settings   JSONB NOT NULL DEFAULT '{}',
+    node_count  INT NOT NULL DEFAULT 0,
+    edge_count  INT NOT NULL DEFAULT 0,
+    max_depth   INT,
+    modified_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    content_hash TEXT
+);
+
+-- Added after the first release of the dags table.
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'draft'
+    CHECK (status IN ('draft', 'published', 'archived'));
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS draft_of TEXT;
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS settings JSONB NOT NULL DEFAULT '{}';
+-- Stats columns are added nullable and filled by the backfill at the end.
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS node_count INT;
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS edge_count INT;
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS max_depth INT;
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS modified_at TIMESTAMPTZ;
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS content_hash TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_dags_tags       ON dags USING GIN (tags);
+CREATE INDEX IF NOT EXISTS idx_dags_created_at ON dags(created_at);
+CREATE INDEX IF NOT EXISTS idx_dags_expires_at ON dags(expires_at) WHERE expires_at IS NOT NULL;
+
+-- Node data shared by content hash (WithDedup).
+CREATE TABLE IF NOT EXISTS dag_node_data (
+    hash       TEXT PRIMARY KEY,
+    data       JSONB NOT NULL,
+    compressed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
 CREATE TABLE IF NOT EXISTS dag_nodes (
     id         TEXT PRIMARY KEY,
     dag_id     TEXT NOT NULL,
     data       JSONB NOT NULL DEFAULT '{}',
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    tags       TEXT[] NOT NULL DEFAULT '{}',
+    compressed BOOLEAN NOT NULL DEFAULT FALSE,
+    blob_key   TEXT,
+    data_hash  TEXT REFERENCES dag_node_data(hash),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
 CREATE TABLE IF NOT EXISTS dag_edges (
@@ -16,23 +63,189 @@ CREATE TABLE IF NOT EXISTS dag_edges (
     from_node_id TEXT NOT NULL REFERENCES dag_nodes(id) ON DELETE CASCADE,
     to_node_id   TEXT NOT NULL REFERENCES dag_nodes(id) ON DELETE CASCADE,
     data         JSONB NOT NULL DEFAULT '{}',
-    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    order_index  INT NOT NULL DEFAULT 0,
+    unique_pair  BOOLEAN NOT NULL DEFAULT FALSE,
+    compressed   BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
+ALTER TABLE dag_nodes ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE dag_edges ADD COLUMN IF NOT EXISTS order_index INT NOT NULL DEFAULT 0;
+ALTER TABLE dag_edges ADD COLUMN IF NOT EXISTS unique_pair BOOLEAN NOT NULL DEFAULT FALSE;
+-- compressed marks rows whose data is a "zstd:..." string (WithCompression).
+ALTER TABLE dag_nodes ADD COLUMN IF NOT EXISTS compressed BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE dag_edges ADD COLUMN IF NOT EXISTS compressed BOOLEAN NOT NULL DEFAULT FALSE;
+-- blob_key is the object key of node data moved out by WithBlobStore.
+ALTER TABLE dag_nodes ADD COLUMN IF NOT EXISTS blob_key TEXT;
+-- data_hash points at dag_node_data; data is then an empty object.
+ALTER TABLE dag_nodes ADD COLUMN IF NOT EXISTS data_hash TEXT REFERENCES dag_node_data(hash);
+-- updated_at is the last change to a row's content; older rows start at
+-- created_at.
+ALTER TABLE dag_nodes ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+ALTER TABLE dag_edges ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+UPDATE dag_nodes SET updated_at = created_at WHERE updated_at IS NULL;
+UPDATE dag_edges SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE dag_nodes ALTER COLUMN updated_at SET DEFAULT NOW(), ALTER COLUMN updated_at SET NOT NULL;
+ALTER TABLE dag_edges ALTER COLUMN updated_at SET DEFAULT NOW(), ALTER COLUMN updated_at SET NOT NULL;
+
+-- dag_touch stamps updated_at when a node's data or tags, or an edge's data
+-- or ends, change. Moving rows between DAGs (PromoteDraft) and reordering
+-- edges don't count.
+CREATE OR REPLACE FUNCTION dag_touch() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at := NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER trg_dag_nodes_touch BEFORE UPDATE ON dag_nodes FOR EACH ROW
+    WHEN (OLD.data IS DISTINCT FROM NEW.data OR OLD.data_hash IS DISTINCT FROM NEW.data_hash OR OLD.tags IS DISTINCT FROM NEW.tags)
+    EXECUTE FUNCTION dag_touch();
+CREATE OR REPLACE TRIGGER trg_dag_edges_touch BEFORE UPDATE ON dag_edges FOR EACH ROW
+    WHEN (OLD.data IS DISTINCT FROM NEW.data OR OLD.from_node_id IS DISTINCT FROM NEW.from_node_id OR OLD.to_node_id IS DISTINCT FROM NEW.to_node_id)
+    EXECUTE FUNCTION dag_touch();
+
 CREATE INDEX IF NOT EXISTS idx_dag_nodes_dag_id ON dag_nodes(dag_id);
+CREATE INDEX IF NOT EXISTS idx_dag_nodes_tags   ON dag_nodes USING GIN (tags);
 CREATE INDEX IF NOT EXISTS idx_dag_edges_dag_id ON dag_edges(dag_id);
 CREATE INDEX IF NOT EXISTS idx_dag_edges_from   ON dag_edges(from_node_id);
 CREATE INDEX IF NOT EXISTS idx_dag_edges_to     ON dag_edges(to_node_id);
+CREATE INDEX IF NOT EXISTS idx_dag_nodes_blob   ON dag_nodes(blob_key) WHERE blob_key IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_dag_nodes_hash   ON dag_nodes(data_hash) WHERE data_hash IS NOT NULL;
+-- Keyset pagination of ListNodesPage / ListEdgesPage in created_at and
+-- updated_at order.
+CREATE INDEX IF NOT EXISTS idx_dag_nodes_list    ON dag_nodes(dag_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_dag_edges_list    ON dag_edges(dag_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_dag_nodes_updated ON dag_nodes(dag_id, updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_dag_edges_updated ON dag_edges(dag_id, updated_at, id);
+-- FindEdges: containment and JSON path matches on edge data.
+CREATE INDEX IF NOT EXISTS idx_dag_edges_data    ON dag_edges USING GIN (data jsonb_path_ops);
+
+-- unique_pair mirrors the owning DAG's no_parallel_edges setting, so this
+-- index only constrains DAGs that forbid parallel edges.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dag_edges_unique_pair
+    ON dag_edges(from_node_id, to_node_id) WHERE unique_pair;
+
+CREATE TABLE IF NOT EXISTS dag_idempotency_keys (
+    key         TEXT PRIMARY KEY,
+    fingerprint TEXT NOT NULL,
+    status      INT NOT NULL DEFAULT 0,
+    body        BYTEA,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS dag_versions (
+    id         BIGSERIAL PRIMARY KEY,
+    dag_id     TEXT NOT NULL,
+    snapshot   JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dag_versions_dag_id ON dag_versions(dag_id, created_at);
+
+-- Append-only write log (WithEventLog). payload holds the event's fields
+-- other than seq, dag_id, type, created_at and actor.
+CREATE TABLE IF NOT EXISTS dag_events (
+    seq        BIGSERIAL PRIMARY KEY,
+    dag_id     TEXT NOT NULL,
+    type       TEXT NOT NULL,
+    payload    JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor      TEXT
+);
+
+-- Added after the first release of the event log.
+ALTER TABLE dag_events ADD COLUMN IF NOT EXISTS actor TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_dag_events_dag_id ON dag_events(dag_id, seq);
+-- QueryAudit across DAGs by time or actor.
+CREATE INDEX IF NOT EXISTS idx_dag_events_created ON dag_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_dag_events_actor   ON dag_events(actor, seq) WHERE actor IS NOT NULL;
+
+-- Events waiting to be published (WithOutbox); Relay deletes them once
+-- published.
+CREATE TABLE IF NOT EXISTS dag_outbox (
+    seq        BIGSERIAL PRIMARY KEY,
+    dag_id     TEXT NOT NULL,
+    type       TEXT NOT NULL,
+    payload    JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor      TEXT
+);
+
+-- Added after the first release of the outbox.
+ALTER TABLE dag_outbox ADD COLUMN IF NOT EXISTS actor TEXT;
+
+-- Visits of runs to nodes (RecordVisit), aggregated by VisitStats.
+CREATE TABLE IF NOT EXISTS dag_visits (
+    seq        BIGSERIAL PRIMARY KEY,
+    dag_id     TEXT NOT NULL,
+    run_id     TEXT NOT NULL,
+    node_id    TEXT NOT NULL,
+    visited_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dag_visits_dag_id ON dag_visits(dag_id, run_id, seq);
+
+-- Backfill metadata rows for DAGs created before the dags table existed.
+INSERT INTO dags (id, created_at)
+SELECT dag_id, MIN(created_at) FROM dag_nodes GROUP BY dag_id
+ON CONFLICT (id) DO NOTHING;
+` + statsSQL + `
+-- Backfill stats for DAGs written before the stats columns existed.
+UPDATE dags d SET
+    node_count = (SELECT COUNT(*) FROM dag_nodes n WHERE n.dag_id = d.id),
+    edge_count = (SELECT COUNT(*) FROM dag_edges e WHERE e.dag_id = d.id),
+    modified_at = d.updated_at
+WHERE d.node_count IS NULL;
+ALTER TABLE dags ALTER COLUMN node_count SET DEFAULT 0, ALTER COLUMN node_count SET NOT NULL,
+    ALTER COLUMN edge_count SET DEFAULT 0, ALTER COLUMN edge_count SET NOT NULL,
+    ALTER COLUMN modified_at SET DEFAULT NOW(), ALTER COLUMN modified_at SET NOT NULL;
 `
 
-// CreateSchema creates the dag_nodes and dag_edges tables if they don't exist.
+// dropSQL drops every table and function CreateSchema creates but
+// dag_schema_migrations.
+const dropSQL = `
+DROP TABLE IF EXISTS dag_locks, dag_visits, dag_outbox, dag_events, dag_versions, dag_idempotency_keys, dag_edges, dag_nodes, dag_node_data,
+    dag_edge_ids, dag_node_ids, dags CASCADE;
+DROP FUNCTION IF EXISTS sync_dag_ids(), dag_stats(), dag_touch();`
+
+// baselineSQL returns the statements of migration 1: schemaSQL, after
+// partitionSQL with WithPartitions.
+func (s *PGStore) baselineSQL() string {
+	if s.partitions > 0 {
+		return partitionSQL(s.partitions) + schemaSQL
+	}
+	return schemaSQL
+}
+
+// createSQL returns the statements that create the latest schema: the
+// baseline followed by every later migration.
+func (s *PGStore) createSQL() string {
+	sql := s.baselineSQL()
+	for _, m := range migrations[1:] {
+		sql += m.up(s)
+	}
+	return sql
+}
+
+// CreateSchema creates the dags, dag_nodes, dag_node_data, dag_edges,
+// dag_idempotency_keys, dag_versions, dag_events, dag_outbox, dag_visits
+// and dag_locks tables if they don't exist, installs the stats triggers,
+// and backfills dags rows and stats for older data. It records every
+// migration as applied in dag_schema_migrations, since it writes the latest
+// schema; see MigrateUp to move an existing database one version at a time.
+// With WithPartitions the node and edge tables are created partitioned.
 func (s *PGStore) CreateSchema(ctx context.Context) error {
-	_, err := s.db.Exec(ctx, schemaSQL)
+	_, err := s.db.Exec(ctx, s.createSQL()+recordMigrationsSQL())
 	return err
 }
 
-// DropSchema drops the dag_edges and dag_nodes tables.
+// DropSchema drops all tables created by CreateSchema, dag_schema_migrations
+// included.
 func (s *PGStore) DropSchema(ctx context.Context) error {
-	_, err := s.db.Exec(ctx, `DROP TABLE IF EXISTS dag_edges, dag_nodes CASCADE;`)
+	_, err := s.db.Exec(ctx, dropSQL+`
+DROP TABLE IF EXISTS dag_schema_migrations;`)
 	return err
 }
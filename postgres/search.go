@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// defaultSearchLimit is used when SearchQuery.Limit is 0.
+const defaultSearchLimit = 50
+
+// SearchDAGs finds DAGs by metadata and, optionally, node data.
+// With a Text query, results are ordered by full-text rank; otherwise by
+// newest first. Returns an empty slice (not nil) if nothing matches.
+func (s *PGStore) SearchDAGs(ctx context.Context, q dag.SearchQuery) ([]dag.SearchResult, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	where, rank := searchFilter(q, arg)
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	var b strings.Builder
+	b.WriteString(`SELECT d.id, d.name, d.tags, d.status, d.created_at, d.updated_at, d.expires_at,
+		COALESCE(d.draft_of, ''), d.settings, COALESCE(d.content_hash, ''),
+		d.node_count, d.edge_count, d.modified_at, ` + rank + ` AS rank
+		FROM dags d`)
+	if len(where) > 0 {
+		b.WriteString(` WHERE ` + strings.Join(where, ` AND `))
+	}
+	b.WriteString(` ORDER BY rank DESC, d.created_at DESC, d.id LIMIT ` + arg(limit))
+
+	rows, err := s.reader(ctx, "").Query(ctx, b.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("dag: search dags: %w", err)
+	}
+	defer rows.Close()
+
+	results := []dag.SearchResult{}
+	for rows.Next() {
+		var r dag.SearchResult
+		if err := rows.Scan(&r.ID, &r.Name, &r.Tags, &r.Status, &r.CreatedAt, &r.UpdatedAt, &r.ExpiresAt, &r.DraftOf, &r.Settings, &r.Hash, &r.NodeCount, &r.EdgeCount, &r.ModifiedAt, &r.Rank); err != nil {
+			return nil, fmt.Errorf("dag: scan dag: %w", err)
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dag: rows dags: %w", err)
+	}
+
+	return results, nil
+}
+
+// searchFilter returns the WHERE conditions matching q over dags d, and the
+// rank expression, adding parameters through arg.
+func searchFilter(q dag.SearchQuery, arg func(v any) string) (where []string, rank string) {
+	if q.Name != "" {
+		where = append(where, `d.name ILIKE '%' || `+arg(escapeLike(q.Name))+` || '%'`)
+	}
+	all := q.Tags
+	if q.Tag != "" {
+		all = append(slices.Clip(all), q.Tag)
+	}
+	if len(all) > 0 {
+		where = append(where, `d.tags @> `+arg(all)+`::text[]`)
+	}
+	if len(q.AnyTags) > 0 {
+		where = append(where, `d.tags && `+arg(q.AnyTags)+`::text[]`)
+	}
+	if q.Status != "" {
+		where = append(where, `d.status = `+arg(string(q.Status)))
+	}
+	if !q.CreatedAfter.IsZero() {
+		where = append(where, `d.created_at > `+arg(q.CreatedAfter))
+	}
+
+	rank = `0::float8`
+	if q.Text != "" {
+		tsq := `plainto_tsquery('simple', ` + arg(q.Text) + `)`
+		meta := `to_tsvector('simple', d.id || ' ' || d.name)`
+		match := meta + ` @@ ` + tsq
+		rank = `ts_rank(` + meta + `, ` + tsq + `)`
+		if q.IncludeNodeData {
+			nodeVec := `to_tsvector('simple', ` + nodeData("n") + `::text)`
+			match = `(` + match + ` OR EXISTS (SELECT 1 FROM dag_nodes n WHERE n.dag_id = d.id AND ` + nodeVec + ` @@ ` + tsq + `))`
+			rank += ` + COALESCE((SELECT MAX(ts_rank(` + nodeVec + `, ` + tsq + `)) FROM dag_nodes n WHERE n.dag_id = d.id), 0)`
+		}
+		where = append(where, match)
+	}
+	return where, rank
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/meikuraledutech/dag"
+)
+
+// uniquePairOf is the SQL for the unique_pair flag of a new edge in the DAG
+// given by parameter $2.
+const uniquePairOf = `COALESCE((SELECT (settings->>'no_parallel_edges')::boolean FROM dags WHERE id = $2), FALSE)`
+
+// UpdateSettings replaces a DAG's settings. The existing graph must already
+// satisfy them: otherwise the error of the first broken rule is returned
+// (ErrDuplicateCondition, ErrParallelEdge, ErrNotTree, ErrMultipleRoots,
+// ErrDisconnected, ErrTooDeep) and nothing changes. Returns ErrDAGNotFound
+// if the DAG has no metadata row and ErrDAGFrozen if it is published or
+// archived.
+func (s *PGStore) UpdateSettings(ctx context.Context, dagID string, settings dag.Settings) error {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("dag: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var status dag.Status
+	err = tx.QueryRow(ctx, `SELECT status FROM dags WHERE id = $1 FOR UPDATE`, dagID).Scan(&status)
+	if err != nil {
+		if isNoRows(err) {
+			return fmt.Errorf("%w: %s", dag.ErrDAGNotFound, dagID)
+		}
+		return fmt.Errorf("dag: get status: %w", err)
+	}
+	if status.Frozen() {
+		return fmt.Errorf("%w: %s is %s", dag.ErrDAGFrozen, dagID, status)
+	}
+
+	nodes, err := s.ListNodes(s.graphCtx(ctx), dagID)
+	if err != nil {
+		return err
+	}
+	edges, err := s.ListEdges(Primary(ctx), dagID)
+	if err != nil {
+		return err
+	}
+	if err := settings.CheckGraph(nodes, edges); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE dags SET settings = $2, updated_at = NOW() WHERE id = $1`, dagID, settings,
+	); err != nil {
+		return fmt.Errorf("dag: update settings: %w", err)
+	}
+	if err := syncUniquePair(ctx, tx, dagID); err != nil {
+		return err
+	}
+	if err := s.recordVersion(ctx, tx, dagID); err != nil {
+		return err
+	}
+	if err := s.recordEvent(ctx, tx, dagID, dag.Event{Type: dag.EventSettings, Settings: &settings}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// checkDepth returns ErrTooDeep if an edge from→to would put a path longer
+// than settings.MaxDepth into the DAG. The longest paths up from `from` and
+// down from `to` are found with recursive reachability queries that stop one
+// step past the limit, so the cost does not grow with the size of the DAG.
+// skipEdgeID excludes an edge that is being rewired.
+func checkDepth(ctx context.Context, db queryRower, dagID string, settings dag.Settings, from, to, skipEdgeID string) error {
+	if settings.MaxDepth <= 0 {
+		return nil
+	}
+	var depth int
+	err := db.QueryRow(ctx, `
+		WITH RECURSIVE up(id, depth) AS (
+			SELECT $1::text, 0
+			UNION
+			SELECT e.from_node_id, u.depth + 1 FROM dag_edges e JOIN up u ON e.to_node_id = u.id
+			WHERE e.dag_id = $5 AND u.depth < $3 AND e.id <> $4
+		), down(id, depth) AS (
+			SELECT $2::text, 0
+			UNION
+			SELECT e.to_node_id, d.depth + 1 FROM dag_edges e JOIN down d ON e.from_node_id = d.id
+			WHERE e.dag_id = $5 AND d.depth < $3 AND e.id <> $4
+		)
+		SELECT (SELECT MAX(depth) FROM up) + 1 + (SELECT MAX(depth) FROM down)`,
+		from, to, settings.MaxDepth, skipEdgeID, dagID,
+	).Scan(&depth)
+	if err != nil {
+		return fmt.Errorf("dag: check depth: %w", err)
+	}
+	if depth > settings.MaxDepth {
+		return fmt.Errorf("%w: edge %s -> %s would make a path of %d edges, max is %d",
+			dag.ErrTooDeep, from, to, depth, settings.MaxDepth)
+	}
+	return nil
+}
+
+// syncUniquePair sets unique_pair on every edge of dagID from the DAG's
+// settings, which makes idx_dag_edges_unique_pair check the existing edges.
+func syncUniquePair(ctx context.Context, db execer, dagID string) error {
+	_, err := db.Exec(ctx, `
+		UPDATE dag_edges e SET unique_pair = COALESCE((d.settings->>'no_parallel_edges')::boolean, FALSE)
+		FROM dags d WHERE d.id = e.dag_id AND e.dag_id = $1`, dagID)
+	if err != nil {
+		return fmt.Errorf("dag: sync edge settings: %w", parallelEdgeErr(err))
+	}
+	return nil
+}
+
+// parallelEdgeErr turns a violation of idx_dag_edges_unique_pair into
+// ErrParallelEdge. Other errors are returned unchanged.
+func parallelEdgeErr(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_dag_edges_unique_pair" {
+		return fmt.Errorf("%w: %s", dag.ErrParallelEdge, pgErr.Detail)
+	}
+	return err
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/meikuraledutech/dag"
+)
+
+// SplitNode divides nodeID in two, as spec says, in one change set under
+// the DAG's lock; see dag.PlanSplit. Generated IDs are filled in in spec.
+// The graph is checked like any change set, so a split that would close a
+// cycle returns ErrCycleDetected and writes nothing. Returns the applied
+// change set: the new node, the edge joining it to nodeID, and the moved
+// edges with their order_index.
+func (s *PGStore) SplitNode(ctx context.Context, dagID, nodeID string, spec *dag.SplitSpec) (*dag.ChangeSet, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	if spec.Node.ID == "" {
+		spec.Node.ID = uuid.NewString()
+	}
+	if spec.Edge.ID == "" {
+		spec.Edge.ID = uuid.NewString()
+	}
+	var cs *dag.ChangeSet
+	err := s.changeDAG(ctx, dagID, func(nodes []dag.Node, edges []dag.Edge) (*dag.ChangeSet, error) {
+		var err error
+		cs, err = dag.PlanSplit(&dag.DAG{Nodes: nodes, Edges: edges}, nodeID, *spec)
+		return cs, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// InsertNodeOnEdge replaces edge A → B with A → node → B in one change set
+// under the DAG's lock; see dag.PlanInsertOnEdge. node.ID is generated if
+// empty and filled in. Returns the applied change set: the new node, the
+// edge, now into the node, and the new edge out of it. ErrEdgeNotFound is
+// returned if the edge doesn't exist.
+func (s *PGStore) InsertNodeOnEdge(ctx context.Context, edgeID string, node *dag.Node, opts ...dag.InsertNodeOptions) (*dag.ChangeSet, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	var o dag.InsertNodeOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	dagID, found, err := s.mutableDAGOf(ctx, s.db, "dag_edges", edgeID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", dag.ErrEdgeNotFound, edgeID)
+	}
+	if node.ID == "" {
+		node.ID = uuid.NewString()
+	}
+	var cs *dag.ChangeSet
+	err = s.changeDAG(ctx, dagID, func(nodes []dag.Node, edges []dag.Edge) (*dag.ChangeSet, error) {
+		var err error
+		cs, err = dag.PlanInsertOnEdge(&dag.DAG{Nodes: nodes, Edges: edges}, edgeID, *node, o.EdgeData)
+		return cs, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// statsSQL installs the triggers that keep the stats columns of dags in
+// step with dag_nodes and dag_edges. They fire once per statement and add
+// up the rows it wrote per DAG, so a batch insert updates each dags row
+// once. An insert creates the dags row if it is missing; deletes and
+// updates only touch existing rows, so deleting a DAG doesn't bring it
+// back. An edge write clears max_depth, which DAGStats recomputes on the
+// next read, and every write clears content_hash, which GetDAG and
+// GetDAGInfo recompute.
+//
+// Postgres allows transition tables only on single-event triggers, hence
+// three triggers per table.
+const statsSQL = `
+CREATE OR REPLACE FUNCTION dag_stats() RETURNS trigger AS $$
+DECLARE
+    reset TEXT := CASE WHEN TG_ARGV[0] = 'edge_count' THEN ', max_depth = NULL' ELSE '' END || ', content_hash = NULL';
+BEGIN
+    IF TG_OP IN ('DELETE', 'UPDATE') THEN
+        EXECUTE format('UPDATE dags d SET %1$I = d.%1$I - c.n, modified_at = NOW()%2$s
+            FROM (SELECT dag_id, COUNT(*) AS n FROM old_rows GROUP BY dag_id) c WHERE d.id = c.dag_id',
+            TG_ARGV[0], reset);
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        EXECUTE format('INSERT INTO dags (id, %1$I) SELECT dag_id, COUNT(*) FROM new_rows GROUP BY dag_id
+            ON CONFLICT (id) DO UPDATE SET %1$I = dags.%1$I + EXCLUDED.%1$I, modified_at = NOW()%2$s',
+            TG_ARGV[0], reset);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER trg_dag_nodes_stats_ins AFTER INSERT ON dag_nodes
+    REFERENCING NEW TABLE AS new_rows FOR EACH STATEMENT EXECUTE FUNCTION dag_stats('node_count');
+CREATE OR REPLACE TRIGGER trg_dag_nodes_stats_del AFTER DELETE ON dag_nodes
+    REFERENCING OLD TABLE AS old_rows FOR EACH STATEMENT EXECUTE FUNCTION dag_stats('node_count');
+CREATE OR REPLACE TRIGGER trg_dag_nodes_stats_upd AFTER UPDATE ON dag_nodes
+    REFERENCING OLD TABLE AS old_rows NEW TABLE AS new_rows FOR EACH STATEMENT EXECUTE FUNCTION dag_stats('node_count');
+CREATE OR REPLACE TRIGGER trg_dag_edges_stats_ins AFTER INSERT ON dag_edges
+    REFERENCING NEW TABLE AS new_rows FOR EACH STATEMENT EXECUTE FUNCTION dag_stats('edge_count');
+CREATE OR REPLACE TRIGGER trg_dag_edges_stats_del AFTER DELETE ON dag_edges
+    REFERENCING OLD TABLE AS old_rows FOR EACH STATEMENT EXECUTE FUNCTION dag_stats('edge_count');
+CREATE OR REPLACE TRIGGER trg_dag_edges_stats_upd AFTER UPDATE ON dag_edges
+    REFERENCING OLD TABLE AS old_rows NEW TABLE AS new_rows FOR EACH STATEMENT EXECUTE FUNCTION dag_stats('edge_count');
+`
+
+// recountSQL recomputes every DAG's stats from the tables.
+const recountSQL = `
+UPDATE dags d SET
+    node_count = (SELECT COUNT(*) FROM dag_nodes n WHERE n.dag_id = d.id),
+    edge_count = (SELECT COUNT(*) FROM dag_edges e WHERE e.dag_id = d.id),
+    max_depth = NULL,
+    content_hash = NULL`
+
+// DAGStats returns dagID's node and edge counts, longest path and last
+// graph write, or nil, nil if the DAG has no metadata row. Counts are read
+// as stored; the depth is computed from the edges if a write has cleared
+// it since it was last stored, then stored again.
+func (s *PGStore) DAGStats(ctx context.Context, dagID string) (*dag.DAGStats, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	var st dag.DAGStats
+	var depth *int
+	err := s.reader(ctx, dagID).QueryRow(ctx,
+		`SELECT node_count, edge_count, max_depth, modified_at FROM dags WHERE id = $1`, dagID,
+	).Scan(&st.NodeCount, &st.EdgeCount, &depth, &st.ModifiedAt)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("dag: get stats: %w", err)
+	}
+	if depth != nil {
+		st.MaxDepth = *depth
+		return &st, nil
+	}
+	if st.MaxDepth, err = s.maxDepth(ctx, dagID); err != nil {
+		return nil, err
+	}
+	if err := s.storeDepth(ctx, dagID, st.MaxDepth, st.ModifiedAt); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// maxDepth computes the longest path of dagID from its edges.
+func (s *PGStore) maxDepth(ctx context.Context, dagID string) (int, error) {
+	rows, err := s.reader(ctx, dagID).Query(ctx,
+		`SELECT from_node_id, to_node_id FROM dag_edges WHERE dag_id = $1`, dagID)
+	if err != nil {
+		return 0, fmt.Errorf("dag: query depth: %w", err)
+	}
+	defer rows.Close()
+	var edges []dag.Edge
+	for rows.Next() {
+		var e dag.Edge
+		if err := rows.Scan(&e.FromNodeID, &e.ToNodeID); err != nil {
+			return 0, fmt.Errorf("dag: scan edge: %w", err)
+		}
+		edges = append(edges, e)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("dag: rows depth: %w", err)
+	}
+	return dag.Depth(edges), nil
+}
+
+// storeDepth caches depth unless the DAG was written after modifiedAt, in
+// which case the next read computes it again.
+func (s *PGStore) storeDepth(ctx context.Context, dagID string, depth int, modifiedAt time.Time) error {
+	_, err := s.db.Exec(ctx,
+		`UPDATE dags SET max_depth = $2 WHERE id = $1 AND max_depth IS NULL AND modified_at = $3`,
+		dagID, depth, modifiedAt)
+	if err != nil {
+		return fmt.Errorf("dag: store depth: %w", err)
+	}
+	return nil
+}
+
+// RecountStats recomputes every DAG's stats from the node and edge tables,
+// for use after rows were written with the triggers disabled, e.g. by a
+// bulk load with session_replication_role = replica.
+func (s *PGStore) RecountStats(ctx context.Context) error {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	if _, err := s.db.Exec(ctx, recountSQL); err != nil {
+		return fmt.Errorf("dag: recount stats: %w", err)
+	}
+	return nil
+}
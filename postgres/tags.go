@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// AddDAGTags adds tags to a DAG, skipping ones it already has, and returns
+// the resulting tags in order. Tags are metadata, so this works on frozen
+// DAGs too. Returns ErrDAGNotFound if the DAG has no metadata row.
+func (s *PGStore) AddDAGTags(ctx context.Context, dagID string, tags ...string) ([]string, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	return s.updateTags(ctx, dagID, `
+		UPDATE dags SET tags = tags || ARRAY(SELECT t FROM unnest($2::text[]) t WHERE NOT t = ANY(tags)),
+			updated_at = NOW()
+		WHERE id = $1 RETURNING tags`, dedupe(tags))
+}
+
+// RemoveDAGTags removes tags from a DAG and returns the remaining tags.
+// Tags the DAG doesn't have are ignored.
+func (s *PGStore) RemoveDAGTags(ctx context.Context, dagID string, tags ...string) ([]string, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	return s.updateTags(ctx, dagID, `
+		UPDATE dags SET tags = ARRAY(SELECT t FROM unnest(tags) WITH ORDINALITY AS u(t, i) WHERE NOT t = ANY($2::text[]) ORDER BY i),
+			updated_at = NOW()
+		WHERE id = $1 RETURNING tags`, tags)
+}
+
+func (s *PGStore) updateTags(ctx context.Context, dagID, query string, tags []string) ([]string, error) {
+	if tags == nil {
+		tags = []string{}
+	}
+	var out []string
+	err := s.inTx(ctx, func(s *PGStore) error {
+		if err := s.db.QueryRow(ctx, query, dagID, tags).Scan(&out); err != nil {
+			if isNoRows(err) {
+				return dag.ErrDAGNotFound
+			}
+			return fmt.Errorf("dag: update tags: %w", err)
+		}
+		if err := s.recordVersion(ctx, s.db, dagID); err != nil {
+			return err
+		}
+		return s.recordEvent(ctx, s.db, dagID, dag.Event{Type: dag.EventTags, Tags: out})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// dedupe drops repeated strings, keeping the first occurrence.
+func dedupe(ss []string) []string {
+	out := make([]string, 0, len(ss))
+	for _, s := range ss {
+		if !slices.Contains(out, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
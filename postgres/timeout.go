@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// WithStatementTimeout limits every statement the store runs to d, so a
+// recursive query on a malformed graph cannot hold a connection for long.
+// The store's transactions run SET LOCAL statement_timeout; single
+// statements outside a transaction are cancelled client-side after d.
+//
+// Under Using(tx) the store's transactions are savepoints, and the SET LOCAL
+// stays in force in tx until it ends.
+func WithStatementTimeout(d time.Duration) Option {
+	return func(s *PGStore) { s.stmtTimeout = d }
+}
+
+// WithCallTimeout bounds each store call to d, every statement it runs
+// included; a caller's earlier deadline still wins. Bulk calls that are
+// expected to run long are not bounded: StreamDAG, DumpAll, Restore,
+// CreateSchema, DropSchema, MigrateUp, MigrateDown, PartitionTables and
+// PruneNodeData.
+func WithCallTimeout(d time.Duration) Option {
+	return func(s *PGStore) { s.callTimeout = d }
+}
+
+// IsTimeout reports whether err comes from a statement or call timeout: a
+// context deadline, or a statement cancelled by Postgres.
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err) {
+		return true
+	}
+	// 57014 is query_canceled, which statement_timeout raises.
+	var pe *pgconn.PgError
+	return errors.As(err, &pe) && pe.Code == "57014"
+}
+
+// bound applies the WithCallTimeout deadline to ctx.
+func (s *PGStore) bound(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.callTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.callTimeout)
+}
+
+// timed wraps db in the WithStatementTimeout limit, if one is set.
+func (s *PGStore) timed(db DB) DB {
+	if s.stmtTimeout <= 0 || db == nil {
+		return db
+	}
+	return &timedDB{db: db, d: s.stmtTimeout}
+}
+
+// timedDB is a DB whose statements time out after d.
+type timedDB struct {
+	db DB
+	d  time.Duration
+}
+
+func (t *timedDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.d)
+	defer cancel()
+	return t.db.Exec(ctx, sql, args...)
+}
+
+func (t *timedDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.d)
+	rows, err := t.db.Query(ctx, sql, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &timedRows{Rows: rows, cancel: cancel}, nil
+}
+
+func (t *timedDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	ctx, cancel := context.WithTimeout(ctx, t.d)
+	return &timedRow{row: t.db.QueryRow(ctx, sql, args...), cancel: cancel}
+}
+
+func (t *timedDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return t.limit(ctx)(t.db.Begin(ctx))
+}
+
+// BeginTx lets beginSnapshot see through the wrapper.
+func (t *timedDB) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	return t.limit(ctx)(beginTx(ctx, t.db, opts))
+}
+
+// limit returns a function that sets the statement timeout in a transaction
+// just begun, rolling it back if that fails.
+func (t *timedDB) limit(ctx context.Context) func(pgx.Tx, error) (pgx.Tx, error) {
+	return func(tx pgx.Tx, err error) (pgx.Tx, error) {
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", t.d.Milliseconds())); err != nil {
+			tx.Rollback(ctx)
+			return nil, fmt.Errorf("dag: set statement timeout: %w", err)
+		}
+		return tx, nil
+	}
+}
+
+// timedRows releases the statement's deadline once the rows are done.
+type timedRows struct {
+	pgx.Rows
+	cancel context.CancelFunc
+}
+
+func (r *timedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.cancel()
+	return false
+}
+
+func (r *timedRows) Close() {
+	r.Rows.Close()
+	r.cancel()
+}
+
+// timedRow releases the statement's deadline after Scan.
+type timedRow struct {
+	row    pgx.Row
+	cancel context.CancelFunc
+}
+
+func (r *timedRow) Scan(dest ...any) error {
+	defer r.cancel()
+	return r.row.Scan(dest...)
+}
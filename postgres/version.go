@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// WithVersioning records a snapshot of a DAG in dag_versions after every
+// write to it, so RestoreDAGAt can bring back any earlier state. Each write
+// costs one extra statement that re-reads the whole DAG.
+func WithVersioning() Option {
+	return func(s *PGStore) { s.versioning = true }
+}
+
+// recordVersion snapshots dagID as it is now. The snapshot has the JSON
+// shape of a dag.DAG; a deleted DAG is recorded with no nodes.
+// Every write calls it, so it also notes the write for WithReadYourWrites.
+func (s *PGStore) recordVersion(ctx context.Context, db execer, dagID string) error {
+	s.recent.note(dagID)
+	if !s.versioning {
+		return nil
+	}
+	_, err := db.Exec(ctx, `
+		INSERT INTO dag_versions (dag_id, snapshot)
+		SELECT $1::text, `+dagSnapshot+`
+		FROM (SELECT 1) one LEFT JOIN dags d ON d.id = $1::text`, dagID)
+	if err != nil {
+		return fmt.Errorf("dag: record version: %w", err)
+	}
+	return nil
+}
+
+// dagSnapshot is the SQL for the DAG $1 as a JSON dag.DAG, given its dags
+// row as d (NULLs if it has none).
+var dagSnapshot = `jsonb_build_object(
+			'id', $1::text,
+			'name', COALESCE(d.name, ''),
+			'tags', COALESCE(to_jsonb(d.tags), '[]'),
+			'expires_at', d.expires_at,
+			'settings', COALESCE(d.settings, '{}'),
+			'nodes', COALESCE((
+				SELECT jsonb_agg(jsonb_build_object('id', n.id, 'data', ` + nodeData("n") + `, 'tags', n.tags) ORDER BY n.created_at, n.id)
+				FROM dag_nodes n WHERE n.dag_id = $1::text), '[]'),
+			'edges', COALESCE((
+				SELECT jsonb_agg(jsonb_build_object('id', e.id, 'from_node_id', e.from_node_id,
+					'to_node_id', e.to_node_id, 'data', e.data, 'order_index', e.order_index)
+					ORDER BY e.order_index, e.created_at, e.id)
+				FROM dag_edges e WHERE e.dag_id = $1::text), '[]'))`
+
+// DAGAt returns the DAG as it was at the given time, from the snapshots
+// written under WithVersioning. Returns dag.ErrNoVersion if no snapshot is
+// that old or the DAG did not exist then.
+func (s *PGStore) DAGAt(ctx context.Context, dagID string, at time.Time) (*dag.DAG, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	var snapshot []byte
+	err := s.reader(ctx, dagID).QueryRow(ctx, `
+		SELECT snapshot FROM dag_versions
+		WHERE dag_id = $1 AND created_at <= $2
+		ORDER BY created_at DESC, id DESC LIMIT 1`, dagID, at,
+	).Scan(&snapshot)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, dag.ErrNoVersion
+		}
+		return nil, fmt.Errorf("dag: get version: %w", err)
+	}
+
+	var d dag.DAG
+	if err := json.Unmarshal(snapshot, &d); err != nil {
+		return nil, fmt.Errorf("dag: decode version: %w", err)
+	}
+	if len(d.Nodes) == 0 {
+		return nil, dag.ErrNoVersion
+	}
+	if err := s.unpackNodes(ctx, d.Nodes); err != nil {
+		return nil, err
+	}
+	if err := unpackEdges(d.Edges); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// RestoreDAGAt replaces the DAG with its state at the given time, as
+// returned by DAGAt. The restore is itself a write, so it is recorded as a
+// new version and can be undone the same way.
+func (s *PGStore) RestoreDAGAt(ctx context.Context, dagID string, at time.Time) (*dag.DAG, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	d, err := s.DAGAt(Primary(ctx), dagID, at)
+	if err != nil {
+		return nil, err
+	}
+	return s.CreateDAG(ctx, d, dag.CreateDAGOptions{Replace: true})
+}
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// RecordVisit appends a visit of runID to nodeID to dag_visits, for
+// VisitStats. A run is any sequence of visits a caller wants counted
+// together, such as one person filling in a form; visits are ordered by
+// when they were recorded. Returns dag.ErrNodeNotFound if nodeID is not a
+// node of dagID.
+func (s *PGStore) RecordVisit(ctx context.Context, dagID, runID, nodeID string) error {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	tag, err := s.db.Exec(ctx, `
+		INSERT INTO dag_visits (dag_id, run_id, node_id)
+		SELECT $1, $2, $3 WHERE EXISTS (SELECT 1 FROM dag_nodes WHERE dag_id = $1 AND id = $3)`,
+		dagID, runID, nodeID)
+	if err != nil {
+		return fmt.Errorf("dag: record visit: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", dag.ErrNodeNotFound, nodeID)
+	}
+	return nil
+}
+
+// VisitStats aggregates dagID's recorded visits: the number of runs, the
+// visits and exits of each node and the transitions between consecutive
+// visits of a run. A DAG without visits has empty stats.
+func (s *PGStore) VisitStats(ctx context.Context, dagID string) (*dag.VisitStats, error) {
+	ctx, cancel := s.bound(ctx)
+	defer cancel()
+	db := s.reader(ctx, dagID)
+	st := &dag.VisitStats{Nodes: []dag.NodeVisits{}, Transitions: []dag.Transition{}}
+
+	if err := db.QueryRow(ctx,
+		`SELECT COUNT(DISTINCT run_id) FROM dag_visits WHERE dag_id = $1`, dagID,
+	).Scan(&st.Runs); err != nil {
+		return nil, fmt.Errorf("dag: count runs: %w", err)
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT v.node_id, COUNT(*), COUNT(DISTINCT v.run_id), COUNT(*) FILTER (WHERE v.seq = l.seq)
+		FROM dag_visits v
+		JOIN (SELECT run_id, MAX(seq) AS seq FROM dag_visits WHERE dag_id = $1 GROUP BY run_id) l ON l.run_id = v.run_id
+		WHERE v.dag_id = $1
+		GROUP BY v.node_id ORDER BY COUNT(*) DESC, v.node_id`, dagID)
+	if err != nil {
+		return nil, fmt.Errorf("dag: query node visits: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var n dag.NodeVisits
+		if err := rows.Scan(&n.NodeID, &n.Visits, &n.Runs, &n.Exits); err != nil {
+			return nil, fmt.Errorf("dag: scan node visits: %w", err)
+		}
+		st.Nodes = append(st.Nodes, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dag: rows node visits: %w", err)
+	}
+
+	rows, err = db.Query(ctx, `
+		SELECT from_node_id, node_id, COUNT(*) FROM (
+			SELECT node_id, LAG(node_id) OVER (PARTITION BY run_id ORDER BY seq) AS from_node_id
+			FROM dag_visits WHERE dag_id = $1
+		) v WHERE from_node_id IS NOT NULL
+		GROUP BY from_node_id, node_id ORDER BY COUNT(*) DESC, from_node_id, node_id`, dagID)
+	if err != nil {
+		return nil, fmt.Errorf("dag: query transitions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t dag.Transition
+		if err := rows.Scan(&t.FromNodeID, &t.ToNodeID, &t.Count); err != nil {
+			return nil, fmt.Errorf("dag: scan transition: %w", err)
+		}
+		st.Transitions = append(st.Transitions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dag: rows transitions: %w", err)
+	}
+	return st, nil
+}
+
+// StreamVisits calls onVisit for each visit of dagID with a Seq greater
+// than afterSeq, oldest first, reading them in one snapshot without
+// holding them in memory. Pass the last Seq seen to pull only new visits.
+// A non-nil error from onVisit stops the scan and is returned as is.
+func (s *PGStore) StreamVisits(ctx context.Context, dagID string, afterSeq int64, onVisit func(dag.Visit) error) error {
+	rows, err := s.reader(ctx, dagID).Query(ctx,
+		`SELECT seq, dag_id, run_id, node_id, visited_at FROM dag_visits WHERE dag_id = $1 AND seq > $2 ORDER BY seq`,
+		dagID, afterSeq)
+	if err != nil {
+		return fmt.Errorf("dag: query visits: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v dag.Visit
+		if err := rows.Scan(&v.Seq, &v.DAGID, &v.RunID, &v.NodeID, &v.VisitedAt); err != nil {
+			return fmt.Errorf("dag: scan visit: %w", err)
+		}
+		if err := onVisit(v); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("dag: rows visits: %w", err)
+	}
+	return nil
+}
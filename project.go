@@ -0,0 +1,80 @@
+package dag
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MaxFields caps the paths one read may project, to keep the SQL that
+// pushes projection down within Postgres's argument limits.
+const MaxFields = 50
+
+// ValidateFields checks projection paths for ListOptions.Fields and
+// GetDAGOptions.Fields. A path is a dotted list of object keys, such as
+// "question" or "meta.label". Empty segments, duplicate paths and a path
+// inside another one are rejected with ErrInvalidField.
+func ValidateFields(fields []string) error {
+	if len(fields) > MaxFields {
+		return fmt.Errorf("%w: at most %d fields", ErrInvalidField, MaxFields)
+	}
+	for i, f := range fields {
+		if slices.Contains(strings.Split(f, "."), "") {
+			return fmt.Errorf("%w %q", ErrInvalidField, f)
+		}
+		for _, g := range fields[:i] {
+			if f == g || strings.HasPrefix(f, g+".") || strings.HasPrefix(g, f+".") {
+				return fmt.Errorf("%w %q overlaps %q", ErrInvalidField, f, g)
+			}
+		}
+	}
+	return nil
+}
+
+// Project returns the parts of data named by fields, nested as they are in
+// data: fields "question" and "meta.label" turn {"question": "q", "meta":
+// {"label": "l", "x": 1}, "y": 2} into {"meta": {"label": "l"}, "question":
+// "q"}. A path missing from data is null in the result. Projecting data
+// that is already projected with the same fields returns it unchanged, so
+// stores may project in SQL and again after decoding. nil data stays nil.
+func Project(data json.RawMessage, fields []string) json.RawMessage {
+	if data == nil || len(fields) == 0 {
+		return data
+	}
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		doc = nil
+	}
+	out := map[string]any{}
+	for _, f := range fields {
+		path := strings.Split(f, ".")
+		dst := out
+		for _, k := range path[:len(path)-1] {
+			next, ok := dst[k].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				dst[k] = next
+			}
+			dst = next
+		}
+		dst[path[len(path)-1]] = lookup(doc, path)
+	}
+	b, _ := json.Marshal(out)
+	return b
+}
+
+// lookup follows path through nested objects in v, or returns nil.
+func lookup(v any, path []string) any {
+	for _, k := range path {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[k]
+	}
+	return v
+}
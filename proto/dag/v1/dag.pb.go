@@ -0,0 +1,1743 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: dag/v1/dag.proto
+
+package dagv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// DAG mirrors dag.DAG.
+type DAG struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Nodes     []*Node                `protobuf:"bytes,2,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	Edges     []*Edge                `protobuf:"bytes,3,rep,name=edges,proto3" json:"edges,omitempty"`
+	Name      string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Tags      []string               `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty"`
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Lifecycle status: "draft", "published" or "archived". Output only.
+	Status   string    `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`
+	Settings *Settings `protobuf:"bytes,8,opt,name=settings,proto3" json:"settings,omitempty"`
+	// Content hash of the nodes and edges (see dag.Hash). Output only.
+	Hash          string `protobuf:"bytes,9,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DAG) Reset() {
+	*x = DAG{}
+	mi := &file_dag_v1_dag_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DAG) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DAG) ProtoMessage() {}
+
+func (x *DAG) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DAG.ProtoReflect.Descriptor instead.
+func (*DAG) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DAG) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DAG) GetNodes() []*Node {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+func (x *DAG) GetEdges() []*Edge {
+	if x != nil {
+		return x.Edges
+	}
+	return nil
+}
+
+func (x *DAG) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DAG) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *DAG) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *DAG) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *DAG) GetSettings() *Settings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+func (x *DAG) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+// Settings mirrors dag.Settings.
+type Settings struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	NoParallelEdges    bool                   `protobuf:"varint,1,opt,name=no_parallel_edges,json=noParallelEdges,proto3" json:"no_parallel_edges,omitempty"`
+	Tree               bool                   `protobuf:"varint,2,opt,name=tree,proto3" json:"tree,omitempty"`
+	SingleRoot         bool                   `protobuf:"varint,3,opt,name=single_root,json=singleRoot,proto3" json:"single_root,omitempty"`
+	Connected          bool                   `protobuf:"varint,4,opt,name=connected,proto3" json:"connected,omitempty"`
+	MaxDepth           int32                  `protobuf:"varint,5,opt,name=max_depth,json=maxDepth,proto3" json:"max_depth,omitempty"`
+	DistinctConditions string                 `protobuf:"bytes,6,opt,name=distinct_conditions,json=distinctConditions,proto3" json:"distinct_conditions,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Settings) Reset() {
+	*x = Settings{}
+	mi := &file_dag_v1_dag_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Settings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Settings) ProtoMessage() {}
+
+func (x *Settings) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Settings.ProtoReflect.Descriptor instead.
+func (*Settings) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Settings) GetNoParallelEdges() bool {
+	if x != nil {
+		return x.NoParallelEdges
+	}
+	return false
+}
+
+func (x *Settings) GetTree() bool {
+	if x != nil {
+		return x.Tree
+	}
+	return false
+}
+
+func (x *Settings) GetSingleRoot() bool {
+	if x != nil {
+		return x.SingleRoot
+	}
+	return false
+}
+
+func (x *Settings) GetConnected() bool {
+	if x != nil {
+		return x.Connected
+	}
+	return false
+}
+
+func (x *Settings) GetMaxDepth() int32 {
+	if x != nil {
+		return x.MaxDepth
+	}
+	return 0
+}
+
+func (x *Settings) GetDistinctConditions() string {
+	if x != nil {
+		return x.DistinctConditions
+	}
+	return ""
+}
+
+// Node mirrors dag.Node. data is the JSON payload as text.
+// ref is only used in CreateDAG and is never persisted.
+type Node struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Ref           string                 `protobuf:"bytes,2,opt,name=ref,proto3" json:"ref,omitempty"`
+	Data          string                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Tags          []string               `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Node) Reset() {
+	*x = Node{}
+	mi := &file_dag_v1_dag_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Node) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Node) ProtoMessage() {}
+
+func (x *Node) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Node.ProtoReflect.Descriptor instead.
+func (*Node) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Node) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Node) GetRef() string {
+	if x != nil {
+		return x.Ref
+	}
+	return ""
+}
+
+func (x *Node) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *Node) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+// Edge mirrors dag.Edge. data is the JSON payload as text.
+// from_node_ref / to_node_ref are only used in CreateDAG.
+type Edge struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	FromNodeId  string                 `protobuf:"bytes,2,opt,name=from_node_id,json=fromNodeId,proto3" json:"from_node_id,omitempty"`
+	ToNodeId    string                 `protobuf:"bytes,3,opt,name=to_node_id,json=toNodeId,proto3" json:"to_node_id,omitempty"`
+	FromNodeRef string                 `protobuf:"bytes,4,opt,name=from_node_ref,json=fromNodeRef,proto3" json:"from_node_ref,omitempty"`
+	ToNodeRef   string                 `protobuf:"bytes,5,opt,name=to_node_ref,json=toNodeRef,proto3" json:"to_node_ref,omitempty"`
+	Data        string                 `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
+	// Position among the edges leaving from_node_id. Output only.
+	OrderIndex    int32 `protobuf:"varint,7,opt,name=order_index,json=orderIndex,proto3" json:"order_index,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Edge) Reset() {
+	*x = Edge{}
+	mi := &file_dag_v1_dag_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Edge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Edge) ProtoMessage() {}
+
+func (x *Edge) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Edge.ProtoReflect.Descriptor instead.
+func (*Edge) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Edge) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Edge) GetFromNodeId() string {
+	if x != nil {
+		return x.FromNodeId
+	}
+	return ""
+}
+
+func (x *Edge) GetToNodeId() string {
+	if x != nil {
+		return x.ToNodeId
+	}
+	return ""
+}
+
+func (x *Edge) GetFromNodeRef() string {
+	if x != nil {
+		return x.FromNodeRef
+	}
+	return ""
+}
+
+func (x *Edge) GetToNodeRef() string {
+	if x != nil {
+		return x.ToNodeRef
+	}
+	return ""
+}
+
+func (x *Edge) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *Edge) GetOrderIndex() int32 {
+	if x != nil {
+		return x.OrderIndex
+	}
+	return 0
+}
+
+type CreateDAGRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Dag   *DAG                   `protobuf:"bytes,1,opt,name=dag,proto3" json:"dag,omitempty"`
+	// Replace an existing DAG with the same ID. Without it, creating an
+	// existing DAG fails with ALREADY_EXISTS.
+	Replace       bool `protobuf:"varint,2,opt,name=replace,proto3" json:"replace,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateDAGRequest) Reset() {
+	*x = CreateDAGRequest{}
+	mi := &file_dag_v1_dag_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateDAGRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateDAGRequest) ProtoMessage() {}
+
+func (x *CreateDAGRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateDAGRequest.ProtoReflect.Descriptor instead.
+func (*CreateDAGRequest) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateDAGRequest) GetDag() *DAG {
+	if x != nil {
+		return x.Dag
+	}
+	return nil
+}
+
+func (x *CreateDAGRequest) GetReplace() bool {
+	if x != nil {
+		return x.Replace
+	}
+	return false
+}
+
+type CreateDAGResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dag           *DAG                   `protobuf:"bytes,1,opt,name=dag,proto3" json:"dag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateDAGResponse) Reset() {
+	*x = CreateDAGResponse{}
+	mi := &file_dag_v1_dag_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateDAGResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateDAGResponse) ProtoMessage() {}
+
+func (x *CreateDAGResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateDAGResponse.ProtoReflect.Descriptor instead.
+func (*CreateDAGResponse) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CreateDAGResponse) GetDag() *DAG {
+	if x != nil {
+		return x.Dag
+	}
+	return nil
+}
+
+type GetDAGRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DagId         string                 `protobuf:"bytes,1,opt,name=dag_id,json=dagId,proto3" json:"dag_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDAGRequest) Reset() {
+	*x = GetDAGRequest{}
+	mi := &file_dag_v1_dag_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDAGRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDAGRequest) ProtoMessage() {}
+
+func (x *GetDAGRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDAGRequest.ProtoReflect.Descriptor instead.
+func (*GetDAGRequest) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetDAGRequest) GetDagId() string {
+	if x != nil {
+		return x.DagId
+	}
+	return ""
+}
+
+type GetDAGResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dag           *DAG                   `protobuf:"bytes,1,opt,name=dag,proto3" json:"dag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDAGResponse) Reset() {
+	*x = GetDAGResponse{}
+	mi := &file_dag_v1_dag_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDAGResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDAGResponse) ProtoMessage() {}
+
+func (x *GetDAGResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDAGResponse.ProtoReflect.Descriptor instead.
+func (*GetDAGResponse) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetDAGResponse) GetDag() *DAG {
+	if x != nil {
+		return x.Dag
+	}
+	return nil
+}
+
+type DeleteDAGRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DagId         string                 `protobuf:"bytes,1,opt,name=dag_id,json=dagId,proto3" json:"dag_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDAGRequest) Reset() {
+	*x = DeleteDAGRequest{}
+	mi := &file_dag_v1_dag_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDAGRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDAGRequest) ProtoMessage() {}
+
+func (x *DeleteDAGRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDAGRequest.ProtoReflect.Descriptor instead.
+func (*DeleteDAGRequest) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeleteDAGRequest) GetDagId() string {
+	if x != nil {
+		return x.DagId
+	}
+	return ""
+}
+
+type DeleteDAGResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDAGResponse) Reset() {
+	*x = DeleteDAGResponse{}
+	mi := &file_dag_v1_dag_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDAGResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDAGResponse) ProtoMessage() {}
+
+func (x *DeleteDAGResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDAGResponse.ProtoReflect.Descriptor instead.
+func (*DeleteDAGResponse) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{9}
+}
+
+type AddNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DagId         string                 `protobuf:"bytes,1,opt,name=dag_id,json=dagId,proto3" json:"dag_id,omitempty"`
+	Node          *Node                  `protobuf:"bytes,2,opt,name=node,proto3" json:"node,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddNodeRequest) Reset() {
+	*x = AddNodeRequest{}
+	mi := &file_dag_v1_dag_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddNodeRequest) ProtoMessage() {}
+
+func (x *AddNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddNodeRequest.ProtoReflect.Descriptor instead.
+func (*AddNodeRequest) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *AddNodeRequest) GetDagId() string {
+	if x != nil {
+		return x.DagId
+	}
+	return ""
+}
+
+func (x *AddNodeRequest) GetNode() *Node {
+	if x != nil {
+		return x.Node
+	}
+	return nil
+}
+
+type AddNodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddNodeResponse) Reset() {
+	*x = AddNodeResponse{}
+	mi := &file_dag_v1_dag_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddNodeResponse) ProtoMessage() {}
+
+func (x *AddNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddNodeResponse.ProtoReflect.Descriptor instead.
+func (*AddNodeResponse) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AddNodeResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetNodeRequest) Reset() {
+	*x = GetNodeRequest{}
+	mi := &file_dag_v1_dag_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetNodeRequest) ProtoMessage() {}
+
+func (x *GetNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetNodeRequest.ProtoReflect.Descriptor instead.
+func (*GetNodeRequest) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetNodeRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+type GetNodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetNodeResponse) Reset() {
+	*x = GetNodeResponse{}
+	mi := &file_dag_v1_dag_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetNodeResponse) ProtoMessage() {}
+
+func (x *GetNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetNodeResponse.ProtoReflect.Descriptor instead.
+func (*GetNodeResponse) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetNodeResponse) GetNode() *Node {
+	if x != nil {
+		return x.Node
+	}
+	return nil
+}
+
+type UpdateNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateNodeRequest) Reset() {
+	*x = UpdateNodeRequest{}
+	mi := &file_dag_v1_dag_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateNodeRequest) ProtoMessage() {}
+
+func (x *UpdateNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateNodeRequest.ProtoReflect.Descriptor instead.
+func (*UpdateNodeRequest) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *UpdateNodeRequest) GetNode() *Node {
+	if x != nil {
+		return x.Node
+	}
+	return nil
+}
+
+type UpdateNodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateNodeResponse) Reset() {
+	*x = UpdateNodeResponse{}
+	mi := &file_dag_v1_dag_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateNodeResponse) ProtoMessage() {}
+
+func (x *UpdateNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateNodeResponse.ProtoReflect.Descriptor instead.
+func (*UpdateNodeResponse) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{15}
+}
+
+type DeleteNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteNodeRequest) Reset() {
+	*x = DeleteNodeRequest{}
+	mi := &file_dag_v1_dag_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteNodeRequest) ProtoMessage() {}
+
+func (x *DeleteNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteNodeRequest.ProtoReflect.Descriptor instead.
+func (*DeleteNodeRequest) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *DeleteNodeRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+type DeleteNodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteNodeResponse) Reset() {
+	*x = DeleteNodeResponse{}
+	mi := &file_dag_v1_dag_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteNodeResponse) ProtoMessage() {}
+
+func (x *DeleteNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteNodeResponse.ProtoReflect.Descriptor instead.
+func (*DeleteNodeResponse) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{17}
+}
+
+type ListNodesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DagId         string                 `protobuf:"bytes,1,opt,name=dag_id,json=dagId,proto3" json:"dag_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListNodesRequest) Reset() {
+	*x = ListNodesRequest{}
+	mi := &file_dag_v1_dag_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListNodesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListNodesRequest) ProtoMessage() {}
+
+func (x *ListNodesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListNodesRequest.ProtoReflect.Descriptor instead.
+func (*ListNodesRequest) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListNodesRequest) GetDagId() string {
+	if x != nil {
+		return x.DagId
+	}
+	return ""
+}
+
+type ListNodesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nodes         []*Node                `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListNodesResponse) Reset() {
+	*x = ListNodesResponse{}
+	mi := &file_dag_v1_dag_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListNodesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListNodesResponse) ProtoMessage() {}
+
+func (x *ListNodesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListNodesResponse.ProtoReflect.Descriptor instead.
+func (*ListNodesResponse) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListNodesResponse) GetNodes() []*Node {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+type AddEdgeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DagId         string                 `protobuf:"bytes,1,opt,name=dag_id,json=dagId,proto3" json:"dag_id,omitempty"`
+	Edge          *Edge                  `protobuf:"bytes,2,opt,name=edge,proto3" json:"edge,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddEdgeRequest) Reset() {
+	*x = AddEdgeRequest{}
+	mi := &file_dag_v1_dag_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddEdgeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddEdgeRequest) ProtoMessage() {}
+
+func (x *AddEdgeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddEdgeRequest.ProtoReflect.Descriptor instead.
+func (*AddEdgeRequest) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *AddEdgeRequest) GetDagId() string {
+	if x != nil {
+		return x.DagId
+	}
+	return ""
+}
+
+func (x *AddEdgeRequest) GetEdge() *Edge {
+	if x != nil {
+		return x.Edge
+	}
+	return nil
+}
+
+type AddEdgeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddEdgeResponse) Reset() {
+	*x = AddEdgeResponse{}
+	mi := &file_dag_v1_dag_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddEdgeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddEdgeResponse) ProtoMessage() {}
+
+func (x *AddEdgeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddEdgeResponse.ProtoReflect.Descriptor instead.
+func (*AddEdgeResponse) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *AddEdgeResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetEdgeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EdgeId        string                 `protobuf:"bytes,1,opt,name=edge_id,json=edgeId,proto3" json:"edge_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEdgeRequest) Reset() {
+	*x = GetEdgeRequest{}
+	mi := &file_dag_v1_dag_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEdgeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEdgeRequest) ProtoMessage() {}
+
+func (x *GetEdgeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEdgeRequest.ProtoReflect.Descriptor instead.
+func (*GetEdgeRequest) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *GetEdgeRequest) GetEdgeId() string {
+	if x != nil {
+		return x.EdgeId
+	}
+	return ""
+}
+
+type GetEdgeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Edge          *Edge                  `protobuf:"bytes,1,opt,name=edge,proto3" json:"edge,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEdgeResponse) Reset() {
+	*x = GetEdgeResponse{}
+	mi := &file_dag_v1_dag_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEdgeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEdgeResponse) ProtoMessage() {}
+
+func (x *GetEdgeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEdgeResponse.ProtoReflect.Descriptor instead.
+func (*GetEdgeResponse) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *GetEdgeResponse) GetEdge() *Edge {
+	if x != nil {
+		return x.Edge
+	}
+	return nil
+}
+
+type UpdateEdgeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Edge          *Edge                  `protobuf:"bytes,1,opt,name=edge,proto3" json:"edge,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateEdgeRequest) Reset() {
+	*x = UpdateEdgeRequest{}
+	mi := &file_dag_v1_dag_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateEdgeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateEdgeRequest) ProtoMessage() {}
+
+func (x *UpdateEdgeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateEdgeRequest.ProtoReflect.Descriptor instead.
+func (*UpdateEdgeRequest) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *UpdateEdgeRequest) GetEdge() *Edge {
+	if x != nil {
+		return x.Edge
+	}
+	return nil
+}
+
+type UpdateEdgeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateEdgeResponse) Reset() {
+	*x = UpdateEdgeResponse{}
+	mi := &file_dag_v1_dag_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateEdgeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateEdgeResponse) ProtoMessage() {}
+
+func (x *UpdateEdgeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateEdgeResponse.ProtoReflect.Descriptor instead.
+func (*UpdateEdgeResponse) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{25}
+}
+
+type DeleteEdgeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EdgeId        string                 `protobuf:"bytes,1,opt,name=edge_id,json=edgeId,proto3" json:"edge_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteEdgeRequest) Reset() {
+	*x = DeleteEdgeRequest{}
+	mi := &file_dag_v1_dag_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteEdgeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteEdgeRequest) ProtoMessage() {}
+
+func (x *DeleteEdgeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteEdgeRequest.ProtoReflect.Descriptor instead.
+func (*DeleteEdgeRequest) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *DeleteEdgeRequest) GetEdgeId() string {
+	if x != nil {
+		return x.EdgeId
+	}
+	return ""
+}
+
+type DeleteEdgeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteEdgeResponse) Reset() {
+	*x = DeleteEdgeResponse{}
+	mi := &file_dag_v1_dag_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteEdgeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteEdgeResponse) ProtoMessage() {}
+
+func (x *DeleteEdgeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteEdgeResponse.ProtoReflect.Descriptor instead.
+func (*DeleteEdgeResponse) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{27}
+}
+
+type ListEdgesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DagId         string                 `protobuf:"bytes,1,opt,name=dag_id,json=dagId,proto3" json:"dag_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListEdgesRequest) Reset() {
+	*x = ListEdgesRequest{}
+	mi := &file_dag_v1_dag_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListEdgesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListEdgesRequest) ProtoMessage() {}
+
+func (x *ListEdgesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListEdgesRequest.ProtoReflect.Descriptor instead.
+func (*ListEdgesRequest) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ListEdgesRequest) GetDagId() string {
+	if x != nil {
+		return x.DagId
+	}
+	return ""
+}
+
+type ListEdgesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Edges         []*Edge                `protobuf:"bytes,1,rep,name=edges,proto3" json:"edges,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListEdgesResponse) Reset() {
+	*x = ListEdgesResponse{}
+	mi := &file_dag_v1_dag_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListEdgesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListEdgesResponse) ProtoMessage() {}
+
+func (x *ListEdgesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_dag_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListEdgesResponse.ProtoReflect.Descriptor instead.
+func (*ListEdgesResponse) Descriptor() ([]byte, []int) {
+	return file_dag_v1_dag_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ListEdgesResponse) GetEdges() []*Edge {
+	if x != nil {
+		return x.Edges
+	}
+	return nil
+}
+
+var File_dag_v1_dag_proto protoreflect.FileDescriptor
+
+const file_dag_v1_dag_proto_rawDesc = "" +
+	"\n" +
+	"\x10dag/v1/dag.proto\x12\x06dag.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x9a\x02\n" +
+	"\x03DAG\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\"\n" +
+	"\x05nodes\x18\x02 \x03(\v2\f.dag.v1.NodeR\x05nodes\x12\"\n" +
+	"\x05edges\x18\x03 \x03(\v2\f.dag.v1.EdgeR\x05edges\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x12\n" +
+	"\x04tags\x18\x05 \x03(\tR\x04tags\x129\n" +
+	"\n" +
+	"expires_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status\x12,\n" +
+	"\bsettings\x18\b \x01(\v2\x10.dag.v1.SettingsR\bsettings\x12\x12\n" +
+	"\x04hash\x18\t \x01(\tR\x04hash\"\xd7\x01\n" +
+	"\bSettings\x12*\n" +
+	"\x11no_parallel_edges\x18\x01 \x01(\bR\x0fnoParallelEdges\x12\x12\n" +
+	"\x04tree\x18\x02 \x01(\bR\x04tree\x12\x1f\n" +
+	"\vsingle_root\x18\x03 \x01(\bR\n" +
+	"singleRoot\x12\x1c\n" +
+	"\tconnected\x18\x04 \x01(\bR\tconnected\x12\x1b\n" +
+	"\tmax_depth\x18\x05 \x01(\x05R\bmaxDepth\x12/\n" +
+	"\x13distinct_conditions\x18\x06 \x01(\tR\x12distinctConditions\"P\n" +
+	"\x04Node\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
+	"\x03ref\x18\x02 \x01(\tR\x03ref\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\tR\x04data\x12\x12\n" +
+	"\x04tags\x18\x04 \x03(\tR\x04tags\"\xcf\x01\n" +
+	"\x04Edge\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12 \n" +
+	"\ffrom_node_id\x18\x02 \x01(\tR\n" +
+	"fromNodeId\x12\x1c\n" +
+	"\n" +
+	"to_node_id\x18\x03 \x01(\tR\btoNodeId\x12\"\n" +
+	"\rfrom_node_ref\x18\x04 \x01(\tR\vfromNodeRef\x12\x1e\n" +
+	"\vto_node_ref\x18\x05 \x01(\tR\ttoNodeRef\x12\x12\n" +
+	"\x04data\x18\x06 \x01(\tR\x04data\x12\x1f\n" +
+	"\vorder_index\x18\a \x01(\x05R\n" +
+	"orderIndex\"K\n" +
+	"\x10CreateDAGRequest\x12\x1d\n" +
+	"\x03dag\x18\x01 \x01(\v2\v.dag.v1.DAGR\x03dag\x12\x18\n" +
+	"\areplace\x18\x02 \x01(\bR\areplace\"2\n" +
+	"\x11CreateDAGResponse\x12\x1d\n" +
+	"\x03dag\x18\x01 \x01(\v2\v.dag.v1.DAGR\x03dag\"&\n" +
+	"\rGetDAGRequest\x12\x15\n" +
+	"\x06dag_id\x18\x01 \x01(\tR\x05dagId\"/\n" +
+	"\x0eGetDAGResponse\x12\x1d\n" +
+	"\x03dag\x18\x01 \x01(\v2\v.dag.v1.DAGR\x03dag\")\n" +
+	"\x10DeleteDAGRequest\x12\x15\n" +
+	"\x06dag_id\x18\x01 \x01(\tR\x05dagId\"\x13\n" +
+	"\x11DeleteDAGResponse\"I\n" +
+	"\x0eAddNodeRequest\x12\x15\n" +
+	"\x06dag_id\x18\x01 \x01(\tR\x05dagId\x12 \n" +
+	"\x04node\x18\x02 \x01(\v2\f.dag.v1.NodeR\x04node\"!\n" +
+	"\x0fAddNodeResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\")\n" +
+	"\x0eGetNodeRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\"3\n" +
+	"\x0fGetNodeResponse\x12 \n" +
+	"\x04node\x18\x01 \x01(\v2\f.dag.v1.NodeR\x04node\"5\n" +
+	"\x11UpdateNodeRequest\x12 \n" +
+	"\x04node\x18\x01 \x01(\v2\f.dag.v1.NodeR\x04node\"\x14\n" +
+	"\x12UpdateNodeResponse\",\n" +
+	"\x11DeleteNodeRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\"\x14\n" +
+	"\x12DeleteNodeResponse\")\n" +
+	"\x10ListNodesRequest\x12\x15\n" +
+	"\x06dag_id\x18\x01 \x01(\tR\x05dagId\"7\n" +
+	"\x11ListNodesResponse\x12\"\n" +
+	"\x05nodes\x18\x01 \x03(\v2\f.dag.v1.NodeR\x05nodes\"I\n" +
+	"\x0eAddEdgeRequest\x12\x15\n" +
+	"\x06dag_id\x18\x01 \x01(\tR\x05dagId\x12 \n" +
+	"\x04edge\x18\x02 \x01(\v2\f.dag.v1.EdgeR\x04edge\"!\n" +
+	"\x0fAddEdgeResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\")\n" +
+	"\x0eGetEdgeRequest\x12\x17\n" +
+	"\aedge_id\x18\x01 \x01(\tR\x06edgeId\"3\n" +
+	"\x0fGetEdgeResponse\x12 \n" +
+	"\x04edge\x18\x01 \x01(\v2\f.dag.v1.EdgeR\x04edge\"5\n" +
+	"\x11UpdateEdgeRequest\x12 \n" +
+	"\x04edge\x18\x01 \x01(\v2\f.dag.v1.EdgeR\x04edge\"\x14\n" +
+	"\x12UpdateEdgeResponse\",\n" +
+	"\x11DeleteEdgeRequest\x12\x17\n" +
+	"\aedge_id\x18\x01 \x01(\tR\x06edgeId\"\x14\n" +
+	"\x12DeleteEdgeResponse\")\n" +
+	"\x10ListEdgesRequest\x12\x15\n" +
+	"\x06dag_id\x18\x01 \x01(\tR\x05dagId\"7\n" +
+	"\x11ListEdgesResponse\x12\"\n" +
+	"\x05edges\x18\x01 \x03(\v2\f.dag.v1.EdgeR\x05edges2\xd1\x06\n" +
+	"\n" +
+	"DagService\x12@\n" +
+	"\tCreateDAG\x12\x18.dag.v1.CreateDAGRequest\x1a\x19.dag.v1.CreateDAGResponse\x127\n" +
+	"\x06GetDAG\x12\x15.dag.v1.GetDAGRequest\x1a\x16.dag.v1.GetDAGResponse\x12@\n" +
+	"\tDeleteDAG\x12\x18.dag.v1.DeleteDAGRequest\x1a\x19.dag.v1.DeleteDAGResponse\x12:\n" +
+	"\aAddNode\x12\x16.dag.v1.AddNodeRequest\x1a\x17.dag.v1.AddNodeResponse\x12:\n" +
+	"\aGetNode\x12\x16.dag.v1.GetNodeRequest\x1a\x17.dag.v1.GetNodeResponse\x12C\n" +
+	"\n" +
+	"UpdateNode\x12\x19.dag.v1.UpdateNodeRequest\x1a\x1a.dag.v1.UpdateNodeResponse\x12C\n" +
+	"\n" +
+	"DeleteNode\x12\x19.dag.v1.DeleteNodeRequest\x1a\x1a.dag.v1.DeleteNodeResponse\x12@\n" +
+	"\tListNodes\x12\x18.dag.v1.ListNodesRequest\x1a\x19.dag.v1.ListNodesResponse\x12:\n" +
+	"\aAddEdge\x12\x16.dag.v1.AddEdgeRequest\x1a\x17.dag.v1.AddEdgeResponse\x12:\n" +
+	"\aGetEdge\x12\x16.dag.v1.GetEdgeRequest\x1a\x17.dag.v1.GetEdgeResponse\x12C\n" +
+	"\n" +
+	"UpdateEdge\x12\x19.dag.v1.UpdateEdgeRequest\x1a\x1a.dag.v1.UpdateEdgeResponse\x12C\n" +
+	"\n" +
+	"DeleteEdge\x12\x19.dag.v1.DeleteEdgeRequest\x1a\x1a.dag.v1.DeleteEdgeResponse\x12@\n" +
+	"\tListEdges\x12\x18.dag.v1.ListEdgesRequest\x1a\x19.dag.v1.ListEdgesResponseB3Z1github.com/meikuraledutech/dag/proto/dag/v1;dagv1b\x06proto3"
+
+var (
+	file_dag_v1_dag_proto_rawDescOnce sync.Once
+	file_dag_v1_dag_proto_rawDescData []byte
+)
+
+func file_dag_v1_dag_proto_rawDescGZIP() []byte {
+	file_dag_v1_dag_proto_rawDescOnce.Do(func() {
+		file_dag_v1_dag_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_dag_v1_dag_proto_rawDesc), len(file_dag_v1_dag_proto_rawDesc)))
+	})
+	return file_dag_v1_dag_proto_rawDescData
+}
+
+var file_dag_v1_dag_proto_msgTypes = make([]protoimpl.MessageInfo, 30)
+var file_dag_v1_dag_proto_goTypes = []any{
+	(*DAG)(nil),                   // 0: dag.v1.DAG
+	(*Settings)(nil),              // 1: dag.v1.Settings
+	(*Node)(nil),                  // 2: dag.v1.Node
+	(*Edge)(nil),                  // 3: dag.v1.Edge
+	(*CreateDAGRequest)(nil),      // 4: dag.v1.CreateDAGRequest
+	(*CreateDAGResponse)(nil),     // 5: dag.v1.CreateDAGResponse
+	(*GetDAGRequest)(nil),         // 6: dag.v1.GetDAGRequest
+	(*GetDAGResponse)(nil),        // 7: dag.v1.GetDAGResponse
+	(*DeleteDAGRequest)(nil),      // 8: dag.v1.DeleteDAGRequest
+	(*DeleteDAGResponse)(nil),     // 9: dag.v1.DeleteDAGResponse
+	(*AddNodeRequest)(nil),        // 10: dag.v1.AddNodeRequest
+	(*AddNodeResponse)(nil),       // 11: dag.v1.AddNodeResponse
+	(*GetNodeRequest)(nil),        // 12: dag.v1.GetNodeRequest
+	(*GetNodeResponse)(nil),       // 13: dag.v1.GetNodeResponse
+	(*UpdateNodeRequest)(nil),     // 14: dag.v1.UpdateNodeRequest
+	(*UpdateNodeResponse)(nil),    // 15: dag.v1.UpdateNodeResponse
+	(*DeleteNodeRequest)(nil),     // 16: dag.v1.DeleteNodeRequest
+	(*DeleteNodeResponse)(nil),    // 17: dag.v1.DeleteNodeResponse
+	(*ListNodesRequest)(nil),      // 18: dag.v1.ListNodesRequest
+	(*ListNodesResponse)(nil),     // 19: dag.v1.ListNodesResponse
+	(*AddEdgeRequest)(nil),        // 20: dag.v1.AddEdgeRequest
+	(*AddEdgeResponse)(nil),       // 21: dag.v1.AddEdgeResponse
+	(*GetEdgeRequest)(nil),        // 22: dag.v1.GetEdgeRequest
+	(*GetEdgeResponse)(nil),       // 23: dag.v1.GetEdgeResponse
+	(*UpdateEdgeRequest)(nil),     // 24: dag.v1.UpdateEdgeRequest
+	(*UpdateEdgeResponse)(nil),    // 25: dag.v1.UpdateEdgeResponse
+	(*DeleteEdgeRequest)(nil),     // 26: dag.v1.DeleteEdgeRequest
+	(*DeleteEdgeResponse)(nil),    // 27: dag.v1.DeleteEdgeResponse
+	(*ListEdgesRequest)(nil),      // 28: dag.v1.ListEdgesRequest
+	(*ListEdgesResponse)(nil),     // 29: dag.v1.ListEdgesResponse
+	(*timestamppb.Timestamp)(nil), // 30: google.protobuf.Timestamp
+}
+var file_dag_v1_dag_proto_depIdxs = []int32{
+	2,  // 0: dag.v1.DAG.nodes:type_name -> dag.v1.Node
+	3,  // 1: dag.v1.DAG.edges:type_name -> dag.v1.Edge
+	30, // 2: dag.v1.DAG.expires_at:type_name -> google.protobuf.Timestamp
+	1,  // 3: dag.v1.DAG.settings:type_name -> dag.v1.Settings
+	0,  // 4: dag.v1.CreateDAGRequest.dag:type_name -> dag.v1.DAG
+	0,  // 5: dag.v1.CreateDAGResponse.dag:type_name -> dag.v1.DAG
+	0,  // 6: dag.v1.GetDAGResponse.dag:type_name -> dag.v1.DAG
+	2,  // 7: dag.v1.AddNodeRequest.node:type_name -> dag.v1.Node
+	2,  // 8: dag.v1.GetNodeResponse.node:type_name -> dag.v1.Node
+	2,  // 9: dag.v1.UpdateNodeRequest.node:type_name -> dag.v1.Node
+	2,  // 10: dag.v1.ListNodesResponse.nodes:type_name -> dag.v1.Node
+	3,  // 11: dag.v1.AddEdgeRequest.edge:type_name -> dag.v1.Edge
+	3,  // 12: dag.v1.GetEdgeResponse.edge:type_name -> dag.v1.Edge
+	3,  // 13: dag.v1.UpdateEdgeRequest.edge:type_name -> dag.v1.Edge
+	3,  // 14: dag.v1.ListEdgesResponse.edges:type_name -> dag.v1.Edge
+	4,  // 15: dag.v1.DagService.CreateDAG:input_type -> dag.v1.CreateDAGRequest
+	6,  // 16: dag.v1.DagService.GetDAG:input_type -> dag.v1.GetDAGRequest
+	8,  // 17: dag.v1.DagService.DeleteDAG:input_type -> dag.v1.DeleteDAGRequest
+	10, // 18: dag.v1.DagService.AddNode:input_type -> dag.v1.AddNodeRequest
+	12, // 19: dag.v1.DagService.GetNode:input_type -> dag.v1.GetNodeRequest
+	14, // 20: dag.v1.DagService.UpdateNode:input_type -> dag.v1.UpdateNodeRequest
+	16, // 21: dag.v1.DagService.DeleteNode:input_type -> dag.v1.DeleteNodeRequest
+	18, // 22: dag.v1.DagService.ListNodes:input_type -> dag.v1.ListNodesRequest
+	20, // 23: dag.v1.DagService.AddEdge:input_type -> dag.v1.AddEdgeRequest
+	22, // 24: dag.v1.DagService.GetEdge:input_type -> dag.v1.GetEdgeRequest
+	24, // 25: dag.v1.DagService.UpdateEdge:input_type -> dag.v1.UpdateEdgeRequest
+	26, // 26: dag.v1.DagService.DeleteEdge:input_type -> dag.v1.DeleteEdgeRequest
+	28, // 27: dag.v1.DagService.ListEdges:input_type -> dag.v1.ListEdgesRequest
+	5,  // 28: dag.v1.DagService.CreateDAG:output_type -> dag.v1.CreateDAGResponse
+	7,  // 29: dag.v1.DagService.GetDAG:output_type -> dag.v1.GetDAGResponse
+	9,  // 30: dag.v1.DagService.DeleteDAG:output_type -> dag.v1.DeleteDAGResponse
+	11, // 31: dag.v1.DagService.AddNode:output_type -> dag.v1.AddNodeResponse
+	13, // 32: dag.v1.DagService.GetNode:output_type -> dag.v1.GetNodeResponse
+	15, // 33: dag.v1.DagService.UpdateNode:output_type -> dag.v1.UpdateNodeResponse
+	17, // 34: dag.v1.DagService.DeleteNode:output_type -> dag.v1.DeleteNodeResponse
+	19, // 35: dag.v1.DagService.ListNodes:output_type -> dag.v1.ListNodesResponse
+	21, // 36: dag.v1.DagService.AddEdge:output_type -> dag.v1.AddEdgeResponse
+	23, // 37: dag.v1.DagService.GetEdge:output_type -> dag.v1.GetEdgeResponse
+	25, // 38: dag.v1.DagService.UpdateEdge:output_type -> dag.v1.UpdateEdgeResponse
+	27, // 39: dag.v1.DagService.DeleteEdge:output_type -> dag.v1.DeleteEdgeResponse
+	29, // 40: dag.v1.DagService.ListEdges:output_type -> dag.v1.ListEdgesResponse
+	28, // [28:41] is the sub-list for method output_type
+	15, // [15:28] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
+}
+
+func init() { file_dag_v1_dag_proto_init() }
+func file_dag_v1_dag_proto_init() {
+	if File_dag_v1_dag_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_dag_v1_dag_proto_rawDesc), len(file_dag_v1_dag_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   30,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_dag_v1_dag_proto_goTypes,
+		DependencyIndexes: file_dag_v1_dag_proto_depIdxs,
+		MessageInfos:      file_dag_v1_dag_proto_msgTypes,
+	}.Build()
+	File_dag_v1_dag_proto = out.File
+	file_dag_v1_dag_proto_goTypes = nil
+	file_dag_v1_dag_proto_depIdxs = nil
+}
@@ -0,0 +1,384 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: dag/v1/event.proto
+
+package dagv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Event mirrors dag.Event: one write to a DAG, as the event log and event
+// sinks record it. Only the field for type is set.
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Seq   int64                  `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	DagId string                 `protobuf:"bytes,2,opt,name=dag_id,json=dagId,proto3" json:"dag_id,omitempty"`
+	// One of dag.EventType: "created", "deleted", "changed", "reordered",
+	// "tags", "settings" or "status".
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	At            *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=at,proto3" json:"at,omitempty"`
+	Actor         string                 `protobuf:"bytes,5,opt,name=actor,proto3" json:"actor,omitempty"`
+	Dag           *DAG                   `protobuf:"bytes,6,opt,name=dag,proto3" json:"dag,omitempty"`
+	Changes       *ChangeSet             `protobuf:"bytes,7,opt,name=changes,proto3" json:"changes,omitempty"`
+	Order         *EdgeOrder             `protobuf:"bytes,8,opt,name=order,proto3" json:"order,omitempty"`
+	Tags          []string               `protobuf:"bytes,9,rep,name=tags,proto3" json:"tags,omitempty"`
+	Settings      *Settings              `protobuf:"bytes,10,opt,name=settings,proto3" json:"settings,omitempty"`
+	Status        string                 `protobuf:"bytes,11,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_dag_v1_event_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_event_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_dag_v1_event_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Event) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Event) GetDagId() string {
+	if x != nil {
+		return x.DagId
+	}
+	return ""
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *Event) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *Event) GetDag() *DAG {
+	if x != nil {
+		return x.Dag
+	}
+	return nil
+}
+
+func (x *Event) GetChanges() *ChangeSet {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+func (x *Event) GetOrder() *EdgeOrder {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+func (x *Event) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Event) GetSettings() *Settings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+func (x *Event) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+// ChangeSet mirrors dag.ChangeSet.
+type ChangeSet struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AddNodes      []*Node                `protobuf:"bytes,1,rep,name=add_nodes,json=addNodes,proto3" json:"add_nodes,omitempty"`
+	UpdateNodes   []*Node                `protobuf:"bytes,2,rep,name=update_nodes,json=updateNodes,proto3" json:"update_nodes,omitempty"`
+	DeleteNodes   []string               `protobuf:"bytes,3,rep,name=delete_nodes,json=deleteNodes,proto3" json:"delete_nodes,omitempty"`
+	AddEdges      []*Edge                `protobuf:"bytes,4,rep,name=add_edges,json=addEdges,proto3" json:"add_edges,omitempty"`
+	UpdateEdges   []*Edge                `protobuf:"bytes,5,rep,name=update_edges,json=updateEdges,proto3" json:"update_edges,omitempty"`
+	DeleteEdges   []string               `protobuf:"bytes,6,rep,name=delete_edges,json=deleteEdges,proto3" json:"delete_edges,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangeSet) Reset() {
+	*x = ChangeSet{}
+	mi := &file_dag_v1_event_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeSet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeSet) ProtoMessage() {}
+
+func (x *ChangeSet) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_event_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeSet.ProtoReflect.Descriptor instead.
+func (*ChangeSet) Descriptor() ([]byte, []int) {
+	return file_dag_v1_event_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ChangeSet) GetAddNodes() []*Node {
+	if x != nil {
+		return x.AddNodes
+	}
+	return nil
+}
+
+func (x *ChangeSet) GetUpdateNodes() []*Node {
+	if x != nil {
+		return x.UpdateNodes
+	}
+	return nil
+}
+
+func (x *ChangeSet) GetDeleteNodes() []string {
+	if x != nil {
+		return x.DeleteNodes
+	}
+	return nil
+}
+
+func (x *ChangeSet) GetAddEdges() []*Edge {
+	if x != nil {
+		return x.AddEdges
+	}
+	return nil
+}
+
+func (x *ChangeSet) GetUpdateEdges() []*Edge {
+	if x != nil {
+		return x.UpdateEdges
+	}
+	return nil
+}
+
+func (x *ChangeSet) GetDeleteEdges() []string {
+	if x != nil {
+		return x.DeleteEdges
+	}
+	return nil
+}
+
+// EdgeOrder mirrors dag.EdgeOrder.
+type EdgeOrder struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromNodeId    string                 `protobuf:"bytes,1,opt,name=from_node_id,json=fromNodeId,proto3" json:"from_node_id,omitempty"`
+	EdgeIds       []string               `protobuf:"bytes,2,rep,name=edge_ids,json=edgeIds,proto3" json:"edge_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EdgeOrder) Reset() {
+	*x = EdgeOrder{}
+	mi := &file_dag_v1_event_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EdgeOrder) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EdgeOrder) ProtoMessage() {}
+
+func (x *EdgeOrder) ProtoReflect() protoreflect.Message {
+	mi := &file_dag_v1_event_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EdgeOrder.ProtoReflect.Descriptor instead.
+func (*EdgeOrder) Descriptor() ([]byte, []int) {
+	return file_dag_v1_event_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *EdgeOrder) GetFromNodeId() string {
+	if x != nil {
+		return x.FromNodeId
+	}
+	return ""
+}
+
+func (x *EdgeOrder) GetEdgeIds() []string {
+	if x != nil {
+		return x.EdgeIds
+	}
+	return nil
+}
+
+var File_dag_v1_event_proto protoreflect.FileDescriptor
+
+const file_dag_v1_event_proto_rawDesc = "" +
+	"\n" +
+	"\x12dag/v1/event.proto\x12\x06dag.v1\x1a\x10dag/v1/dag.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd5\x02\n" +
+	"\x05Event\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x03R\x03seq\x12\x15\n" +
+	"\x06dag_id\x18\x02 \x01(\tR\x05dagId\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12*\n" +
+	"\x02at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x14\n" +
+	"\x05actor\x18\x05 \x01(\tR\x05actor\x12\x1d\n" +
+	"\x03dag\x18\x06 \x01(\v2\v.dag.v1.DAGR\x03dag\x12+\n" +
+	"\achanges\x18\a \x01(\v2\x11.dag.v1.ChangeSetR\achanges\x12'\n" +
+	"\x05order\x18\b \x01(\v2\x11.dag.v1.EdgeOrderR\x05order\x12\x12\n" +
+	"\x04tags\x18\t \x03(\tR\x04tags\x12,\n" +
+	"\bsettings\x18\n" +
+	" \x01(\v2\x10.dag.v1.SettingsR\bsettings\x12\x16\n" +
+	"\x06status\x18\v \x01(\tR\x06status\"\x89\x02\n" +
+	"\tChangeSet\x12)\n" +
+	"\tadd_nodes\x18\x01 \x03(\v2\f.dag.v1.NodeR\baddNodes\x12/\n" +
+	"\fupdate_nodes\x18\x02 \x03(\v2\f.dag.v1.NodeR\vupdateNodes\x12!\n" +
+	"\fdelete_nodes\x18\x03 \x03(\tR\vdeleteNodes\x12)\n" +
+	"\tadd_edges\x18\x04 \x03(\v2\f.dag.v1.EdgeR\baddEdges\x12/\n" +
+	"\fupdate_edges\x18\x05 \x03(\v2\f.dag.v1.EdgeR\vupdateEdges\x12!\n" +
+	"\fdelete_edges\x18\x06 \x03(\tR\vdeleteEdges\"H\n" +
+	"\tEdgeOrder\x12 \n" +
+	"\ffrom_node_id\x18\x01 \x01(\tR\n" +
+	"fromNodeId\x12\x19\n" +
+	"\bedge_ids\x18\x02 \x03(\tR\aedgeIdsB3Z1github.com/meikuraledutech/dag/proto/dag/v1;dagv1b\x06proto3"
+
+var (
+	file_dag_v1_event_proto_rawDescOnce sync.Once
+	file_dag_v1_event_proto_rawDescData []byte
+)
+
+func file_dag_v1_event_proto_rawDescGZIP() []byte {
+	file_dag_v1_event_proto_rawDescOnce.Do(func() {
+		file_dag_v1_event_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_dag_v1_event_proto_rawDesc), len(file_dag_v1_event_proto_rawDesc)))
+	})
+	return file_dag_v1_event_proto_rawDescData
+}
+
+var file_dag_v1_event_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_dag_v1_event_proto_goTypes = []any{
+	(*Event)(nil),                 // 0: dag.v1.Event
+	(*ChangeSet)(nil),             // 1: dag.v1.ChangeSet
+	(*EdgeOrder)(nil),             // 2: dag.v1.EdgeOrder
+	(*timestamppb.Timestamp)(nil), // 3: google.protobuf.Timestamp
+	(*DAG)(nil),                   // 4: dag.v1.DAG
+	(*Settings)(nil),              // 5: dag.v1.Settings
+	(*Node)(nil),                  // 6: dag.v1.Node
+	(*Edge)(nil),                  // 7: dag.v1.Edge
+}
+var file_dag_v1_event_proto_depIdxs = []int32{
+	3, // 0: dag.v1.Event.at:type_name -> google.protobuf.Timestamp
+	4, // 1: dag.v1.Event.dag:type_name -> dag.v1.DAG
+	1, // 2: dag.v1.Event.changes:type_name -> dag.v1.ChangeSet
+	2, // 3: dag.v1.Event.order:type_name -> dag.v1.EdgeOrder
+	5, // 4: dag.v1.Event.settings:type_name -> dag.v1.Settings
+	6, // 5: dag.v1.ChangeSet.add_nodes:type_name -> dag.v1.Node
+	6, // 6: dag.v1.ChangeSet.update_nodes:type_name -> dag.v1.Node
+	7, // 7: dag.v1.ChangeSet.add_edges:type_name -> dag.v1.Edge
+	7, // 8: dag.v1.ChangeSet.update_edges:type_name -> dag.v1.Edge
+	9, // [9:9] is the sub-list for method output_type
+	9, // [9:9] is the sub-list for method input_type
+	9, // [9:9] is the sub-list for extension type_name
+	9, // [9:9] is the sub-list for extension extendee
+	0, // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_dag_v1_event_proto_init() }
+func file_dag_v1_event_proto_init() {
+	if File_dag_v1_event_proto != nil {
+		return
+	}
+	file_dag_v1_dag_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_dag_v1_event_proto_rawDesc), len(file_dag_v1_event_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_dag_v1_event_proto_goTypes,
+		DependencyIndexes: file_dag_v1_event_proto_depIdxs,
+		MessageInfos:      file_dag_v1_event_proto_msgTypes,
+	}.Build()
+	File_dag_v1_event_proto = out.File
+	file_dag_v1_event_proto_goTypes = nil
+	file_dag_v1_event_proto_depIdxs = nil
+}
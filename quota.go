@@ -0,0 +1,58 @@
+package dag
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Quota caps the size of a single DAG. Zero fields mean unlimited.
+type Quota struct {
+	MaxNodes     int `json:"max_nodes,omitempty"`
+	MaxEdges     int `json:"max_edges,omitempty"`
+	MaxDataBytes int `json:"max_data_bytes,omitempty"`
+}
+
+// CheckNodes returns ErrQuotaExceeded if a DAG would hold more than MaxNodes nodes.
+func (q Quota) CheckNodes(n int) error {
+	if q.MaxNodes > 0 && n > q.MaxNodes {
+		return fmt.Errorf("%w: dag may hold at most %d nodes", ErrQuotaExceeded, q.MaxNodes)
+	}
+	return nil
+}
+
+// CheckEdges returns ErrQuotaExceeded if a DAG would hold more than MaxEdges edges.
+func (q Quota) CheckEdges(n int) error {
+	if q.MaxEdges > 0 && n > q.MaxEdges {
+		return fmt.Errorf("%w: dag may hold at most %d edges", ErrQuotaExceeded, q.MaxEdges)
+	}
+	return nil
+}
+
+// CheckData returns ErrQuotaExceeded if a node or edge payload is larger than MaxDataBytes.
+func (q Quota) CheckData(data json.RawMessage) error {
+	if q.MaxDataBytes > 0 && len(data) > q.MaxDataBytes {
+		return fmt.Errorf("%w: data may be at most %d bytes, got %d", ErrQuotaExceeded, q.MaxDataBytes, len(data))
+	}
+	return nil
+}
+
+// CheckDAG checks a whole DAG (as passed to CreateDAG) against q.
+func (q Quota) CheckDAG(d *DAG) error {
+	if err := q.CheckNodes(len(d.Nodes)); err != nil {
+		return err
+	}
+	if err := q.CheckEdges(len(d.Edges)); err != nil {
+		return err
+	}
+	for _, n := range d.Nodes {
+		if err := q.CheckData(n.Data); err != nil {
+			return err
+		}
+	}
+	for _, e := range d.Edges {
+		if err := q.CheckData(e.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
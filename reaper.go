@@ -0,0 +1,79 @@
+package dag
+
+import (
+	"context"
+	"time"
+)
+
+// Reaper deletes DAGs whose ExpiresAt has passed. Run it in a goroutine;
+// it stops when ctx is cancelled.
+//
+//	r := &dag.Reaper{Store: store, Interval: time.Minute}
+//	go r.Run(ctx)
+type Reaper struct {
+	Store Store
+	// Interval between sweeps. 0 means one minute.
+	Interval time.Duration
+	// BatchSize caps how many DAGs one sweep handles. 0 means 100.
+	BatchSize int
+	// BeforeDelete, if set, runs for each expired DAG before it is deleted —
+	// e.g. to archive it. An error skips that DAG until the next sweep.
+	BeforeDelete func(ctx context.Context, dagID string) error
+	// OnError, if set, receives errors from sweeps and BeforeDelete.
+	OnError func(dagID string, err error)
+}
+
+// Run sweeps immediately and then every Interval until ctx is done.
+func (r *Reaper) Run(ctx context.Context) {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		r.Sweep(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Sweep deletes one batch of expired DAGs and returns the IDs deleted.
+func (r *Reaper) Sweep(ctx context.Context) []string {
+	limit := r.BatchSize
+	if limit <= 0 {
+		limit = 100
+	}
+
+	ids, err := r.Store.ExpiredDAGs(ctx, time.Now(), limit)
+	if err != nil {
+		r.fail("", err)
+		return nil
+	}
+
+	var deleted []string
+	for _, id := range ids {
+		if r.BeforeDelete != nil {
+			if err := r.BeforeDelete(ctx, id); err != nil {
+				r.fail(id, err)
+				continue
+			}
+		}
+		if err := r.Store.DeleteDAG(ctx, id); err != nil {
+			r.fail(id, err)
+			continue
+		}
+		deleted = append(deleted, id)
+	}
+	return deleted
+}
+
+func (r *Reaper) fail(dagID string, err error) {
+	if r.OnError != nil {
+		r.OnError(dagID, err)
+	}
+}
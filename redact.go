@@ -0,0 +1,175 @@
+package dag
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// DefaultMask replaces masked values when RedactionPolicy.MaskValue is empty.
+const DefaultMask = "[REDACTED]"
+
+// RedactionPolicy removes or masks personal data in node and edge data
+// before a DAG leaves the system, e.g. in an export shared with a vendor or
+// a log line. It never changes what is stored.
+//
+// A path is a dot-separated list of object keys; "*" matches every member
+// of an object or element of an array, and a number picks one element.
+// Each path also applies to the localized variants under LocalesKey.
+//
+//	p := &dag.RedactionPolicy{
+//		Strip: []string{"internal_notes"},
+//		Mask:  []string{"answer", "contact.email", "people.*.phone"},
+//	}
+//	shared := p.RedactDAG(d)
+//
+// A nil policy redacts nothing.
+type RedactionPolicy struct {
+	// Strip lists node data paths whose values are removed.
+	Strip []string `json:"strip,omitempty"`
+	// Mask lists node data paths whose values are replaced by MaskValue.
+	Mask []string `json:"mask,omitempty"`
+	// EdgeStrip and EdgeMask are the same for edge data.
+	EdgeStrip []string `json:"edge_strip,omitempty"`
+	EdgeMask  []string `json:"edge_mask,omitempty"`
+	// MaskValue replaces masked values. "" means DefaultMask.
+	MaskValue string `json:"mask_value,omitempty"`
+}
+
+// RedactDAG returns a copy of d with its node and edge data redacted.
+func (p *RedactionPolicy) RedactDAG(d *DAG) *DAG {
+	if p == nil || d == nil {
+		return d
+	}
+	out := *d
+	out.Nodes = make([]Node, len(d.Nodes))
+	for i, n := range d.Nodes {
+		out.Nodes[i] = p.RedactNode(n)
+	}
+	out.Edges = make([]Edge, len(d.Edges))
+	for i, e := range d.Edges {
+		out.Edges[i] = p.RedactEdge(e)
+	}
+	return &out
+}
+
+// RedactNode returns n with its data redacted.
+func (p *RedactionPolicy) RedactNode(n Node) Node {
+	if p != nil {
+		n.Data = p.redact(n.Data, p.Strip, p.Mask)
+	}
+	return n
+}
+
+// RedactEdge returns e with its data redacted.
+func (p *RedactionPolicy) RedactEdge(e Edge) Edge {
+	if p != nil {
+		e.Data = p.redact(e.Data, p.EdgeStrip, p.EdgeMask)
+	}
+	return e
+}
+
+// redact returns data with the values at strip removed and those at mask
+// replaced. Data that is not valid JSON is masked as a whole rather than
+// passed through.
+func (p *RedactionPolicy) redact(data json.RawMessage, strip, mask []string) json.RawMessage {
+	if len(data) == 0 || len(strip)+len(mask) == 0 {
+		return data
+	}
+	maskValue := p.MaskValue
+	if maskValue == "" {
+		maskValue = DefaultMask
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		b, _ := json.Marshal(maskValue)
+		return b
+	}
+
+	changed := false
+	for _, path := range LocalizedPaths(mask) {
+		doc = redactAt(doc, strings.Split(path, "."), func() any {
+			changed = true
+			return maskValue
+		})
+	}
+	for _, path := range LocalizedPaths(strip) {
+		doc = redactAt(doc, strings.Split(path, "."), func() any {
+			changed = true
+			return stripped{}
+		})
+	}
+	if !changed {
+		return data
+	}
+	b, err := json.Marshal(dropStripped(doc))
+	if err != nil {
+		return data
+	}
+	return b
+}
+
+// stripped marks a value removed by a Strip path until dropStripped
+// deletes it, so array indexes stay stable while paths are applied.
+type stripped struct{}
+
+// redactAt replaces the values of v at path with the result of fn.
+func redactAt(v any, path []string, fn func() any) any {
+	if len(path) == 0 {
+		return v
+	}
+	seg, rest := path[0], path[1:]
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if seg != "*" && seg != k {
+				continue
+			}
+			if len(rest) == 0 {
+				v[k] = fn()
+			} else {
+				v[k] = redactAt(child, rest, fn)
+			}
+		}
+	case []any:
+		i, convErr := strconv.Atoi(seg)
+		for j, child := range v {
+			if seg != "*" && (convErr != nil || i != j) {
+				continue
+			}
+			if len(rest) == 0 {
+				v[j] = fn()
+			} else {
+				v[j] = redactAt(child, rest, fn)
+			}
+		}
+	}
+	return v
+}
+
+// dropStripped removes the values redactAt marked as stripped.
+func dropStripped(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if _, ok := child.(stripped); ok {
+				delete(v, k)
+			} else {
+				v[k] = dropStripped(child)
+			}
+		}
+	case []any:
+		out := v[:0]
+		for _, child := range v {
+			if _, ok := child.(stripped); !ok {
+				out = append(out, dropStripped(child))
+			}
+		}
+		return out
+	}
+	return v
+}
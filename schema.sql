@@ -1,8 +1,55 @@
+CREATE TABLE IF NOT EXISTS dags (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL DEFAULT '',
+    tags       TEXT[] NOT NULL DEFAULT '{}',
+    status     TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'published', 'archived')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ,
+    draft_of   TEXT,
+    settings   JSONB NOT NULL DEFAULT '{}',
+    node_count  INT NOT NULL DEFAULT 0,
+    edge_count  INT NOT NULL DEFAULT 0,
+    max_depth   INT,
+    modified_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    content_hash TEXT
+);
+
+-- Added after the first release of the dags table.
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'draft'
+    CHECK (status IN ('draft', 'published', 'archived'));
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS draft_of TEXT;
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS settings JSONB NOT NULL DEFAULT '{}';
+-- Stats columns are added nullable and filled by the backfill at the end.
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS node_count INT;
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS edge_count INT;
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS max_depth INT;
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS modified_at TIMESTAMPTZ;
+ALTER TABLE dags ADD COLUMN IF NOT EXISTS content_hash TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_dags_tags       ON dags USING GIN (tags);
+CREATE INDEX IF NOT EXISTS idx_dags_created_at ON dags(created_at);
+CREATE INDEX IF NOT EXISTS idx_dags_expires_at ON dags(expires_at) WHERE expires_at IS NOT NULL;
+
+-- Node data shared by content hash (WithDedup).
+CREATE TABLE IF NOT EXISTS dag_node_data (
+    hash       TEXT PRIMARY KEY,
+    data       JSONB NOT NULL,
+    compressed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
 CREATE TABLE IF NOT EXISTS dag_nodes (
     id         TEXT PRIMARY KEY,
     dag_id     TEXT NOT NULL,
     data       JSONB NOT NULL DEFAULT '{}',
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    tags       TEXT[] NOT NULL DEFAULT '{}',
+    compressed BOOLEAN NOT NULL DEFAULT FALSE,
+    blob_key   TEXT,
+    data_hash  TEXT REFERENCES dag_node_data(hash),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
 CREATE TABLE IF NOT EXISTS dag_edges (
@@ -11,10 +58,193 @@ CREATE TABLE IF NOT EXISTS dag_edges (
     from_node_id TEXT NOT NULL REFERENCES dag_nodes(id) ON DELETE CASCADE,
     to_node_id   TEXT NOT NULL REFERENCES dag_nodes(id) ON DELETE CASCADE,
     data         JSONB NOT NULL DEFAULT '{}',
-    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    order_index  INT NOT NULL DEFAULT 0,
+    unique_pair  BOOLEAN NOT NULL DEFAULT FALSE,
+    compressed   BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
+ALTER TABLE dag_nodes ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE dag_edges ADD COLUMN IF NOT EXISTS order_index INT NOT NULL DEFAULT 0;
+ALTER TABLE dag_edges ADD COLUMN IF NOT EXISTS unique_pair BOOLEAN NOT NULL DEFAULT FALSE;
+-- compressed marks rows whose data is a "zstd:..." string (WithCompression).
+ALTER TABLE dag_nodes ADD COLUMN IF NOT EXISTS compressed BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE dag_edges ADD COLUMN IF NOT EXISTS compressed BOOLEAN NOT NULL DEFAULT FALSE;
+-- blob_key is the object key of node data moved out by WithBlobStore.
+ALTER TABLE dag_nodes ADD COLUMN IF NOT EXISTS blob_key TEXT;
+-- data_hash points at dag_node_data; data is then an empty object.
+ALTER TABLE dag_nodes ADD COLUMN IF NOT EXISTS data_hash TEXT REFERENCES dag_node_data(hash);
+-- updated_at is the last change to a row's content; older rows start at
+-- created_at.
+ALTER TABLE dag_nodes ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+ALTER TABLE dag_edges ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+UPDATE dag_nodes SET updated_at = created_at WHERE updated_at IS NULL;
+UPDATE dag_edges SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE dag_nodes ALTER COLUMN updated_at SET DEFAULT NOW(), ALTER COLUMN updated_at SET NOT NULL;
+ALTER TABLE dag_edges ALTER COLUMN updated_at SET DEFAULT NOW(), ALTER COLUMN updated_at SET NOT NULL;
+
+-- dag_touch stamps updated_at when a node's data or tags, or an edge's data
+-- or ends, change. Moving rows between DAGs (PromoteDraft) and reordering
+-- edges don't count.
+CREATE OR REPLACE FUNCTION dag_touch() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at := NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER trg_dag_nodes_touch BEFORE UPDATE ON dag_nodes FOR EACH ROW
+    WHEN (OLD.data IS DISTINCT FROM NEW.data OR OLD.data_hash IS DISTINCT FROM NEW.data_hash OR OLD.tags IS DISTINCT FROM NEW.tags)
+    EXECUTE FUNCTION dag_touch();
+CREATE OR REPLACE TRIGGER trg_dag_edges_touch BEFORE UPDATE ON dag_edges FOR EACH ROW
+    WHEN (OLD.data IS DISTINCT FROM NEW.data OR OLD.from_node_id IS DISTINCT FROM NEW.from_node_id OR OLD.to_node_id IS DISTINCT FROM NEW.to_node_id)
+    EXECUTE FUNCTION dag_touch();
+
 CREATE INDEX IF NOT EXISTS idx_dag_nodes_dag_id ON dag_nodes(dag_id);
+CREATE INDEX IF NOT EXISTS idx_dag_nodes_tags   ON dag_nodes USING GIN (tags);
 CREATE INDEX IF NOT EXISTS idx_dag_edges_dag_id ON dag_edges(dag_id);
 CREATE INDEX IF NOT EXISTS idx_dag_edges_from   ON dag_edges(from_node_id);
 CREATE INDEX IF NOT EXISTS idx_dag_edges_to     ON dag_edges(to_node_id);
+CREATE INDEX IF NOT EXISTS idx_dag_nodes_blob   ON dag_nodes(blob_key) WHERE blob_key IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_dag_nodes_hash   ON dag_nodes(data_hash) WHERE data_hash IS NOT NULL;
+-- Keyset pagination of ListNodesPage / ListEdgesPage in created_at and
+-- updated_at order.
+CREATE INDEX IF NOT EXISTS idx_dag_nodes_list    ON dag_nodes(dag_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_dag_edges_list    ON dag_edges(dag_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_dag_nodes_updated ON dag_nodes(dag_id, updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_dag_edges_updated ON dag_edges(dag_id, updated_at, id);
+-- FindEdges: containment and JSON path matches on edge data.
+CREATE INDEX IF NOT EXISTS idx_dag_edges_data    ON dag_edges USING GIN (data jsonb_path_ops);
+
+-- unique_pair mirrors the owning DAG's no_parallel_edges setting, so this
+-- index only constrains DAGs that forbid parallel edges.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dag_edges_unique_pair
+    ON dag_edges(from_node_id, to_node_id) WHERE unique_pair;
+
+CREATE TABLE IF NOT EXISTS dag_idempotency_keys (
+    key         TEXT PRIMARY KEY,
+    fingerprint TEXT NOT NULL,
+    status      INT NOT NULL DEFAULT 0,
+    body        BYTEA,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    claimed_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS dag_versions (
+    id         BIGSERIAL PRIMARY KEY,
+    dag_id     TEXT NOT NULL,
+    snapshot   JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dag_versions_dag_id ON dag_versions(dag_id, created_at);
+
+-- Append-only write log (WithEventLog). payload holds the event's fields
+-- other than seq, dag_id, type, created_at and actor.
+CREATE TABLE IF NOT EXISTS dag_events (
+    seq        BIGSERIAL PRIMARY KEY,
+    dag_id     TEXT NOT NULL,
+    type       TEXT NOT NULL,
+    payload    JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor      TEXT
+);
+
+-- Added after the first release of the event log.
+ALTER TABLE dag_events ADD COLUMN IF NOT EXISTS actor TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_dag_events_dag_id ON dag_events(dag_id, seq);
+-- QueryAudit across DAGs by time or actor.
+CREATE INDEX IF NOT EXISTS idx_dag_events_created ON dag_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_dag_events_actor   ON dag_events(actor, seq) WHERE actor IS NOT NULL;
+
+-- Events waiting to be published (WithOutbox); Relay deletes them once
+-- published.
+CREATE TABLE IF NOT EXISTS dag_outbox (
+    seq        BIGSERIAL PRIMARY KEY,
+    dag_id     TEXT NOT NULL,
+    type       TEXT NOT NULL,
+    payload    JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor      TEXT
+);
+
+-- Added after the first release of the outbox.
+ALTER TABLE dag_outbox ADD COLUMN IF NOT EXISTS actor TEXT;
+
+-- Visits of runs to nodes (RecordVisit), aggregated by VisitStats.
+CREATE TABLE IF NOT EXISTS dag_visits (
+    seq        BIGSERIAL PRIMARY KEY,
+    dag_id     TEXT NOT NULL,
+    run_id     TEXT NOT NULL,
+    node_id    TEXT NOT NULL,
+    visited_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dag_visits_dag_id ON dag_visits(dag_id, run_id, seq);
+
+-- Backfill metadata rows for DAGs created before the dags table existed.
+INSERT INTO dags (id, created_at)
+SELECT dag_id, MIN(created_at) FROM dag_nodes GROUP BY dag_id
+ON CONFLICT (id) DO NOTHING;
+
+CREATE OR REPLACE FUNCTION dag_stats() RETURNS trigger AS $$
+DECLARE
+    depth TEXT := CASE WHEN TG_ARGV[0] = 'edge_count' THEN ', max_depth = NULL' ELSE '' END;
+BEGIN
+    IF TG_OP IN ('DELETE', 'UPDATE') THEN
+        EXECUTE format('UPDATE dags d SET %1$I = d.%1$I - c.n, modified_at = NOW()%2$s
+            FROM (SELECT dag_id, COUNT(*) AS n FROM old_rows GROUP BY dag_id) c WHERE d.id = c.dag_id',
+            TG_ARGV[0], depth);
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        EXECUTE format('INSERT INTO dags (id, %1$I) SELECT dag_id, COUNT(*) FROM new_rows GROUP BY dag_id
+            ON CONFLICT (id) DO UPDATE SET %1$I = dags.%1$I + EXCLUDED.%1$I, modified_at = NOW()%2$s',
+            TG_ARGV[0], depth);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER trg_dag_nodes_stats_ins AFTER INSERT ON dag_nodes
+    REFERENCING NEW TABLE AS new_rows FOR EACH STATEMENT EXECUTE FUNCTION dag_stats('node_count');
+CREATE OR REPLACE TRIGGER trg_dag_nodes_stats_del AFTER DELETE ON dag_nodes
+    REFERENCING OLD TABLE AS old_rows FOR EACH STATEMENT EXECUTE FUNCTION dag_stats('node_count');
+CREATE OR REPLACE TRIGGER trg_dag_nodes_stats_upd AFTER UPDATE ON dag_nodes
+    REFERENCING OLD TABLE AS old_rows NEW TABLE AS new_rows FOR EACH STATEMENT EXECUTE FUNCTION dag_stats('node_count');
+CREATE OR REPLACE TRIGGER trg_dag_edges_stats_ins AFTER INSERT ON dag_edges
+    REFERENCING NEW TABLE AS new_rows FOR EACH STATEMENT EXECUTE FUNCTION dag_stats('edge_count');
+CREATE OR REPLACE TRIGGER trg_dag_edges_stats_del AFTER DELETE ON dag_edges
+    REFERENCING OLD TABLE AS old_rows FOR EACH STATEMENT EXECUTE FUNCTION dag_stats('edge_count');
+CREATE OR REPLACE TRIGGER trg_dag_edges_stats_upd AFTER UPDATE ON dag_edges
+    REFERENCING OLD TABLE AS old_rows NEW TABLE AS new_rows FOR EACH STATEMENT EXECUTE FUNCTION dag_stats('edge_count');
+
+-- Backfill stats for DAGs written before the stats columns existed.
+UPDATE dags d SET
+    node_count = (SELECT COUNT(*) FROM dag_nodes n WHERE n.dag_id = d.id),
+    edge_count = (SELECT COUNT(*) FROM dag_edges e WHERE e.dag_id = d.id),
+    modified_at = d.updated_at
+WHERE d.node_count IS NULL;
+ALTER TABLE dags ALTER COLUMN node_count SET DEFAULT 0, ALTER COLUMN node_count SET NOT NULL,
+    ALTER COLUMN edge_count SET DEFAULT 0, ALTER COLUMN edge_count SET NOT NULL,
+    ALTER COLUMN modified_at SET DEFAULT NOW(), ALTER COLUMN modified_at SET NOT NULL;
+
+-- Advisory edit locks; node_id is '' for a lock on the whole DAG.
+CREATE TABLE IF NOT EXISTS dag_locks (
+    dag_id      TEXT NOT NULL,
+    node_id     TEXT NOT NULL DEFAULT '',
+    owner       TEXT NOT NULL,
+    acquired_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at  TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (dag_id, node_id)
+);
+
+-- Versions applied by MigrateUp, or all of them by CreateSchema.
+CREATE TABLE IF NOT EXISTS dag_schema_migrations (
+    version    INT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+INSERT INTO dag_schema_migrations (version, name) VALUES (1, 'baseline'), (2, 'locks'), (3, 'idempotency_lease')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,63 @@
+package dag
+
+import "time"
+
+// DAGInfo is a DAG's metadata, without its nodes and edges.
+type DAGInfo struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Tags      []string   `json:"tags"`
+	Status    Status     `json:"status"`
+	DraftOf   string     `json:"draft_of,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Settings  Settings   `json:"settings,omitzero"`
+	// Hash is the DAG's content hash (see Hash), empty for a DAG with no
+	// nodes.
+	Hash string `json:"hash,omitempty"`
+}
+
+// DAGStats are a DAG's size and shape. The store keeps them up to date on
+// every write, so reading them does not count rows.
+type DAGStats struct {
+	NodeCount int `json:"node_count"`
+	EdgeCount int `json:"edge_count"`
+	// MaxDepth is the number of edges on the longest path.
+	MaxDepth int `json:"max_depth"`
+	// ModifiedAt is the time of the last node or edge write.
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// SearchQuery filters SearchDAGs. Empty fields are ignored; all set
+// fields must match.
+type SearchQuery struct {
+	// Name matches DAGs whose name contains it (case-insensitive).
+	Name string
+	// Tag matches DAGs carrying this exact tag. It is shorthand for a
+	// one-element Tags.
+	Tag string
+	// Tags matches DAGs carrying all of these tags.
+	Tags []string
+	// AnyTags matches DAGs carrying at least one of these tags.
+	AnyTags []string
+	// CreatedAfter matches DAGs created strictly after this time.
+	CreatedAfter time.Time
+	// Status matches DAGs in this lifecycle status.
+	Status Status
+	// Text is a full-text query over the DAG ID and name, and over node
+	// data when IncludeNodeData is set. Results are ranked by relevance.
+	Text            string
+	IncludeNodeData bool
+	// Limit caps the number of results. 0 means 50.
+	Limit int
+}
+
+// SearchResult is one DAG matched by SearchDAGs.
+type SearchResult struct {
+	DAGInfo
+	NodeCount  int       `json:"node_count"`
+	EdgeCount  int       `json:"edge_count"`
+	ModifiedAt time.Time `json:"modified_at"`
+	Rank       float64   `json:"rank"`
+}
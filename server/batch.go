@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/meikuraledutech/dag"
+)
+
+// maxBatchItems caps the number of items in one batch request.
+const maxBatchItems = 1000
+
+// maxBatchGet caps the DAG IDs in one GET /dags:batch.
+const maxBatchGet = 100
+
+// batchItem is the per-item entry of a 207 Multi-Status batch response.
+type batchItem struct {
+	Index  int       `json:"index"`
+	Status int       `json:"status"`
+	ID     string    `json:"id,omitempty"`
+	Error  *apiError `json:"error,omitempty"`
+}
+
+// batchResponse is the body of a 207 Multi-Status batch response.
+type batchResponse struct {
+	Results []batchItem `json:"results"`
+}
+
+// checkBatchSize rejects empty and oversized batches.
+func checkBatchSize(n int) error {
+	switch {
+	case n == 0:
+		return validationFailed([]fieldError{{Field: "items", Message: "must contain at least one item"}})
+	case n > maxBatchItems:
+		return validationFailed([]fieldError{{Field: "items", Message: fmt.Sprintf("must contain at most %d items", maxBatchItems)}})
+	}
+	return nil
+}
+
+// runBatch validates each item, sends the valid ones to add in one call and
+// merges the store results back by index. Items that fail validation never
+// reach the store.
+func runBatch[T any](c fiber.Ctx, items []T, validate func(prefix string, item *T) []fieldError, add func(valid []T) ([]dag.BatchResult, error)) error {
+	if err := checkBatchSize(len(items)); err != nil {
+		return err
+	}
+
+	results := make([]batchItem, len(items))
+	var valid []T
+	var validIdx []int
+	for i := range items {
+		results[i].Index = i
+		if errs := validate(fmt.Sprintf("items[%d]", i), &items[i]); len(errs) > 0 {
+			results[i].Status = fiber.StatusBadRequest
+			results[i].Error = validationFailed(errs)
+			continue
+		}
+		valid = append(valid, items[i])
+		validIdx = append(validIdx, i)
+	}
+
+	if len(valid) > 0 {
+		stored, err := add(valid)
+		if err != nil {
+			return err
+		}
+		for j, r := range stored {
+			i := validIdx[j]
+			if r.Err != nil {
+				results[i].Error = toAPIError(r.Err)
+				results[i].Status = results[i].Error.Status
+				continue
+			}
+			results[i].Status = fiber.StatusCreated
+			results[i].ID = r.ID
+		}
+	}
+
+	return c.Status(fiber.StatusMultiStatus).JSON(batchResponse{Results: results})
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"os"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/meikuraledutech/dag"
+	"github.com/meikuraledutech/dag/export"
+)
+
+// bundleKey is one entry of the DAG_BUNDLE_KEYS file. Secret and the
+// Ed25519 keys are base64; private_key may be a 32-byte seed or a 64-byte
+// private key.
+type bundleKey struct {
+	ID         string `json:"id"`
+	Alg        string `json:"alg"`
+	Secret     string `json:"secret"`
+	PrivateKey string `json:"private_key"`
+	PublicKey  string `json:"public_key"`
+}
+
+// bundleKeysFromFile reads a JSON array of bundleKey.
+func bundleKeysFromFile(file string) ([]*export.Key, error) {
+	body, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var list []bundleKey
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	keys := make([]*export.Key, len(list))
+	for i, k := range list {
+		if keys[i], err = k.key(); err != nil {
+			return nil, fmt.Errorf("%s: key %d (%q): %w", file, i, k.ID, err)
+		}
+	}
+	return keys, nil
+}
+
+func (k bundleKey) key() (*export.Key, error) {
+	switch k.Alg {
+	case export.HMACSHA256:
+		secret, err := base64.StdEncoding.DecodeString(k.Secret)
+		if err != nil || len(secret) < 32 {
+			return nil, fmt.Errorf("secret must be at least 32 bytes, base64-encoded")
+		}
+		return export.HMACKey(k.ID, secret), nil
+	case export.Ed25519:
+		if k.PrivateKey != "" {
+			priv, err := base64.StdEncoding.DecodeString(k.PrivateKey)
+			switch {
+			case err == nil && len(priv) == ed25519.SeedSize:
+				return export.Ed25519Key(k.ID, ed25519.NewKeyFromSeed(priv)), nil
+			case err == nil && len(priv) == ed25519.PrivateKeySize:
+				return export.Ed25519Key(k.ID, ed25519.PrivateKey(priv)), nil
+			}
+			return nil, fmt.Errorf("private_key must be a base64 32-byte seed or 64-byte private key")
+		}
+		pub, err := base64.StdEncoding.DecodeString(k.PublicKey)
+		if err != nil || len(pub) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("public_key must be a base64 32-byte public key")
+		}
+		return export.Ed25519PublicKey(k.ID, pub), nil
+	}
+	return nil, fmt.Errorf("alg must be %s or %s", export.HMACSHA256, export.Ed25519)
+}
+
+// signingKey returns the first of keys that can sign, or nil.
+func signingKey(keys []*export.Key) *export.Key {
+	for _, k := range keys {
+		if k.CanSign() {
+			return k
+		}
+	}
+	return nil
+}
+
+// sendBundle sends d as a signed bundle attachment.
+func sendBundle(c fiber.Ctx, d *dag.DAG, key *export.Key) error {
+	var buf bytes.Buffer
+	if err := export.WriteBundle(&buf, d, key); err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, export.JSON.ContentType())
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{
+		"filename": d.ID + ".bundle.json",
+	}))
+	return c.Send(buf.Bytes())
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// Count headers set by the HEAD routes, which answer with sizes instead of
+// bodies.
+const (
+	totalCountHeader = "X-Total-Count"
+	nodeCountHeader  = "X-Node-Count"
+	edgeCountHeader  = "X-Edge-Count"
+)
+
+// sendCounts answers a HEAD request with 200 and each count in its header.
+func sendCounts(c fiber.Ctx, counts map[string]int) error {
+	for h, n := range counts {
+		c.Set(h, strconv.Itoa(n))
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
+	"github.com/meikuraledutech/dag"
+	"github.com/meikuraledutech/dag/archive"
+	"github.com/meikuraledutech/dag/export"
+	"github.com/meikuraledutech/dag/formflow"
+	"github.com/meikuraledutech/dag/postgres"
+)
+
+// Error codes returned in the "code" field of the error envelope.
+// They are part of the public API — never rename an existing code.
+const (
+	codeInvalidBody           = "invalid_body"
+	codeValidationFailed      = "validation_failed"
+	codeNotFound              = "not_found"
+	codeDAGNotFound           = "dag_not_found"
+	codeNodeNotFound          = "node_not_found"
+	codeEdgeNotFound          = "edge_not_found"
+	codePathNotFound          = "path_not_found"
+	codeVersionNotFound       = "version_not_found"
+	codePreconditionFailed    = "precondition_failed"
+	codePreconditionRequired  = "precondition_required"
+	codeCycleDetected         = "cycle_detected"
+	codeDAGFrozen             = "dag_frozen"
+	codeDAGExists             = "dag_already_exists"
+	codeInvalidOrder          = "invalid_order"
+	codeParallelEdge          = "parallel_edge"
+	codeDuplicateCondition    = "duplicate_condition"
+	codeNotTree               = "not_tree"
+	codeMultipleRoots         = "multiple_roots"
+	codeDisconnected          = "disconnected"
+	codeTooDeep               = "too_deep"
+	codeOutDegree             = "out_degree_exceeded"
+	codeQuotaExceeded         = "quota_exceeded"
+	codeIdempotencyKeyReused  = "idempotency_key_reused"
+	codeIdempotencyInProgress = "idempotency_in_progress"
+	codeMethodNotAllowed      = "method_not_allowed"
+	codeNotAcceptable         = "not_acceptable"
+	codeUnsupportedMediaType  = "unsupported_media_type"
+	codePatchFailed           = "patch_failed"
+	codeInvalidAnswer         = "invalid_answer"
+	codeInvalidSignature      = "invalid_signature"
+	codeLocked                = "locked"
+	codeTimeout               = "timeout"
+	codeInternal              = "internal_error"
+)
+
+// errorEnvelope is the JSON body of every error response:
+//
+//	{"error": {"code": "...", "message": "...", "details": ..., "request_id": "..."}}
+type errorEnvelope struct {
+	Error apiError `json:"error"`
+}
+
+// apiError is an error that knows its HTTP status and machine-readable code.
+// Handlers return it (or a dag sentinel error) and errorHandler renders it.
+type apiError struct {
+	Status    int    `json:"-"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func (e *apiError) Error() string { return e.Message }
+
+// fieldError describes a single invalid field in a request body.
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// newError builds an apiError with the given status, code and message.
+func newError(status int, code, message string) *apiError {
+	return &apiError{Status: status, Code: code, Message: message}
+}
+
+// invalidBody wraps a body decoding error into a 400 with the decoder's
+// position information in details, when available.
+func invalidBody(err error) *apiError {
+	e := newError(fiber.StatusBadRequest, codeInvalidBody, "invalid body")
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		e.Details = fiber.Map{"offset": syntaxErr.Offset, "reason": syntaxErr.Error()}
+	case errors.As(err, &typeErr):
+		e.Details = []fieldError{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value),
+		}}
+	case err != nil:
+		e.Details = fiber.Map{"reason": err.Error()}
+	}
+	return e
+}
+
+// validationFailed returns a 400 listing every invalid field.
+func validationFailed(fields []fieldError) *apiError {
+	e := newError(fiber.StatusBadRequest, codeValidationFailed, "validation failed")
+	e.Details = fields
+	return e
+}
+
+// dataInvalid turns a schema violation into a 400 whose fields point into
+// the request body: "data.question" for a single node or edge, and
+// "nodes[2].data.question" inside a CreateDAG body.
+func dataInvalid(de *dag.DataError) *apiError {
+	prefix := "data"
+	if de.Index >= 0 {
+		prefix = fmt.Sprintf("%ss[%d].data", de.Kind, de.Index)
+	}
+	fields := make([]fieldError, len(de.Fields))
+	for i, f := range de.Fields {
+		fields[i] = fieldError{Field: prefix + f.Path, Message: f.Message}
+	}
+	return validationFailed(fields)
+}
+
+// toAPIError maps any error returned by a handler to an apiError.
+func toAPIError(err error) *apiError {
+	var ae *apiError
+	if errors.As(err, &ae) {
+		return ae
+	}
+	var de *dag.DataError
+	if errors.As(err, &de) {
+		return dataInvalid(de)
+	}
+	var le *dag.LockError
+	if errors.As(err, &le) {
+		e := newError(fiber.StatusConflict, codeLocked, le.Error())
+		e.Details = le
+		return e
+	}
+	var ans *formflow.AnswerError
+	if errors.As(err, &ans) {
+		e := newError(fiber.StatusBadRequest, codeInvalidAnswer, "answer matches no option")
+		e.Details = ans
+		return e
+	}
+
+	switch {
+	case errors.Is(err, dag.ErrCycleDetected):
+		return newError(fiber.StatusUnprocessableEntity, codeCycleDetected, "cycle detected")
+	case errors.Is(err, dag.ErrNodeNotFound):
+		return newError(fiber.StatusNotFound, codeNodeNotFound, "node not found")
+	case errors.Is(err, dag.ErrEdgeNotFound):
+		return newError(fiber.StatusNotFound, codeEdgeNotFound, "edge not found")
+	case errors.Is(err, dag.ErrDAGNotFound):
+		return newError(fiber.StatusNotFound, codeDAGNotFound, "dag not found")
+	case errors.Is(err, dag.ErrDAGFrozen):
+		return newError(fiber.StatusConflict, codeDAGFrozen, err.Error())
+	case errors.Is(err, dag.ErrDAGExists):
+		return newError(fiber.StatusConflict, codeDAGExists, err.Error()+"; pass replace=true to replace it")
+	case errors.Is(err, dag.ErrNoVersion):
+		return newError(fiber.StatusNotFound, codeVersionNotFound, "no version of the dag at that time")
+	case errors.Is(err, archive.ErrNotFound):
+		return newError(fiber.StatusNotFound, codeDAGNotFound, "dag not found")
+	case errors.Is(err, dag.ErrInvalidOrder):
+		return newError(fiber.StatusUnprocessableEntity, codeInvalidOrder, err.Error())
+	case errors.Is(err, dag.ErrParallelEdge):
+		return newError(fiber.StatusUnprocessableEntity, codeParallelEdge, err.Error())
+	case errors.Is(err, dag.ErrDuplicateCondition):
+		return newError(fiber.StatusUnprocessableEntity, codeDuplicateCondition, err.Error())
+	case errors.Is(err, dag.ErrNotTree):
+		return newError(fiber.StatusUnprocessableEntity, codeNotTree, err.Error())
+	case errors.Is(err, dag.ErrMultipleRoots):
+		return newError(fiber.StatusUnprocessableEntity, codeMultipleRoots, err.Error())
+	case errors.Is(err, dag.ErrDisconnected):
+		return newError(fiber.StatusUnprocessableEntity, codeDisconnected, err.Error())
+	case errors.Is(err, dag.ErrTooDeep):
+		return newError(fiber.StatusUnprocessableEntity, codeTooDeep, err.Error())
+	case errors.Is(err, dag.ErrUnknownNodeType):
+		return validationFailed([]fieldError{{Field: "data.type", Message: err.Error()}})
+	case errors.Is(err, dag.ErrOutDegree):
+		return newError(fiber.StatusUnprocessableEntity, codeOutDegree, err.Error())
+	case errors.Is(err, dag.ErrQuotaExceeded):
+		return newError(fiber.StatusRequestEntityTooLarge, codeQuotaExceeded, err.Error())
+	case errors.Is(err, dag.ErrInvalidCursor):
+		return validationFailed([]fieldError{{Field: "cursor", Message: "is invalid or expired"}})
+	case errors.Is(err, dag.ErrInvalidSort):
+		return validationFailed([]fieldError{{Field: "sort", Message: "must be created_at, updated_at, id, label or data.<path>, optionally prefixed with -"}})
+	case errors.Is(err, dag.ErrInvalidFilter):
+		return validationFailed([]fieldError{{Field: "match", Message: "must be a JSON path predicate"}})
+	case errors.Is(err, dag.ErrInvalidHops):
+		return validationFailed([]fieldError{{Field: "k", Message: "must be a non-negative hop count with direction out, in or both"}})
+	case errors.Is(err, dag.ErrInvalidMerge):
+		return validationFailed([]fieldError{{Field: "strategy", Message: err.Error()}})
+	case errors.Is(err, dag.ErrInvalidSubgraph):
+		return validationFailed([]fieldError{{Field: "body", Message: err.Error()}})
+	case errors.Is(err, dag.ErrInvalidDuration):
+		return validationFailed([]fieldError{{Field: "duration", Message: err.Error()}})
+	case errors.Is(err, export.ErrBadSignature), errors.Is(err, export.ErrUnknownKey):
+		return newError(fiber.StatusUnprocessableEntity, codeInvalidSignature, err.Error())
+	case errors.Is(err, formflow.ErrUnknownMatcher):
+		return validationFailed([]fieldError{{Field: "match", Message: err.Error()}})
+	case errors.Is(err, dag.ErrInvalidField):
+		return validationFailed([]fieldError{{Field: "fields", Message: fmt.Sprintf("must be at most %d dot-separated data paths, none inside another", dag.MaxFields)}})
+	case postgres.IsTimeout(err):
+		return newError(fiber.StatusGatewayTimeout, codeTimeout, "the operation timed out")
+	}
+
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		switch fe.Code {
+		case fiber.StatusNotFound:
+			return newError(fe.Code, codeNotFound, fe.Message)
+		case fiber.StatusMethodNotAllowed:
+			return newError(fe.Code, codeMethodNotAllowed, fe.Message)
+		case fiber.StatusBadRequest:
+			return invalidBody(fe)
+		}
+		return newError(fe.Code, codeInternal, fe.Message)
+	}
+
+	return newError(fiber.StatusInternalServerError, codeInternal, err.Error())
+}
+
+// errorHandler is the fiber ErrorHandler: it renders every error as the
+// documented envelope and stamps it with the request ID.
+func errorHandler(c fiber.Ctx, err error) error {
+	ae := *toAPIError(err)
+	ae.RequestID = requestid.FromContext(c)
+	return c.Status(ae.Status).JSON(errorEnvelope{Error: ae})
+}
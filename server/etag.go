@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/meikuraledutech/dag/postgres"
+)
+
+// etagOf returns a strong ETag for v: a hash of its JSON encoding.
+// The same resource always produces the same tag, so clients can compare
+// tags across GETs and writes without the server storing anything.
+func etagOf(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// sendWithETag writes v as JSON with an ETag header, or a bodyless 304 if
+// the request's If-None-Match already names the current tag.
+func sendWithETag(c fiber.Ctx, v any) error {
+	tag, err := etagOf(v)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderETag, tag)
+	if etagMatches(c.Get(fiber.HeaderIfNoneMatch), tag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	return c.JSON(v)
+}
+
+// checkIfMatch enforces the If-Match header on a write, and returns the
+// context to run the write with. load fetches the current resource from s
+// and returns nil if it doesn't exist; the first comparison reads pg.
+// Without an If-Match header the write
+// proceeds, unless strict is set.
+//
+// The tag is compared twice: now, so a stale tag fails before any work, and
+// again inside the write once it holds the DAG's lock (see
+// postgres.WithPrecondition), so two writes sending the same tag can't both
+// succeed.
+func checkIfMatch[T any](c fiber.Ctx, pg *postgres.PGStore, strict bool, load func(ctx context.Context, s *postgres.PGStore) (*T, error)) (context.Context, error) {
+	return checkIfMatchTag(c, pg, strict, func(ctx context.Context, s *postgres.PGStore) (string, error) {
+		cur, err := load(ctx, s)
+		if err != nil || cur == nil {
+			return "", err
+		}
+		return etagOf(cur)
+	})
+}
+
+// checkIfMatchTag is checkIfMatch for resources whose ETag is computed
+// without loading them. tag returns "" if the resource doesn't exist.
+func checkIfMatchTag(c fiber.Ctx, pg *postgres.PGStore, strict bool, tag func(ctx context.Context, s *postgres.PGStore) (string, error)) (context.Context, error) {
+	ifMatch := c.Get(fiber.HeaderIfMatch)
+	if ifMatch == "" {
+		if strict {
+			return nil, newError(fiber.StatusPreconditionRequired, codePreconditionRequired, "If-Match header is required")
+		}
+		return c.Context(), nil
+	}
+
+	check := func(ctx context.Context, s *postgres.PGStore) error {
+		cur, err := tag(ctx, s)
+		if err != nil {
+			return err
+		}
+		if cur == "" {
+			return newError(fiber.StatusPreconditionFailed, codePreconditionFailed, "resource does not exist")
+		}
+		if !etagMatches(ifMatch, cur) {
+			return newError(fiber.StatusPreconditionFailed, codePreconditionFailed, "resource has been modified")
+		}
+		return nil
+	}
+	if err := check(c.Context(), pg); err != nil {
+		return nil, err
+	}
+	return postgres.WithPrecondition(c.Context(), check), nil
+}
+
+// etagMatches reports whether a comma-separated If-Match / If-None-Match
+// header value names tag. "*" matches any tag; weak prefixes are ignored.
+func etagMatches(header, tag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == "*" || t == tag {
+			return true
+		}
+	}
+	return false
+}
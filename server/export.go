@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/meikuraledutech/dag"
+	"github.com/meikuraledutech/dag/export"
+	"github.com/meikuraledutech/dag/formflow"
+	"github.com/meikuraledutech/dag/postgres"
+)
+
+// exportFormat picks the export format from ?format= if present, otherwise
+// by negotiating the Accept header. JSON is the default when the client
+// accepts anything.
+func exportFormat(c fiber.Ctx) (export.Format, error) {
+	if q := c.Query("format"); q != "" {
+		f, err := export.ParseFormat(q)
+		if err != nil {
+			return "", validationFailed([]fieldError{{Field: "format", Message: fmt.Sprintf("unsupported format %q", q)}})
+		}
+		return f, nil
+	}
+
+	offers := make([]string, len(export.Formats))
+	for i, f := range export.Formats {
+		offers[i] = f.ContentType()
+	}
+	accepted := c.Accepts(offers...)
+	for _, f := range export.Formats {
+		if f.ContentType() == accepted {
+			return f, nil
+		}
+	}
+	return "", newError(fiber.StatusNotAcceptable, codeNotAcceptable, "none of the accepted types can be exported")
+}
+
+// redaction returns the policy to apply when the request asks for
+// ?redact=true, or nil. Asking for redaction without a configured policy is
+// an error rather than silently returning personal data.
+func redaction(c fiber.Ctx, policy *dag.RedactionPolicy) (*dag.RedactionPolicy, error) {
+	switch c.Query("redact") {
+	case "", "false":
+		return nil, nil
+	case "true":
+		if policy == nil {
+			return nil, validationFailed([]fieldError{{Field: "redact", Message: "no redaction policy is configured"}})
+		}
+		return policy, nil
+	}
+	return nil, validationFailed([]fieldError{{Field: "redact", Message: "must be true or false"}})
+}
+
+// sendExport renders d in format f as a downloadable attachment, redacted
+// by p if it is non-nil.
+func sendExport(c fiber.Ctx, d *dag.DAG, f export.Format, p *dag.RedactionPolicy) error {
+	var buf bytes.Buffer
+	if err := export.WriteRedacted(&buf, d, f, p); err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, f.ContentType())
+	c.Set(fiber.HeaderVary, fiber.HeaderAccept)
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{
+		"filename": d.ID + "." + f.Extension(),
+	}))
+	return c.Send(buf.Bytes())
+}
+
+// sendCanonical sends d as canonical JSON (see dag.MarshalCanonical) in a
+// downloadable attachment.
+func sendCanonical(c fiber.Ctx, d *dag.DAG) error {
+	body, err := dag.MarshalCanonical(d)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, export.JSON.ContentType())
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{
+		"filename": d.ID + ".json",
+	}))
+	return c.Send(body)
+}
+
+// sendDecisionTable sends the decision table of DAG dagID as JSON, or as a
+// CSV attachment if format is "csv". X-Truncated is set when the table was
+// cut off at ?limit=.
+func sendDecisionTable(c fiber.Ctx, dagID string, rows []formflow.Decision, truncated bool, format string) error {
+	if truncated {
+		c.Set("X-Truncated", "true")
+	}
+	if format != "csv" {
+		return c.JSON(fiber.Map{"rows": rows, "truncated": truncated})
+	}
+	var buf bytes.Buffer
+	if err := formflow.WriteDecisionTable(&buf, rows); err != nil {
+		return err
+	}
+	setCSVAttachment(c, dagID+"-decisions.csv")
+	return c.Send(buf.Bytes())
+}
+
+// sendAnalytics sends table ("nodes" or "edges") of the heatmap h of DAG
+// dagID as a CSV attachment.
+func sendAnalytics(c fiber.Ctx, dagID, table string, h *dag.Heatmap) error {
+	var buf bytes.Buffer
+	write := export.WriteNodeHeatCSV
+	if table == "edges" {
+		write = export.WriteEdgeHeatCSV
+	}
+	if err := write(&buf, dagID, h); err != nil {
+		return err
+	}
+	setCSVAttachment(c, dagID+"-"+table+".csv")
+	return c.Send(buf.Bytes())
+}
+
+// streamVisits streams the visits of DAG dagID after seq as a CSV
+// attachment. Errors after the first row can only be logged.
+func streamVisits(c fiber.Ctx, pg *postgres.PGStore, dagID string, after int64) error {
+	ctx := c.Context()
+	setCSVAttachment(c, dagID+"-visits.csv")
+	return c.SendStreamWriter(func(bw *bufio.Writer) {
+		vw, err := export.NewVisitCSV(bw)
+		if err == nil {
+			err = pg.StreamVisits(ctx, dagID, after, vw.Write)
+		}
+		if err == nil {
+			err = vw.Flush()
+		}
+		if err != nil {
+			log.Printf("stream visits %s: %v", dagID, err)
+		}
+	})
+}
+
+// auditExportPage is how many events streamAudit reads per query.
+const auditExportPage = 500
+
+// streamAudit streams every event f selects, ignoring f.Limit, as a CSV
+// attachment, reading them a page at a time. Errors after the first row
+// can only be logged.
+func streamAudit(c fiber.Ctx, pg *postgres.PGStore, f dag.AuditFilter) error {
+	ctx := c.Context()
+	setCSVAttachment(c, "audit.csv")
+	return c.SendStreamWriter(func(bw *bufio.Writer) {
+		if err := writeAudit(ctx, pg, f, bw); err != nil {
+			log.Printf("stream audit: %v", err)
+		}
+	})
+}
+
+func writeAudit(ctx context.Context, pg *postgres.PGStore, f dag.AuditFilter, w io.Writer) error {
+	aw, err := export.NewAuditCSV(w)
+	if err != nil {
+		return err
+	}
+	f.Limit = auditExportPage
+	for {
+		events, err := pg.QueryAudit(ctx, f)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if err := aw.Write(e); err != nil {
+				return err
+			}
+		}
+		if len(events) < f.Limit {
+			return aw.Flush()
+		}
+		f.AfterSeq = events[len(events)-1].Seq
+	}
+}
+
+func setCSVAttachment(c fiber.Ctx, filename string) {
+	c.Set(fiber.HeaderContentType, export.CSV.ContentType())
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+}
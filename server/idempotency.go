@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/meikuraledutech/dag/postgres"
+)
+
+const (
+	idempotencyHeader   = "Idempotency-Key"
+	idempotencyReplayed = "Idempotent-Replayed"
+	idempotencyKeyMax   = 255
+	idempotencyTTL      = 24 * time.Hour
+)
+
+// idempotencyStore persists request fingerprints and their responses.
+// *postgres.PGStore implements it.
+type idempotencyStore interface {
+	ClaimIdempotencyKey(ctx context.Context, key, fingerprint string, ttl time.Duration) (*postgres.IdempotencyRecord, bool, error)
+	RenewIdempotencyKey(ctx context.Context, key string) error
+	CompleteIdempotencyKey(ctx context.Context, key string, status int, body []byte) error
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+}
+
+// idempotency returns a route middleware that honours the Idempotency-Key
+// header. The first request with a key runs normally and its response is
+// stored; retries with the same key and body replay that response instead of
+// creating duplicates. Requests without the header pass straight through.
+func idempotency(store idempotencyStore) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		key := c.Get(idempotencyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > idempotencyKeyMax {
+			return validationFailed([]fieldError{{Field: idempotencyHeader, Message: "must be at most 255 characters"}})
+		}
+
+		fp := fingerprint(c.Method(), c.Path(), string(c.Request().URI().QueryString()), c.Body())
+		rec, claimed, err := store.ClaimIdempotencyKey(c.Context(), key, fp, idempotencyTTL)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			if rec.Fingerprint != fp {
+				return newError(fiber.StatusUnprocessableEntity, codeIdempotencyKeyReused,
+					"idempotency key was already used for a different request")
+			}
+			if rec.Status == 0 {
+				return newError(fiber.StatusConflict, codeIdempotencyInProgress,
+					"a request with this idempotency key is still in progress")
+			}
+			c.Set(idempotencyReplayed, "true")
+			c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			return c.Status(rec.Status).Send(rec.Body)
+		}
+
+		stop := keepClaimed(store, key)
+		defer stop()
+
+		// Render errors here rather than in the app ErrorHandler so the
+		// final status and body can be recorded.
+		if err := c.Next(); err != nil {
+			if herr := errorHandler(c, err); herr != nil {
+				_ = store.ReleaseIdempotencyKey(c.Context(), key)
+				return herr
+			}
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			// Server errors are not final — let the client retry with the same key.
+			return store.ReleaseIdempotencyKey(c.Context(), key)
+		}
+		body := append([]byte(nil), c.Response().Body()...)
+		return store.CompleteIdempotencyKey(c.Context(), key, status, body)
+	}
+}
+
+// keepClaimed renews the claim on key every third of
+// postgres.IdempotencyLease until stop is called, so a retry doesn't take
+// over a request that is slow but still running. stop waits for the last
+// renewal to finish.
+func keepClaimed(store idempotencyStore, key string) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t := time.NewTicker(postgres.IdempotencyLease / 3)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				_ = store.RenewIdempotencyKey(ctx, key)
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// fingerprint identifies a request by method, path, query and raw body.
+// The query is sorted by parameter, so the order they are given in does not
+// matter, but flags such as ?dry_run= and ?replace= do.
+func fingerprint(method, path, query string, body []byte) string {
+	if q, err := url.ParseQuery(query); err == nil {
+		query = q.Encode()
+	}
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write([]byte(query))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/meikuraledutech/dag"
+	"github.com/meikuraledutech/dag/export"
+)
+
+// importResult is the body of POST /dag/:id/import.
+type importResult struct {
+	DryRun bool         `json:"dry_run"`
+	Valid  bool         `json:"valid"`
+	Nodes  int          `json:"nodes"`
+	Edges  int          `json:"edges"`
+	Issues []fieldError `json:"issues"`
+}
+
+// importFormat picks the import format from ?format= if present, otherwise
+// from the Content-Type header. JSON is the default.
+func importFormat(c fiber.Ctx) (export.Format, error) {
+	if q := c.Query("format"); q != "" {
+		f, err := export.ParseFormat(q)
+		if err != nil {
+			return "", validationFailed([]fieldError{{Field: "format", Message: fmt.Sprintf("unsupported format %q", q)}})
+		}
+		return f, nil
+	}
+	ct := strings.ToLower(c.Get(fiber.HeaderContentType))
+	for _, f := range export.Formats {
+		if strings.HasPrefix(ct, f.ContentType()) {
+			return f, nil
+		}
+	}
+	return export.JSON, nil
+}
+
+// storeIssues turns the error of a dry-run CreateDAG into import issues.
+// A validation failure gives its fields; any other client error, such as
+// dag_already_exists or quota_exceeded, is one issue whose field is its
+// code. Server errors are returned as they are.
+func storeIssues(err error) ([]fieldError, error) {
+	if err == nil {
+		return nil, nil
+	}
+	ae := toAPIError(err)
+	if ae.Status >= fiber.StatusInternalServerError {
+		return nil, err
+	}
+	if fields, ok := ae.Details.([]fieldError); ok && ae.Code == codeValidationFailed {
+		return fields, nil
+	}
+	return []fieldError{{Field: ae.Code, Message: ae.Message}}, nil
+}
+
+// importIssues checks an imported DAG the same way POST /dag does, and also
+// that every edge points at a node in the import and that there is no cycle.
+func importIssues(d *dag.DAG) []fieldError {
+	errs := validateDAG(d)
+
+	// Key nodes by ID, or by ref for JSON input that uses refs, so the
+	// cycle check can run before any IDs are generated.
+	ids := make(map[string]bool, len(d.Nodes))
+	nodes := make([]dag.Node, len(d.Nodes))
+	for i, n := range d.Nodes {
+		switch {
+		case n.ID != "":
+			ids[n.ID] = true
+			nodes[i].ID = n.ID
+		case n.Ref != "":
+			nodes[i].ID = "ref:" + n.Ref
+		default:
+			nodes[i].ID = fmt.Sprintf("#%d", i)
+		}
+	}
+
+	edges := make([]dag.Edge, len(d.Edges))
+	for i, e := range d.Edges {
+		prefix := fmt.Sprintf("edges[%d]", i)
+		edges[i].FromNodeID, edges[i].ToNodeID = e.FromNodeID, e.ToNodeID
+		if e.FromNodeRef != "" {
+			edges[i].FromNodeID = "ref:" + e.FromNodeRef
+		} else if e.FromNodeID != "" && !ids[e.FromNodeID] {
+			errs = append(errs, fieldError{Field: prefix + ".from_node_id", Message: fmt.Sprintf("unknown node %q", e.FromNodeID)})
+		}
+		if e.ToNodeRef != "" {
+			edges[i].ToNodeID = "ref:" + e.ToNodeRef
+		} else if e.ToNodeID != "" && !ids[e.ToNodeID] {
+			errs = append(errs, fieldError{Field: prefix + ".to_node_id", Message: fmt.Sprintf("unknown node %q", e.ToNodeID)})
+		}
+	}
+
+	if len(errs) == 0 {
+		if err := dag.ValidateAcyclic(nodes, edges); err != nil {
+			errs = append(errs, fieldError{Field: "edges", Message: "graph contains a cycle"})
+		}
+	}
+	return errs
+}
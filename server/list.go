@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/meikuraledutech/dag"
+)
+
+// maxListLimit caps ?limit= on list endpoints.
+const maxListLimit = 1000
+
+// listOptions reads limit, cursor, sort, filter and fields from the query
+// string.
+// paged is false when none are present, so callers can keep the plain
+// array response for existing clients.
+//
+// filter is a comma-separated list of key:value pairs matched against
+// top-level keys of data, e.g. ?filter=type:select,required:true.
+// fields lists the data paths to return, e.g. ?fields=question,meta.label.
+func listOptions(c fiber.Ctx) (opts dag.ListOptions, paged bool, err error) {
+	q := func(k string) string {
+		v := c.Query(k)
+		if v != "" {
+			paged = true
+		}
+		return v
+	}
+
+	var errs []fieldError
+	if v := q("limit"); v != "" {
+		n, convErr := strconv.Atoi(v)
+		if convErr != nil || n < 1 || n > maxListLimit {
+			errs = append(errs, fieldError{Field: "limit", Message: "must be an integer between 1 and 1000"})
+		}
+		opts.Limit = n
+	}
+	opts.Cursor = q("cursor")
+	opts.Sort = q("sort")
+	if v := q("filter"); v != "" {
+		opts.Filter = make(map[string]string)
+		for _, pair := range strings.Split(v, ",") {
+			key, val, ok := strings.Cut(pair, ":")
+			if !ok || key == "" {
+				errs = append(errs, fieldError{Field: "filter", Message: "must be a comma-separated list of key:value pairs"})
+				break
+			}
+			opts.Filter[key] = val
+		}
+	}
+	if opts.Fields = queryFields(c); opts.Fields != nil {
+		paged = true
+	}
+
+	if len(errs) > 0 {
+		return opts, paged, validationFailed(errs)
+	}
+	return opts, paged, nil
+}
+
+// edgeFilter reads contains, match, from_node_id and to_node_id from the
+// query string. ok is false when none are present.
+//
+// contains is a JSON document the edge data must contain, e.g.
+// ?contains={"answer":"yes"}; match is a JSON path predicate, e.g.
+// ?match=$.weight > 2.
+func edgeFilter(c fiber.Ctx) (f dag.EdgeFilter, ok bool, err error) {
+	if v := c.Query("contains"); v != "" {
+		if !json.Valid([]byte(v)) {
+			return f, false, validationFailed([]fieldError{{Field: "contains", Message: "must be a JSON document"}})
+		}
+		f.Contains = json.RawMessage(v)
+	}
+	f.Match = c.Query("match")
+	f.FromNodeID = c.Query("from_node_id")
+	f.ToNodeID = c.Query("to_node_id")
+	ok = f.Contains != nil || f.Match != "" || f.FromNodeID != "" || f.ToNodeID != ""
+	return f, ok, nil
+}
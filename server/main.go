@@ -2,14 +2,23 @@ package main
 
 import (
 	"context"
-	"errors"
+	"encoding/json"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/meikuraledutech/dag"
-	"github.com/meikuraledutech/dag/postgres"
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/meikuraledutech/dag"
+	"github.com/meikuraledutech/dag/archive"
+	"github.com/meikuraledutech/dag/export"
+	"github.com/meikuraledutech/dag/postgres"
 )
 
 func main() {
@@ -24,174 +33,317 @@ func main() {
 	}
 	defer pool.Close()
 
-	var store dag.Store = postgres.New(pool)
-
-	app := fiber.New()
+	// DAG_STRICT_ETAG=true makes If-Match mandatory on PUT/DELETE.
+	strict := os.Getenv("DAG_STRICT_ETAG") == "true"
 
-	// ── Schema ────────────────────────────────────────────────────────
-	app.Post("/schema", func(c fiber.Ctx) error {
-		if err := store.CreateSchema(c.Context()); err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
-		}
-		return c.JSON(fiber.Map{"message": "schema created"})
-	})
+	quota, err := quotaFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	app.Delete("/schema", func(c fiber.Ctx) error {
-		if err := store.DropSchema(c.Context()); err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	opts := []postgres.Option{postgres.WithQuota(quota)}
+	// DAG_VERSIONING=true keeps a snapshot per write for point-in-time restore.
+	if os.Getenv("DAG_VERSIONING") == "true" {
+		opts = append(opts, postgres.WithVersioning())
+	}
+	// DAG_EVENT_LOG=true appends every write to dag_events for replay.
+	if os.Getenv("DAG_EVENT_LOG") == "true" {
+		opts = append(opts, postgres.WithEventLog())
+	}
+	// DAG_KAFKA_BROKERS and DAG_NATS_URL publish every write through the
+	// outbox; see eventSinkFromEnv.
+	sink, closeSink, err := eventSinkFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeSink()
+	if sink != nil {
+		opts = append(opts, postgres.WithOutbox())
+	}
+	// DAG_COMPRESS_ABOVE zstd-compresses node and edge data over this many bytes.
+	if v := os.Getenv("DAG_COMPRESS_ABOVE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatal("DAG_COMPRESS_ABOVE must be a non-negative integer")
 		}
-		return c.JSON(fiber.Map{"message": "schema dropped"})
-	})
-
-	// ── DAG (bulk) ────────────────────────────────────────────────────
-	app.Post("/dag", func(c fiber.Ctx) error {
-		var d dag.DAG
-		if err := c.Bind().JSON(&d); err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "invalid body"})
+		opts = append(opts, postgres.WithCompression(n))
+	}
+	// DATABASE_REPLICA_URL sends reads to a streaming replica. Reads of a
+	// DAG stay on the primary for DAG_READ_YOUR_WRITES (default 5s) after
+	// this server writes to it.
+	if replicaURL := os.Getenv("DATABASE_REPLICA_URL"); replicaURL != "" {
+		replica, err := pgxpool.New(context.Background(), replicaURL)
+		if err != nil {
+			log.Fatalf("connect replica: %v", err)
 		}
-		result, err := store.CreateDAG(c.Context(), &d)
-		if errors.Is(err, dag.ErrCycleDetected) {
-			return c.Status(422).JSON(fiber.Map{"error": "cycle detected"})
+		defer replica.Close()
+		window := 5 * time.Second
+		if v := os.Getenv("DAG_READ_YOUR_WRITES"); v != "" {
+			if window, err = time.ParseDuration(v); err != nil {
+				log.Fatal("DAG_READ_YOUR_WRITES must be a duration, e.g. 5s")
+			}
 		}
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		opts = append(opts, postgres.WithReplica(replica), postgres.WithReadYourWrites(window))
+	}
+	// DAG_QUERY_EXEC_MODE sets how statements are sent; use exec or
+	// simple_protocol behind pgbouncer in transaction pooling mode.
+	if v := os.Getenv("DAG_QUERY_EXEC_MODE"); v != "" {
+		mode, ok := map[string]pgx.QueryExecMode{
+			"cache_statement": pgx.QueryExecModeCacheStatement,
+			"cache_describe":  pgx.QueryExecModeCacheDescribe,
+			"describe_exec":   pgx.QueryExecModeDescribeExec,
+			"exec":            pgx.QueryExecModeExec,
+			"simple_protocol": pgx.QueryExecModeSimpleProtocol,
+		}[v]
+		if !ok {
+			log.Fatal("DAG_QUERY_EXEC_MODE must be cache_statement, cache_describe, describe_exec, exec or simple_protocol")
 		}
-		return c.Status(201).JSON(result)
-	})
-
-	app.Get("/dag/:id", func(c fiber.Ctx) error {
-		d, err := store.GetDAG(c.Context(), c.Params("id"))
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		opts = append(opts, postgres.WithQueryExecMode(mode))
+	}
+	// DAG_STATEMENT_TIMEOUT limits each SQL statement, and DAG_CALL_TIMEOUT
+	// each store call, e.g. 5s.
+	for name, opt := range map[string]func(time.Duration) postgres.Option{
+		"DAG_STATEMENT_TIMEOUT": postgres.WithStatementTimeout,
+		"DAG_CALL_TIMEOUT":      postgres.WithCallTimeout,
+	} {
+		if v := os.Getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				log.Fatalf("%s must be a positive duration, e.g. 5s", name)
+			}
+			opts = append(opts, opt(d))
 		}
-		if d == nil {
-			return c.Status(404).JSON(fiber.Map{"error": "dag not found"})
+	}
+	// DAG_PARTITIONS hash-partitions the node and edge tables by DAG.
+	if v := os.Getenv("DAG_PARTITIONS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			log.Fatal("DAG_PARTITIONS must be a positive integer")
 		}
-		return c.JSON(d)
-	})
-
-	app.Delete("/dag/:id", func(c fiber.Ctx) error {
-		if err := store.DeleteDAG(c.Context(), c.Params("id")); err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		opts = append(opts, postgres.WithPartitions(n))
+	}
+	// DAG_DEDUP_ABOVE stores node data over this many bytes once per
+	// distinct content.
+	if v := os.Getenv("DAG_DEDUP_ABOVE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatal("DAG_DEDUP_ABOVE must be a non-negative integer")
 		}
-		return c.SendStatus(204)
-	})
-
-	// ── Nodes ─────────────────────────────────────────────────────────
-	app.Post("/dag/:id/nodes", func(c fiber.Ctx) error {
-		var node dag.Node
-		if err := c.Bind().JSON(&node); err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "invalid body"})
+		opts = append(opts, postgres.WithDedup(n))
+	}
+	// DAG_BLOB_DIR moves node data over DAG_BLOB_ABOVE bytes (default 1 MiB)
+	// out of the database into that directory.
+	if dir := os.Getenv("DAG_BLOB_DIR"); dir != "" {
+		above := 1 << 20
+		if v := os.Getenv("DAG_BLOB_ABOVE"); v != "" {
+			if above, err = strconv.Atoi(v); err != nil || above < 1 {
+				log.Fatal("DAG_BLOB_ABOVE must be a positive integer")
+			}
 		}
-		id, err := store.AddNode(c.Context(), c.Params("id"), &node)
+		opts = append(opts, postgres.WithBlobStore(archive.Dir(dir), above))
+	}
+	// DAG_SCHEMA_DIR holds JSON Schemas for node and edge data.
+	if dir := os.Getenv("DAG_SCHEMA_DIR"); dir != "" {
+		schemas, err := schemasFromDir(dir)
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			log.Fatal(err)
 		}
-		return c.Status(201).JSON(fiber.Map{"id": id})
-	})
-
-	app.Get("/dag/:id/nodes", func(c fiber.Ctx) error {
-		nodes, err := store.ListNodes(c.Context(), c.Params("id"))
+		opts = append(opts, postgres.WithDataSchemas(schemas))
+	}
+	// DAG_NODE_TYPES is a JSON file listing node types; with
+	// DAG_NODE_TYPES_STRICT=true, nodes of other types are rejected.
+	if file := os.Getenv("DAG_NODE_TYPES"); file != "" {
+		types, err := nodeTypesFromFile(file)
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			log.Fatal(err)
 		}
-		return c.JSON(nodes)
-	})
+		types.Strict = os.Getenv("DAG_NODE_TYPES_STRICT") == "true"
+		opts = append(opts, postgres.WithNodeTypes(types))
+	}
 
-	app.Get("/nodes/:id", func(c fiber.Ctx) error {
-		n, err := store.GetNode(c.Context(), c.Params("id"))
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
-		}
-		if n == nil {
-			return c.Status(404).JSON(fiber.Map{"error": "node not found"})
+	// DAG_REDACTION_POLICY is a JSON file with a dag.RedactionPolicy, applied
+	// to GET /dag/:id and exports requested with ?redact=true.
+	var redact *dag.RedactionPolicy
+	if file := os.Getenv("DAG_REDACTION_POLICY"); file != "" {
+		if redact, err = redactionFromFile(file); err != nil {
+			log.Fatal(err)
 		}
-		return c.JSON(n)
-	})
+	}
 
-	app.Put("/nodes/:id", func(c fiber.Ctx) error {
-		var node dag.Node
-		if err := c.Bind().JSON(&node); err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "invalid body"})
-		}
-		node.ID = c.Params("id")
-		err := store.UpdateNode(c.Context(), &node)
-		if errors.Is(err, dag.ErrNodeNotFound) {
-			return c.Status(404).JSON(fiber.Map{"error": "node not found"})
-		}
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	// DAG_BUNDLE_KEYS is a JSON file of keys for signed export bundles.
+	// Exports with ?sign=true are signed with the first key that can sign,
+	// and once it is set every import must be a bundle one of the keys
+	// verifies.
+	var keys []*export.Key
+	if file := os.Getenv("DAG_BUNDLE_KEYS"); file != "" {
+		if keys, err = bundleKeysFromFile(file); err != nil {
+			log.Fatal(err)
 		}
-		return c.SendStatus(204)
-	})
+	}
 
-	app.Delete("/nodes/:id", func(c fiber.Ctx) error {
-		if err := store.DeleteNode(c.Context(), c.Params("id")); err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
-		}
-		return c.SendStatus(204)
-	})
+	pg := postgres.New(pool, opts...)
+	var store dag.Store = pg
 
-	// ── Edges ─────────────────────────────────────────────────────────
-	app.Post("/dag/:id/edges", func(c fiber.Ctx) error {
-		var edge dag.Edge
-		if err := c.Bind().JSON(&edge); err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "invalid body"})
-		}
-		id, err := store.AddEdge(c.Context(), c.Params("id"), &edge)
-		if errors.Is(err, dag.ErrCycleDetected) {
-			return c.Status(422).JSON(fiber.Map{"error": "cycle detected"})
+	// DAG_ARCHIVE_DIR enables archiving: archived DAGs are restored on
+	// read, and expired DAGs are archived before the reaper deletes them.
+	var arch *archive.Store
+	if dir := os.Getenv("DAG_ARCHIVE_DIR"); dir != "" {
+		arch = archive.New(pg, archive.Dir(dir))
+		// DAG_ARCHIVE_BINARY=true writes archives in the binary encoding.
+		arch.Binary = os.Getenv("DAG_ARCHIVE_BINARY") == "true"
+		store = arch
+	}
+
+	// Delete DAGs past their expires_at. DAG_REAP_INTERVAL=0 disables.
+	if interval, err := reapIntervalFromEnv(); err != nil {
+		log.Fatal(err)
+	} else if interval > 0 {
+		reaper := &dag.Reaper{
+			Store:    pg,
+			Interval: interval,
+			OnError: func(dagID string, err error) {
+				log.Printf("reaper: %s: %v", dagID, err)
+			},
 		}
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		if arch != nil {
+			reaper.BeforeDelete = arch.Snapshot
 		}
-		return c.Status(201).JSON(fiber.Map{"id": id})
-	})
+		go reaper.Run(context.Background())
+	}
 
-	app.Get("/dag/:id/edges", func(c fiber.Ctx) error {
-		edges, err := store.ListEdges(c.Context(), c.Params("id"))
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	if sink != nil {
+		relay := &postgres.Relay{
+			Store: pg,
+			Sink:  sink,
+			OnError: func(err error) {
+				log.Printf("relay: %v", err)
+			},
 		}
-		return c.JSON(edges)
-	})
+		go relay.Run(context.Background())
+	}
 
-	app.Get("/edges/:id", func(c fiber.Ctx) error {
-		e, err := store.GetEdge(c.Context(), c.Params("id"))
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
-		}
-		if e == nil {
-			return c.Status(404).JSON(fiber.Map{"error": "edge not found"})
-		}
-		return c.JSON(e)
-	})
+	app := fiber.New(fiber.Config{ErrorHandler: errorHandler})
+	app.Use(requestid.New())
+	// DAG_ACTOR_HEADER names a header, set by an authenticating proxy in
+	// front of this server, whose value is recorded as the actor of each
+	// write in the event log.
+	if h := os.Getenv("DAG_ACTOR_HEADER"); h != "" {
+		app.Use(actorFromHeader(h))
+	}
+
+	registerUI(app)
 
-	app.Put("/edges/:id", func(c fiber.Ctx) error {
-		var edge dag.Edge
-		if err := c.Bind().JSON(&edge); err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "invalid body"})
+	// Routes live under /v1. Unversioned paths are rewritten to /v1 and
+	// answered with deprecation headers until clients migrate.
+	app.Use(legacyRewrite(apiV1))
+	registerV1(app.Group(apiV1, apiVersion("1")), store, pg, arch, redact, keys, strict)
+
+	log.Fatal(app.Listen(":3000"))
+}
+
+// actorFromHeader puts the value of header in each request's context with
+// dag.WithActor.
+func actorFromHeader(header string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if v := c.Get(header); v != "" {
+			c.SetContext(dag.WithActor(c.Context(), v))
 		}
-		edge.ID = c.Params("id")
-		err := store.UpdateEdge(c.Context(), &edge)
-		if errors.Is(err, dag.ErrEdgeNotFound) {
-			return c.Status(404).JSON(fiber.Map{"error": "edge not found"})
+		return c.Next()
+	}
+}
+
+// reapIntervalFromEnv reads DAG_REAP_INTERVAL (a Go duration, default 1m).
+func reapIntervalFromEnv() (time.Duration, error) {
+	v := os.Getenv("DAG_REAP_INTERVAL")
+	if v == "" {
+		return time.Minute, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("DAG_REAP_INTERVAL must be a non-negative duration")
+	}
+	return d, nil
+}
+
+// schemasFromDir loads node.<type>.json and edge.<type>.json files from dir;
+// node.json and edge.json apply to data without a type.
+func schemasFromDir(dir string) (*dag.DataSchemas, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	schemas := dag.NewDataSchemas()
+	for _, f := range files {
+		kind, typ, _ := strings.Cut(strings.TrimSuffix(filepath.Base(f), ".json"), ".")
+		body, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
 		}
-		if errors.Is(err, dag.ErrCycleDetected) {
-			return c.Status(422).JSON(fiber.Map{"error": "cycle detected"})
+		switch kind {
+		case "node":
+			err = schemas.RegisterNode(typ, body)
+		case "edge":
+			err = schemas.RegisterEdge(typ, body)
+		default:
+			return nil, fmt.Errorf("%s: schema files must be named node.<type>.json or edge.<type>.json", f)
 		}
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			return nil, fmt.Errorf("%s: %w", f, err)
 		}
-		return c.SendStatus(204)
-	})
+	}
+	return schemas, nil
+}
 
-	app.Delete("/edges/:id", func(c fiber.Ctx) error {
-		if err := store.DeleteEdge(c.Context(), c.Params("id")); err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+// nodeTypesFromFile loads a JSON array of dag.NodeType.
+func nodeTypesFromFile(file string) (*dag.NodeTypes, error) {
+	body, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var list []dag.NodeType
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	types := dag.NewNodeTypes()
+	for _, t := range list {
+		if err := types.Register(t); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
 		}
-		return c.SendStatus(204)
-	})
+	}
+	return types, nil
+}
 
-	log.Fatal(app.Listen(":3000"))
+// redactionFromFile reads a dag.RedactionPolicy from a JSON file.
+func redactionFromFile(file string) (*dag.RedactionPolicy, error) {
+	body, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var p dag.RedactionPolicy
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return &p, nil
+}
+
+// quotaFromEnv reads DAG_MAX_NODES, DAG_MAX_EDGES and DAG_MAX_DATA_BYTES.
+// Unset variables mean unlimited.
+func quotaFromEnv() (dag.Quota, error) {
+	var q dag.Quota
+	for name, dst := range map[string]*int{
+		"DAG_MAX_NODES":      &q.MaxNodes,
+		"DAG_MAX_EDGES":      &q.MaxEdges,
+		"DAG_MAX_DATA_BYTES": &q.MaxDataBytes,
+	} {
+		v := os.Getenv(name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return q, fmt.Errorf("%s must be a non-negative integer", name)
+		}
+		*dst = n
+	}
+	return q, nil
 }
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/meikuraledutech/dag"
+)
+
+// jsonPatchType is the media type of an RFC 6902 JSON Patch.
+const jsonPatchType = "application/json-patch+json"
+
+// patchOp is one operation of a JSON Patch.
+type patchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// patchDAG applies a JSON Patch to d as GET /dag/:id renders it and
+// returns the patched DAG. Only nodes and edges may change.
+func patchDAG(d *dag.DAG, body []byte) (*dag.DAG, error) {
+	var ops []patchOp
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return nil, invalidBody(err)
+	}
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	for i, op := range ops {
+		if doc, err = applyPatchOp(doc, op); err != nil {
+			e := newError(fiber.StatusUnprocessableEntity, codePatchFailed, fmt.Sprintf("operation %d (%s %s): %v", i, op.Op, op.Path, err))
+			e.Details = fiber.Map{"index": i}
+			return nil, e
+		}
+	}
+
+	raw, err = json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var out dag.DAG
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, invalidBody(err)
+	}
+	if !sameDAGFields(d, &out) {
+		return nil, newError(fiber.StatusUnprocessableEntity, codePatchFailed, "only /nodes and /edges can be patched")
+	}
+	return &out, nil
+}
+
+// sameDAGFields reports whether a and b render the same apart from their
+// nodes and edges.
+func sameDAGFields(a, b *dag.DAG) bool {
+	ca, cb := *a, *b
+	ca.Nodes, ca.Edges, cb.Nodes, cb.Edges = nil, nil, nil, nil
+	ja, err1 := json.Marshal(ca)
+	jb, err2 := json.Marshal(cb)
+	return err1 == nil && err2 == nil && string(ja) == string(jb)
+}
+
+// applyPatchOp applies one RFC 6902 operation to doc.
+func applyPatchOp(doc any, op patchOp) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("value is required")
+		}
+		var v any
+		if err := json.Unmarshal(op.Value, &v); err != nil {
+			return nil, fmt.Errorf("invalid value: %w", err)
+		}
+		switch op.Op {
+		case "add":
+			return pointerAdd(doc, path, v)
+		case "replace":
+			if doc, _, err = pointerRemove(doc, path); err != nil {
+				return nil, err
+			}
+			return pointerAdd(doc, path, v)
+		}
+		cur, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(cur, v) {
+			return nil, fmt.Errorf("test failed")
+		}
+		return doc, nil
+	case "remove":
+		doc, _, err = pointerRemove(doc, path)
+		return doc, err
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		var v any
+		if op.Op == "move" {
+			if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+				return nil, fmt.Errorf("cannot move a value into itself")
+			}
+			doc, v, err = pointerRemove(doc, from)
+		} else {
+			v, err = pointerGet(doc, from)
+			v = deepCopy(v)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, v)
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("path %q must start with /", p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses an array index token; "-" means one past the end and
+// is only allowed if end is true.
+func arrayIndex(tok string, n int, end bool) (int, error) {
+	if tok == "-" && end {
+		return n, nil
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i < 0 || (tok != "0" && strings.HasPrefix(tok, "0")) {
+		return 0, fmt.Errorf("invalid array index %q", tok)
+	}
+	max := n - 1
+	if end {
+		max = n
+	}
+	if i > max {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func pointerGet(doc any, path []string) (any, error) {
+	for _, tok := range path {
+		switch v := doc.(type) {
+		case map[string]any:
+			next, ok := v[tok]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", tok)
+			}
+			doc = next
+		case []any:
+			i, err := arrayIndex(tok, len(v), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = v[i]
+		default:
+			return nil, fmt.Errorf("cannot index a scalar with %q", tok)
+		}
+	}
+	return doc, nil
+}
+
+// pointerAdd inserts v at path, returning the new document.
+func pointerAdd(doc any, path []string, v any) (any, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	parent, err := pointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	tok := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		p[tok] = v
+		return doc, nil
+	case []any:
+		i, err := arrayIndex(tok, len(p), true)
+		if err != nil {
+			return nil, err
+		}
+		grown := append(p[:i:i], append([]any{v}, p[i:]...)...)
+		return pointerSet(doc, path[:len(path)-1], grown)
+	}
+	return nil, fmt.Errorf("cannot add to a scalar")
+}
+
+// pointerRemove removes the value at path and returns the new document and
+// the removed value.
+func pointerRemove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+	parent, err := pointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	tok := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		v, ok := p[tok]
+		if !ok {
+			return nil, nil, fmt.Errorf("member %q not found", tok)
+		}
+		delete(p, tok)
+		return doc, v, nil
+	case []any:
+		i, err := arrayIndex(tok, len(p), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		v := p[i]
+		shrunk := append(p[:i:i], p[i+1:]...)
+		doc, err = pointerSet(doc, path[:len(path)-1], shrunk)
+		return doc, v, err
+	}
+	return nil, nil, fmt.Errorf("cannot remove from a scalar")
+}
+
+// pointerSet replaces the value at path, which must exist, with v.
+func pointerSet(doc any, path []string, v any) (any, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	parent, err := pointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	tok := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		p[tok] = v
+	case []any:
+		i, err := arrayIndex(tok, len(p), false)
+		if err != nil {
+			return nil, err
+		}
+		p[i] = v
+	}
+	return doc, nil
+}
+
+// deepCopy copies a decoded JSON value so a copied subtree is not shared.
+func deepCopy(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(t))
+		for k, x := range t {
+			m[k] = deepCopy(x)
+		}
+		return m
+	case []any:
+		s := make([]any, len(t))
+		for i, x := range t {
+			s[i] = deepCopy(x)
+		}
+		return s
+	}
+	return v
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/meikuraledutech/dag"
+	"github.com/meikuraledutech/dag/kafkasink"
+	"github.com/meikuraledutech/dag/natssink"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/segmentio/kafka-go"
+)
+
+// eventSinkFromEnv builds the sink the outbox relay publishes to, or nil
+// if none is configured:
+//
+//   - DAG_KAFKA_BROKERS (comma-separated) writes to the DAG_KAFKA_TOPIC
+//     topic (default dag-events).
+//   - DAG_NATS_URL publishes to JetStream on DAG_NATS_SUBJECT.<type>
+//     (default dag.events), or to core NATS with DAG_NATS_CORE=true.
+//
+// With both set, every batch goes to Kafka and then NATS. The returned
+// func closes the connections.
+func eventSinkFromEnv() (dag.EventSink, func(), error) {
+	var sinks []dag.EventSink
+	var closers []func()
+	closeAll := func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+
+	if brokers := os.Getenv("DAG_KAFKA_BROKERS"); brokers != "" {
+		topic := os.Getenv("DAG_KAFKA_TOPIC")
+		if topic == "" {
+			topic = "dag-events"
+		}
+		w := &kafka.Writer{
+			Addr:         kafka.TCP(strings.Split(brokers, ",")...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+		}
+		closers = append(closers, func() { w.Close() })
+		sinks = append(sinks, kafkasink.New(w))
+	}
+
+	if url := os.Getenv("DAG_NATS_URL"); url != "" {
+		subject := os.Getenv("DAG_NATS_SUBJECT")
+		if subject == "" {
+			subject = "dag.events"
+		}
+		nc, err := nats.Connect(url)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("connect nats: %w", err)
+		}
+		closers = append(closers, nc.Close)
+		if os.Getenv("DAG_NATS_CORE") == "true" {
+			sinks = append(sinks, natssink.NewCore(nc, subject))
+		} else {
+			js, err := jetstream.New(nc)
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("jetstream: %w", err)
+			}
+			sinks = append(sinks, natssink.NewJetStream(js, subject))
+		}
+	}
+
+	switch len(sinks) {
+	case 0:
+		return nil, closeAll, nil
+	case 1:
+		return sinks[0], closeAll, nil
+	}
+	return dag.EventSinkFunc(func(ctx context.Context, events []dag.Event) error {
+		for _, s := range sinks {
+			if err := s.Publish(ctx, events); err != nil {
+				return err
+			}
+		}
+		return nil
+	}), closeAll, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/meikuraledutech/dag"
+)
+
+// fingerprinter hashes a DAG in the database. *postgres.PGStore implements
+// it.
+type fingerprinter interface {
+	DAGFingerprint(ctx context.Context, dagID string) (string, error)
+}
+
+// dagETag turns a database fingerprint into a quoted ETag ("" stays "").
+func dagETag(fp string) string {
+	if fp == "" {
+		return ""
+	}
+	return `"` + fp + `"`
+}
+
+// streamDAG writes GET /dag/:id as chunked JSON, encoding each node and edge
+// as it is scanned so large DAGs are never held in memory. The body is
+// gzip-compressed when the client accepts it.
+//
+// The status line is sent before the first row is read, so existence and the
+// ETag are checked up front with a cheap fingerprint query. An error after
+// that point can only truncate the body; it is logged.
+//
+// If p is non-nil every node and edge is redacted by it, and the ETag is
+// marked so it never matches the unredacted body.
+func streamDAG(c fiber.Ctx, store dag.Store, fp fingerprinter, dagID string, p *dag.RedactionPolicy) error {
+	ctx := c.Context()
+
+	tag, err := fp.DAGFingerprint(ctx, dagID)
+	if err != nil {
+		return err
+	}
+	if tag != "" && p != nil {
+		tag += "-redacted"
+	}
+	tag = dagETag(tag)
+	if tag == "" {
+		return newError(fiber.StatusNotFound, codeDAGNotFound, "dag not found")
+	}
+	c.Set(fiber.HeaderETag, tag)
+	if etagMatches(c.Get(fiber.HeaderIfNoneMatch), tag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	gz := strings.Contains(c.Get(fiber.HeaderAcceptEncoding), "gzip")
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	c.Set(fiber.HeaderVary, fiber.HeaderAcceptEncoding)
+	if gz {
+		c.Set(fiber.HeaderContentEncoding, "gzip")
+	}
+
+	return c.SendStreamWriter(func(bw *bufio.Writer) {
+		var w io.Writer = bw
+		if gz {
+			zw := gzip.NewWriter(bw)
+			defer zw.Close()
+			w = zw
+		}
+		if err := writeDAGStream(ctx, w, store, dagID, p); err != nil {
+			log.Printf("stream dag %s: %v", dagID, err)
+		}
+	})
+}
+
+// writeDAGStream emits {"id":...,"name":...,"tags":...,"nodes":[...],"edges":[...]}
+// to w, matching the JSON encoding of a dag.DAG. Data is redacted by p if it
+// is non-nil.
+func writeDAGStream(ctx context.Context, w io.Writer, store dag.Store, dagID string, p *dag.RedactionPolicy) error {
+	head := dag.DAG{ID: dagID}
+	info, err := store.GetDAGInfo(ctx, dagID)
+	if err != nil {
+		return err
+	}
+	if info != nil {
+		head.Name, head.Tags, head.Status, head.ExpiresAt = info.Name, info.Tags, info.Status, info.ExpiresAt
+		head.Settings = info.Settings
+	}
+	b, err := json.Marshal(struct {
+		ID        string       `json:"id"`
+		Name      string       `json:"name,omitempty"`
+		Tags      []string     `json:"tags,omitempty"`
+		Status    dag.Status   `json:"status,omitempty"`
+		ExpiresAt *time.Time   `json:"expires_at,omitempty"`
+		Settings  dag.Settings `json:"settings,omitzero"`
+	}{head.ID, head.Name, head.Tags, head.Status, head.ExpiresAt, head.Settings})
+	if err != nil {
+		return err
+	}
+	// Reopen the object to append the streamed arrays.
+	if _, err := w.Write(b[:len(b)-1]); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, `,"nodes":[`); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	sep := ""
+	writeItem := func(v any) error {
+		if _, err := io.WriteString(w, sep); err != nil {
+			return err
+		}
+		sep = ","
+		return enc.Encode(v)
+	}
+
+	edgesStarted := false
+	startEdges := func() error {
+		if edgesStarted {
+			return nil
+		}
+		edgesStarted = true
+		sep = ""
+		_, err := io.WriteString(w, `],"edges":[`)
+		return err
+	}
+
+	err = store.StreamDAG(ctx, dagID,
+		func(n dag.Node) error { return writeItem(p.RedactNode(n)) },
+		func(e dag.Edge) error {
+			if err := startEdges(); err != nil {
+				return err
+			}
+			return writeItem(p.RedactEdge(e))
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if err := startEdges(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, `]}`)
+	return err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/static"
+)
+
+//go:embed ui
+var uiFiles embed.FS
+
+// uiPath is where the DAG viewer is served.
+const uiPath = "/ui"
+
+// registerUI serves the DAG viewer, a single page that draws a DAG with
+// pan, zoom and node inspection. The page reads DAGs through the v1 routes,
+// so it sees what any API client sees, redaction included. It must be
+// registered before legacyRewrite, which would send /ui to /v1/ui.
+func registerUI(app *fiber.App) {
+	files, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		panic(err)
+	}
+	// The page loads its assets relative to its own URL, so it needs the
+	// trailing slash. Routing ignores it, hence the check.
+	app.Get(uiPath, func(c fiber.Ctx) error {
+		if c.Path() != uiPath {
+			return c.Next()
+		}
+		to := uiPath + "/"
+		if q := c.Request().URI().QueryString(); len(q) > 0 {
+			to += "?" + string(q)
+		}
+		return c.Redirect().Status(fiber.StatusMovedPermanently).To(to)
+	})
+	app.Use(uiPath+"/", static.New("", static.Config{FS: files}))
+}
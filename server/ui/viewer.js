@@ -0,0 +1,369 @@
+// DAG viewer: loads a DAG from the v1 API, lays it out in layers from its
+// roots, and draws it as SVG with pan, zoom and node inspection.
+"use strict";
+
+const API = new URL("../v1/", location.href);
+const NODE_W = 168, NODE_H = 48, GAP_X = 40, GAP_Y = 80;
+const NODE_LABEL_KEYS = ["label", "name", "title", "question"];
+const EDGE_LABEL_KEYS = ["label", "answer", "condition"];
+const SVG_NS = "http://www.w3.org/2000/svg";
+
+const $ = (id) => document.getElementById(id);
+const canvas = $("canvas"), viewport = $("viewport");
+
+let graph = null;      // {dag, nodes: Map(id → node), pos: Map(id → {x, y}), out, in}
+let view = { x: 0, y: 0, k: 1 };
+let selected = null;   // {kind: "node"|"edge", id}
+
+// ── Loading ──────────────────────────────────────────────────────────
+
+async function api(path) {
+  const resp = await fetch(new URL(path, API), { headers: { Accept: "application/json" } });
+  const body = await resp.json().catch(() => null);
+  if (!resp.ok) {
+    throw new Error(body?.error?.message || `${resp.status} ${resp.statusText}`);
+  }
+  return body;
+}
+
+async function open(id) {
+  setStatus(`Loading ${id}…`);
+  try {
+    const dag = await api("dag/" + encodeURIComponent(id));
+    graph = layout(dag);
+    selected = null;
+    $("title").textContent = dag.name ? `${dag.name} (${dag.id})` : dag.id;
+    document.title = `${dag.id} – DAG viewer`;
+    render();
+    fit();
+    inspect(null);
+    setStatus(`${dag.nodes.length} nodes, ${dag.edges.length} edges`);
+  } catch (err) {
+    setStatus(`Could not load ${id}: ${err.message}`);
+  }
+}
+
+async function suggest(text) {
+  try {
+    const q = new URLSearchParams({ q: text, limit: "20" });
+    const { items } = await api("dags?" + q);
+    const list = $("dag-list");
+    list.replaceChildren(...items.map((d) => {
+      const o = document.createElement("option");
+      o.value = d.id;
+      o.label = d.name || d.id;
+      return o;
+    }));
+  } catch {
+    // Suggestions are a convenience; typing an ID still works.
+  }
+}
+
+// ── Layout ───────────────────────────────────────────────────────────
+
+// layout places each node in the layer of its longest path from a root,
+// then orders every layer by the mean position of each node's neighbours
+// in the layer before, sweeping down and up a few times to cut crossings.
+function layout(dag) {
+  const nodes = new Map(dag.nodes.map((n) => [n.id, n]));
+  const out = new Map(), inc = new Map();
+  for (const id of nodes.keys()) { out.set(id, []); inc.set(id, []); }
+  for (const e of dag.edges) {
+    if (!nodes.has(e.from_node_id) || !nodes.has(e.to_node_id)) continue;
+    out.get(e.from_node_id).push(e);
+    inc.get(e.to_node_id).push(e);
+  }
+  for (const list of out.values()) list.sort((a, b) => a.order_index - b.order_index);
+
+  // Longest-path layering in topological order.
+  const layer = new Map(), pending = new Map();
+  const queue = [];
+  for (const id of nodes.keys()) {
+    pending.set(id, inc.get(id).length);
+    if (pending.get(id) === 0) { queue.push(id); layer.set(id, 0); }
+  }
+  for (let i = 0; i < queue.length; i++) {
+    const id = queue[i];
+    for (const e of out.get(id)) {
+      const to = e.to_node_id;
+      layer.set(to, Math.max(layer.get(to) ?? 0, layer.get(id) + 1));
+      pending.set(to, pending.get(to) - 1);
+      if (pending.get(to) === 0) queue.push(to);
+    }
+  }
+  for (const id of nodes.keys()) if (!layer.has(id)) layer.set(id, 0); // on a cycle
+
+  const layers = [];
+  for (const id of nodes.keys()) (layers[layer.get(id)] ??= []).push(id);
+  const index = new Map();
+  const reindex = (ids) => ids.forEach((id, i) => index.set(id, i));
+  layers.forEach(reindex);
+  const mean = (ids) => ids.length ? ids.reduce((s, id) => s + index.get(id), 0) / ids.length : null;
+  const sweep = (l, neighbours) => {
+    const ids = layers[l];
+    if (!ids) return;
+    const key = new Map(ids.map((id) => [id, mean(neighbours(id)) ?? index.get(id)]));
+    ids.sort((a, b) => key.get(a) - key.get(b));
+    reindex(ids);
+  };
+  for (let pass = 0; pass < 4; pass++) {
+    for (let l = 1; l < layers.length; l++) {
+      sweep(l, (id) => inc.get(id).map((e) => e.from_node_id).filter((f) => layer.get(f) === l - 1));
+    }
+    for (let l = layers.length - 2; l >= 0; l--) {
+      sweep(l, (id) => out.get(id).map((e) => e.to_node_id).filter((t) => layer.get(t) === l + 1));
+    }
+  }
+
+  const widest = Math.max(1, ...layers.map((ids) => ids?.length ?? 0));
+  const pos = new Map();
+  layers.forEach((ids, l) => {
+    const offset = (widest - ids.length) * (NODE_W + GAP_X) / 2;
+    ids.forEach((id, i) => pos.set(id, { x: offset + i * (NODE_W + GAP_X), y: l * (NODE_H + GAP_Y) }));
+  });
+  return { dag, nodes, pos, out, in: inc };
+}
+
+// ── Rendering ────────────────────────────────────────────────────────
+
+function el(name, attrs = {}, text) {
+  const e = document.createElementNS(SVG_NS, name);
+  for (const [k, v] of Object.entries(attrs)) e.setAttribute(k, v);
+  if (text !== undefined) e.textContent = text;
+  return e;
+}
+
+function label(data, keys) {
+  if (data && typeof data === "object") {
+    for (const k of keys) if (typeof data[k] === "string" && data[k] !== "") return data[k];
+  }
+  return "";
+}
+
+function clip(s, n) {
+  return s.length > n ? s.slice(0, n - 1) + "…" : s;
+}
+
+function render() {
+  viewport.replaceChildren();
+  const edges = el("g"), nodes = el("g");
+  for (const e of graph.dag.edges) {
+    const a = graph.pos.get(e.from_node_id), b = graph.pos.get(e.to_node_id);
+    if (!a || !b) continue;
+    const x1 = a.x + NODE_W / 2, y1 = a.y + NODE_H, x2 = b.x + NODE_W / 2, y2 = b.y;
+    const dy = Math.max(40, (y2 - y1) / 2);
+    const d = `M ${x1} ${y1} C ${x1} ${y1 + dy}, ${x2} ${y2 - dy}, ${x2} ${y2}`;
+    const g = el("g", { class: "edge", "data-id": e.id });
+    g.append(el("path", { d, "marker-end": "url(#arrow)" }), el("path", { d, class: "hit" }));
+    const text = label(e.data, EDGE_LABEL_KEYS) || (e.data?.answer !== undefined ? JSON.stringify(e.data.answer) : "");
+    if (text) g.append(el("text", { x: (x1 + x2) / 2, y: (y1 + y2) / 2 }, clip(text, 24)));
+    g.addEventListener("click", (ev) => { ev.stopPropagation(); select({ kind: "edge", id: e.id }); });
+    edges.append(g);
+  }
+  for (const n of graph.dag.nodes) {
+    const p = graph.pos.get(n.id);
+    const g = el("g", { class: "node", "data-id": n.id, transform: `translate(${p.x} ${p.y})` });
+    const name = label(n.data, NODE_LABEL_KEYS);
+    g.append(el("rect", { width: NODE_W, height: NODE_H }));
+    g.append(el("text", { x: NODE_W / 2, y: name ? NODE_H / 2 - 7 : NODE_H / 2 }, clip(name || n.id, 24)));
+    if (name) g.append(el("text", { x: NODE_W / 2, y: NODE_H / 2 + 10, class: "id" }, clip(n.id, 28)));
+    g.append(el("title", {}, n.id));
+    g.addEventListener("click", (ev) => { ev.stopPropagation(); select({ kind: "node", id: n.id }); });
+    nodes.append(g);
+  }
+  viewport.append(edges, nodes);
+  applyView();
+  highlight();
+}
+
+function highlight() {
+  const linked = new Set(), near = new Set();
+  if (selected?.kind === "node") {
+    near.add(selected.id);
+    for (const e of [...graph.out.get(selected.id), ...graph.in.get(selected.id)]) {
+      linked.add(e.id);
+      near.add(e.from_node_id);
+      near.add(e.to_node_id);
+    }
+  } else if (selected?.kind === "edge") {
+    const e = graph.dag.edges.find((x) => x.id === selected.id);
+    if (e) { near.add(e.from_node_id); near.add(e.to_node_id); }
+  }
+  for (const g of viewport.querySelectorAll(".node, .edge")) {
+    const id = g.dataset.id, isNode = g.classList.contains("node");
+    const kind = isNode ? "node" : "edge";
+    g.classList.toggle("selected", selected?.kind === kind && selected.id === id);
+    g.classList.toggle("linked", !isNode && linked.has(id));
+    const inFocus = isNode ? near.has(id) : linked.has(id) || (selected?.kind === "edge" && selected.id === id);
+    g.classList.toggle("dimmed", selected !== null && !inFocus);
+  }
+}
+
+// ── Inspection ───────────────────────────────────────────────────────
+
+function select(s) {
+  selected = s;
+  highlight();
+  inspect(s);
+}
+
+function link(text, s) {
+  const a = document.createElement("a");
+  a.textContent = text;
+  a.addEventListener("click", () => { select(s); centre(s); });
+  return a;
+}
+
+function edgeList(title, edges, end) {
+  const frag = document.createDocumentFragment();
+  if (edges.length === 0) return frag;
+  const h = document.createElement("h3");
+  h.textContent = `${title} (${edges.length})`;
+  const ul = document.createElement("ul");
+  for (const e of edges) {
+    const li = document.createElement("li");
+    const other = e[end];
+    const name = label(graph.nodes.get(other)?.data, NODE_LABEL_KEYS);
+    li.append(link(e.id, { kind: "edge", id: e.id }), " → ", link(name ? `${name} (${other})` : other, { kind: "node", id: other }));
+    ul.append(li);
+  }
+  frag.append(h, ul);
+  return frag;
+}
+
+function inspect(s) {
+  const panel = $("inspector");
+  if (!s) { panel.hidden = true; return; }
+  const fields = $("inspect-fields"), links = $("inspect-links");
+  fields.replaceChildren();
+  links.replaceChildren();
+  const field = (k, v) => {
+    const dt = document.createElement("dt"), dd = document.createElement("dd");
+    dt.textContent = k;
+    dd.append(v);
+    fields.append(dt, dd);
+  };
+  if (s.kind === "node") {
+    const n = graph.nodes.get(s.id);
+    $("inspect-title").textContent = label(n.data, NODE_LABEL_KEYS) || n.id;
+    field("id", n.id);
+    if (n.tags?.length) field("tags", n.tags.join(", "));
+    field("depth", String(graph.pos.get(n.id).y / (NODE_H + GAP_Y)));
+    $("inspect-data").textContent = JSON.stringify(n.data, null, 2);
+    links.append(edgeList("Incoming", graph.in.get(n.id), "from_node_id"), edgeList("Outgoing", graph.out.get(n.id), "to_node_id"));
+  } else {
+    const e = graph.dag.edges.find((x) => x.id === s.id);
+    $("inspect-title").textContent = label(e.data, EDGE_LABEL_KEYS) || e.id;
+    field("id", e.id);
+    field("from", link(e.from_node_id, { kind: "node", id: e.from_node_id }));
+    field("to", link(e.to_node_id, { kind: "node", id: e.to_node_id }));
+    field("order", String(e.order_index));
+    $("inspect-data").textContent = JSON.stringify(e.data, null, 2);
+  }
+  panel.hidden = false;
+}
+
+// ── Pan and zoom ─────────────────────────────────────────────────────
+
+function applyView() {
+  viewport.setAttribute("transform", `translate(${view.x} ${view.y}) scale(${view.k})`);
+}
+
+function fit() {
+  if (!graph || graph.pos.size === 0) return;
+  const xs = [...graph.pos.values()].map((p) => p.x), ys = [...graph.pos.values()].map((p) => p.y);
+  const minX = Math.min(...xs), maxX = Math.max(...xs) + NODE_W;
+  const minY = Math.min(...ys), maxY = Math.max(...ys) + NODE_H;
+  const { width, height } = canvas.getBoundingClientRect();
+  const pad = 40;
+  const k = Math.min(2, (width - 2 * pad) / (maxX - minX), (height - 2 * pad) / (maxY - minY));
+  view = { k, x: (width - k * (maxX + minX)) / 2, y: (height - k * (maxY + minY)) / 2 };
+  applyView();
+}
+
+function centre(s) {
+  const id = s.kind === "node" ? s.id : graph.dag.edges.find((e) => e.id === s.id)?.to_node_id;
+  const p = graph.pos.get(id);
+  if (!p) return;
+  const { width, height } = canvas.getBoundingClientRect();
+  view.x = width / 2 - view.k * (p.x + NODE_W / 2);
+  view.y = height / 2 - view.k * (p.y + NODE_H / 2);
+  applyView();
+}
+
+let drag = null;
+canvas.addEventListener("pointerdown", (ev) => {
+  drag = { x: ev.clientX, y: ev.clientY, vx: view.x, vy: view.y, moved: false };
+});
+canvas.addEventListener("pointermove", (ev) => {
+  if (!drag) return;
+  const dx = ev.clientX - drag.x, dy = ev.clientY - drag.y;
+  if (!drag.moved && Math.abs(dx) + Math.abs(dy) > 3) {
+    // Capture only once panning, so a plain click still reaches its node.
+    drag.moved = true;
+    canvas.setPointerCapture(ev.pointerId);
+    canvas.classList.add("panning");
+  }
+  if (!drag.moved) return;
+  view.x = drag.vx + dx;
+  view.y = drag.vy + dy;
+  applyView();
+});
+canvas.addEventListener("pointerup", () => {
+  canvas.classList.remove("panning");
+  setTimeout(() => { drag = null; });
+});
+canvas.addEventListener("click", () => {
+  // A click on the background, not the end of a pan, clears the selection.
+  if (!drag?.moved && graph) select(null);
+});
+canvas.addEventListener("wheel", (ev) => {
+  ev.preventDefault();
+  const r = canvas.getBoundingClientRect();
+  const mx = ev.clientX - r.left, my = ev.clientY - r.top;
+  const k = Math.min(4, Math.max(0.05, view.k * Math.exp(-ev.deltaY * 0.0015)));
+  view.x = mx - (mx - view.x) * (k / view.k);
+  view.y = my - (my - view.y) * (k / view.k);
+  view.k = k;
+  applyView();
+}, { passive: false });
+
+// ── Wiring ───────────────────────────────────────────────────────────
+
+function setStatus(text) {
+  $("status").textContent = text;
+}
+
+$("open").addEventListener("submit", (ev) => {
+  ev.preventDefault();
+  const id = $("dag-id").value.trim();
+  if (!id) return;
+  history.pushState(null, "", "?dag=" + encodeURIComponent(id));
+  open(id);
+});
+let suggestTimer;
+$("dag-id").addEventListener("input", (ev) => {
+  clearTimeout(suggestTimer);
+  const text = ev.target.value.trim();
+  if (text.length >= 2) suggestTimer = setTimeout(() => suggest(text), 200);
+});
+$("fit").addEventListener("click", fit);
+$("close").addEventListener("click", () => select(null));
+document.addEventListener("keydown", (ev) => {
+  if (ev.target instanceof HTMLInputElement) return;
+  if (ev.key === "Escape") select(null);
+  if (ev.key === "f" || ev.key === "F") fit();
+});
+window.addEventListener("popstate", start);
+window.addEventListener("resize", () => { if (graph && !selected) fit(); });
+
+function start() {
+  const id = new URLSearchParams(location.search).get("dag");
+  if (id) {
+    $("dag-id").value = id;
+    open(id);
+  } else {
+    setStatus("Enter a DAG ID to view it.");
+  }
+}
+start();
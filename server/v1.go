@@ -0,0 +1,1240 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/meikuraledutech/dag"
+	"github.com/meikuraledutech/dag/archive"
+	"github.com/meikuraledutech/dag/export"
+	"github.com/meikuraledutech/dag/formflow"
+	"github.com/meikuraledutech/dag/postgres"
+)
+
+// registerV1 mounts the v1 API on r. Response shapes under /v1 are frozen;
+// breaking changes go in a new registerV2 mounted at /v2.
+// arch is nil when archiving is disabled, and redact when no redaction
+// policy is configured.
+func registerV1(r fiber.Router, store dag.Store, pg *postgres.PGStore, arch *archive.Store, redact *dag.RedactionPolicy, keys []*export.Key, strict bool) {
+	idem := idempotency(pg)
+
+	// ── Schema ────────────────────────────────────────────────────────
+	r.Post("/schema", func(c fiber.Ctx) error {
+		if err := store.CreateSchema(c.Context()); err != nil {
+			return err
+		}
+		return c.JSON(fiber.Map{"message": "schema created"})
+	})
+
+	r.Delete("/schema", func(c fiber.Ctx) error {
+		if err := store.DropSchema(c.Context()); err != nil {
+			return err
+		}
+		return c.JSON(fiber.Map{"message": "schema dropped"})
+	})
+
+	// ── Node types ────────────────────────────────────────────────────
+	r.Get("/node-types", func(c fiber.Ctx) error {
+		types := []dag.NodeType{}
+		if reg := pg.NodeTypes(); reg != nil {
+			types = reg.All()
+		}
+		return c.JSON(types)
+	})
+
+	// ── Search ────────────────────────────────────────────────────────
+	// HEAD routes answer with counts in headers instead of running the GET.
+	r.Head("/dags", func(c fiber.Ctx) error {
+		q, err := searchQuery(c)
+		if err != nil {
+			return err
+		}
+		n, err := store.CountDAGs(c.Context(), q)
+		if err != nil {
+			return err
+		}
+		return sendCounts(c, map[string]int{totalCountHeader: n})
+	})
+
+	r.Get("/dags", func(c fiber.Ctx) error {
+		q, err := searchQuery(c)
+		if err != nil {
+			return err
+		}
+		results, err := store.SearchDAGs(c.Context(), q)
+		if err != nil {
+			return err
+		}
+		return c.JSON(fiber.Map{"items": results})
+	})
+
+	// ── DAG (bulk) ────────────────────────────────────────────────────
+	r.Get("/dags\\:batch", func(c fiber.Ctx) error {
+		ids := queryAll(c, "id")
+		switch {
+		case len(ids) == 0:
+			return validationFailed([]fieldError{{Field: "id", Message: "is required"}})
+		case len(ids) > maxBatchGet:
+			return validationFailed([]fieldError{{Field: "id", Message: fmt.Sprintf("must be given at most %d times", maxBatchGet)}})
+		}
+		p, err := redaction(c, redact)
+		if err != nil {
+			return err
+		}
+		o, _, err := getDAGOptions(c)
+		if err != nil {
+			return err
+		}
+		dags, err := store.GetDAGs(c.Context(), ids, o)
+		if err != nil {
+			return err
+		}
+		if p != nil {
+			for id, d := range dags {
+				dags[id] = p.RedactDAG(d)
+			}
+		}
+		return c.JSON(fiber.Map{"items": dags})
+	})
+
+	r.Get("/dags\\:expired", func(c fiber.Ctx) error {
+		before, limit, err := expiredQuery(c)
+		if err != nil {
+			return err
+		}
+		ids, err := store.ExpiredDAGs(c.Context(), before, limit)
+		if err != nil {
+			return err
+		}
+		if ids == nil {
+			ids = []string{}
+		}
+		return c.JSON(fiber.Map{"items": ids})
+	})
+
+	r.Post("/dag", idem, func(c fiber.Ctx) error {
+		var d dag.DAG
+		if err := c.Bind().JSON(&d); err != nil {
+			return invalidBody(err)
+		}
+		if errs := validateDAG(&d); len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		dryRun, err := queryFlag(c, "dry_run")
+		if err != nil {
+			return err
+		}
+		replace, err := queryFlag(c, "replace")
+		if err != nil {
+			return err
+		}
+		result, err := store.CreateDAG(c.Context(), &d, dag.CreateDAGOptions{DryRun: dryRun, Replace: replace})
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			return c.JSON(result)
+		}
+		return c.Status(201).JSON(result)
+	})
+
+	r.Head("/dag/:id", func(c fiber.Ctx) error {
+		ctx, id := c.Context(), c.Params("id")
+		ok, err := store.DAGExists(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return c.SendStatus(fiber.StatusNotFound)
+		}
+		nodes, err := store.CountNodes(ctx, id)
+		if err != nil {
+			return err
+		}
+		edges, err := store.CountEdges(ctx, id)
+		if err != nil {
+			return err
+		}
+		return sendCounts(c, map[string]int{nodeCountHeader: nodes, edgeCountHeader: edges})
+	})
+
+	r.Get("/dag/:id", func(c fiber.Ctx) error {
+		p, err := redaction(c, redact)
+		if err != nil {
+			return err
+		}
+		o, trimmed, err := getDAGOptions(c)
+		if err != nil {
+			return err
+		}
+		if trimmed {
+			// A trimmed DAG is small enough to build in memory, and an
+			// ordered one must be.
+			d, err := store.GetDAG(c.Context(), c.Params("id"), o)
+			if err != nil {
+				return err
+			}
+			if d == nil {
+				return newError(fiber.StatusNotFound, codeDAGNotFound, "dag not found")
+			}
+			if p != nil {
+				d = p.RedactDAG(d)
+			}
+			return sendWithETag(c, d)
+		}
+		if arch != nil {
+			// Restore first so the stream below finds the rows.
+			if _, err := arch.GetDAGInfo(c.Context(), c.Params("id")); err != nil {
+				return err
+			}
+		}
+		return streamDAG(c, store, pg, c.Params("id"), p)
+	})
+
+	r.Get("/dag/:id/info", func(c fiber.Ctx) error {
+		info, err := store.GetDAGInfo(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		if info == nil {
+			return newError(fiber.StatusNotFound, codeDAGNotFound, "dag not found")
+		}
+		return c.JSON(info)
+	})
+
+	r.Post("/dag/:id/archive", func(c fiber.Ctx) error {
+		if arch == nil {
+			return newError(fiber.StatusNotFound, codeNotFound, "archiving is not enabled")
+		}
+		if err := arch.Archive(c.Context(), c.Params("id")); err != nil {
+			return err
+		}
+		return c.SendStatus(204)
+	})
+
+	r.Get("/dag/:id/export", func(c fiber.Ctx) error {
+		f, err := exportFormat(c)
+		if err != nil {
+			return err
+		}
+		p, err := redaction(c, redact)
+		if err != nil {
+			return err
+		}
+		sign, err := queryFlag(c, "sign")
+		if err != nil {
+			return err
+		}
+		canonical, err := queryFlag(c, "canonical")
+		if err != nil {
+			return err
+		}
+		key := signingKey(keys)
+		switch {
+		case sign && key == nil:
+			return validationFailed([]fieldError{{Field: "sign", Message: "no signing key is configured"}})
+		case sign && f != export.JSON:
+			return validationFailed([]fieldError{{Field: "format", Message: "signed exports are json"}})
+		case canonical && (f != export.JSON || sign):
+			return validationFailed([]fieldError{{Field: "canonical", Message: "only applies to unsigned json exports"}})
+		}
+		d, err := store.GetDAG(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			return newError(404, codeDAGNotFound, "dag not found")
+		}
+		if sign {
+			return sendBundle(c, p.RedactDAG(d), key)
+		}
+		if canonical {
+			return sendCanonical(c, p.RedactDAG(d))
+		}
+		return sendExport(c, d, f, p)
+	})
+
+	r.Post("/dag/:id/import", idem, func(c fiber.Ctx) error {
+		f, err := importFormat(c)
+		if err != nil {
+			return err
+		}
+		var d *dag.DAG
+		if len(keys) > 0 || export.IsBundle(c.Body()) {
+			d, err = export.ReadBundle(bytes.NewReader(c.Body()), keys...)
+		} else {
+			d, err = export.Read(bytes.NewReader(c.Body()), f)
+		}
+		if err != nil {
+			switch {
+			case errors.Is(err, export.ErrNotReadable):
+				return validationFailed([]fieldError{{Field: "format", Message: fmt.Sprintf("%s cannot be imported", f)}})
+			case errors.Is(err, export.ErrNotBundle):
+				return validationFailed([]fieldError{{Field: "body", Message: "must be a signed bundle"}})
+			case errors.Is(err, export.ErrBadSignature), errors.Is(err, export.ErrUnknownKey):
+				return err
+			}
+			return invalidBody(err)
+		}
+		d.ID = c.Params("id")
+		dryRun, err := queryFlag(c, "dry_run")
+		if err != nil {
+			return err
+		}
+		replace, err := queryFlag(c, "replace")
+		if err != nil {
+			return err
+		}
+
+		issues := importIssues(d)
+		res := importResult{DryRun: dryRun, Nodes: len(d.Nodes), Edges: len(d.Edges)}
+		if !dryRun {
+			if len(issues) > 0 {
+				return validationFailed(issues)
+			}
+			if _, err := store.CreateDAG(c.Context(), d, dag.CreateDAGOptions{Replace: replace}); err != nil {
+				return err
+			}
+			res.Valid, res.Issues = true, []fieldError{}
+			return c.Status(201).JSON(res)
+		}
+		if len(issues) == 0 {
+			// Run the store's own checks too: quotas, settings, data
+			// schemas, node types and whether the ID is taken.
+			_, err := store.CreateDAG(c.Context(), d, dag.CreateDAGOptions{DryRun: true, Replace: replace})
+			if issues, err = storeIssues(err); err != nil {
+				return err
+			}
+		}
+		res.Valid, res.Issues = len(issues) == 0, issues
+		if res.Issues == nil {
+			res.Issues = []fieldError{}
+		}
+		return c.JSON(res)
+	})
+
+	r.Get("/dag/:id/path", func(c fiber.Ctx) error {
+		from, to := c.Query("from"), c.Query("to")
+		if errs := validatePathQuery(from, to); len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		nodes, err := store.Path(c.Context(), c.Params("id"), from, to)
+		if err != nil {
+			return err
+		}
+		if nodes == nil {
+			return newError(404, codePathNotFound, "no path between the nodes")
+		}
+		return c.JSON(fiber.Map{"nodes": nodes})
+	})
+
+	r.Get("/dag/:id/lca", func(c fiber.Ctx) error {
+		a, b := c.Query("a"), c.Query("b")
+		if errs := validateLCAQuery(a, b); len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		d, err := store.GetDAG(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			return newError(404, codeDAGNotFound, "dag not found")
+		}
+		nodes, err := dag.LCA(d, a, b)
+		if err != nil {
+			return err
+		}
+		return c.JSON(fiber.Map{"nodes": nodes})
+	})
+
+	// ── Tags ──────────────────────────────────────────────────────────
+	r.Post("/dag/:id/tags", func(c fiber.Ctx) error {
+		var body struct {
+			Tags []string `json:"tags"`
+		}
+		if err := c.Bind().JSON(&body); err != nil {
+			return invalidBody(err)
+		}
+		if errs := validateTags(body.Tags); len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		tags, err := store.AddDAGTags(c.Context(), c.Params("id"), body.Tags...)
+		if err != nil {
+			return err
+		}
+		return c.JSON(fiber.Map{"tags": tags})
+	})
+
+	r.Delete("/dag/:id/tags/:tag", func(c fiber.Ctx) error {
+		tags, err := store.RemoveDAGTags(c.Context(), c.Params("id"), c.Params("tag"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(fiber.Map{"tags": tags})
+	})
+
+	r.Put("/dag/:id/status", func(c fiber.Ctx) error {
+		var body struct {
+			Status dag.Status `json:"status"`
+		}
+		if err := c.Bind().JSON(&body); err != nil {
+			return invalidBody(err)
+		}
+		var err error
+		switch body.Status {
+		case dag.StatusPublished:
+			err = store.PublishDAG(c.Context(), c.Params("id"))
+		case dag.StatusArchived:
+			err = store.ArchiveDAG(c.Context(), c.Params("id"))
+		default:
+			return validationFailed([]fieldError{{Field: "status", Message: "must be published or archived"}})
+		}
+		if err != nil {
+			return err
+		}
+		info, err := store.GetDAGInfo(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(info)
+	})
+
+	r.Put("/dag/:id/settings", func(c fiber.Ctx) error {
+		var settings dag.Settings
+		if err := c.Bind().JSON(&settings); err != nil {
+			return invalidBody(err)
+		}
+		if errs := validateSettings("", settings); len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		if err := store.UpdateSettings(c.Context(), c.Params("id"), settings); err != nil {
+			return err
+		}
+		info, err := store.GetDAGInfo(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(info)
+	})
+
+	// ── Locks ─────────────────────────────────────────────────────────
+	r.Get("/dag/:id/locks", func(c fiber.Ctx) error {
+		locks, err := pg.Locks(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(fiber.Map{"items": locks})
+	})
+
+	r.Post("/dag/:id/lock", func(c fiber.Ctx) error {
+		owner, ttl, err := lockRequest(c)
+		if err != nil {
+			return err
+		}
+		l, err := pg.AcquireLock(c.Context(), c.Params("id"), owner, ttl)
+		if err != nil {
+			return err
+		}
+		return c.JSON(l)
+	})
+
+	r.Delete("/dag/:id/lock", func(c fiber.Ctx) error {
+		owner, err := lockOwner(c, c.Query("owner"))
+		if err != nil {
+			return err
+		}
+		if err := pg.ReleaseLock(c.Context(), c.Params("id"), owner); err != nil {
+			return err
+		}
+		return c.SendStatus(204)
+	})
+
+	r.Post("/dag/:id/nodes/:nodeId/lock", func(c fiber.Ctx) error {
+		owner, ttl, err := lockRequest(c)
+		if err != nil {
+			return err
+		}
+		l, err := pg.AcquireNodeLock(c.Context(), c.Params("id"), c.Params("nodeId"), owner, ttl)
+		if err != nil {
+			return err
+		}
+		return c.JSON(l)
+	})
+
+	r.Delete("/dag/:id/nodes/:nodeId/lock", func(c fiber.Ctx) error {
+		owner, err := lockOwner(c, c.Query("owner"))
+		if err != nil {
+			return err
+		}
+		if err := pg.ReleaseNodeLock(c.Context(), c.Params("id"), c.Params("nodeId"), owner); err != nil {
+			return err
+		}
+		return c.SendStatus(204)
+	})
+
+	// ── Drafts ────────────────────────────────────────────────────────
+	r.Post("/dag/:id/draft", func(c fiber.Ctx) error {
+		d, err := store.CreateDraft(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(d)
+	})
+
+	r.Post("/dag/:id/draft/promote", func(c fiber.Ctx) error {
+		d, err := store.PromoteDraft(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(d)
+	})
+
+	r.Delete("/dag/:id/draft", func(c fiber.Ctx) error {
+		if err := store.DeleteDAG(c.Context(), dag.DraftID(c.Params("id"))); err != nil {
+			return err
+		}
+		return c.SendStatus(204)
+	})
+
+	r.Post("/dag/:id/restore", func(c fiber.Ctx) error {
+		var body struct {
+			At string `json:"at"`
+		}
+		if err := c.Bind().JSON(&body); err != nil {
+			return invalidBody(err)
+		}
+		at, errs := validateRestore(body.At)
+		if len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		d, err := pg.RestoreDAGAt(c.Context(), c.Params("id"), at)
+		if err != nil {
+			return err
+		}
+		return c.JSON(d)
+	})
+
+	r.Post("/dag/:id/prune", func(c fiber.Ctx) error {
+		dryRun, err := queryFlag(c, "dry_run")
+		if err != nil {
+			return err
+		}
+		o := dag.PruneOptions{DryRun: dryRun}
+		if v := c.Query("roots"); v != "" {
+			o.Roots = strings.Split(v, ",")
+		}
+		ids, err := pg.PruneOrphans(c.Context(), c.Params("id"), o)
+		if err != nil {
+			return err
+		}
+		return c.JSON(fiber.Map{"node_ids": ids})
+	})
+
+	r.Delete("/dag/:id/subgraph/:nodeId", func(c fiber.Ctx) error {
+		rewire, err := queryFlag(c, "rewire")
+		if err != nil {
+			return err
+		}
+		dryRun, err := queryFlag(c, "dry_run")
+		if err != nil {
+			return err
+		}
+		res, err := pg.DeleteSubgraph(c.Context(), c.Params("id"), c.Params("nodeId"),
+			dag.DeleteSubgraphOptions{Rewire: rewire, DryRun: dryRun})
+		if err != nil {
+			return err
+		}
+		return c.JSON(res)
+	})
+
+	r.Put("/dag/:id/subgraph/:nodeId", func(c fiber.Ctx) error {
+		var replacement dag.DAG
+		if err := c.Bind().JSON(&replacement); err != nil {
+			return invalidBody(err)
+		}
+		if errs := validateReplacement(&replacement); len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		cs, err := pg.ReplaceSubgraph(c.Context(), c.Params("id"), c.Params("nodeId"), &replacement)
+		if err != nil {
+			return err
+		}
+		return c.JSON(cs)
+	})
+
+	r.Post("/dag/:id/merge", func(c fiber.Ctx) error {
+		var body struct {
+			KeepID   string            `json:"keep_id"`
+			DropID   string            `json:"drop_id"`
+			Strategy dag.MergeStrategy `json:"strategy"`
+		}
+		if err := c.Bind().JSON(&body); err != nil {
+			return invalidBody(err)
+		}
+		strategy, errs := validateMerge(body.KeepID, body.DropID, body.Strategy)
+		if len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		n, err := pg.MergeNodes(c.Context(), c.Params("id"), body.KeepID, body.DropID, strategy)
+		if err != nil {
+			return err
+		}
+		return c.JSON(n)
+	})
+
+	r.Post("/dag/:id/nodes/:nodeId/split", func(c fiber.Ctx) error {
+		var spec dag.SplitSpec
+		if err := c.Bind().JSON(&spec); err != nil {
+			return invalidBody(err)
+		}
+		if errs := validateSplit(&spec); len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		cs, err := pg.SplitNode(c.Context(), c.Params("id"), c.Params("nodeId"), &spec)
+		if err != nil {
+			return err
+		}
+		return c.Status(201).JSON(cs)
+	})
+
+	r.Get("/dag/:id/order", func(c fiber.Ctx) error {
+		o := dag.TopoOptions{NodePriority: c.Query("node_priority"), EdgePriority: c.Query("edge_priority")}
+		if errs := validateDataPaths(map[string]string{"node_priority": o.NodePriority, "edge_priority": o.EdgePriority}); len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		d, err := store.GetDAG(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			return newError(404, codeDAGNotFound, "dag not found")
+		}
+		ids, err := dag.TopoSort(d, o)
+		if err != nil {
+			return err
+		}
+		return c.JSON(fiber.Map{"node_ids": ids})
+	})
+
+	r.Post("/dag/:id/simulate", func(c fiber.Ctx) error {
+		var body struct {
+			Start   string                     `json:"start"`
+			Answers map[string]json.RawMessage `json:"answers"`
+			Seed    string                     `json:"seed"`
+		}
+		if err := c.Bind().JSON(&body); err != nil {
+			return invalidBody(err)
+		}
+		d, err := store.GetDAG(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			return newError(404, codeDAGNotFound, "dag not found")
+		}
+		sim, err := formflow.Simulate(d, body.Start, body.Answers, formflow.Options{Seed: body.Seed})
+		if err != nil {
+			return err
+		}
+		return c.JSON(sim)
+	})
+
+	r.Post("/dag/:id/nodes/:nodeId/next", func(c fiber.Ctx) error {
+		var body struct {
+			Answer json.RawMessage `json:"answer"`
+			Seed   string          `json:"seed"`
+		}
+		if err := c.Bind().JSON(&body); err != nil {
+			return invalidBody(err)
+		}
+		d, err := store.GetDAG(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			return newError(404, codeDAGNotFound, "dag not found")
+		}
+		var variant *formflow.Assignment
+		next, err := formflow.Next(d, c.Params("nodeId"), body.Answer, formflow.Options{
+			Seed:      body.Seed,
+			OnVariant: func(a formflow.Assignment) { variant = &a },
+		})
+		if err != nil {
+			return err
+		}
+		return c.JSON(fiber.Map{"node": next, "complete": next == nil, "variant": variant})
+	})
+
+	r.Get("/dag/:id/decisions", func(c fiber.Ctx) error {
+		format, limit, errs := validateDecisionQuery(c.Query("format"), c.Query("limit"))
+		if len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		d, err := store.GetDAG(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			return newError(404, codeDAGNotFound, "dag not found")
+		}
+		rows, truncated := formflow.DecisionTable(d, limit)
+		return sendDecisionTable(c, d.ID, rows, truncated, format)
+	})
+
+	r.Get("/dag/:id/schedule", func(c fiber.Ctx) error {
+		field := c.Query("duration")
+		if field == "" {
+			return validationFailed([]fieldError{{Field: "duration", Message: "is required"}})
+		}
+		if errs := validateDataPaths(map[string]string{"duration": field}); len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		d, err := store.GetDAG(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			return newError(404, codeDAGNotFound, "dag not found")
+		}
+		s, err := dag.CriticalPath(d, field)
+		if err != nil {
+			return err
+		}
+		return c.JSON(s)
+	})
+
+	r.Get("/dag/:id/condensed", func(c fiber.Ctx) error {
+		minLength, errs := validateCondense(c.Query("min_length"))
+		if len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		d, err := store.GetDAG(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			return newError(404, codeDAGNotFound, "dag not found")
+		}
+		return c.JSON(dag.Condense(d, dag.CondenseOptions{MinLength: minLength}))
+	})
+
+	r.Get("/dag/:id/reverse", func(c fiber.Ctx) error {
+		d, err := store.GetDAG(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			return newError(404, codeDAGNotFound, "dag not found")
+		}
+		return c.JSON(dag.Reverse(d))
+	})
+
+	r.Post("/dag/:id/reverse", func(c fiber.Ctx) error {
+		var body struct {
+			ID string `json:"id"`
+		}
+		if err := c.Bind().JSON(&body); err != nil {
+			return invalidBody(err)
+		}
+		if body.ID == "" {
+			return validationFailed([]fieldError{{Field: "id", Message: "is required"}})
+		}
+		dryRun, err := queryFlag(c, "dry_run")
+		if err != nil {
+			return err
+		}
+		replace, err := queryFlag(c, "replace")
+		if err != nil {
+			return err
+		}
+		d, err := pg.ReverseDAG(c.Context(), c.Params("id"), body.ID, dag.CreateDAGOptions{DryRun: dryRun, Replace: replace})
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			return c.JSON(d)
+		}
+		return c.Status(201).JSON(d)
+	})
+
+	r.Get("/dag/:id/stats", func(c fiber.Ctx) error {
+		st, err := pg.DAGStats(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		if st == nil {
+			return newError(fiber.StatusNotFound, codeDAGNotFound, "dag not found")
+		}
+		return c.JSON(st)
+	})
+
+	r.Post("/dag/:id/visits", func(c fiber.Ctx) error {
+		var body struct {
+			RunID  string `json:"run_id"`
+			NodeID string `json:"node_id"`
+		}
+		if err := c.Bind().JSON(&body); err != nil {
+			return invalidBody(err)
+		}
+		if errs := validateVisit(body.RunID, body.NodeID); len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		if err := pg.RecordVisit(c.Context(), c.Params("id"), body.RunID, body.NodeID); err != nil {
+			return err
+		}
+		return c.SendStatus(204)
+	})
+
+	r.Get("/dag/:id/visits", func(c fiber.Ctx) error {
+		st, err := pg.VisitStats(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(st)
+	})
+
+	r.Get("/dag/:id/analytics", func(c fiber.Ctx) error {
+		d, err := store.GetDAG(c.Context(), c.Params("id"), dag.GetDAGOptions{SkipData: true})
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			return newError(404, codeDAGNotFound, "dag not found")
+		}
+		st, err := pg.VisitStats(c.Context(), d.ID)
+		if err != nil {
+			return err
+		}
+		return c.JSON(dag.NewHeatmap(d, st))
+	})
+
+	r.Get("/dag/:id/analytics/export", func(c fiber.Ctx) error {
+		table, after, errs := validateAnalyticsExport(c.Query("table"), c.Query("format"), c.Query("after"))
+		if len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		if table == "visits" {
+			return streamVisits(c, pg, c.Params("id"), after)
+		}
+		d, err := store.GetDAG(c.Context(), c.Params("id"), dag.GetDAGOptions{SkipData: true})
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			return newError(404, codeDAGNotFound, "dag not found")
+		}
+		st, err := pg.VisitStats(c.Context(), d.ID)
+		if err != nil {
+			return err
+		}
+		return sendAnalytics(c, d.ID, table, dag.NewHeatmap(d, st))
+	})
+
+	r.Get("/dag/:id/events", func(c fiber.Ctx) error {
+		after, limit, err := eventsQuery(c)
+		if err != nil {
+			return err
+		}
+		events, err := pg.Events(c.Context(), c.Params("id"), after, limit)
+		if err != nil {
+			return err
+		}
+		return c.JSON(events)
+	})
+
+	r.Get("/audit", func(c fiber.Ctx) error {
+		f, err := auditQuery(c)
+		if err != nil {
+			return err
+		}
+		events, err := pg.QueryAudit(c.Context(), f)
+		if err != nil {
+			return err
+		}
+		return c.JSON(events)
+	})
+
+	r.Get("/audit/export", func(c fiber.Ctx) error {
+		f, err := auditQuery(c)
+		if err != nil {
+			return err
+		}
+		if v := c.Query("format"); v != "" && v != "csv" {
+			return validationFailed([]fieldError{{Field: "format", Message: "must be csv"}})
+		}
+		return streamAudit(c, pg, f)
+	})
+
+	r.Get("/dag/:id/replay", func(c fiber.Ctx) error {
+		var seq int64
+		if v := c.Query("seq"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 1 {
+				return validationFailed([]fieldError{{Field: "seq", Message: "must be a positive integer"}})
+			}
+			seq = n
+		}
+		d, err := pg.ReplayDAG(c.Context(), c.Params("id"), seq)
+		if err != nil {
+			return err
+		}
+		return c.JSON(d)
+	})
+
+	r.Delete("/dag/:id", func(c fiber.Ctx) error {
+		ctx, err := checkIfMatchTag(c, pg, strict, func(ctx context.Context, s *postgres.PGStore) (string, error) {
+			fp, err := s.DAGFingerprint(ctx, c.Params("id"))
+			return dagETag(fp), err
+		})
+		if err != nil {
+			return err
+		}
+		if err := store.DeleteDAG(ctx, c.Params("id")); err != nil {
+			return err
+		}
+		return c.SendStatus(204)
+	})
+
+	r.Post("/dag/:id/changes", idem, func(c fiber.Ctx) error {
+		var cs dag.ChangeSet
+		if err := c.Bind().JSON(&cs); err != nil {
+			return invalidBody(err)
+		}
+		if errs := validateChangeSet(&cs); len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		ctx, err := checkIfMatchTag(c, pg, strict, func(ctx context.Context, s *postgres.PGStore) (string, error) {
+			fp, err := s.DAGFingerprint(ctx, c.Params("id"))
+			return dagETag(fp), err
+		})
+		if err != nil {
+			return err
+		}
+		if err := store.ApplyChangeSet(ctx, c.Params("id"), &cs); err != nil {
+			return err
+		}
+		return c.JSON(cs)
+	})
+
+	r.Patch("/dag/:id", idem, func(c fiber.Ctx) error {
+		ct := strings.ToLower(c.Get(fiber.HeaderContentType))
+		if !strings.HasPrefix(ct, jsonPatchType) {
+			return newError(fiber.StatusUnsupportedMediaType, codeUnsupportedMediaType, "content type must be "+jsonPatchType)
+		}
+		ctx, err := checkIfMatchTag(c, pg, strict, func(ctx context.Context, s *postgres.PGStore) (string, error) {
+			fp, err := s.DAGFingerprint(ctx, c.Params("id"))
+			return dagETag(fp), err
+		})
+		if err != nil {
+			return err
+		}
+		d, err := store.GetDAG(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			return newError(404, codeDAGNotFound, "dag not found")
+		}
+		patched, err := patchDAG(d, c.Body())
+		if err != nil {
+			return err
+		}
+		// The patch is applied as the change set between the two
+		// versions, so the graph is validated and written atomically.
+		cs := dag.Diff(d, patched)
+		if cs.Empty() {
+			return c.JSON(d)
+		}
+		if errs := validateChangeSet(cs); len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		if err := store.ApplyChangeSet(ctx, c.Params("id"), cs); err != nil {
+			return err
+		}
+		if d, err = store.GetDAG(c.Context(), c.Params("id")); err != nil {
+			return err
+		}
+		return c.JSON(d)
+	})
+
+	// ── Nodes ─────────────────────────────────────────────────────────
+	r.Post("/dag/:id/nodes", idem, func(c fiber.Ctx) error {
+		var node dag.Node
+		if err := c.Bind().JSON(&node); err != nil {
+			return invalidBody(err)
+		}
+		if errs := validateNode("", &node); len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		id, err := store.AddNode(c.Context(), c.Params("id"), &node)
+		if err != nil {
+			return err
+		}
+		return c.Status(201).JSON(fiber.Map{"id": id})
+	})
+
+	r.Post("/dag/:id/nodes\\:batch", idem, func(c fiber.Ctx) error {
+		var nodes []dag.Node
+		if err := c.Bind().JSON(&nodes); err != nil {
+			return invalidBody(err)
+		}
+		return runBatch(c, nodes, validateNode, func(valid []dag.Node) ([]dag.BatchResult, error) {
+			return store.AddNodes(c.Context(), c.Params("id"), valid)
+		})
+	})
+
+	r.Head("/dag/:id/nodes", func(c fiber.Ctx) error {
+		n, err := store.CountNodes(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		return sendCounts(c, map[string]int{totalCountHeader: n})
+	})
+
+	r.Get("/dag/:id/nodes", func(c fiber.Ctx) error {
+		if tag := c.Query("tag"); tag != "" {
+			nodes, err := store.FindNodesByTag(c.Context(), c.Params("id"), tag)
+			if err != nil {
+				return err
+			}
+			return c.JSON(nodes)
+		}
+		opts, paged, err := listOptions(c)
+		if err != nil {
+			return err
+		}
+		if paged {
+			page, err := store.ListNodesPage(c.Context(), c.Params("id"), opts)
+			if err != nil {
+				return err
+			}
+			return c.JSON(page)
+		}
+		nodes, err := store.ListNodes(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(nodes)
+	})
+
+	r.Get("/nodes/:id", func(c fiber.Ctx) error {
+		n, err := store.GetNode(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		if n == nil {
+			return dag.ErrNodeNotFound
+		}
+		return sendWithETag(c, n)
+	})
+
+	r.Get("/nodes/:id/ancestors", func(c fiber.Ctx) error {
+		nodes, err := store.Ancestors(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(nodes)
+	})
+
+	r.Get("/nodes/:id/descendants", func(c fiber.Ctx) error {
+		nodes, err := store.Descendants(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(nodes)
+	})
+
+	r.Get("/nodes/:id/neighborhood", func(c fiber.Ctx) error {
+		k, dir, errs := validateNeighborhood(c.Query("k"), c.Query("direction"))
+		if len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		d, err := store.Neighborhood(c.Context(), c.Params("id"), k, dir)
+		if err != nil {
+			return err
+		}
+		return c.JSON(d)
+	})
+
+	r.Put("/nodes/:id", func(c fiber.Ctx) error {
+		var node dag.Node
+		if err := c.Bind().JSON(&node); err != nil {
+			return invalidBody(err)
+		}
+		if errs := validateNode("", &node); len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		node.ID = c.Params("id")
+		ctx, err := checkIfMatch(c, pg, strict, func(ctx context.Context, s *postgres.PGStore) (*dag.Node, error) {
+			return s.GetNode(ctx, node.ID)
+		})
+		if err != nil {
+			return err
+		}
+		if err := store.UpdateNode(ctx, &node); err != nil {
+			return err
+		}
+		return c.SendStatus(204)
+	})
+
+	r.Put("/nodes/:id/edges/order", func(c fiber.Ctx) error {
+		var body struct {
+			EdgeIDs []string `json:"edge_ids"`
+		}
+		if err := c.Bind().JSON(&body); err != nil {
+			return invalidBody(err)
+		}
+		if body.EdgeIDs == nil {
+			return validationFailed([]fieldError{{Field: "edge_ids", Message: "is required"}})
+		}
+		if err := store.ReorderEdges(c.Context(), c.Params("id"), body.EdgeIDs); err != nil {
+			return err
+		}
+		return c.SendStatus(204)
+	})
+
+	r.Delete("/nodes/:id", func(c fiber.Ctx) error {
+		ctx, err := checkIfMatch(c, pg, strict, func(ctx context.Context, s *postgres.PGStore) (*dag.Node, error) {
+			return s.GetNode(ctx, c.Params("id"))
+		})
+		if err != nil {
+			return err
+		}
+		if err := store.DeleteNode(ctx, c.Params("id")); err != nil {
+			return err
+		}
+		return c.SendStatus(204)
+	})
+
+	// ── Edges ─────────────────────────────────────────────────────────
+	r.Post("/dag/:id/edges", idem, func(c fiber.Ctx) error {
+		var edge dag.Edge
+		if err := c.Bind().JSON(&edge); err != nil {
+			return invalidBody(err)
+		}
+		if errs := validateEdge("", &edge); len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		id, err := store.AddEdge(c.Context(), c.Params("id"), &edge)
+		if err != nil {
+			return err
+		}
+		return c.Status(201).JSON(fiber.Map{"id": id})
+	})
+
+	r.Post("/dag/:id/edges\\:batch", idem, func(c fiber.Ctx) error {
+		var edges []dag.Edge
+		if err := c.Bind().JSON(&edges); err != nil {
+			return invalidBody(err)
+		}
+		return runBatch(c, edges, validateEdge, func(valid []dag.Edge) ([]dag.BatchResult, error) {
+			return store.AddEdges(c.Context(), c.Params("id"), valid)
+		})
+	})
+
+	r.Head("/dag/:id/edges", func(c fiber.Ctx) error {
+		n, err := store.CountEdges(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		return sendCounts(c, map[string]int{totalCountHeader: n})
+	})
+
+	r.Get("/dag/:id/edges", func(c fiber.Ctx) error {
+		if f, ok, err := edgeFilter(c); err != nil {
+			return err
+		} else if ok {
+			edges, err := store.FindEdges(c.Context(), c.Params("id"), f)
+			if err != nil {
+				return err
+			}
+			return c.JSON(edges)
+		}
+		opts, paged, err := listOptions(c)
+		if err != nil {
+			return err
+		}
+		if paged {
+			page, err := store.ListEdgesPage(c.Context(), c.Params("id"), opts)
+			if err != nil {
+				return err
+			}
+			return c.JSON(page)
+		}
+		edges, err := store.ListEdges(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(edges)
+	})
+
+	r.Get("/edges/:id", func(c fiber.Ctx) error {
+		e, err := store.GetEdge(c.Context(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		if e == nil {
+			return dag.ErrEdgeNotFound
+		}
+		return sendWithETag(c, e)
+	})
+
+	r.Put("/edges/:id", func(c fiber.Ctx) error {
+		var edge dag.Edge
+		if err := c.Bind().JSON(&edge); err != nil {
+			return invalidBody(err)
+		}
+		if errs := validateEdge("", &edge); len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		edge.ID = c.Params("id")
+		ctx, err := checkIfMatch(c, pg, strict, func(ctx context.Context, s *postgres.PGStore) (*dag.Edge, error) {
+			return s.GetEdge(ctx, edge.ID)
+		})
+		if err != nil {
+			return err
+		}
+		if err := store.UpdateEdge(ctx, &edge); err != nil {
+			return err
+		}
+		return c.SendStatus(204)
+	})
+
+	r.Post("/edges/:id/insert", func(c fiber.Ctx) error {
+		var body struct {
+			Node     dag.Node              `json:"node"`
+			EdgeData dag.EdgeDataPlacement `json:"edge_data"`
+		}
+		if err := c.Bind().JSON(&body); err != nil {
+			return invalidBody(err)
+		}
+		if errs := validateInsertNode(&body.Node, body.EdgeData); len(errs) > 0 {
+			return validationFailed(errs)
+		}
+		cs, err := pg.InsertNodeOnEdge(c.Context(), c.Params("id"), &body.Node,
+			dag.InsertNodeOptions{EdgeData: body.EdgeData})
+		if err != nil {
+			return err
+		}
+		return c.Status(201).JSON(cs)
+	})
+
+	r.Delete("/edges/:id", func(c fiber.Ctx) error {
+		ctx, err := checkIfMatch(c, pg, strict, func(ctx context.Context, s *postgres.PGStore) (*dag.Edge, error) {
+			return s.GetEdge(ctx, c.Params("id"))
+		})
+		if err != nil {
+			return err
+		}
+		if err := store.DeleteEdge(ctx, c.Params("id")); err != nil {
+			return err
+		}
+		return c.SendStatus(204)
+	})
+}
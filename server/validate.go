@@ -0,0 +1,653 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// validateDAG checks a CreateDAG body before it reaches the store.
+func validateDAG(d *dag.DAG) []fieldError {
+	var errs []fieldError
+	if d.ID == "" {
+		errs = append(errs, fieldError{Field: "id", Message: "is required"})
+	}
+	if len(d.Nodes) == 0 {
+		errs = append(errs, fieldError{Field: "nodes", Message: "must contain at least one node"})
+	}
+	for i, t := range d.Tags {
+		if t == "" {
+			errs = append(errs, fieldError{Field: fmt.Sprintf("tags[%d]", i), Message: "must not be empty"})
+		}
+	}
+
+	errs = append(errs, validateSettings("settings", d.Settings)...)
+	return append(errs, validateGraph(d)...)
+}
+
+// validateGraph checks the nodes and edges of a DAG body, whose edges may
+// use node refs.
+func validateGraph(d *dag.DAG) []fieldError {
+	var errs []fieldError
+	refs := make(map[string]bool)
+	for i, n := range d.Nodes {
+		prefix := fmt.Sprintf("nodes[%d]", i)
+		errs = append(errs, validateNode(prefix, &n)...)
+		if n.Ref != "" {
+			if refs[n.Ref] {
+				errs = append(errs, fieldError{Field: prefix + ".ref", Message: fmt.Sprintf("duplicate ref %q", n.Ref)})
+			}
+			refs[n.Ref] = true
+		}
+	}
+
+	for i, e := range d.Edges {
+		prefix := fmt.Sprintf("edges[%d]", i)
+		if e.FromNodeID == "" && e.FromNodeRef == "" {
+			errs = append(errs, fieldError{Field: prefix + ".from_node_id", Message: "from_node_id or from_node_ref is required"})
+		}
+		if e.ToNodeID == "" && e.ToNodeRef == "" {
+			errs = append(errs, fieldError{Field: prefix + ".to_node_id", Message: "to_node_id or to_node_ref is required"})
+		}
+		if e.FromNodeRef != "" && !refs[e.FromNodeRef] {
+			errs = append(errs, fieldError{Field: prefix + ".from_node_ref", Message: fmt.Sprintf("unknown ref %q", e.FromNodeRef)})
+		}
+		if e.ToNodeRef != "" && !refs[e.ToNodeRef] {
+			errs = append(errs, fieldError{Field: prefix + ".to_node_ref", Message: fmt.Sprintf("unknown ref %q", e.ToNodeRef)})
+		}
+		if len(e.Data) == 0 {
+			errs = append(errs, fieldError{Field: prefix + ".data", Message: "is required"})
+		}
+	}
+
+	return errs
+}
+
+// validateChangeSet checks a POST /dag/:id/changes body. New nodes may
+// carry refs, which new and updated edges may use.
+func validateChangeSet(cs *dag.ChangeSet) []fieldError {
+	var errs []fieldError
+	if cs.Empty() {
+		errs = append(errs, fieldError{Field: "body", Message: "must change something"})
+	}
+	refs := make(map[string]bool)
+	for i, n := range cs.AddNodes {
+		prefix := fmt.Sprintf("add_nodes[%d]", i)
+		errs = append(errs, validateNode(prefix, &n)...)
+		if n.Ref != "" {
+			if refs[n.Ref] {
+				errs = append(errs, fieldError{Field: prefix + ".ref", Message: fmt.Sprintf("duplicate ref %q", n.Ref)})
+			}
+			refs[n.Ref] = true
+		}
+	}
+	for i, n := range cs.UpdateNodes {
+		prefix := fmt.Sprintf("update_nodes[%d]", i)
+		if n.ID == "" {
+			errs = append(errs, fieldError{Field: prefix + ".id", Message: "is required"})
+		}
+		errs = append(errs, validateNode(prefix, &n)...)
+	}
+	for _, list := range []struct {
+		name  string
+		edges []dag.Edge
+	}{{"add_edges", cs.AddEdges}, {"update_edges", cs.UpdateEdges}} {
+		for i, e := range list.edges {
+			prefix := fmt.Sprintf("%s[%d]", list.name, i)
+			if list.name == "update_edges" && e.ID == "" {
+				errs = append(errs, fieldError{Field: prefix + ".id", Message: "is required"})
+			}
+			if e.FromNodeID == "" && e.FromNodeRef == "" {
+				errs = append(errs, fieldError{Field: prefix + ".from_node_id", Message: "from_node_id or from_node_ref is required"})
+			}
+			if e.ToNodeID == "" && e.ToNodeRef == "" {
+				errs = append(errs, fieldError{Field: prefix + ".to_node_id", Message: "to_node_id or to_node_ref is required"})
+			}
+			if e.FromNodeRef != "" && !refs[e.FromNodeRef] {
+				errs = append(errs, fieldError{Field: prefix + ".from_node_ref", Message: fmt.Sprintf("unknown ref %q", e.FromNodeRef)})
+			}
+			if e.ToNodeRef != "" && !refs[e.ToNodeRef] {
+				errs = append(errs, fieldError{Field: prefix + ".to_node_ref", Message: fmt.Sprintf("unknown ref %q", e.ToNodeRef)})
+			}
+			if len(e.Data) == 0 {
+				errs = append(errs, fieldError{Field: prefix + ".data", Message: "is required"})
+			}
+		}
+	}
+	return errs
+}
+
+// validateNode checks a node body. prefix is prepended to field names.
+func validateNode(prefix string, n *dag.Node) []fieldError {
+	var errs []fieldError
+	if len(n.Data) == 0 {
+		errs = append(errs, fieldError{Field: join(prefix, "data"), Message: "is required"})
+	}
+	for i, t := range n.Tags {
+		if t == "" {
+			errs = append(errs, fieldError{Field: join(prefix, fmt.Sprintf("tags[%d]", i)), Message: "must not be empty"})
+		}
+	}
+	return errs
+}
+
+// validateSettings checks a DAG's settings. prefix is prepended to field names.
+func validateSettings(prefix string, s dag.Settings) []fieldError {
+	var errs []fieldError
+	if s.MaxDepth < 0 {
+		errs = append(errs, fieldError{Field: join(prefix, "max_depth"), Message: "must not be negative"})
+	}
+	if s.DistinctConditions != "" && strings.TrimSpace(s.DistinctConditions) == "" {
+		errs = append(errs, fieldError{Field: join(prefix, "distinct_conditions"), Message: "must be a data key, not blank"})
+	}
+	return errs
+}
+
+// validateEdge checks an AddEdge/UpdateEdge body, which must use real node IDs.
+func validateEdge(prefix string, e *dag.Edge) []fieldError {
+	var errs []fieldError
+	if e.FromNodeID == "" {
+		errs = append(errs, fieldError{Field: join(prefix, "from_node_id"), Message: "is required"})
+	}
+	if e.ToNodeID == "" {
+		errs = append(errs, fieldError{Field: join(prefix, "to_node_id"), Message: "is required"})
+	}
+	if len(e.Data) == 0 {
+		errs = append(errs, fieldError{Field: join(prefix, "data"), Message: "is required"})
+	}
+	return errs
+}
+
+// validatePathQuery checks the from/to query parameters of GET /dag/:id/path.
+func validatePathQuery(from, to string) []fieldError {
+	var errs []fieldError
+	if from == "" {
+		errs = append(errs, fieldError{Field: "from", Message: "is required"})
+	}
+	if to == "" {
+		errs = append(errs, fieldError{Field: "to", Message: "is required"})
+	}
+	return errs
+}
+
+// validateLCAQuery checks the a and b query parameters of
+// GET /dag/:id/lca.
+func validateLCAQuery(a, b string) []fieldError {
+	var errs []fieldError
+	if a == "" {
+		errs = append(errs, fieldError{Field: "a", Message: "is required"})
+	}
+	if b == "" {
+		errs = append(errs, fieldError{Field: "b", Message: "is required"})
+	}
+	return errs
+}
+
+// maxHops caps ?k= on GET /nodes/:id/neighborhood.
+const maxHops = 10
+
+// validateNeighborhood parses the k and direction query parameters of
+// GET /nodes/:id/neighborhood. k defaults to 1 and direction to both.
+func validateNeighborhood(k, direction string) (int, dag.Direction, []fieldError) {
+	var errs []fieldError
+	hops := 1
+	if k != "" {
+		n, err := strconv.Atoi(k)
+		if err != nil || n < 0 || n > maxHops {
+			errs = append(errs, fieldError{Field: "k", Message: fmt.Sprintf("must be an integer between 0 and %d", maxHops)})
+		}
+		hops = n
+	}
+	dir := dag.Both
+	if direction != "" {
+		if dir = dag.Direction(direction); !dir.Valid() {
+			errs = append(errs, fieldError{Field: "direction", Message: "must be out, in or both"})
+		}
+	}
+	return hops, dir, errs
+}
+
+// validateMerge checks a POST /dag/:id/merge body. strategy defaults to
+// keep.
+func validateMerge(keepID, dropID string, strategy dag.MergeStrategy) (dag.MergeStrategy, []fieldError) {
+	var errs []fieldError
+	if keepID == "" {
+		errs = append(errs, fieldError{Field: "keep_id", Message: "is required"})
+	}
+	if dropID == "" {
+		errs = append(errs, fieldError{Field: "drop_id", Message: "is required"})
+	} else if dropID == keepID {
+		errs = append(errs, fieldError{Field: "drop_id", Message: "must differ from keep_id"})
+	}
+	if strategy == "" {
+		strategy = dag.MergeKeep
+	}
+	if !strategy.Valid() {
+		errs = append(errs, fieldError{Field: "strategy", Message: "must be keep, replace, shallow or deep"})
+	}
+	return strategy, errs
+}
+
+// Lock lifetimes for POST /dag/:id/lock and /dag/:id/nodes/:nodeId/lock.
+const (
+	defaultLockTTL = 5 * time.Minute
+	maxLockTTL     = 24 * time.Hour
+)
+
+// lockRequest reads the owner and TTL of a lock request body, {"owner":
+// "...", "ttl": "10m"}. Both are optional; see lockOwner and
+// defaultLockTTL. An empty body is allowed.
+func lockRequest(c fiber.Ctx) (string, time.Duration, error) {
+	var body struct {
+		Owner string `json:"owner"`
+		TTL   string `json:"ttl"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.Bind().JSON(&body); err != nil {
+			return "", 0, invalidBody(err)
+		}
+	}
+	ttl := defaultLockTTL
+	if body.TTL != "" {
+		d, err := time.ParseDuration(body.TTL)
+		if err != nil || d <= 0 || d > maxLockTTL {
+			return "", 0, validationFailed([]fieldError{{Field: "ttl", Message: "must be a positive duration of at most 24h, such as 10m"}})
+		}
+		ttl = d
+	}
+	owner, err := lockOwner(c, body.Owner)
+	return owner, ttl, err
+}
+
+// lockOwner returns owner, or the request's actor (DAG_ACTOR_HEADER) if
+// owner is empty, failing validation if both are.
+func lockOwner(c fiber.Ctx, owner string) (string, error) {
+	if owner == "" {
+		owner = dag.ActorFrom(c.Context())
+	}
+	if owner == "" {
+		return "", validationFailed([]fieldError{{Field: "owner", Message: "is required without an actor header"}})
+	}
+	return owner, nil
+}
+
+// validateVisit checks a POST /dag/:id/visits body.
+func validateVisit(runID, nodeID string) []fieldError {
+	var errs []fieldError
+	if runID == "" {
+		errs = append(errs, fieldError{Field: "run_id", Message: "is required"})
+	}
+	if nodeID == "" {
+		errs = append(errs, fieldError{Field: "node_id", Message: "is required"})
+	}
+	return errs
+}
+
+// validateSplit checks a POST /dag/:id/nodes/:nodeId/split body.
+func validateSplit(spec *dag.SplitSpec) []fieldError {
+	errs := validateNode("node", &spec.Node)
+	for i, id := range spec.Incoming {
+		if id == "" {
+			errs = append(errs, fieldError{Field: fmt.Sprintf("incoming[%d]", i), Message: "must not be empty"})
+		}
+	}
+	for i, id := range spec.Outgoing {
+		if id == "" {
+			errs = append(errs, fieldError{Field: fmt.Sprintf("outgoing[%d]", i), Message: "must not be empty"})
+		}
+	}
+	return errs
+}
+
+// validateInsertNode checks a POST /edges/:id/insert body. edge_data may
+// be empty.
+func validateInsertNode(n *dag.Node, p dag.EdgeDataPlacement) []fieldError {
+	errs := validateNode("node", n)
+	if p != "" && !p.Valid() {
+		errs = append(errs, fieldError{Field: "edge_data", Message: "must be incoming, outgoing or both"})
+	}
+	return errs
+}
+
+// validateReplacement checks a PUT /dag/:id/subgraph/:nodeId body. Its
+// edges may also name nodes of the DAG outside the replaced region by ID.
+func validateReplacement(d *dag.DAG) []fieldError {
+	var errs []fieldError
+	if len(d.Nodes) == 0 {
+		errs = append(errs, fieldError{Field: "nodes", Message: "must contain at least one node; use DELETE to remove the subgraph"})
+	}
+	return append(errs, validateGraph(d)...)
+}
+
+// validateCondense parses the min_length query parameter of
+// GET /dag/:id/condensed. It defaults to 2.
+func validateCondense(minLength string) (int, []fieldError) {
+	if minLength == "" {
+		return 2, nil
+	}
+	n, err := strconv.Atoi(minLength)
+	if err != nil || n < 2 {
+		return 0, []fieldError{{Field: "min_length", Message: "must be an integer of at least 2"}}
+	}
+	return n, nil
+}
+
+// validateDataPaths checks query parameters holding dotted data paths,
+// such as node_priority on GET /dag/:id/order. Empty ones are allowed.
+func validateDataPaths(params map[string]string) []fieldError {
+	var errs []fieldError
+	for name, p := range params {
+		if p != "" && slices.Contains(strings.Split(p, "."), "") {
+			errs = append(errs, fieldError{Field: name, Message: "must be a dotted data path"})
+		}
+	}
+	slices.SortFunc(errs, func(a, b fieldError) int { return strings.Compare(a.Field, b.Field) })
+	return errs
+}
+
+// maxDecisionRows caps ?limit= on GET /dag/:id/decisions.
+const maxDecisionRows = 100000
+
+// validateDecisionQuery parses the format and limit query parameters of
+// GET /dag/:id/decisions. format defaults to json and limit to 10000.
+func validateDecisionQuery(format, limit string) (string, int, []fieldError) {
+	var errs []fieldError
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		errs = append(errs, fieldError{Field: "format", Message: "must be json or csv"})
+	}
+	rows := 10000
+	if limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxDecisionRows {
+			errs = append(errs, fieldError{Field: "limit", Message: fmt.Sprintf("must be an integer between 1 and %d", maxDecisionRows)})
+		}
+		rows = n
+	}
+	return format, rows, errs
+}
+
+// validateAnalyticsExport checks the query of GET
+// /dag/:id/analytics/export. table defaults to visits and format to csv,
+// the only format.
+func validateAnalyticsExport(table, format, after string) (string, int64, []fieldError) {
+	var errs []fieldError
+	if table == "" {
+		table = "visits"
+	}
+	if table != "visits" && table != "nodes" && table != "edges" {
+		errs = append(errs, fieldError{Field: "table", Message: "must be visits, nodes or edges"})
+	}
+	if format != "" && format != "csv" {
+		errs = append(errs, fieldError{Field: "format", Message: "must be csv"})
+	}
+	var seq int64
+	if after != "" {
+		n, err := strconv.ParseInt(after, 10, 64)
+		if err != nil || n < 0 {
+			errs = append(errs, fieldError{Field: "after", Message: "must be a non-negative integer"})
+		}
+		seq = n
+	}
+	return table, seq, errs
+}
+
+// validateRestore parses the "at" field of a POST /dag/:id/restore body.
+func validateRestore(at string) (time.Time, []fieldError) {
+	if at == "" {
+		return time.Time{}, []fieldError{{Field: "at", Message: "is required"}}
+	}
+	t, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		return time.Time{}, []fieldError{{Field: "at", Message: "must be an RFC 3339 timestamp"}}
+	}
+	return t, nil
+}
+
+// queryFlag reads a boolean query parameter: "1" or "true" turn it on, and
+// an absent parameter, "0" or "false" leave it off.
+func queryFlag(c fiber.Ctx, name string) (bool, error) {
+	v := c.Query(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, validationFailed([]fieldError{{Field: name, Message: "must be true or false"}})
+	}
+	return b, nil
+}
+
+// getDAGOptions reads ?skip= (data, nodes or edges; repeated or
+// comma-separated), ?fields=, ?locale= and ?order=topological into
+// dag.GetDAGOptions. ok is false if nothing is skipped, projected,
+// localized or ordered.
+func getDAGOptions(c fiber.Ctx) (o dag.GetDAGOptions, ok bool, err error) {
+	for _, v := range queryAll(c, "skip") {
+		for part := range strings.SplitSeq(v, ",") {
+			switch part {
+			case "data":
+				o.SkipData = true
+			case "nodes":
+				o.SkipNodes = true
+			case "edges":
+				o.SkipEdges = true
+			default:
+				return o, false, validationFailed([]fieldError{{Field: "skip", Message: "must be data, nodes or edges"}})
+			}
+			ok = true
+		}
+	}
+	if o.Fields = queryFields(c); o.Fields != nil {
+		ok = true
+	}
+	for _, v := range queryAll(c, "locale") {
+		o.Locale = append(o.Locale, strings.Split(v, ",")...)
+	}
+	if o.Locale != nil {
+		ok = true
+	}
+	switch c.Query("order") {
+	case "":
+	case "topological":
+		o.Topological, ok = true, true
+	default:
+		return o, false, validationFailed([]fieldError{{Field: "order", Message: "must be topological"}})
+	}
+	return o, ok, nil
+}
+
+// queryFields reads ?fields= (data paths; repeated or comma-separated).
+// The store validates them.
+func queryFields(c fiber.Ctx) []string {
+	var fields []string
+	for _, v := range queryAll(c, "fields") {
+		fields = append(fields, strings.Split(v, ",")...)
+	}
+	return fields
+}
+
+// searchQuery reads GET /dags query parameters into a dag.SearchQuery.
+func searchQuery(c fiber.Ctx) (dag.SearchQuery, error) {
+	q := dag.SearchQuery{
+		Name:            c.Query("name"),
+		Tags:            queryAll(c, "tag"),
+		AnyTags:         queryAll(c, "any_tag"),
+		Text:            c.Query("q"),
+		IncludeNodeData: c.Query("nodes") == "true",
+	}
+
+	var errs []fieldError
+	if v := c.Query("status"); v != "" {
+		q.Status = dag.Status(v)
+		if !validStatus(q.Status) {
+			errs = append(errs, fieldError{Field: "status", Message: "must be draft, published or archived"})
+		}
+	}
+	if v := c.Query("created_after"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			errs = append(errs, fieldError{Field: "created_after", Message: "must be an RFC 3339 timestamp"})
+		}
+		q.CreatedAfter = t
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxListLimit {
+			errs = append(errs, fieldError{Field: "limit", Message: "must be an integer between 1 and 1000"})
+		}
+		q.Limit = n
+	}
+
+	if len(errs) > 0 {
+		return q, validationFailed(errs)
+	}
+	return q, nil
+}
+
+// defaultExpiredLimit is the number of IDs GET /dags:expired returns
+// without ?limit, as a Reaper sweep asks for.
+const defaultExpiredLimit = 100
+
+// expiredQuery reads ?before= (RFC 3339, default now) and ?limit= of
+// GET /dags:expired.
+func expiredQuery(c fiber.Ctx) (before time.Time, limit int, err error) {
+	var errs []fieldError
+	before = time.Now()
+	if v := c.Query("before"); v != "" {
+		t, parseErr := time.Parse(time.RFC3339, v)
+		if parseErr != nil {
+			errs = append(errs, fieldError{Field: "before", Message: "must be an RFC 3339 timestamp"})
+		}
+		before = t
+	}
+	limit = defaultExpiredLimit
+	if v := c.Query("limit"); v != "" {
+		n, convErr := strconv.Atoi(v)
+		if convErr != nil || n < 1 || n > maxListLimit {
+			errs = append(errs, fieldError{Field: "limit", Message: "must be an integer between 1 and 1000"})
+		}
+		limit = n
+	}
+	if len(errs) > 0 {
+		return before, limit, validationFailed(errs)
+	}
+	return before, limit, nil
+}
+
+// defaultEventLimit is the page size of GET /dag/:id/events without ?limit.
+const defaultEventLimit = 100
+
+// eventsQuery reads ?after= and ?limit= of GET /dag/:id/events.
+func eventsQuery(c fiber.Ctx) (after int64, limit int, err error) {
+	after, limit, errs := eventPage(c)
+	if len(errs) > 0 {
+		return 0, 0, validationFailed(errs)
+	}
+	return after, limit, nil
+}
+
+// eventPage reads ?after= and ?limit= of an event listing.
+func eventPage(c fiber.Ctx) (after int64, limit int, errs []fieldError) {
+	if v := c.Query("after"); v != "" {
+		n, convErr := strconv.ParseInt(v, 10, 64)
+		if convErr != nil || n < 0 {
+			errs = append(errs, fieldError{Field: "after", Message: "must be a non-negative integer"})
+		}
+		after = n
+	}
+	limit = defaultEventLimit
+	if v := c.Query("limit"); v != "" {
+		n, convErr := strconv.Atoi(v)
+		if convErr != nil || n < 1 || n > maxListLimit {
+			errs = append(errs, fieldError{Field: "limit", Message: "must be an integer between 1 and 1000"})
+		}
+		limit = n
+	}
+	return after, limit, errs
+}
+
+// auditQuery reads GET /audit query parameters into a dag.AuditFilter:
+// dag_id, actor, type (repeated or comma-separated), from, to, after and
+// limit.
+func auditQuery(c fiber.Ctx) (dag.AuditFilter, error) {
+	f := dag.AuditFilter{DAGID: c.Query("dag_id"), Actor: c.Query("actor")}
+	var errs []fieldError
+	f.AfterSeq, f.Limit, errs = eventPage(c)
+	for _, v := range queryAll(c, "type") {
+		for _, t := range strings.Split(v, ",") {
+			if !validEventType(dag.EventType(t)) {
+				errs = append(errs, fieldError{Field: "type", Message: "must be created, deleted, changed, reordered, tags, settings or status"})
+				break
+			}
+			f.Types = append(f.Types, dag.EventType(t))
+		}
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		if v := c.Query(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				errs = append(errs, fieldError{Field: p.name, Message: "must be an RFC 3339 timestamp"})
+			}
+			*p.dst = t
+		}
+	}
+	if len(errs) > 0 {
+		return f, validationFailed(errs)
+	}
+	return f, nil
+}
+
+func validEventType(t dag.EventType) bool {
+	switch t {
+	case dag.EventCreated, dag.EventDeleted, dag.EventChanged, dag.EventReordered,
+		dag.EventTags, dag.EventSettings, dag.EventStatus:
+		return true
+	}
+	return false
+}
+
+// validateTags checks the body of POST /dag/:id/tags.
+func validateTags(tags []string) []fieldError {
+	if len(tags) == 0 {
+		return []fieldError{{Field: "tags", Message: "must contain at least one tag"}}
+	}
+	var errs []fieldError
+	for i, t := range tags {
+		if t == "" {
+			errs = append(errs, fieldError{Field: fmt.Sprintf("tags[%d]", i), Message: "must not be empty"})
+		}
+	}
+	return errs
+}
+
+// queryAll returns every value of a repeated query parameter (?tag=a&tag=b).
+func queryAll(c fiber.Ctx, key string) []string {
+	var out []string
+	for _, v := range c.RequestCtx().QueryArgs().PeekMulti(key) {
+		out = append(out, string(v))
+	}
+	return out
+}
+
+func validStatus(s dag.Status) bool {
+	return s == dag.StatusDraft || s == dag.StatusPublished || s == dag.StatusArchived
+}
+
+func join(prefix, field string) string {
+	if prefix == "" {
+		return field
+	}
+	return prefix + "." + field
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// apiV1 is the path prefix of the current API version.
+const apiV1 = "/v1"
+
+// apiVersion returns a group middleware that stamps every response with
+// the API version that served it.
+func apiVersion(v string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		c.Set("API-Version", v)
+		return c.Next()
+	}
+}
+
+// deprecated returns a middleware that marks responses as deprecated
+// (Deprecation header, RFC 9745) and points at the successor via Link.
+// A non-zero sunset adds a Sunset header (RFC 8594) announcing removal.
+// Attach it to a version group once its successor ships:
+//
+//	v1 := app.Group("/v1", apiVersion("1"), deprecated("/v2", sunset))
+func deprecated(successor string, sunset time.Time) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		markDeprecated(c, successor, sunset)
+		return c.Next()
+	}
+}
+
+// markDeprecated sets the Deprecation, Link and (optional) Sunset headers.
+func markDeprecated(c fiber.Ctx, successor string, sunset time.Time) {
+	c.Set("Deprecation", "true")
+	c.Set(fiber.HeaderLink, "<"+successor+">; rel=\"successor-version\"")
+	if !sunset.IsZero() {
+		c.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+	}
+}
+
+// legacyRewrite serves unversioned paths (the API before versioning) from
+// the given version prefix. Responses are marked deprecated with a Link to
+// the versioned path, so old clients keep working while they migrate.
+func legacyRewrite(prefix string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		p := c.Path()
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return c.Next()
+		}
+		markDeprecated(c, prefix+p, time.Time{})
+		c.Path(prefix + p)
+		return c.Next()
+	}
+}
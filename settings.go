@@ -0,0 +1,227 @@
+package dag
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Settings are per-DAG structural rules the store enforces on every write.
+// The zero value allows everything, which is how DAGs behave by default.
+type Settings struct {
+	// NoParallelEdges forbids more than one edge between the same ordered
+	// pair of nodes. When false the DAG is a multigraph: parallel edges are
+	// stored and traversed like any other edge.
+	NoParallelEdges bool `json:"no_parallel_edges,omitempty"`
+	// Tree allows at most one incoming edge per node. Together with
+	// SingleRoot it makes the DAG a rooted tree.
+	Tree bool `json:"tree,omitempty"`
+	// SingleRoot requires exactly one node without incoming edges.
+	SingleRoot bool `json:"single_root,omitempty"`
+	// Connected requires every node to be reachable from every other when
+	// edge direction is ignored.
+	Connected bool `json:"connected,omitempty"`
+	// MaxDepth caps the number of edges on any path. 0 means unlimited.
+	MaxDepth int `json:"max_depth,omitempty"`
+	// DistinctConditions names the edge data key that holds a branch's
+	// condition, such as "answer". When set, the edges leaving a node must
+	// have different values under it, so an answer never matches two
+	// branches. An edge without the key, or with null, is the node's
+	// default branch, and a node may have one.
+	DistinctConditions string `json:"distinct_conditions,omitempty"`
+}
+
+// CheckEdges returns ErrParallelEdge if edges break NoParallelEdges, or
+// ErrDuplicateCondition if they break DistinctConditions.
+func (s Settings) CheckEdges(edges []Edge) error {
+	if err := s.checkConditions(edges); err != nil {
+		return err
+	}
+	if !s.NoParallelEdges {
+		return nil
+	}
+	type pair struct{ from, to string }
+	seen := make(map[pair]string, len(edges))
+	for _, e := range edges {
+		p := pair{e.FromNodeID, e.ToNodeID}
+		if id, ok := seen[p]; ok {
+			return fmt.Errorf("%w: edges %s and %s both go from %s to %s", ErrParallelEdge, id, e.ID, e.FromNodeID, e.ToNodeID)
+		}
+		seen[p] = e.ID
+	}
+	return nil
+}
+
+// CheckGraph checks a whole graph, as passed to CreateDAG, against every
+// rule in s. It returns ErrDuplicateCondition, ErrParallelEdge, ErrNotTree,
+// ErrMultipleRoots, ErrDisconnected or ErrTooDeep for the first rule
+// broken. An empty graph passes. The graph must be acyclic.
+func (s Settings) CheckGraph(nodes []Node, edges []Edge) error {
+	if err := s.CheckEdges(edges); err != nil {
+		return err
+	}
+	if err := s.checkTree(edges); err != nil {
+		return err
+	}
+	if s.SingleRoot && len(nodes) > 0 {
+		if n := len(roots(nodes, edges)); n != 1 {
+			return fmt.Errorf("%w: found %d", ErrMultipleRoots, n)
+		}
+	}
+	if s.Connected && len(nodes) > 0 {
+		if n := components(nodes, edges); n != 1 {
+			return fmt.Errorf("%w: found %d components", ErrDisconnected, n)
+		}
+	}
+	if s.MaxDepth > 0 {
+		if n := Depth(edges); n > s.MaxDepth {
+			return fmt.Errorf("%w: longest path has %d edges, max is %d", ErrTooDeep, n, s.MaxDepth)
+		}
+	}
+	return nil
+}
+
+// CheckChange checks an edge write that turns the DAG's edges from before
+// into after. DistinctConditions, NoParallelEdges and Tree must hold
+// afterwards; SingleRoot and
+// Connected only fail if the write adds roots or components, because a DAG
+// built node by node has several of both until its edges are in place.
+// MaxDepth is left to the store, which checks it with a bounded query
+// around the written edge instead of walking the whole graph.
+func (s Settings) CheckChange(nodes []Node, before, after []Edge) error {
+	if err := s.CheckEdges(after); err != nil {
+		return err
+	}
+	if err := s.checkTree(after); err != nil {
+		return err
+	}
+	if s.SingleRoot {
+		if b, a := len(roots(nodes, before)), len(roots(nodes, after)); a > 1 && a > b {
+			return fmt.Errorf("%w: write would leave %d", ErrMultipleRoots, a)
+		}
+	}
+	if s.Connected {
+		if b, a := components(nodes, before), components(nodes, after); a > 1 && a > b {
+			return fmt.Errorf("%w: write would leave %d components", ErrDisconnected, a)
+		}
+	}
+	return nil
+}
+
+// checkConditions returns ErrDuplicateCondition if DistinctConditions is
+// set and two edges leaving the same node have the same condition.
+func (s Settings) checkConditions(edges []Edge) error {
+	if s.DistinctConditions == "" {
+		return nil
+	}
+	type branch struct{ from, cond string }
+	seen := make(map[branch]string, len(edges))
+	for _, e := range edges {
+		b := branch{e.FromNodeID, conditionOf(e.Data, s.DistinctConditions)}
+		id, ok := seen[b]
+		switch {
+		case !ok:
+			seen[b] = e.ID
+		case b.cond == "":
+			return fmt.Errorf("%w: edges %s and %s from %s both have no %q", ErrDuplicateCondition, id, e.ID, e.FromNodeID, s.DistinctConditions)
+		default:
+			return fmt.Errorf("%w: edges %s and %s from %s both have %q %s", ErrDuplicateCondition, id, e.ID, e.FromNodeID, s.DistinctConditions, b.cond)
+		}
+	}
+	return nil
+}
+
+// conditionOf returns the value under key in the JSON object data,
+// re-encoded so that equal values compare equal whatever their spacing or
+// key order, or "" if data has no such value or it is null.
+func conditionOf(data json.RawMessage, key string) string {
+	var m map[string]any
+	if json.Unmarshal(data, &m) != nil || m[key] == nil {
+		return ""
+	}
+	b, _ := json.Marshal(m[key])
+	return string(b)
+}
+
+// checkTree returns ErrNotTree if Tree is set and a node has two parents.
+func (s Settings) checkTree(edges []Edge) error {
+	if !s.Tree {
+		return nil
+	}
+	parent := make(map[string]string, len(edges))
+	for _, e := range edges {
+		if p, ok := parent[e.ToNodeID]; ok {
+			return fmt.Errorf("%w: %s has parents %s and %s", ErrNotTree, e.ToNodeID, p, e.FromNodeID)
+		}
+		parent[e.ToNodeID] = e.FromNodeID
+	}
+	return nil
+}
+
+// Depth returns the number of edges on the longest path through an acyclic
+// graph.
+func Depth(edges []Edge) int {
+	adj := make(map[string][]string)
+	for _, e := range edges {
+		adj[e.FromNodeID] = append(adj[e.FromNodeID], e.ToNodeID)
+	}
+	memo := make(map[string]int, len(adj))
+	var longest func(string) int
+	longest = func(id string) int {
+		if d, ok := memo[id]; ok {
+			return d
+		}
+		d := 0
+		for _, next := range adj[id] {
+			d = max(d, longest(next)+1)
+		}
+		memo[id] = d
+		return d
+	}
+	depth := 0
+	for id := range adj {
+		depth = max(depth, longest(id))
+	}
+	return depth
+}
+
+// roots returns the IDs of nodes without incoming edges, in node order.
+func roots(nodes []Node, edges []Edge) []string {
+	hasParent := make(map[string]bool, len(edges))
+	for _, e := range edges {
+		hasParent[e.ToNodeID] = true
+	}
+	var out []string
+	for _, n := range nodes {
+		if !hasParent[n.ID] {
+			out = append(out, n.ID)
+		}
+	}
+	return out
+}
+
+// components counts the weakly connected components of the graph.
+func components(nodes []Node, edges []Edge) int {
+	parent := make(map[string]string, len(nodes))
+	var find func(string) string
+	find = func(id string) string {
+		p, ok := parent[id]
+		if !ok || p == id {
+			return id
+		}
+		r := find(p)
+		parent[id] = r
+		return r
+	}
+	for _, n := range nodes {
+		parent[n.ID] = n.ID
+	}
+	n := len(nodes)
+	for _, e := range edges {
+		a, b := find(e.FromNodeID), find(e.ToNodeID)
+		if a != b {
+			parent[a] = b
+			n--
+		}
+	}
+	return n
+}
@@ -0,0 +1,439 @@
+// Package shard spreads DAGs over several stores, typically one PGStore per
+// Postgres cluster, for deployments too large for a single database.
+//
+//	s := shard.New(postgres.New(poolA), postgres.New(poolB), postgres.New(poolC))
+//	s.CreateDAG(ctx, d)        // written to the shard d.ID hashes to
+//	s.SearchDAGs(ctx, q)       // asked of every shard, results merged
+//
+// A DAG lives entirely on one shard, together with its draft.
+package shard
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meikuraledutech/dag"
+)
+
+// Store is a dag.Store that routes each DAG to one of Shards.
+//
+// Calls that name a DAG go to its shard only. Calls that name just a node
+// or edge (GetNode, UpdateEdge, Ancestors, ...) first ask every shard which
+// one holds it, so prefer the DAG-scoped calls on hot paths. CreateSchema,
+// DropSchema, SearchDAGs and ExpiredDAGs run on every shard.
+type Store struct {
+	Shards []dag.Store
+	// Route returns the index in Shards of the shard that holds dagID.
+	Route func(dagID string) int
+}
+
+var _ dag.Store = (*Store)(nil)
+
+// New routes DAGs over shards by hash of their ID.
+func New(shards ...dag.Store) *Store {
+	return &Store{Shards: shards, Route: HashRoute(len(shards))}
+}
+
+// HashRoute spreads DAG IDs evenly over n shards by their FNV-1a hash.
+// Changing n moves most DAGs, so pick it with room to grow, or move DAGs
+// between clusters with Dump and Restore when it changes.
+func HashRoute(n int) func(dagID string) int {
+	return func(dagID string) int {
+		h := fnv.New32a()
+		h.Write([]byte(dagID))
+		return int(h.Sum32() % uint32(n))
+	}
+}
+
+// RangeRoute assigns DAG IDs to shards by range: shard i holds the IDs
+// below bounds[i] and at or above bounds[i-1], and the last shard holds
+// the IDs at or above the last bound. bounds must be sorted, and there is
+// one more shard than bounds. With tenant-prefixed IDs such as
+// "acme/onboarding", a bound per tenant pins tenants to shards.
+func RangeRoute(bounds ...string) func(dagID string) int {
+	return func(dagID string) int {
+		i, found := slices.BinarySearch(bounds, dagID)
+		if found {
+			i++
+		}
+		return i
+	}
+}
+
+// For returns the shard that holds dagID. A draft is kept on the shard of
+// the DAG it was created from.
+func (s *Store) For(dagID string) dag.Store {
+	return s.Shards[s.index(dagID)]
+}
+
+// index returns the position in Shards of the shard that holds dagID.
+func (s *Store) index(dagID string) int {
+	return s.Route(strings.TrimSuffix(dagID, dag.DraftSuffix))
+}
+
+// each calls fn on every shard concurrently and joins their errors.
+func (s *Store) each(fn func(i int, sh dag.Store) error) error {
+	errs := make([]error, len(s.Shards))
+	var wg sync.WaitGroup
+	for i, sh := range s.Shards {
+		wg.Go(func() { errs[i] = fn(i, sh) })
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// locate returns the shard on which has reports true, or nil if none does.
+func (s *Store) locate(has func(sh dag.Store) (bool, error)) (dag.Store, error) {
+	found := make([]bool, len(s.Shards))
+	err := s.each(func(i int, sh dag.Store) error {
+		ok, err := has(sh)
+		found[i] = ok
+		return err
+	})
+	if i := slices.Index(found, true); i >= 0 {
+		return s.Shards[i], nil
+	}
+	return nil, err
+}
+
+// nodeShard returns the shard holding nodeID, or nil if none does.
+func (s *Store) nodeShard(ctx context.Context, nodeID string) (dag.Store, error) {
+	return s.locate(func(sh dag.Store) (bool, error) {
+		n, err := sh.GetNode(ctx, nodeID)
+		return n != nil, err
+	})
+}
+
+// edgeShard returns the shard holding edgeID, or nil if none does.
+func (s *Store) edgeShard(ctx context.Context, edgeID string) (dag.Store, error) {
+	return s.locate(func(sh dag.Store) (bool, error) {
+		e, err := sh.GetEdge(ctx, edgeID)
+		return e != nil, err
+	})
+}
+
+// --- Schema ---
+
+// CreateSchema creates the schema on every shard.
+func (s *Store) CreateSchema(ctx context.Context) error {
+	return s.each(func(_ int, sh dag.Store) error { return sh.CreateSchema(ctx) })
+}
+
+// DropSchema drops the schema on every shard.
+func (s *Store) DropSchema(ctx context.Context) error {
+	return s.each(func(_ int, sh dag.Store) error { return sh.DropSchema(ctx) })
+}
+
+// --- DAG ---
+
+func (s *Store) CreateDAG(ctx context.Context, d *dag.DAG, opts ...dag.CreateDAGOptions) (*dag.DAG, error) {
+	return s.For(d.ID).CreateDAG(ctx, d, opts...)
+}
+
+func (s *Store) GetDAG(ctx context.Context, dagID string, opts ...dag.GetDAGOptions) (*dag.DAG, error) {
+	return s.For(dagID).GetDAG(ctx, dagID, opts...)
+}
+
+func (s *Store) StreamDAG(ctx context.Context, dagID string, onNode func(dag.Node) error, onEdge func(dag.Edge) error) error {
+	return s.For(dagID).StreamDAG(ctx, dagID, onNode, onEdge)
+}
+
+// GetDAGs asks each shard for its share of dagIDs, concurrently.
+func (s *Store) GetDAGs(ctx context.Context, dagIDs []string, opts ...dag.GetDAGOptions) (map[string]*dag.DAG, error) {
+	ids := make([][]string, len(s.Shards))
+	for _, id := range dagIDs {
+		i := s.index(id)
+		ids[i] = append(ids[i], id)
+	}
+	parts := make([]map[string]*dag.DAG, len(s.Shards))
+	err := s.each(func(i int, sh dag.Store) error {
+		if len(ids[i]) == 0 {
+			return nil
+		}
+		var err error
+		parts[i], err = sh.GetDAGs(ctx, ids[i], opts...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]*dag.DAG, len(dagIDs))
+	for _, p := range parts {
+		maps.Copy(out, p)
+	}
+	return out, nil
+}
+
+func (s *Store) DeleteDAG(ctx context.Context, dagID string) error {
+	return s.For(dagID).DeleteDAG(ctx, dagID)
+}
+
+func (s *Store) GetDAGInfo(ctx context.Context, dagID string) (*dag.DAGInfo, error) {
+	return s.For(dagID).GetDAGInfo(ctx, dagID)
+}
+
+func (s *Store) DAGExists(ctx context.Context, dagID string) (bool, error) {
+	return s.For(dagID).DAGExists(ctx, dagID)
+}
+
+// CountDAGs adds up the counts of every shard.
+func (s *Store) CountDAGs(ctx context.Context, q dag.SearchQuery) (int, error) {
+	counts := make([]int, len(s.Shards))
+	err := s.each(func(i int, sh dag.Store) error {
+		var err error
+		counts[i], err = sh.CountDAGs(ctx, q)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, c := range counts {
+		n += c
+	}
+	return n, nil
+}
+
+// SearchDAGs searches every shard and merges the results in the order a
+// single store returns them: by rank, then newest first.
+func (s *Store) SearchDAGs(ctx context.Context, q dag.SearchQuery) ([]dag.SearchResult, error) {
+	parts := make([][]dag.SearchResult, len(s.Shards))
+	err := s.each(func(i int, sh dag.Store) error {
+		var err error
+		parts[i], err = sh.SearchDAGs(ctx, q)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := slices.Concat(parts...)
+	slices.SortStableFunc(out, func(a, b dag.SearchResult) int {
+		return cmp.Or(
+			cmp.Compare(b.Rank, a.Rank),
+			b.CreatedAt.Compare(a.CreatedAt),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	return out[:min(limit, len(out))], nil
+}
+
+// ExpiredDAGs collects up to limit expired DAG IDs from all shards. Each
+// shard's IDs come oldest first, but the shards are not interleaved.
+func (s *Store) ExpiredDAGs(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	parts := make([][]string, len(s.Shards))
+	err := s.each(func(i int, sh dag.Store) error {
+		var err error
+		parts[i], err = sh.ExpiredDAGs(ctx, before, limit)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := slices.Concat(parts...)
+	return out[:min(limit, len(out))], nil
+}
+
+func (s *Store) AddDAGTags(ctx context.Context, dagID string, tags ...string) ([]string, error) {
+	return s.For(dagID).AddDAGTags(ctx, dagID, tags...)
+}
+
+func (s *Store) RemoveDAGTags(ctx context.Context, dagID string, tags ...string) ([]string, error) {
+	return s.For(dagID).RemoveDAGTags(ctx, dagID, tags...)
+}
+
+func (s *Store) UpdateSettings(ctx context.Context, dagID string, settings dag.Settings) error {
+	return s.For(dagID).UpdateSettings(ctx, dagID, settings)
+}
+
+// --- Lifecycle ---
+
+func (s *Store) PublishDAG(ctx context.Context, dagID string) error {
+	return s.For(dagID).PublishDAG(ctx, dagID)
+}
+
+func (s *Store) ArchiveDAG(ctx context.Context, dagID string) error {
+	return s.For(dagID).ArchiveDAG(ctx, dagID)
+}
+
+func (s *Store) CreateDraft(ctx context.Context, dagID string) (*dag.DAG, error) {
+	return s.For(dagID).CreateDraft(ctx, dagID)
+}
+
+func (s *Store) PromoteDraft(ctx context.Context, dagID string) (*dag.DAG, error) {
+	return s.For(dagID).PromoteDraft(ctx, dagID)
+}
+
+// --- Node ---
+
+func (s *Store) AddNode(ctx context.Context, dagID string, node *dag.Node) (string, error) {
+	return s.For(dagID).AddNode(ctx, dagID, node)
+}
+
+func (s *Store) GetNode(ctx context.Context, nodeID string) (*dag.Node, error) {
+	var mu sync.Mutex
+	var found *dag.Node
+	err := s.each(func(_ int, sh dag.Store) error {
+		n, err := sh.GetNode(ctx, nodeID)
+		if n != nil {
+			mu.Lock()
+			found = n
+			mu.Unlock()
+		}
+		return err
+	})
+	if found != nil {
+		return found, nil
+	}
+	return nil, err
+}
+
+func (s *Store) UpdateNode(ctx context.Context, node *dag.Node) error {
+	sh, err := s.nodeShard(ctx, node.ID)
+	if sh == nil {
+		return cmp.Or(err, dag.ErrNodeNotFound)
+	}
+	return sh.UpdateNode(ctx, node)
+}
+
+func (s *Store) DeleteNode(ctx context.Context, nodeID string) error {
+	sh, err := s.nodeShard(ctx, nodeID)
+	if sh == nil {
+		return err
+	}
+	return sh.DeleteNode(ctx, nodeID)
+}
+
+func (s *Store) ListNodes(ctx context.Context, dagID string) ([]dag.Node, error) {
+	return s.For(dagID).ListNodes(ctx, dagID)
+}
+
+func (s *Store) FindNodesByTag(ctx context.Context, dagID, tag string) ([]dag.Node, error) {
+	return s.For(dagID).FindNodesByTag(ctx, dagID, tag)
+}
+
+func (s *Store) FindEdges(ctx context.Context, dagID string, filter dag.EdgeFilter) ([]dag.Edge, error) {
+	return s.For(dagID).FindEdges(ctx, dagID, filter)
+}
+
+func (s *Store) CountNodes(ctx context.Context, dagID string) (int, error) {
+	return s.For(dagID).CountNodes(ctx, dagID)
+}
+
+func (s *Store) ListNodesPage(ctx context.Context, dagID string, opts dag.ListOptions) (*dag.Page[dag.Node], error) {
+	return s.For(dagID).ListNodesPage(ctx, dagID, opts)
+}
+
+func (s *Store) AddNodes(ctx context.Context, dagID string, nodes []dag.Node) ([]dag.BatchResult, error) {
+	return s.For(dagID).AddNodes(ctx, dagID, nodes)
+}
+
+// --- Edge ---
+
+func (s *Store) AddEdge(ctx context.Context, dagID string, edge *dag.Edge) (string, error) {
+	return s.For(dagID).AddEdge(ctx, dagID, edge)
+}
+
+func (s *Store) GetEdge(ctx context.Context, edgeID string) (*dag.Edge, error) {
+	var mu sync.Mutex
+	var found *dag.Edge
+	err := s.each(func(_ int, sh dag.Store) error {
+		e, err := sh.GetEdge(ctx, edgeID)
+		if e != nil {
+			mu.Lock()
+			found = e
+			mu.Unlock()
+		}
+		return err
+	})
+	if found != nil {
+		return found, nil
+	}
+	return nil, err
+}
+
+func (s *Store) UpdateEdge(ctx context.Context, edge *dag.Edge) error {
+	sh, err := s.edgeShard(ctx, edge.ID)
+	if sh == nil {
+		return cmp.Or(err, dag.ErrEdgeNotFound)
+	}
+	return sh.UpdateEdge(ctx, edge)
+}
+
+func (s *Store) DeleteEdge(ctx context.Context, edgeID string) error {
+	sh, err := s.edgeShard(ctx, edgeID)
+	if sh == nil {
+		return err
+	}
+	return sh.DeleteEdge(ctx, edgeID)
+}
+
+func (s *Store) ListEdges(ctx context.Context, dagID string) ([]dag.Edge, error) {
+	return s.For(dagID).ListEdges(ctx, dagID)
+}
+
+func (s *Store) CountEdges(ctx context.Context, dagID string) (int, error) {
+	return s.For(dagID).CountEdges(ctx, dagID)
+}
+
+func (s *Store) ListEdgesPage(ctx context.Context, dagID string, opts dag.ListOptions) (*dag.Page[dag.Edge], error) {
+	return s.For(dagID).ListEdgesPage(ctx, dagID, opts)
+}
+
+func (s *Store) AddEdges(ctx context.Context, dagID string, edges []dag.Edge) ([]dag.BatchResult, error) {
+	return s.For(dagID).AddEdges(ctx, dagID, edges)
+}
+
+func (s *Store) ReorderEdges(ctx context.Context, fromNodeID string, edgeIDs []string) error {
+	sh, err := s.nodeShard(ctx, fromNodeID)
+	if sh == nil {
+		return cmp.Or(err, dag.ErrNodeNotFound)
+	}
+	return sh.ReorderEdges(ctx, fromNodeID, edgeIDs)
+}
+
+// --- Change sets ---
+
+func (s *Store) ApplyChangeSet(ctx context.Context, dagID string, cs *dag.ChangeSet) error {
+	return s.For(dagID).ApplyChangeSet(ctx, dagID, cs)
+}
+
+// --- Queries ---
+
+func (s *Store) Ancestors(ctx context.Context, nodeID string) ([]dag.Node, error) {
+	sh, err := s.nodeShard(ctx, nodeID)
+	if sh == nil {
+		return nil, cmp.Or(err, dag.ErrNodeNotFound)
+	}
+	return sh.Ancestors(ctx, nodeID)
+}
+
+func (s *Store) Descendants(ctx context.Context, nodeID string) ([]dag.Node, error) {
+	sh, err := s.nodeShard(ctx, nodeID)
+	if sh == nil {
+		return nil, cmp.Or(err, dag.ErrNodeNotFound)
+	}
+	return sh.Descendants(ctx, nodeID)
+}
+
+func (s *Store) Neighborhood(ctx context.Context, nodeID string, k int, dir dag.Direction) (*dag.DAG, error) {
+	sh, err := s.nodeShard(ctx, nodeID)
+	if sh == nil {
+		return nil, cmp.Or(err, dag.ErrNodeNotFound)
+	}
+	return sh.Neighborhood(ctx, nodeID, k, dir)
+}
+
+func (s *Store) Path(ctx context.Context, dagID, fromID, toID string) ([]dag.Node, error) {
+	return s.For(dagID).Path(ctx, dagID, fromID, toID)
+}
@@ -0,0 +1,132 @@
+package dag
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// SplitSpec says how SplitNode divides a node in two: the node stays and
+// is joined by Edge to the new Node that follows it, and the edges listed
+// in Incoming and Outgoing move from the node to the new one. Edges not
+// listed stay where they are.
+type SplitSpec struct {
+	// Node is the new node. Its ID is generated if empty.
+	Node Node `json:"node"`
+	// Edge is the edge from the node to the new node; only its ID and
+	// data are used. Its ID is generated if empty and its data defaults
+	// to {}.
+	Edge Edge `json:"edge"`
+	// Incoming lists edges into the node that point at the new node
+	// instead.
+	Incoming []string `json:"incoming,omitempty"`
+	// Outgoing lists edges out of the node that leave from the new node
+	// instead.
+	Outgoing []string `json:"outgoing,omitempty"`
+}
+
+// PlanSplit returns the change set that splits nodeID as spec says: it
+// adds spec.Node and spec.Edge and updates the moved edges. Outgoing edges
+// moved to the new node keep their relative order. spec.Node needs an ID
+// or a Ref for the edges to point at. The change set is not checked for
+// cycles, which moving both incoming and outgoing edges can close.
+// ErrNodeNotFound is returned if nodeID is not in d, and ErrEdgeNotFound
+// if a listed edge is not an edge into or out of it.
+func PlanSplit(d *DAG, nodeID string, spec SplitSpec) (*ChangeSet, error) {
+	if !slices.ContainsFunc(d.Nodes, func(n Node) bool { return n.ID == nodeID }) {
+		return nil, fmt.Errorf("%w: %s", ErrNodeNotFound, nodeID)
+	}
+	byID := make(map[string]Edge, len(d.Edges))
+	for _, e := range d.Edges {
+		byID[e.ID] = e
+	}
+	for _, id := range spec.Incoming {
+		if e, ok := byID[id]; !ok || e.ToNodeID != nodeID {
+			return nil, fmt.Errorf("%w: %s is not an edge into %s", ErrEdgeNotFound, id, nodeID)
+		}
+	}
+	for _, id := range spec.Outgoing {
+		if e, ok := byID[id]; !ok || e.FromNodeID != nodeID {
+			return nil, fmt.Errorf("%w: %s is not an edge out of %s", ErrEdgeNotFound, id, nodeID)
+		}
+	}
+
+	data := spec.Edge.Data
+	if len(data) == 0 {
+		data = json.RawMessage(`{}`)
+	}
+	cs := &ChangeSet{
+		AddNodes: []Node{spec.Node},
+		AddEdges: []Edge{{ID: spec.Edge.ID, FromNodeID: nodeID, ToNodeID: spec.Node.ID, ToNodeRef: spec.Node.Ref, Data: data}},
+	}
+	// Walk d.Edges rather than the lists, so moved outgoing edges are
+	// written, and ordered under the new node, in their current order.
+	for _, e := range d.Edges {
+		switch {
+		case slices.Contains(spec.Incoming, e.ID):
+			e.ToNodeID, e.ToNodeRef = spec.Node.ID, spec.Node.Ref
+		case slices.Contains(spec.Outgoing, e.ID):
+			e.FromNodeID, e.FromNodeRef = spec.Node.ID, spec.Node.Ref
+		default:
+			continue
+		}
+		cs.UpdateEdges = append(cs.UpdateEdges, e)
+	}
+	return cs, nil
+}
+
+// EdgeDataPlacement says which of the two edges that InsertNodeOnEdge
+// leaves in place of an edge gets that edge's data. The other gets {}.
+type EdgeDataPlacement string
+
+const (
+	// DataOnIncoming gives the data to the edge into the new node.
+	DataOnIncoming EdgeDataPlacement = "incoming"
+	// DataOnOutgoing gives the data to the edge out of the new node.
+	DataOnOutgoing EdgeDataPlacement = "outgoing"
+	// DataOnBoth copies the data to both edges.
+	DataOnBoth EdgeDataPlacement = "both"
+)
+
+// Valid reports whether p is one of the placements.
+func (p EdgeDataPlacement) Valid() bool {
+	return p == DataOnIncoming || p == DataOnOutgoing || p == DataOnBoth
+}
+
+// InsertNodeOptions configures InsertNodeOnEdge.
+type InsertNodeOptions struct {
+	// EdgeData says where the split edge's data goes. Empty means
+	// DataOnIncoming.
+	EdgeData EdgeDataPlacement
+}
+
+// PlanInsertOnEdge returns the change set that replaces edge A → B with
+// A → n → B. The edge keeps its ID and its place among A's outgoing edges
+// and now points at n; a new edge, with an ID generated on apply, joins n
+// to B. n needs an ID or a Ref. ErrEdgeNotFound is returned if edgeID is
+// not in d, and an error if p is neither empty nor a valid placement.
+func PlanInsertOnEdge(d *DAG, edgeID string, n Node, p EdgeDataPlacement) (*ChangeSet, error) {
+	if p == "" {
+		p = DataOnIncoming
+	}
+	if !p.Valid() {
+		return nil, fmt.Errorf("dag: unknown edge data placement %q", p)
+	}
+	i := slices.IndexFunc(d.Edges, func(e Edge) bool { return e.ID == edgeID })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrEdgeNotFound, edgeID)
+	}
+	e := d.Edges[i]
+	in, out := e.Data, json.RawMessage(`{}`)
+	switch p {
+	case DataOnOutgoing:
+		in, out = out, in
+	case DataOnBoth:
+		out = in
+	}
+	return &ChangeSet{
+		AddNodes:    []Node{n},
+		UpdateEdges: []Edge{{ID: e.ID, FromNodeID: e.FromNodeID, ToNodeID: n.ID, ToNodeRef: n.Ref, Data: in}},
+		AddEdges:    []Edge{{FromNodeID: n.ID, FromNodeRef: n.Ref, ToNodeID: e.ToNodeID, Data: out}},
+	}, nil
+}
@@ -3,12 +3,37 @@ package dag
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 var (
-	ErrCycleDetected = errors.New("dag: cycle detected, graph is not acyclic")
-	ErrNodeNotFound  = errors.New("dag: node not found")
-	ErrEdgeNotFound  = errors.New("dag: edge not found")
+	ErrCycleDetected      = errors.New("dag: cycle detected, graph is not acyclic")
+	ErrNodeNotFound       = errors.New("dag: node not found")
+	ErrEdgeNotFound       = errors.New("dag: edge not found")
+	ErrInvalidCursor      = errors.New("dag: invalid cursor")
+	ErrInvalidSort        = errors.New("dag: invalid sort")
+	ErrQuotaExceeded      = errors.New("dag: quota exceeded")
+	ErrNoVersion          = errors.New("dag: no version at that time")
+	ErrDAGNotFound        = errors.New("dag: dag not found")
+	ErrDAGFrozen          = errors.New("dag: dag is frozen")
+	ErrInvalidOrder       = errors.New("dag: edge order must list every outgoing edge exactly once")
+	ErrParallelEdge       = errors.New("dag: parallel edge between the same nodes")
+	ErrDuplicateCondition = errors.New("dag: edges from the same node share a condition")
+	ErrNotTree            = errors.New("dag: node has more than one parent")
+	ErrMultipleRoots      = errors.New("dag: dag must have exactly one root")
+	ErrDisconnected       = errors.New("dag: dag is not connected")
+	ErrTooDeep            = errors.New("dag: dag exceeds its maximum depth")
+	ErrInvalidData        = errors.New("dag: data does not match its schema")
+	ErrUnknownNodeType    = errors.New("dag: unknown node type")
+	ErrOutDegree          = errors.New("dag: node has too many outgoing edges")
+	ErrDAGExists          = errors.New("dag: dag already exists")
+	ErrInvalidField       = errors.New("dag: invalid field")
+	ErrInvalidFilter      = errors.New("dag: invalid filter")
+	ErrInvalidHops        = errors.New("dag: invalid neighborhood")
+	ErrInvalidMerge       = errors.New("dag: invalid merge")
+	ErrInvalidSubgraph    = errors.New("dag: invalid replacement subgraph")
+	ErrInvalidDuration    = errors.New("dag: invalid duration")
+	ErrLocked             = errors.New("dag: locked by another owner")
 )
 
 // Store defines the contract for persisting and retrieving DAGs.
@@ -18,9 +43,29 @@ type Store interface {
 	DropSchema(ctx context.Context) error
 
 	// DAG (bulk operations)
-	CreateDAG(ctx context.Context, d *DAG) (*DAG, error)
-	GetDAG(ctx context.Context, dagID string) (*DAG, error)
+	CreateDAG(ctx context.Context, d *DAG, opts ...CreateDAGOptions) (*DAG, error)
+	GetDAG(ctx context.Context, dagID string, opts ...GetDAGOptions) (*DAG, error)
+	GetDAGs(ctx context.Context, dagIDs []string, opts ...GetDAGOptions) (map[string]*DAG, error)
+	// StreamDAG calls onNode for each node and then onEdge for each edge,
+	// in GetDAG's order, as rows are read, so memory use does not grow
+	// with the DAG. A nil callback skips that half. An error from a
+	// callback stops the read and is returned as-is.
+	StreamDAG(ctx context.Context, dagID string, onNode func(Node) error, onEdge func(Edge) error) error
 	DeleteDAG(ctx context.Context, dagID string) error
+	GetDAGInfo(ctx context.Context, dagID string) (*DAGInfo, error)
+	DAGExists(ctx context.Context, dagID string) (bool, error)
+	CountDAGs(ctx context.Context, q SearchQuery) (int, error)
+	SearchDAGs(ctx context.Context, q SearchQuery) ([]SearchResult, error)
+	ExpiredDAGs(ctx context.Context, before time.Time, limit int) ([]string, error)
+	AddDAGTags(ctx context.Context, dagID string, tags ...string) ([]string, error)
+	RemoveDAGTags(ctx context.Context, dagID string, tags ...string) ([]string, error)
+	UpdateSettings(ctx context.Context, dagID string, s Settings) error
+
+	// Lifecycle
+	PublishDAG(ctx context.Context, dagID string) error
+	ArchiveDAG(ctx context.Context, dagID string) error
+	CreateDraft(ctx context.Context, dagID string) (*DAG, error)
+	PromoteDraft(ctx context.Context, dagID string) (*DAG, error)
 
 	// Nodes
 	AddNode(ctx context.Context, dagID string, node *Node) (string, error)
@@ -28,6 +73,10 @@ type Store interface {
 	UpdateNode(ctx context.Context, node *Node) error
 	DeleteNode(ctx context.Context, nodeID string) error
 	ListNodes(ctx context.Context, dagID string) ([]Node, error)
+	FindNodesByTag(ctx context.Context, dagID, tag string) ([]Node, error)
+	CountNodes(ctx context.Context, dagID string) (int, error)
+	ListNodesPage(ctx context.Context, dagID string, opts ListOptions) (*Page[Node], error)
+	AddNodes(ctx context.Context, dagID string, nodes []Node) ([]BatchResult, error)
 
 	// Edges
 	AddEdge(ctx context.Context, dagID string, edge *Edge) (string, error)
@@ -35,4 +84,18 @@ type Store interface {
 	UpdateEdge(ctx context.Context, edge *Edge) error
 	DeleteEdge(ctx context.Context, edgeID string) error
 	ListEdges(ctx context.Context, dagID string) ([]Edge, error)
+	FindEdges(ctx context.Context, dagID string, filter EdgeFilter) ([]Edge, error)
+	CountEdges(ctx context.Context, dagID string) (int, error)
+	ListEdgesPage(ctx context.Context, dagID string, opts ListOptions) (*Page[Edge], error)
+	AddEdges(ctx context.Context, dagID string, edges []Edge) ([]BatchResult, error)
+	ReorderEdges(ctx context.Context, fromNodeID string, edgeIDs []string) error
+
+	// Change sets
+	ApplyChangeSet(ctx context.Context, dagID string, cs *ChangeSet) error
+
+	// Traversal
+	Ancestors(ctx context.Context, nodeID string) ([]Node, error)
+	Descendants(ctx context.Context, nodeID string) ([]Node, error)
+	Path(ctx context.Context, dagID, fromID, toID string) ([]Node, error)
+	Neighborhood(ctx context.Context, nodeID string, k int, dir Direction) (*DAG, error)
 }
@@ -0,0 +1,351 @@
+package dag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Codec converts typed payloads to and from the Data of nodes and edges.
+type Codec interface {
+	Marshal(v any) (json.RawMessage, error)
+	Unmarshal(data json.RawMessage, v any) error
+}
+
+// JSONCodec is the default Codec, using encoding/json.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) (json.RawMessage, error) { return json.Marshal(v) }
+
+func (JSONCodec) Unmarshal(data json.RawMessage, v any) error { return json.Unmarshal(data, v) }
+
+// TypedNode is a Node whose Data is decoded into N.
+type TypedNode[N any] struct {
+	ID   string
+	Ref  string
+	Data N
+	Tags []string
+}
+
+// TypedEdge is an Edge whose Data is decoded into E.
+type TypedEdge[E any] struct {
+	ID          string
+	FromNodeID  string
+	ToNodeID    string
+	FromNodeRef string
+	ToNodeRef   string
+	Data        E
+	OrderIndex  int
+}
+
+// TypedDAG is a DAG whose nodes and edges carry typed data.
+type TypedDAG[N, E any] struct {
+	ID        string
+	Name      string
+	Tags      []string
+	Status    Status
+	ExpiresAt *time.Time
+	Settings  Settings
+	Hash      string
+	Nodes     []TypedNode[N]
+	Edges     []TypedEdge[E]
+}
+
+// Typed wraps a Store so node data is read and written as N and edge data
+// as E, instead of json.RawMessage.
+//
+//	type Question struct{ Text string `json:"text"` }
+//	type Answer struct{ Label string `json:"label"` }
+//
+//	t := dag.NewTyped[Question, Answer](store)
+//	id, _ := t.AddNode(ctx, "flow", &dag.TypedNode[Question]{Data: Question{Text: "Age?"}})
+//	n, _ := t.GetNode(ctx, id) // n.Data.Text == "Age?"
+//
+// Data that does not decode into N or E is an error on reads.
+type Typed[N, E any] struct {
+	Store Store
+	// Codec marshals Data. nil means JSONCodec.
+	Codec Codec
+}
+
+// NewTyped wraps s using JSONCodec.
+func NewTyped[N, E any](s Store) *Typed[N, E] {
+	return &Typed[N, E]{Store: s, Codec: JSONCodec{}}
+}
+
+func (t *Typed[N, E]) codec() Codec {
+	if t.Codec == nil {
+		return JSONCodec{}
+	}
+	return t.Codec
+}
+
+// EncodeNode converts a typed node into a Node.
+func (t *Typed[N, E]) EncodeNode(n TypedNode[N]) (Node, error) {
+	data, err := t.codec().Marshal(n.Data)
+	if err != nil {
+		return Node{}, fmt.Errorf("dag: encode node %s: %w", n.ID, err)
+	}
+	return Node{ID: n.ID, Ref: n.Ref, Data: data, Tags: n.Tags}, nil
+}
+
+// DecodeNode converts a Node into a typed node.
+func (t *Typed[N, E]) DecodeNode(n Node) (TypedNode[N], error) {
+	out := TypedNode[N]{ID: n.ID, Ref: n.Ref, Tags: n.Tags}
+	if err := t.codec().Unmarshal(n.Data, &out.Data); err != nil {
+		return out, fmt.Errorf("dag: decode node %s: %w", n.ID, err)
+	}
+	return out, nil
+}
+
+// EncodeEdge converts a typed edge into an Edge.
+func (t *Typed[N, E]) EncodeEdge(e TypedEdge[E]) (Edge, error) {
+	data, err := t.codec().Marshal(e.Data)
+	if err != nil {
+		return Edge{}, fmt.Errorf("dag: encode edge %s: %w", e.ID, err)
+	}
+	return Edge{
+		ID: e.ID, FromNodeID: e.FromNodeID, ToNodeID: e.ToNodeID,
+		FromNodeRef: e.FromNodeRef, ToNodeRef: e.ToNodeRef,
+		Data: data, OrderIndex: e.OrderIndex,
+	}, nil
+}
+
+// DecodeEdge converts an Edge into a typed edge.
+func (t *Typed[N, E]) DecodeEdge(e Edge) (TypedEdge[E], error) {
+	out := TypedEdge[E]{
+		ID: e.ID, FromNodeID: e.FromNodeID, ToNodeID: e.ToNodeID,
+		FromNodeRef: e.FromNodeRef, ToNodeRef: e.ToNodeRef,
+		OrderIndex: e.OrderIndex,
+	}
+	if err := t.codec().Unmarshal(e.Data, &out.Data); err != nil {
+		return out, fmt.Errorf("dag: decode edge %s: %w", e.ID, err)
+	}
+	return out, nil
+}
+
+// EncodeDAG converts a typed DAG into a DAG.
+func (t *Typed[N, E]) EncodeDAG(d *TypedDAG[N, E]) (*DAG, error) {
+	out := &DAG{
+		ID: d.ID, Name: d.Name, Tags: d.Tags, Status: d.Status,
+		ExpiresAt: d.ExpiresAt, Settings: d.Settings,
+		Nodes: make([]Node, len(d.Nodes)), Edges: make([]Edge, len(d.Edges)),
+	}
+	var err error
+	for i, n := range d.Nodes {
+		if out.Nodes[i], err = t.EncodeNode(n); err != nil {
+			return nil, err
+		}
+	}
+	for i, e := range d.Edges {
+		if out.Edges[i], err = t.EncodeEdge(e); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// DecodeDAG converts a DAG into a typed DAG.
+func (t *Typed[N, E]) DecodeDAG(d *DAG) (*TypedDAG[N, E], error) {
+	out := &TypedDAG[N, E]{
+		ID: d.ID, Name: d.Name, Tags: d.Tags, Status: d.Status,
+		ExpiresAt: d.ExpiresAt, Settings: d.Settings, Hash: d.Hash,
+	}
+	var err error
+	if out.Nodes, err = t.decodeNodes(d.Nodes); err != nil {
+		return nil, err
+	}
+	if out.Edges, err = t.decodeEdges(d.Edges); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (t *Typed[N, E]) decodeNodes(nodes []Node) ([]TypedNode[N], error) {
+	out := make([]TypedNode[N], len(nodes))
+	for i, n := range nodes {
+		var err error
+		if out[i], err = t.DecodeNode(n); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (t *Typed[N, E]) decodeEdges(edges []Edge) ([]TypedEdge[E], error) {
+	out := make([]TypedEdge[E], len(edges))
+	for i, e := range edges {
+		var err error
+		if out[i], err = t.DecodeEdge(e); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// CreateDAG creates d and returns it with real IDs assigned.
+func (t *Typed[N, E]) CreateDAG(ctx context.Context, d *TypedDAG[N, E], opts ...CreateDAGOptions) (*TypedDAG[N, E], error) {
+	raw, err := t.EncodeDAG(d)
+	if err != nil {
+		return nil, err
+	}
+	created, err := t.Store.CreateDAG(ctx, raw, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return t.DecodeDAG(created)
+}
+
+// GetDAG returns nil, nil if the DAG does not exist.
+func (t *Typed[N, E]) GetDAG(ctx context.Context, dagID string) (*TypedDAG[N, E], error) {
+	d, err := t.Store.GetDAG(ctx, dagID)
+	if err != nil || d == nil {
+		return nil, err
+	}
+	return t.DecodeDAG(d)
+}
+
+// AddNode inserts n and sets n.ID if it was empty.
+func (t *Typed[N, E]) AddNode(ctx context.Context, dagID string, n *TypedNode[N]) (string, error) {
+	raw, err := t.EncodeNode(*n)
+	if err != nil {
+		return "", err
+	}
+	id, err := t.Store.AddNode(ctx, dagID, &raw)
+	if err != nil {
+		return "", err
+	}
+	n.ID = id
+	return id, nil
+}
+
+// GetNode returns nil, nil if the node does not exist.
+func (t *Typed[N, E]) GetNode(ctx context.Context, nodeID string) (*TypedNode[N], error) {
+	n, err := t.Store.GetNode(ctx, nodeID)
+	if err != nil || n == nil {
+		return nil, err
+	}
+	out, err := t.DecodeNode(*n)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateNode replaces the node's data, and its tags if n.Tags is non-nil.
+func (t *Typed[N, E]) UpdateNode(ctx context.Context, n *TypedNode[N]) error {
+	raw, err := t.EncodeNode(*n)
+	if err != nil {
+		return err
+	}
+	return t.Store.UpdateNode(ctx, &raw)
+}
+
+// ListNodes returns all nodes of a DAG.
+func (t *Typed[N, E]) ListNodes(ctx context.Context, dagID string) ([]TypedNode[N], error) {
+	nodes, err := t.Store.ListNodes(ctx, dagID)
+	if err != nil {
+		return nil, err
+	}
+	return t.decodeNodes(nodes)
+}
+
+// FindNodesByTag returns the nodes of a DAG carrying tag.
+func (t *Typed[N, E]) FindNodesByTag(ctx context.Context, dagID, tag string) ([]TypedNode[N], error) {
+	nodes, err := t.Store.FindNodesByTag(ctx, dagID, tag)
+	if err != nil {
+		return nil, err
+	}
+	return t.decodeNodes(nodes)
+}
+
+// AddEdge inserts e and sets e.ID and e.OrderIndex.
+func (t *Typed[N, E]) AddEdge(ctx context.Context, dagID string, e *TypedEdge[E]) (string, error) {
+	raw, err := t.EncodeEdge(*e)
+	if err != nil {
+		return "", err
+	}
+	id, err := t.Store.AddEdge(ctx, dagID, &raw)
+	if err != nil {
+		return "", err
+	}
+	e.ID, e.OrderIndex = id, raw.OrderIndex
+	return id, nil
+}
+
+// GetEdge returns nil, nil if the edge does not exist.
+func (t *Typed[N, E]) GetEdge(ctx context.Context, edgeID string) (*TypedEdge[E], error) {
+	e, err := t.Store.GetEdge(ctx, edgeID)
+	if err != nil || e == nil {
+		return nil, err
+	}
+	out, err := t.DecodeEdge(*e)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateEdge replaces the edge's endpoints and data.
+func (t *Typed[N, E]) UpdateEdge(ctx context.Context, e *TypedEdge[E]) error {
+	raw, err := t.EncodeEdge(*e)
+	if err != nil {
+		return err
+	}
+	return t.Store.UpdateEdge(ctx, &raw)
+}
+
+// ListEdges returns all edges of a DAG.
+func (t *Typed[N, E]) ListEdges(ctx context.Context, dagID string) ([]TypedEdge[E], error) {
+	edges, err := t.Store.ListEdges(ctx, dagID)
+	if err != nil {
+		return nil, err
+	}
+	return t.decodeEdges(edges)
+}
+
+// FindEdges returns the edges of a DAG matching filter.
+func (t *Typed[N, E]) FindEdges(ctx context.Context, dagID string, filter EdgeFilter) ([]TypedEdge[E], error) {
+	edges, err := t.Store.FindEdges(ctx, dagID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return t.decodeEdges(edges)
+}
+
+// Ancestors returns every node that can reach nodeID.
+func (t *Typed[N, E]) Ancestors(ctx context.Context, nodeID string) ([]TypedNode[N], error) {
+	nodes, err := t.Store.Ancestors(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	return t.decodeNodes(nodes)
+}
+
+// Descendants returns every node reachable from nodeID.
+func (t *Typed[N, E]) Descendants(ctx context.Context, nodeID string) ([]TypedNode[N], error) {
+	nodes, err := t.Store.Descendants(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	return t.decodeNodes(nodes)
+}
+
+// Neighborhood returns the subgraph within k hops of nodeID.
+func (t *Typed[N, E]) Neighborhood(ctx context.Context, nodeID string, k int, dir Direction) (*TypedDAG[N, E], error) {
+	d, err := t.Store.Neighborhood(ctx, nodeID, k, dir)
+	if err != nil {
+		return nil, err
+	}
+	return t.DecodeDAG(d)
+}
+
+// Path returns the nodes on a path from fromID to toID.
+func (t *Typed[N, E]) Path(ctx context.Context, dagID, fromID, toID string) ([]TypedNode[N], error) {
+	nodes, err := t.Store.Path(ctx, dagID, fromID, toID)
+	if err != nil {
+		return nil, err
+	}
+	return t.decodeNodes(nodes)
+}
@@ -0,0 +1,108 @@
+package dag
+
+import "time"
+
+// Visit is one recorded visit of a run to a node. Seq increases with every
+// visit recorded, across all DAGs.
+type Visit struct {
+	Seq       int64     `json:"seq"`
+	DAGID     string    `json:"dag_id"`
+	RunID     string    `json:"run_id"`
+	NodeID    string    `json:"node_id"`
+	VisitedAt time.Time `json:"visited_at"`
+}
+
+// VisitStats aggregates the recorded visits of a DAG's runs, such as people
+// filling in a form, for drop-off and path analysis.
+type VisitStats struct {
+	// Runs is the number of distinct runs with at least one visit.
+	Runs int64 `json:"runs"`
+	// Nodes lists each visited node, most visited first.
+	Nodes []NodeVisits `json:"nodes"`
+	// Transitions lists how often runs moved from one node to the next,
+	// most frequent first.
+	Transitions []Transition `json:"transitions"`
+}
+
+// NodeVisits counts the visits to one node.
+type NodeVisits struct {
+	NodeID string `json:"node_id"`
+	// Visits counts every visit, and Runs the runs that visited at least
+	// once, so Visits > Runs means runs came back to the node.
+	Visits int64 `json:"visits"`
+	Runs   int64 `json:"runs"`
+	// Exits counts the runs whose last visit was to this node.
+	Exits int64 `json:"exits"`
+}
+
+// Transition counts the times a run visited ToNodeID right after
+// FromNodeID.
+type Transition struct {
+	FromNodeID string `json:"from_node_id"`
+	ToNodeID   string `json:"to_node_id"`
+	Count      int64  `json:"count"`
+}
+
+// Heatmap is VisitStats laid over a DAG, for colouring a flow editor.
+type Heatmap struct {
+	Runs  int64      `json:"runs"`
+	Nodes []NodeHeat `json:"nodes"`
+	Edges []EdgeHeat `json:"edges"`
+}
+
+// NodeHeat is the traffic of one node.
+type NodeHeat struct {
+	NodeVisits
+	// DropOff is the share of the runs that visited the node and stopped
+	// there, from 0 to 1. It is 0 for a node without outgoing edges, where
+	// stopping is finishing, and for a node no run visited.
+	DropOff float64 `json:"drop_off"`
+}
+
+// EdgeHeat is the traffic along one edge.
+type EdgeHeat struct {
+	EdgeID     string `json:"edge_id"`
+	FromNodeID string `json:"from_node_id"`
+	ToNodeID   string `json:"to_node_id"`
+	// Count is the number of transitions from the edge's source to its
+	// target. Visits record nodes, not edges, so parallel edges each get
+	// the count of their pair.
+	Count int64 `json:"count"`
+}
+
+// NewHeatmap lays st over d: every node and edge of d in order, with zero
+// counts where no run went. Visits to nodes that are no longer in d, and
+// transitions that follow no edge, such as going back, are left out.
+func NewHeatmap(d *DAG, st *VisitStats) *Heatmap {
+	visits := make(map[string]NodeVisits, len(st.Nodes))
+	for _, n := range st.Nodes {
+		visits[n.NodeID] = n
+	}
+	pairs := make(map[[2]string]int64, len(st.Transitions))
+	for _, t := range st.Transitions {
+		pairs[[2]string{t.FromNodeID, t.ToNodeID}] = t.Count
+	}
+	hasOut := make(map[string]bool)
+	for _, e := range d.Edges {
+		hasOut[e.FromNodeID] = true
+	}
+
+	h := &Heatmap{Runs: st.Runs, Nodes: make([]NodeHeat, len(d.Nodes)), Edges: make([]EdgeHeat, len(d.Edges))}
+	for i, n := range d.Nodes {
+		v := visits[n.ID]
+		v.NodeID = n.ID
+		h.Nodes[i] = NodeHeat{NodeVisits: v}
+		if hasOut[n.ID] && v.Runs > 0 {
+			h.Nodes[i].DropOff = float64(v.Exits) / float64(v.Runs)
+		}
+	}
+	for i, e := range d.Edges {
+		h.Edges[i] = EdgeHeat{
+			EdgeID:     e.ID,
+			FromNodeID: e.FromNodeID,
+			ToNodeID:   e.ToNodeID,
+			Count:      pairs[[2]string{e.FromNodeID, e.ToNodeID}],
+		}
+	}
+	return h
+}